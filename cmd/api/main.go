@@ -2,20 +2,28 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/sopatech/afterwave.fm/internal/admin"
 	"github.com/sopatech/afterwave.fm/internal/artists"
 	"github.com/sopatech/afterwave.fm/internal/auth"
 	"github.com/sopatech/afterwave.fm/internal/cognito"
 	"github.com/sopatech/afterwave.fm/internal/config"
+	"github.com/sopatech/afterwave.fm/internal/domains"
 	"github.com/sopatech/afterwave.fm/internal/feed"
 	"github.com/sopatech/afterwave.fm/internal/follows"
 	apphttp "github.com/sopatech/afterwave.fm/internal/http"
 	"github.com/sopatech/afterwave.fm/internal/infra"
+	"github.com/sopatech/afterwave.fm/internal/invitations"
+	"github.com/sopatech/afterwave.fm/internal/mail"
+	"github.com/sopatech/afterwave.fm/internal/markdown"
+	"github.com/sopatech/afterwave.fm/internal/media"
 	"github.com/sopatech/afterwave.fm/internal/metrics"
 	"github.com/sopatech/afterwave.fm/internal/search"
 	"github.com/sopatech/afterwave.fm/internal/users"
@@ -41,6 +49,23 @@ type Config struct {
 	CognitoCallbackURL  string `envconfig:"COGNITO_CALLBACK_URL"`                 // e.g. https://api.afterwave.fm/v1/auth/callback
 	FrontendRedirectURI string `envconfig:"FRONTEND_REDIRECT_URI"`                // e.g. https://app.afterwave.fm/auth/callback
 	OAuthStateSecret    string `envconfig:"OAUTH_STATE_SECRET"`                   // optional; if set, federated flow validates CSRF state cookie
+	PlatformAdminUserIDs []string `envconfig:"PLATFORM_ADMIN_USER_IDS"`           // optional; comma-separated user IDs granted platform admin on startup
+	ReservedHandles     []string `envconfig:"RESERVED_HANDLES"`                   // optional; comma-separated handles reserved in addition to artists.DefaultReservedHandles
+	DeniedHandleTerms   []string `envconfig:"DENIED_HANDLE_TERMS"`                // optional; comma-separated terms never allowed inside a handle
+	ArtistPurgeInterval time.Duration `envconfig:"ARTIST_PURGE_INTERVAL" default:"1h"` // how often deleted artist pages past their restore period are purged
+	DomainVerifyInterval time.Duration `envconfig:"DOMAIN_VERIFY_INTERVAL" default:"5m"` // how often pending custom domains have their TXT record checked
+	PostPublishInterval time.Duration `envconfig:"POST_PUBLISH_INTERVAL" default:"1m"` // how often due scheduled posts are published
+	MediaBucket         string `envconfig:"MEDIA_BUCKET"`                         // optional; S3 bucket for uploaded images. When empty, files are stored in MEDIA_DIR and served at /files
+	MediaS3Endpoint     string `envconfig:"MEDIA_S3_ENDPOINT"`                    // optional, e.g. http://localhost:9000 for MinIO
+	MediaPublicBaseURL  string `envconfig:"MEDIA_PUBLIC_BASE_URL"`                // where uploaded files are served from, e.g. a CDN; with MEDIA_DIR, the API's /files URL
+	MediaDir            string `envconfig:"MEDIA_DIR" default:"data/media"`        // uploaded files when MEDIA_BUCKET is empty (local development)
+	MediaUploadSecret   string `envconfig:"MEDIA_UPLOAD_SECRET" obfuscate:"true"` // signs upload URLs with MEDIA_DIR; random per process when empty
+	MediaOrphanTTL      time.Duration `envconfig:"MEDIA_ORPHAN_TTL" default:"24h"` // how long an upload may go unattached to a post before it is deleted
+	MediaCleanupInterval time.Duration `envconfig:"MEDIA_CLEANUP_INTERVAL" default:"15m"` // how often orphaned uploads are deleted
+	PostAllowedElements []string `envconfig:"POST_ALLOWED_ELEMENTS"`             // optional; comma-separated HTML elements post Markdown may render to (default markdown.DefaultElements)
+	CommentRateLimit    int           `envconfig:"COMMENT_RATE_LIMIT" default:"10"`   // comments a user may post per COMMENT_RATE_WINDOW
+	CommentRateWindow   time.Duration `envconfig:"COMMENT_RATE_WINDOW" default:"1m"`
+	ReactionReconcileInterval time.Duration `envconfig:"REACTION_RECONCILE_INTERVAL" default:"15m"` // how often recently reacted posts have their reaction counts recounted
 }
 
 func main() {
@@ -96,6 +121,12 @@ func main() {
 		logger.Error("register MAU counter", "err", err)
 		os.Exit(1)
 	}
+	// DynamoDB reads per HTTP request, by route (GET /metrics: http_request_dynamodb_reads)
+	dynamoReads, err := metrics.NewDynamoReadRecorder(prometheus.DefaultRegisterer)
+	if err != nil {
+		logger.Error("register DynamoDB reads histogram", "err", err)
+		os.Exit(1)
+	}
 	authService := auth.NewService(authStore, jwtPrivateKey, mauRecorder)
 	cookieCfg := auth.CookieConfig{Secure: cfg.CookieSecure}
 	authHandler := auth.NewHandler(authService, cookieCfg)
@@ -107,33 +138,83 @@ func main() {
 		logger.Error("cognito init", "err", err)
 		os.Exit(1)
 	}
-	usersService := users.NewService(usersStore, cognitoClient)
+	mailer := mail.NewLogSender(logger)
+	usersService := users.NewService(usersStore, cognitoClient, mailer)
 	usersHandler := users.NewHandler(usersService, authService, cookieCfg, cfg.CognitoHostedDomain, cfg.AWSRegion, cfg.CognitoUserPoolID, cfg.CognitoClientID, cfg.CognitoClientSecret, cfg.CognitoCallbackURL, cfg.FrontendRedirectURI, cfg.OAuthStateSecret)
 
+	// --- Stores with handle-keyed data (moved on artist rename) ---
+	followsStore := follows.NewStore(db, cfg.DynamoTable)
+	invitationsStore := invitations.NewStore(db, cfg.DynamoTable)
+	feedStore := feed.NewStore(db, cfg.DynamoTable)
+	domainsStore := domains.NewStore(db, cfg.DynamoTable)
+	mediaStore := media.NewStore(db, cfg.DynamoTable)
+	mediaObjects, mediaFiles, err := newMediaObjectStore(context.Background(), cfg)
+	if err != nil {
+		logger.Error("media object store", "err", err)
+		os.Exit(1)
+	}
+	osClient := infra.NewOpenSearch(cfg.OpenSearchEndpoint, nil)
+	feedIndex := search.NewFeedIndex(osClient, cfg.OpenSearchFeedIndex)
+	if err := feedIndex.EnsureIndex(context.Background()); err != nil {
+		logger.Error("opensearch ensure feed index", "err", err)
+		os.Exit(1)
+	}
+
 	// --- Artists: store, service, handler ---
 	artistsStore := artists.NewStore(db, cfg.DynamoTable)
 	artistsMemberStore := artists.NewMemberStore(db, cfg.DynamoTable)
-	artistsService := artists.NewService(artistsStore, artistsMemberStore)
+	handlePolicy := artists.DefaultHandlePolicy(cfg.ReservedHandles, cfg.DeniedHandleTerms)
+	artistsService := artists.NewService(artistsStore, artistsMemberStore, handlePolicy,
+		followsStore, invitationsStore, feed.NewHandleData(feedStore, feedIndex), domainsStore, media.NewHandleData(mediaStore, mediaObjects))
 	artistsHandler := artists.NewHandler(artistsService)
+	go purgeDeletedArtists(logger, artistsService, cfg.ArtistPurgeInterval)
 
-	// --- Follows: store, service, handler ---
-	followsStore := follows.NewStore(db, cfg.DynamoTable)
+	// --- Invitations: service, handler ---
+	invitationsService := invitations.NewService(invitationsStore, artistsService, artistsMemberStore, usersService, mailer)
+	invitationsHandler := invitations.NewHandler(invitationsService)
+
+	// --- Custom domains: service (TXT lookups via the system resolver), handler ---
+	domainsService := domains.NewService(domainsStore, artistsService, net.DefaultResolver)
+	domainsHandler := domains.NewHandler(domainsService)
+	go verifyPendingDomains(logger, domainsService, cfg.DomainVerifyInterval)
+
+	// --- Media: service (presigned uploads to the object store), handler ---
+	mediaService := media.NewService(mediaStore, mediaObjects, artistsService, cfg.MediaOrphanTTL)
+	mediaHandler := media.NewHandler(mediaService)
+	go cleanupOrphanedMedia(logger, mediaService, cfg.MediaCleanupInterval)
+
+	// --- Follows: service, handler ---
 	followsService := follows.NewService(followsStore, artistsService)
 	followsHandler := follows.NewHandler(followsService)
 
-	// --- Feed: store, OpenSearch index, service, handler ---
-	feedStore := feed.NewStore(db, cfg.DynamoTable)
-	osClient := infra.NewOpenSearch(cfg.OpenSearchEndpoint, nil)
-	feedIndex := search.NewFeedIndex(osClient, cfg.OpenSearchFeedIndex)
-	if err := feedIndex.EnsureIndex(context.Background()); err != nil {
-		logger.Error("opensearch ensure feed index", "err", err)
+	// --- Platform admin store (admins + audit); feed checks it for moderators ---
+	adminStore := admin.NewStore(db, cfg.DynamoTable)
+	if err := adminStore.EnsureAdmins(context.Background(), cfg.PlatformAdminUserIDs); err != nil {
+		logger.Error("ensure platform admins", "err", err)
+		os.Exit(1)
+	}
+
+	// --- Feed: Markdown renderer, service, handler ---
+	markdownPolicy := markdown.DefaultPolicy()
+	if len(cfg.PostAllowedElements) > 0 {
+		markdownPolicy.Elements = cfg.PostAllowedElements
+	}
+	markdownRenderer, err := markdown.New(markdownPolicy)
+	if err != nil {
+		logger.Error("post markdown policy", "err", err)
 		os.Exit(1)
 	}
-	feedService := feed.NewServiceWithSearch(feedStore, artistsService, artistsService, feedIndex, followsService, feedIndex)
+	feedService := feed.NewServiceWithSearch(feedStore, artistsService, artistsService, artistsService, feedIndex, followsService, feedIndex, usersService, adminStore, mediaService, markdownRenderer, feed.CommentRateLimit{Max: cfg.CommentRateLimit, Window: cfg.CommentRateWindow})
 	feedHandler := feed.NewHandler(feedService)
+	go publishScheduledPosts(logger, feedService, cfg.PostPublishInterval)
+	go reconcileReactionCounts(logger, feedService, cfg.ReactionReconcileInterval)
+
+	// --- Platform admin: service, handler ---
+	adminService := admin.NewService(adminStore, usersService, artistsService, authService, artistsService, artistsService)
+	adminHandler := admin.NewHandler(adminService)
 
 	// --- Router and HTTP server ---
-	r := apphttp.NewRouter(logger, usersHandler, authHandler, artistsHandler, followsHandler, feedHandler, metrics.Handler(), jwtPublicKey)
+	r := apphttp.NewRouter(logger, usersHandler, authHandler, artistsHandler, followsHandler, feedHandler, invitationsHandler, domainsHandler, mediaHandler, mediaFiles, adminHandler, metrics.Handler(), dynamoReads, jwtPublicKey, authService)
 
 	srv := &http.Server{
 		Addr:         cfg.Addr,
@@ -148,3 +229,110 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// purgeDeletedArtists permanently removes deleted artist pages whose restore period has ended, every interval.
+func purgeDeletedArtists(logger *slog.Logger, svc artists.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := svc.PurgeDue(context.Background(), time.Now())
+		if err != nil {
+			logger.Error("artist purge", "err", err, "purged", n)
+			continue
+		}
+		if n > 0 {
+			logger.Info("artist purge", "purged", n)
+		}
+	}
+}
+
+// verifyPendingDomains checks the TXT records of pending custom domains, every interval.
+func verifyPendingDomains(logger *slog.Logger, svc domains.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := svc.VerifyPending(context.Background(), time.Now())
+		if err != nil {
+			logger.Error("domain verify", "err", err, "verified", n)
+			continue
+		}
+		if n > 0 {
+			logger.Info("domain verify", "verified", n)
+		}
+	}
+}
+
+// publishScheduledPosts publishes scheduled posts that are due, every interval. Safe to run on every task.
+func publishScheduledPosts(logger *slog.Logger, svc feed.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := svc.PublishDue(context.Background(), time.Now())
+		if err != nil {
+			logger.Error("post publish", "err", err, "published", n)
+			continue
+		}
+		if n > 0 {
+			logger.Info("post publish", "published", n)
+		}
+	}
+}
+
+// reconcileReactionCounts recounts the reactions of recently reacted posts and corrects drifted counts, every
+// interval. Safe to run on every task.
+func reconcileReactionCounts(logger *slog.Logger, svc feed.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := svc.ReconcileReactions(context.Background(), time.Now())
+		if err != nil {
+			logger.Error("reaction reconcile", "err", err, "corrected", n)
+			continue
+		}
+		if n > 0 {
+			logger.Info("reaction reconcile", "corrected", n)
+		}
+	}
+}
+
+// cleanupOrphanedMedia deletes uploads that were never attached to a post within their timeout, every interval.
+// Safe to run on every task.
+func cleanupOrphanedMedia(logger *slog.Logger, svc media.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := svc.CleanupOrphans(context.Background(), time.Now())
+		if err != nil {
+			logger.Error("media cleanup", "err", err, "deleted", n)
+			continue
+		}
+		if n > 0 {
+			logger.Info("media cleanup", "deleted", n)
+		}
+	}
+}
+
+// newMediaObjectStore returns the S3 bucket for uploaded images or, when no bucket is configured, a filesystem store
+// and the handler that serves it (mounted at /files).
+func newMediaObjectStore(ctx context.Context, cfg Config) (media.ObjectStore, http.Handler, error) {
+	if cfg.MediaBucket != "" {
+		s3, err := infra.NewS3(ctx, cfg.AWSRegion, cfg.MediaS3Endpoint, cfg.MediaBucket, cfg.MediaPublicBaseURL)
+		return s3, nil, err
+	}
+	secret := []byte(cfg.MediaUploadSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, nil, err
+		}
+	}
+	baseURL := cfg.MediaPublicBaseURL
+	if baseURL == "" {
+		baseURL = "http://localhost" + cfg.Addr + "/files"
+	}
+	files, err := media.NewFileStore(cfg.MediaDir, baseURL, secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, files, nil
+}
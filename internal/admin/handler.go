@@ -0,0 +1,227 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/auth"
+)
+
+// Checker reports whether a user is a platform admin. Implemented by Service.
+type Checker interface {
+	IsAdmin(ctx context.Context, userID string) (bool, error)
+}
+
+// RequireAdmin rejects requests from users who are not platform admins. Must run after auth.Authenticate.
+func RequireAdmin(checker Checker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := auth.UserIDFromContext(r.Context())
+			if userID == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			ok, err := checker.IsAdmin(r.Context(), userID)
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RequireAdmin is RequireAdmin(svc) for use when chaining routes.
+func (h *Handler) RequireAdmin(next http.Handler) http.Handler {
+	return RequireAdmin(h.svc)(next)
+}
+
+// FindUser looks up a user by ?email= or ?cognito_sub=.
+func (h *Handler) FindUser(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	q := r.URL.Query()
+	detail, err := h.svc.FindUser(r.Context(), actorUserID, q.Get("email"), q.Get("cognito_sub"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// GetUser returns a user by ID with artists, memberships, session count and linked identities.
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	detail, err := h.svc.GetUser(r.Context(), actorUserID, r.PathValue("userId"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// Suspend suspends the user. Body: {"reason": "..."}.
+func (h *Handler) Suspend(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	detail, err := h.svc.Suspend(r.Context(), actorUserID, r.PathValue("userId"), body.Reason)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// Unsuspend lifts the user's suspension.
+func (h *Handler) Unsuspend(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	detail, err := h.svc.Unsuspend(r.Context(), actorUserID, r.PathValue("userId"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// SignOut revokes all of the user's sessions.
+func (h *Handler) SignOut(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	if err := h.svc.ForceSignOut(r.Context(), actorUserID, r.PathValue("userId")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAudit returns admin actions on the user, newest first. Optional ?limit= (default 50, max 100).
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if s := r.URL.Query().Get("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	entries, err := h.svc.ListAudit(r.Context(), r.PathValue("userId"), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"entries": entries})
+}
+
+// GrantHandle grants a reserved or disallowed handle to a user. Body: {"user_id": "..."}.
+func (h *Handler) GrantHandle(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	var body struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if err := h.svc.GrantHandle(r.Context(), actorUserID, r.PathValue("handle"), body.UserID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeHandleGrant removes the grant for a handle.
+func (h *Handler) RevokeHandleGrant(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	if err := h.svc.RevokeHandleGrant(r.Context(), actorUserID, r.PathValue("handle")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListVerifications returns pending artist verification requests, oldest first. Optional ?limit= (default 50, max 100).
+func (h *Handler) ListVerifications(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	list, err := h.svc.ListVerifications(r.Context(), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"verifications": list})
+}
+
+// ApproveVerification marks the artist verified.
+func (h *Handler) ApproveVerification(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	v, err := h.svc.ApproveVerification(r.Context(), actorUserID, r.PathValue("handle"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// RejectVerification rejects the artist's pending verification request. Body: {"reason": "..."}.
+func (h *Handler) RejectVerification(w http.ResponseWriter, r *http.Request) {
+	actorUserID := auth.UserIDFromContext(r.Context())
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	v, err := h.svc.RejectVerification(r.Context(), actorUserID, r.PathValue("handle"), body.Reason)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case err == ErrUserNotFound, err == artists.ErrGrantNotFound, err == artists.ErrArtistNotFound, err == artists.ErrVerificationNotFound:
+		http.Error(w, "not found", http.StatusNotFound)
+	case err == artists.ErrVerificationNotPending:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err == artists.ErrInvalidHandle:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == artists.ErrHandleTaken:
+		http.Error(w, "handle already in use", http.StatusConflict)
+	case err == ErrLookupKeyRequired, err == ErrInvalidReason, err == ErrCannotSuspendSelf:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
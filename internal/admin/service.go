@@ -0,0 +1,326 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/auth"
+	"github.com/sopatech/afterwave.fm/internal/users"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrCannotSuspendSelf = errors.New("cannot suspend your own account")
+	ErrLookupKeyRequired = errors.New("exactly one of email or cognito_sub is required")
+	ErrInvalidReason     = errors.New("reason required (max 500 characters)")
+)
+
+// Audit actions.
+const (
+	ActionLookup       = "user.lookup"
+	ActionSuspend      = "user.suspend"
+	ActionUnsuspend    = "user.unsuspend"
+	ActionForceLogout  = "user.sign_out"
+	ActionGrantHandle  = "handle.grant"
+	ActionRevokeGrant  = "handle.revoke_grant"
+	ActionVerifyArtist = "artist.verify"
+	ActionRejectVerify = "artist.reject_verification"
+)
+
+const maxReasonLen = 500
+
+// UserDirectory looks up users and their sign-in identities. Implemented by users.Service.
+type UserDirectory interface {
+	GetByID(ctx context.Context, userID string) (*users.User, error)
+	GetByEmail(ctx context.Context, email string) (*users.User, error)
+	GetByCognitoSub(ctx context.Context, cognitoSub string) (*users.User, error)
+	ListIdentities(ctx context.Context, userID string) ([]users.Identity, error)
+}
+
+// ArtistLister lists the artist pages a user owns or belongs to. Implemented by artists.Service.
+type ArtistLister interface {
+	ListForUser(ctx context.Context, userID string) ([]artists.ArtistWithRole, error)
+}
+
+// SessionManager counts, revokes and suspends sessions. Implemented by *auth.Service.
+type SessionManager interface {
+	CountSessions(ctx context.Context, userID string) (int, error)
+	RevokeAllSessionsForUser(ctx context.Context, userID string) error
+	Suspend(ctx context.Context, userID, actorUserID, reason string) (*auth.Suspension, error)
+	Unsuspend(ctx context.Context, userID string) error
+	GetSuspension(ctx context.Context, userID string) (*auth.Suspension, error)
+}
+
+// HandleGranter grants reserved or disallowed artist handles to users. Implemented by artists.Service.
+type HandleGranter interface {
+	GrantHandle(ctx context.Context, handle, userID, actorUserID string) error
+	RevokeHandleGrant(ctx context.Context, handle string) (userID string, err error)
+}
+
+// VerificationReviewer lists and decides artist verification requests. Implemented by artists.Service.
+type VerificationReviewer interface {
+	ListVerificationQueue(ctx context.Context, limit int) ([]artists.Verification, error)
+	ReviewVerification(ctx context.Context, handle string, approve bool, note, reviewerUserID string) (*artists.Verification, error)
+}
+
+type Service interface {
+	IsAdmin(ctx context.Context, userID string) (bool, error)
+	GetUser(ctx context.Context, actorUserID, userID string) (*UserDetail, error)
+	FindUser(ctx context.Context, actorUserID, email, cognitoSub string) (*UserDetail, error)
+	Suspend(ctx context.Context, actorUserID, userID, reason string) (*UserDetail, error)
+	Unsuspend(ctx context.Context, actorUserID, userID string) (*UserDetail, error)
+	ForceSignOut(ctx context.Context, actorUserID, userID string) error
+	ListAudit(ctx context.Context, userID string, limit int) ([]AuditEntry, error)
+	GrantHandle(ctx context.Context, actorUserID, handle, userID string) error
+	RevokeHandleGrant(ctx context.Context, actorUserID, handle string) error
+	ListVerifications(ctx context.Context, limit int) ([]artists.Verification, error)
+	ApproveVerification(ctx context.Context, actorUserID, handle string) (*artists.Verification, error)
+	RejectVerification(ctx context.Context, actorUserID, handle, reason string) (*artists.Verification, error)
+}
+
+// UserDetail is the support view of a user (GET /admin/users/...).
+type UserDetail struct {
+	User             users.User               `json:"user"`
+	Suspended        bool                     `json:"suspended"`
+	Suspension       *auth.Suspension         `json:"suspension,omitempty"`
+	OwnedArtists     []artists.Artist         `json:"owned_artists"`
+	Memberships      []artists.ArtistWithRole `json:"memberships"`
+	SessionCount     int                      `json:"session_count"`
+	LinkedIdentities []users.Identity         `json:"linked_identities"`
+}
+
+// AuditEntry records one admin action on a user.
+type AuditEntry struct {
+	ID           string `json:"id"`
+	Action       string `json:"action"`
+	ActorUserID  string `json:"actor_user_id"`
+	TargetUserID string `json:"target_user_id"`
+	Detail       string `json:"detail,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type service struct {
+	store    *Store
+	users    UserDirectory
+	artists  ArtistLister
+	sessions SessionManager
+	handles  HandleGranter
+	verifier VerificationReviewer
+}
+
+func NewService(store *Store, users UserDirectory, artists ArtistLister, sessions SessionManager, handles HandleGranter, verifier VerificationReviewer) Service {
+	return &service{store: store, users: users, artists: artists, sessions: sessions, handles: handles, verifier: verifier}
+}
+
+func (s *service) IsAdmin(ctx context.Context, userID string) (bool, error) {
+	return s.store.IsAdmin(ctx, userID)
+}
+
+// GetUser returns the support view of the user by ID. The lookup is audited.
+func (s *service) GetUser(ctx context.Context, actorUserID, userID string) (*UserDetail, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit(ctx, ActionLookup, actorUserID, user.ID, "by id"); err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, user)
+}
+
+// FindUser looks up a user by email or Cognito sub (exactly one must be set). The lookup is audited.
+func (s *service) FindUser(ctx context.Context, actorUserID, email, cognitoSub string) (*UserDetail, error) {
+	email, cognitoSub = strings.TrimSpace(email), strings.TrimSpace(cognitoSub)
+	if (email == "") == (cognitoSub == "") {
+		return nil, ErrLookupKeyRequired
+	}
+	var (
+		user *users.User
+		err  error
+		by   string
+	)
+	if email != "" {
+		user, err = s.users.GetByEmail(ctx, email)
+		by = "by email"
+	} else {
+		user, err = s.users.GetByCognitoSub(ctx, cognitoSub)
+		by = "by cognito_sub"
+	}
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if err := s.audit(ctx, ActionLookup, actorUserID, user.ID, by); err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, user)
+}
+
+// Suspend blocks the user from signing in or using existing sessions, and revokes their sessions.
+func (s *service) Suspend(ctx context.Context, actorUserID, userID, reason string) (*UserDetail, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxReasonLen {
+		return nil, ErrInvalidReason
+	}
+	if userID == actorUserID {
+		return nil, ErrCannotSuspendSelf
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.sessions.Suspend(ctx, user.ID, actorUserID, reason); err != nil {
+		return nil, err
+	}
+	if err := s.audit(ctx, ActionSuspend, actorUserID, user.ID, reason); err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, user)
+}
+
+// Unsuspend lifts a suspension. Idempotent.
+func (s *service) Unsuspend(ctx context.Context, actorUserID, userID string) (*UserDetail, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Unsuspend(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.audit(ctx, ActionUnsuspend, actorUserID, user.ID, ""); err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, user)
+}
+
+// ForceSignOut revokes all of the user's sessions and refresh tokens.
+func (s *service) ForceSignOut(ctx context.Context, actorUserID, userID string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeAllSessionsForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.audit(ctx, ActionForceLogout, actorUserID, user.ID, "")
+}
+
+// GrantHandle lets the user claim a reserved or disallowed handle (e.g. a verified artist). Audited on the user.
+func (s *service) GrantHandle(ctx context.Context, actorUserID, handle, userID string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.handles.GrantHandle(ctx, handle, user.ID, actorUserID); err != nil {
+		return err
+	}
+	return s.audit(ctx, ActionGrantHandle, actorUserID, user.ID, handle)
+}
+
+// RevokeHandleGrant removes an unclaimed grant. Audited on the user it was granted to.
+func (s *service) RevokeHandleGrant(ctx context.Context, actorUserID, handle string) error {
+	userID, err := s.handles.RevokeHandleGrant(ctx, handle)
+	if err != nil {
+		return err
+	}
+	return s.audit(ctx, ActionRevokeGrant, actorUserID, userID, handle)
+}
+
+// ListVerifications returns pending artist verification requests, oldest first.
+func (s *service) ListVerifications(ctx context.Context, limit int) ([]artists.Verification, error) {
+	return s.verifier.ListVerificationQueue(ctx, limit)
+}
+
+// ApproveVerification marks the artist verified. Audited on the user who requested verification.
+func (s *service) ApproveVerification(ctx context.Context, actorUserID, handle string) (*artists.Verification, error) {
+	v, err := s.verifier.ReviewVerification(ctx, handle, true, "", actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit(ctx, ActionVerifyArtist, actorUserID, v.RequestedBy, v.Handle); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// RejectVerification rejects the artist's pending request; the reason is shown to the page. Audited on the user
+// who requested verification.
+func (s *service) RejectVerification(ctx context.Context, actorUserID, handle, reason string) (*artists.Verification, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxReasonLen {
+		return nil, ErrInvalidReason
+	}
+	v, err := s.verifier.ReviewVerification(ctx, handle, false, reason, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit(ctx, ActionRejectVerify, actorUserID, v.RequestedBy, v.Handle+": "+reason); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListAudit returns admin actions on the user, newest first.
+func (s *service) ListAudit(ctx context.Context, userID string, limit int) ([]AuditEntry, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.store.ListAuditForUser(ctx, userID, limit)
+}
+
+func (s *service) getUser(ctx context.Context, userID string) (*users.User, error) {
+	if userID == "" {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *service) detail(ctx context.Context, user *users.User) (*UserDetail, error) {
+	out := &UserDetail{
+		User:             *user,
+		OwnedArtists:     []artists.Artist{},
+		Memberships:      []artists.ArtistWithRole{},
+		LinkedIdentities: []users.Identity{},
+	}
+	susp, err := s.sessions.GetSuspension(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	out.Suspended = susp != nil
+	out.Suspension = susp
+	list, err := s.artists.ListForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range list {
+		if a.Role == artists.RoleOwner {
+			out.OwnedArtists = append(out.OwnedArtists, a.Artist)
+		} else {
+			out.Memberships = append(out.Memberships, a)
+		}
+	}
+	if out.SessionCount, err = s.sessions.CountSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	identities, err := s.users.ListIdentities(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	out.LinkedIdentities = append(out.LinkedIdentities, identities...)
+	return out, nil
+}
+
+func (s *service) audit(ctx context.Context, action, actorUserID, targetUserID, detail string) error {
+	return s.store.PutAudit(ctx, newAuditEntry(action, actorUserID, targetUserID, detail))
+}
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/infra"
+)
+
+// Admin domain: platform admins and the admin audit trail (two-row pattern, no GSI).
+// Platform admin: PK = ADMIN#PLATFORM, SK = USER#<user_id> — seeded from config on startup.
+// Audit by target: PK = ADMIN#AUDIT#USER#<target_user_id>, SK = <created_at>#<id> — history of actions on a user.
+// Audit by day:    PK = ADMIN#AUDIT#DAY#<YYYY-MM-DD>, SK = <created_at>#<id> — everything admins did that day.
+
+const (
+	adminPK           = "ADMIN#PLATFORM"
+	adminSKPrefix     = "USER#"
+	auditUserPKPrefix = "ADMIN#AUDIT#USER#"
+	auditDayPKPrefix  = "ADMIN#AUDIT#DAY#"
+	auditDayLayout    = "2006-01-02"
+	auditTimeLayout   = "2006-01-02T15:04:05.000000000Z07:00" // fixed width so SK order is time order
+)
+
+type adminRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	UserID    string `dynamo:"user_id"`
+	GrantedAt string `dynamo:"granted_at"`
+}
+
+type auditRow struct {
+	PK           string `dynamo:"pk"`
+	SK           string `dynamo:"sk"`
+	ID           string `dynamo:"id"`
+	Action       string `dynamo:"action"`
+	ActorUserID  string `dynamo:"actor_user_id"`
+	TargetUserID string `dynamo:"target_user_id"`
+	Detail       string `dynamo:"detail,omitempty"`
+	CreatedAt    string `dynamo:"created_at"`
+}
+
+type Store struct {
+	db        *infra.Dynamo
+	tableName string
+}
+
+func NewStore(db *infra.Dynamo, tableName string) *Store {
+	return &Store{db: db, tableName: tableName}
+}
+
+func (s *Store) tbl() dynamo.Table {
+	return s.db.Table(s.tableName)
+}
+
+// IsAdmin reports whether the user is a platform admin.
+func (s *Store) IsAdmin(ctx context.Context, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	var row adminRow
+	err := s.tbl().Get("pk", adminPK).Range("sk", dynamo.Equal, adminSKPrefix+userID).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// PutAdmin grants platform admin to the user. Idempotent.
+func (s *Store) PutAdmin(ctx context.Context, userID string) error {
+	row := adminRow{
+		PK:        adminPK,
+		SK:        adminSKPrefix + userID,
+		UserID:    userID,
+		GrantedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	return s.tbl().Put(row).If("attribute_not_exists(pk)").Run(ctx)
+}
+
+// EnsureAdmins grants platform admin to each user ID (e.g. from PLATFORM_ADMIN_USER_IDS on startup). Idempotent.
+func (s *Store) EnsureAdmins(ctx context.Context, userIDs []string) error {
+	for _, id := range userIDs {
+		if id == "" {
+			continue
+		}
+		if err := s.PutAdmin(ctx, id); err != nil && !dynamo.IsCondCheckFailed(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutAudit writes the entry under the target user and under the day, in one transaction.
+func (s *Store) PutAudit(ctx context.Context, e AuditEntry) error {
+	sk := e.CreatedAt + "#" + e.ID
+	day := e.CreatedAt
+	if t, err := time.Parse(auditTimeLayout, e.CreatedAt); err == nil {
+		day = t.UTC().Format(auditDayLayout)
+	}
+	byUser := auditRow{
+		PK:           auditUserPKPrefix + e.TargetUserID,
+		SK:           sk,
+		ID:           e.ID,
+		Action:       e.Action,
+		ActorUserID:  e.ActorUserID,
+		TargetUserID: e.TargetUserID,
+		Detail:       e.Detail,
+		CreatedAt:    e.CreatedAt,
+	}
+	byDay := byUser
+	byDay.PK = auditDayPKPrefix + day
+	return s.db.WriteTx().
+		Put(s.tbl().Put(byUser)).
+		Put(s.tbl().Put(byDay)).
+		Run(ctx)
+}
+
+// ListAuditForUser returns the newest entries about the target user, newest first.
+func (s *Store) ListAuditForUser(ctx context.Context, targetUserID string, limit int) ([]AuditEntry, error) {
+	var rows []auditRow
+	err := s.tbl().Get("pk", auditUserPKPrefix+targetUserID).Order(dynamo.Descending).Limit(limit).All(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AuditEntry, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, AuditEntry{
+			ID:           r.ID,
+			Action:       r.Action,
+			ActorUserID:  r.ActorUserID,
+			TargetUserID: r.TargetUserID,
+			Detail:       r.Detail,
+			CreatedAt:    r.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+func newAuditEntry(action, actorUserID, targetUserID, detail string) AuditEntry {
+	return AuditEntry{
+		ID:           uuid.New().String(),
+		Action:       action,
+		ActorUserID:  actorUserID,
+		TargetUserID: targetUserID,
+		Detail:       detail,
+		CreatedAt:    time.Now().UTC().Format(auditTimeLayout),
+	}
+}
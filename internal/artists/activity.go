@@ -0,0 +1,151 @@
+package artists
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Activity log: who changed what on the page. Written by the artists, feed, invitations and domains services;
+// readable by any member with artist:list_members.
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Activity actions.
+const (
+	ActivityArtistUpdate        = "artist.update"
+	ActivityArtistRename        = "artist.rename"
+	ActivityArtistDelete        = "artist.delete"
+	ActivityArtistRestore       = "artist.restore"
+	ActivityMemberAdd           = "member.add"
+	ActivityMemberRemove        = "member.remove"
+	ActivityMemberRoles         = "member.update_roles"
+	ActivityRoleCreate          = "role.create"
+	ActivityRoleUpdate          = "role.update"
+	ActivityRoleDelete          = "role.delete"
+	ActivityTransferNominate    = "transfer.nominate"
+	ActivityTransferCancel      = "transfer.cancel"
+	ActivityTransferAccept      = "transfer.accept"
+	ActivityPostCreate          = "post.create"
+	ActivityPostUpdate          = "post.update"
+	ActivityPostDelete          = "post.delete"
+	ActivityPostPublish         = "post.publish" // scheduled post published by the scheduler; no actor
+	ActivityPostPin             = "post.pin"
+	ActivityPostUnpin           = "post.unpin"
+	ActivityVerificationRequest = "verification.request"
+	ActivityVerificationApprove = "verification.approve"
+	ActivityVerificationReject  = "verification.reject"
+	ActivityVerificationRevoke  = "verification.revoke"
+	ActivityDomainAdd           = "domain.add"
+	ActivityDomainRemove        = "domain.remove"
+)
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 100
+)
+
+// FieldChange is one field's value before and after an action. Before is empty for values that were created,
+// After for values that were removed. Lists are comma-separated.
+type FieldChange struct {
+	Field  string `json:"field" dynamo:"field"`
+	Before string `json:"before,omitempty" dynamo:"before,omitempty"`
+	After  string `json:"after,omitempty" dynamo:"after,omitempty"`
+}
+
+// AppendChange appends the change for field unless before and after are equal.
+func AppendChange(changes []FieldChange, field, before, after string) []FieldChange {
+	if before == after {
+		return changes
+	}
+	return append(changes, FieldChange{Field: field, Before: before, After: after})
+}
+
+// ActivityEntry is one action in an artist's activity log. Target is the user, post or role the action is about.
+type ActivityEntry struct {
+	ID          string        `json:"id"`
+	Action      string        `json:"action"`
+	ActorUserID string        `json:"actor_user_id"`
+	Target      string        `json:"target,omitempty"`
+	Changes     []FieldChange `json:"changes,omitempty"`
+	CreatedAt   string        `json:"created_at"`
+}
+
+// RecordActivity appends an entry to the artist's activity log; ID and CreatedAt are set here. Changes whose store
+// writes a transaction add the entry to it with ActivityPut instead, so the change is not saved without it.
+func (s *service) RecordActivity(ctx context.Context, handle string, e ActivityEntry) error {
+	return s.store.PutActivity(ctx, normalizeHandle(handle), newActivityRow(e))
+}
+
+func newActivityRow(e ActivityEntry) activityRow {
+	return activityRow{
+		ID:          uuid.New().String(),
+		Action:      e.Action,
+		ActorUserID: e.ActorUserID,
+		Target:      e.Target,
+		Changes:     e.Changes,
+		CreatedAt:   time.Now().UTC().Format(activityTimeLayout),
+	}
+}
+
+// ListActivity returns the page's activity log newest first, with cursor pagination.
+func (s *service) ListActivity(ctx context.Context, handle string, limit int, cursor, actorUserID string) ([]ActivityEntry, string, error) {
+	handle = normalizeHandle(handle)
+	if err := s.requirePermission(ctx, handle, actorUserID, PermArtistListMembers); err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+	if limit > maxActivityLimit {
+		limit = maxActivityLimit
+	}
+	// The cursor is the SK of the last entry returned, so it only works within this artist's partition.
+	var afterSK string
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || !strings.HasPrefix(string(b), activitySKPrefix) {
+			return nil, "", ErrInvalidCursor
+		}
+		afterSK = string(b)
+	}
+	rows, nextSK, err := s.store.ListActivityPage(ctx, handle, limit, afterSK)
+	if err != nil {
+		return nil, "", err
+	}
+	var next string
+	if nextSK != "" {
+		next = base64.RawURLEncoding.EncodeToString([]byte(nextSK))
+	}
+	out := make([]ActivityEntry, len(rows))
+	for i, r := range rows {
+		out[i] = ActivityEntry{
+			ID:          r.ID,
+			Action:      r.Action,
+			ActorUserID: r.ActorUserID,
+			Target:      r.Target,
+			Changes:     r.Changes,
+			CreatedAt:   r.CreatedAt,
+		}
+	}
+	return out, next, nil
+}
+
+func joinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+func sectionsString(sections []Section) string {
+	parts := make([]string, len(sections))
+	for i, sec := range sections {
+		parts[i] = sec.Name
+		if !sec.Visible {
+			parts[i] += " (hidden)"
+		}
+	}
+	return joinList(parts)
+}
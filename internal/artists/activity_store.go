@@ -0,0 +1,90 @@
+package artists
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/guregu/dynamo/v2"
+)
+
+// Activity log: append-only, one row per entry in the artist partition, newest last in SK order.
+// Activity row: PK = ARTISTS#<handle>, SK = ACTIVITY#<created_at>#<id> — action, actor, target, changes.
+
+const (
+	activitySKPrefix   = "ACTIVITY#"
+	activityTimeLayout = "2006-01-02T15:04:05.000000000Z07:00" // fixed width so SK order is time order
+)
+
+type activityRow struct {
+	PK          string        `dynamo:"pk"`
+	SK          string        `dynamo:"sk"`
+	ID          string        `dynamo:"id"`
+	Action      string        `dynamo:"action"`
+	ActorUserID string        `dynamo:"actor_user_id"`
+	Target      string        `dynamo:"target,omitempty"`
+	Changes     []FieldChange `dynamo:"changes,omitempty"`
+	CreatedAt   string        `dynamo:"created_at"`
+}
+
+func activitySK(createdAt, id string) string {
+	return activitySKPrefix + createdAt + "#" + id
+}
+
+// PutActivity appends the entry. Entries are never overwritten.
+func (s *Store) PutActivity(ctx context.Context, handle string, row activityRow) error {
+	row.PK, row.SK = artistPK(handle), activitySK(row.CreatedAt, row.ID)
+	return s.tbl().Put(row).If("attribute_not_exists(pk)").Run(ctx)
+}
+
+// ActivityPut returns the put that appends e to the artist's activity log (tbl is the single table), for a store to
+// add to the transaction of the change e records. ID and CreatedAt are set here.
+func ActivityPut(tbl dynamo.Table, handle string, e ActivityEntry) *dynamo.Put {
+	row := newActivityRow(e)
+	row.PK, row.SK = artistPK(normalizeHandle(handle)), activitySK(row.CreatedAt, row.ID)
+	return tbl.Put(row).If("attribute_not_exists(pk)")
+}
+
+// ListActivityPage returns up to limit entries newest first, starting after afterSK (empty for the first page).
+// nextSK is non-empty when more entries exist.
+func (s *Store) ListActivityPage(ctx context.Context, handle string, limit int, afterSK string) (rows []activityRow, nextSK string, err error) {
+	q := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, activitySKPrefix).
+		Order(dynamo.Descending).Limit(limit + 1)
+	if afterSK != "" {
+		q = q.StartFrom(dynamo.PagingKey{
+			"pk": &types.AttributeValueMemberS{Value: artistPK(handle)},
+			"sk": &types.AttributeValueMemberS{Value: afterSK},
+		})
+	}
+	if err := q.All(ctx, &rows); err != nil {
+		return nil, "", err
+	}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		nextSK = rows[limit-1].SK
+	}
+	return rows, nextSK, nil
+}
+
+// MoveActivity moves the activity log from oldHandle to newHandle after a rename. Entries are copied before the old
+// ones are removed, so an interrupted run can be repeated.
+func (s *Store) MoveActivity(ctx context.Context, oldHandle, newHandle string) error {
+	var rows []activityRow
+	if err := s.tbl().Get("pk", artistPK(oldHandle)).Range("sk", dynamo.BeginsWith, activitySKPrefix).All(ctx, &rows); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	puts := make([]any, len(rows))
+	keys := make([]dynamo.Keyed, len(rows))
+	for i, row := range rows {
+		keys[i] = dynamo.Keys{row.PK, row.SK}
+		row.PK = artistPK(newHandle)
+		puts[i] = row
+	}
+	if _, err := s.tbl().Batch("pk", "sk").Write().Put(puts...).Run(ctx); err != nil {
+		return err
+	}
+	_, err := s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
@@ -0,0 +1,117 @@
+package artists
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Request cache: a read-through cache of artist main rows and member rows that lives for one HTTP request, so the
+// services that look up the same page and membership (feed checks the artist, then the actor's permission) read
+// DynamoDB once. Writes through Store and MemberStore drop the handle's entries. follower_count, which follows
+// updates on the main row, may be stale for the rest of the request that changed it.
+
+type requestCacheKey struct{}
+
+type requestCache struct {
+	mu      sync.Mutex
+	artists map[string]*artistRow // nil value: known not to exist
+	members map[string]*memberRow // key: handle + "#" + user id
+}
+
+// WithRequestCache returns ctx carrying an empty artist and member cache. Set once per HTTP request (router
+// middleware); without it every lookup goes to DynamoDB.
+func WithRequestCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestCacheKey{}, &requestCache{
+		artists: make(map[string]*artistRow),
+		members: make(map[string]*memberRow),
+	})
+}
+
+// cacheFrom returns the request's cache, or nil. All methods are no-ops on a nil cache.
+func cacheFrom(ctx context.Context) *requestCache {
+	c, _ := ctx.Value(requestCacheKey{}).(*requestCache)
+	return c
+}
+
+func memberCacheKey(handle, userID string) string {
+	return handle + "#" + userID
+}
+
+// artist returns a copy of the cached row (nil if known missing) and whether the handle was cached.
+func (c *requestCache) artist(handle string) (*artistRow, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	row, ok := c.artists[handle]
+	return copyArtistRow(row), ok
+}
+
+func (c *requestCache) putArtist(handle string, row *artistRow) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.artists[handle] = copyArtistRow(row)
+}
+
+// member returns a copy of the cached row (nil if known missing) and whether the membership was cached.
+func (c *requestCache) member(handle, userID string) (*memberRow, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	row, ok := c.members[memberCacheKey(handle, userID)]
+	return copyMemberRow(row), ok
+}
+
+func (c *requestCache) putMember(handle, userID string, row *memberRow) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.members[memberCacheKey(handle, userID)] = copyMemberRow(row)
+}
+
+// forget drops the artist row and every member row cached for the handles.
+func (c *requestCache) forget(handles ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, h := range handles {
+		delete(c.artists, h)
+		for k := range c.members {
+			if strings.HasPrefix(k, h+"#") {
+				delete(c.members, k)
+			}
+		}
+	}
+}
+
+// Callers modify the rows they get (e.g. Update builds the new row from the old), so the cache hands out copies.
+
+func copyArtistRow(row *artistRow) *artistRow {
+	if row == nil {
+		return nil
+	}
+	out := *row
+	out.Sections = slices.Clone(row.Sections)
+	return &out
+}
+
+func copyMemberRow(row *memberRow) *memberRow {
+	if row == nil {
+		return nil
+	}
+	out := *row
+	out.Roles = slices.Clone(row.Roles)
+	return &out
+}
@@ -0,0 +1,284 @@
+package artists
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/guregu/dynamo/v2"
+)
+
+// Custom roles let an artist bundle permissions under its own role names (e.g. "Tour manager" with gigs and photos).
+// Managed with artist:manage_members; any member can list them.
+
+var (
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrInvalidRoleName    = errors.New("role name must be 1–40 characters and not a predefined role")
+	ErrInvalidPermissions = errors.New("permissions must list at least one known permission")
+	ErrRoleNameTaken      = errors.New("a role with this name already exists")
+	ErrTooManyRoles       = errors.New("an artist can have at most 20 custom roles")
+	ErrRoleInUse          = errors.New("role is assigned to members; remove it from them first")
+)
+
+const (
+	maxCustomRoles    = 20
+	maxCustomRoleName = 40
+)
+
+// Role is a predefined or custom role. Members reference a role by ID: the role name for predefined roles,
+// a generated id for custom roles.
+type Role struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	Custom      bool     `json:"custom"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+}
+
+// PredefinedRoles returns the assignable predefined roles with their permissions.
+func PredefinedRoles() []Role {
+	names := AllPredefinedRoles()
+	out := make([]Role, len(names))
+	for i, name := range names {
+		out[i] = Role{ID: name, Name: name, Permissions: RolePermissions(name)}
+	}
+	return out
+}
+
+func rowToRole(r *customRoleRow) Role {
+	return Role{ID: r.ID, Name: r.Name, Permissions: r.Permissions, Custom: true, CreatedAt: r.CreatedAt}
+}
+
+func normalizeRoleName(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || utf8.RuneCountInString(s) > maxCustomRoleName || PredefinedRole(strings.ToLower(s)) {
+		return "", ErrInvalidRoleName
+	}
+	return s, nil
+}
+
+func normalizePermissions(perms []string) ([]string, error) {
+	perms = dedupeRoles(perms)
+	if len(perms) == 0 {
+		return nil, ErrInvalidPermissions
+	}
+	for _, p := range perms {
+		if !GrantablePermission(p) {
+			return nil, ErrInvalidPermissions
+		}
+	}
+	return perms, nil
+}
+
+// ListRoles returns the predefined roles followed by the artist's custom roles. Any page member can list.
+func (s *service) ListRoles(ctx context.Context, handle, actorUserID string) ([]Role, error) {
+	handle = normalizeHandle(handle)
+	if err := s.requirePermission(ctx, handle, actorUserID, PermArtistListMembers); err != nil {
+		return nil, err
+	}
+	rows, err := s.store.ListCustomRoles(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	out := PredefinedRoles()
+	for i := range rows {
+		out = append(out, rowToRole(&rows[i]))
+	}
+	return out, nil
+}
+
+// CreateRole adds a custom role.
+func (s *service) CreateRole(ctx context.Context, handle, name string, permissions []string, actorUserID string) (*Role, error) {
+	handle = normalizeHandle(handle)
+	if err := s.requirePermission(ctx, handle, actorUserID, PermArtistManageMembers); err != nil {
+		return nil, err
+	}
+	name, err := normalizeRoleName(name)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err = normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.store.ListCustomRoles(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxCustomRoles {
+		return nil, ErrTooManyRoles
+	}
+	if roleNameTaken(existing, name, "") {
+		return nil, ErrRoleNameTaken
+	}
+	row := customRoleRow{
+		ID:          uuid.New().String(),
+		Handle:      handle,
+		Name:        name,
+		Permissions: permissions,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	var changes []FieldChange
+	changes = AppendChange(changes, "name", "", row.Name)
+	changes = AppendChange(changes, "permissions", "", joinList(row.Permissions))
+	if err := s.store.PutCustomRole(ctx, row, &ActivityEntry{Action: ActivityRoleCreate, ActorUserID: actorUserID, Target: row.ID, Changes: changes}); err != nil {
+		return nil, err
+	}
+	role := rowToRole(&row)
+	return &role, nil
+}
+
+// UpdateRole renames a custom role and/or replaces its permissions; nil fields are left unchanged. Members holding
+// the role get the new permissions at once.
+func (s *service) UpdateRole(ctx context.Context, handle, roleID string, name *string, permissions []string, actorUserID string) (*Role, error) {
+	handle = normalizeHandle(handle)
+	if err := s.requirePermission(ctx, handle, actorUserID, PermArtistManageMembers); err != nil {
+		return nil, err
+	}
+	row, err := s.store.GetCustomRole(ctx, handle, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrRoleNotFound
+	}
+	before := *row
+	if name != nil {
+		if row.Name, err = normalizeRoleName(*name); err != nil {
+			return nil, err
+		}
+		existing, err := s.store.ListCustomRoles(ctx, handle)
+		if err != nil {
+			return nil, err
+		}
+		if roleNameTaken(existing, row.Name, row.ID) {
+			return nil, ErrRoleNameTaken
+		}
+	}
+	if permissions != nil {
+		if row.Permissions, err = normalizePermissions(permissions); err != nil {
+			return nil, err
+		}
+	}
+	var changes []FieldChange
+	changes = AppendChange(changes, "name", before.Name, row.Name)
+	changes = AppendChange(changes, "permissions", joinList(before.Permissions), joinList(row.Permissions))
+	var activity *ActivityEntry
+	if len(changes) > 0 {
+		activity = &ActivityEntry{Action: ActivityRoleUpdate, ActorUserID: actorUserID, Target: row.ID, Changes: changes}
+	}
+	if err := s.store.UpdateCustomRole(ctx, *row, activity); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	role := rowToRole(row)
+	return &role, nil
+}
+
+// DeleteRole removes a custom role that no member holds.
+func (s *service) DeleteRole(ctx context.Context, handle, roleID, actorUserID string) error {
+	handle = normalizeHandle(handle)
+	if err := s.requirePermission(ctx, handle, actorUserID, PermArtistManageMembers); err != nil {
+		return err
+	}
+	members, err := s.memberStore.ListByArtist(ctx, handle)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		for _, r := range m.Roles {
+			if r == roleID {
+				return ErrRoleInUse
+			}
+		}
+	}
+	row, err := s.store.GetCustomRole(ctx, handle, roleID)
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		return ErrRoleNotFound
+	}
+	var changes []FieldChange
+	changes = AppendChange(changes, "name", row.Name, "")
+	changes = AppendChange(changes, "permissions", joinList(row.Permissions), "")
+	err = s.store.DeleteCustomRole(ctx, handle, roleID, ActivityEntry{Action: ActivityRoleDelete, ActorUserID: actorUserID, Target: roleID, Changes: changes})
+	if dynamo.IsCondCheckFailed(err) {
+		return ErrRoleNotFound
+	}
+	return err
+}
+
+// RolesAssignable returns true if every role is an assignable predefined role or one of the artist's custom roles.
+func (s *service) RolesAssignable(ctx context.Context, handle string, roles []string) (bool, error) {
+	handle = normalizeHandle(handle)
+	for _, r := range roles {
+		if AssignableRole(r) {
+			continue
+		}
+		if PredefinedRole(r) {
+			return false, nil
+		}
+		row, err := s.store.GetCustomRole(ctx, handle, r)
+		if err != nil || row == nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// customRolesGrantPermission returns true if any of the member's custom roles grants the permission. Role ids that
+// no longer exist grant nothing.
+func (s *service) customRolesGrantPermission(ctx context.Context, handle string, roles []string, permission string) (bool, error) {
+	for _, r := range roles {
+		if PredefinedRole(r) {
+			continue
+		}
+		row, err := s.store.GetCustomRole(ctx, handle, r)
+		if err != nil {
+			return false, err
+		}
+		if row == nil {
+			continue
+		}
+		for _, p := range row.Permissions {
+			if p == permission {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// requirePermission returns ErrArtistNotFound or ErrForbidden unless the user has the permission on the page.
+func (s *service) requirePermission(ctx context.Context, handle, userID, permission string) error {
+	row, err := s.activeRow(ctx, handle)
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		return ErrArtistNotFound
+	}
+	ok, err := s.HasPermission(ctx, handle, userID, permission)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func roleNameTaken(rows []customRoleRow, name, exceptID string) bool {
+	for _, r := range rows {
+		if r.ID != exceptID && strings.EqualFold(r.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package artists
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Custom roles: one row per role in the artist partition. Members reference a custom role by its id in their roles
+// list, next to predefined role names.
+// Custom role row: PK = ARTISTS#<handle>, SK = ROLE#<id> — name, permissions.
+
+const customRoleSKPrefix = "ROLE#"
+
+type customRoleRow struct {
+	PK          string   `dynamo:"pk"`
+	SK          string   `dynamo:"sk"`
+	ID          string   `dynamo:"id"`
+	Handle      string   `dynamo:"handle"`
+	Name        string   `dynamo:"name"`
+	Permissions []string `dynamo:"permissions"`
+	CreatedAt   string   `dynamo:"created_at"`
+}
+
+func customRoleSK(id string) string {
+	return customRoleSKPrefix + id
+}
+
+// ListCustomRoles returns the artist's custom roles, oldest first.
+func (s *Store) ListCustomRoles(ctx context.Context, handle string) ([]customRoleRow, error) {
+	var out []customRoleRow
+	if err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, customRoleSKPrefix).All(ctx, &out); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out, nil
+}
+
+// GetCustomRole returns the custom role, or nil if not found.
+func (s *Store) GetCustomRole(ctx context.Context, handle, id string) (*customRoleRow, error) {
+	var row customRoleRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, customRoleSK(id)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// PutCustomRole creates the custom role, with the activity entry (nil for none), in one transaction.
+func (s *Store) PutCustomRole(ctx context.Context, row customRoleRow, activity *ActivityEntry) error {
+	row.PK, row.SK = artistPK(row.Handle), customRoleSK(row.ID)
+	tx := s.db.WriteTx().Put(s.tbl().Put(row))
+	if activity != nil {
+		tx = tx.Put(ActivityPut(s.tbl(), row.Handle, *activity))
+	}
+	return tx.Run(ctx)
+}
+
+// UpdateCustomRole replaces an existing custom role, with the activity entry (nil for none), in one transaction. Fails
+// with a condition check if the role was deleted meanwhile, so an edit never brings it back.
+func (s *Store) UpdateCustomRole(ctx context.Context, row customRoleRow, activity *ActivityEntry) error {
+	row.PK, row.SK = artistPK(row.Handle), customRoleSK(row.ID)
+	tx := s.db.WriteTx().Put(s.tbl().Put(row).If("attribute_exists(pk)"))
+	if activity != nil {
+		tx = tx.Put(ActivityPut(s.tbl(), row.Handle, *activity))
+	}
+	return tx.Run(ctx)
+}
+
+// DeleteCustomRole removes the custom role, with the activity entry, in one transaction. Fails with a condition check
+// if it does not exist.
+func (s *Store) DeleteCustomRole(ctx context.Context, handle, id string, activity ActivityEntry) error {
+	return s.db.WriteTx().
+		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", customRoleSK(id)).If("attribute_exists(pk)")).
+		Put(ActivityPut(s.tbl(), handle, activity)).
+		Run(ctx)
+}
+
+// MoveCustomRoles moves all custom roles from oldHandle to newHandle after a rename. Role ids are kept, so member
+// rows stay valid. Idempotent.
+func (s *Store) MoveCustomRoles(ctx context.Context, oldHandle, newHandle string) error {
+	rows, err := s.ListCustomRoles(ctx, oldHandle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		moved := row
+		moved.PK, moved.Handle = artistPK(newHandle), newHandle
+		err := s.db.WriteTx().
+			Put(s.tbl().Put(moved)).
+			Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", customRoleSK(row.ID))).
+			Run(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package artists
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+var (
+	ErrNotPendingDeletion = errors.New("artist page is not pending deletion")
+	ErrRestoreExpired     = errors.New("restore period has ended; the page is being permanently deleted")
+)
+
+// DeletionGracePeriod is how long a deleted page can be restored before the purge job removes it for good.
+const DeletionGracePeriod = 30 * 24 * time.Hour
+
+// StatusPendingDeletion is Artist.Status while a deleted page can still be restored.
+const StatusPendingDeletion = "pending_deletion"
+
+// purgeBatchSize bounds how many pages one PurgeDue call removes.
+const purgeBatchSize = 25
+
+// HandlePurger removes data keyed by an artist handle (posts and their search documents, followers, invitations, ...)
+// when a deleted page is purged, including rows outside the artist partition. Implementations must be idempotent so
+// an interrupted purge is retried by the next run.
+type HandlePurger interface {
+	PurgeHandle(ctx context.Context, handle string) error
+}
+
+// HandleData is handle-keyed data owned by another package: moved on rename, removed on purge.
+type HandleData interface {
+	HandleMigrator
+	HandlePurger
+}
+
+// activeRow returns the artist main row, or nil if there is none or it is pending deletion. Pending pages are hidden
+// from every read and write except Restore.
+func (s *service) activeRow(ctx context.Context, handle string) (*artistRow, error) {
+	row, err := s.store.GetByHandle(ctx, handle)
+	if err != nil || row == nil || row.DeletedAt != "" {
+		return nil, err
+	}
+	return row, nil
+}
+
+// Delete puts the page in pending deletion (owner only): it is hidden at once and purged after
+// DeletionGracePeriod unless the owner restores it. The handle stays taken until the purge.
+func (s *service) Delete(ctx context.Context, handle, actorUserID string) error {
+	handle = normalizeHandle(handle)
+	if handle == "" {
+		return ErrArtistNotFound
+	}
+
+	row, err := s.activeRow(ctx, handle)
+	if err != nil || row == nil {
+		return ErrArtistNotFound
+	}
+	ok, err := s.HasPermission(ctx, handle, actorUserID, PermArtistDelete)
+	if err != nil || !ok {
+		return ErrForbidden
+	}
+	now := time.Now().UTC()
+	activity := ActivityEntry{Action: ActivityArtistDelete, ActorUserID: actorUserID}
+	err = s.store.MarkDeleted(ctx, row, now.Format(time.RFC3339), now.Add(DeletionGracePeriod).Format(time.RFC3339), activity)
+	if dynamo.IsCondCheckFailed(err) {
+		return ErrArtistNotFound
+	}
+	return err
+}
+
+// Restore brings back a page pending deletion (owner only) before its purge_after.
+func (s *service) Restore(ctx context.Context, handle, actorUserID string) (*Artist, error) {
+	handle = normalizeHandle(handle)
+	row, err := s.store.GetByHandle(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrArtistNotFound
+	}
+	if row.OwnerUserID != actorUserID {
+		// Members lose access while the page is pending deletion; don't reveal it to them.
+		if row.DeletedAt != "" {
+			return nil, ErrArtistNotFound
+		}
+		return nil, ErrForbidden
+	}
+	if row.DeletedAt == "" {
+		return nil, ErrNotPendingDeletion
+	}
+	if purgeAfter, err := time.Parse(time.RFC3339, row.PurgeAfter); err == nil && !time.Now().Before(purgeAfter) {
+		return nil, ErrRestoreExpired
+	}
+	if err := s.store.Restore(ctx, row, ActivityEntry{Action: ActivityArtistRestore, ActorUserID: actorUserID}); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrNotPendingDeletion
+		}
+		return nil, err
+	}
+	row.DeletedAt, row.PurgeAfter = "", ""
+	return rowToArtist(row), nil
+}
+
+// PurgeDue permanently removes up to purgeBatchSize pages whose restore period ended before now, with everything
+// keyed by their handle. Returns how many pages were purged. A page that fails stays queued for the next run.
+func (s *service) PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.store.ListPurgeDue(ctx, now.UTC().Format(time.RFC3339), purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	var errs []error
+	for _, q := range due {
+		ok, err := s.purge(ctx, q)
+		if err != nil {
+			errs = append(errs, err)
+		} else if ok {
+			purged++
+		}
+	}
+	return purged, errors.Join(errs...)
+}
+
+// purge removes one queued page. ok is false when the entry was stale (page restored or already gone).
+func (s *service) purge(ctx context.Context, q purgeQueueRow) (ok bool, err error) {
+	row, err := s.store.GetByHandle(ctx, q.Handle)
+	if err != nil {
+		return false, err
+	}
+	if row == nil || row.DeletedAt == "" || row.PurgeAfter != q.PurgeAfter {
+		return false, s.store.DeleteQueueEntry(ctx, q)
+	}
+	for _, d := range s.handleData {
+		if err := d.PurgeHandle(ctx, q.Handle); err != nil {
+			return false, err
+		}
+	}
+	if err := s.memberStore.PurgeHandle(ctx, q.Handle); err != nil {
+		return false, err
+	}
+	if err := s.store.DeleteVerificationQueueEntry(ctx, q.Handle); err != nil {
+		return false, err
+	}
+	if err := s.store.DeletePartition(ctx, q.Handle); err != nil {
+		return false, err
+	}
+	// Restore is refused once purge_after has passed, so the page cannot come back while this runs.
+	if err := s.store.Purge(ctx, row); err != nil {
+		return false, err
+	}
+	return true, nil
+}
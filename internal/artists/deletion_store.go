@@ -0,0 +1,117 @@
+package artists
+
+import (
+	"context"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Soft delete: deleting a page sets deleted_at and purge_after on the main row and the owner index row, and queues
+// the handle for purge. Until purge_after the owner can restore the page; afterwards the purge job removes every row.
+// Purge queue row: PK = ARTISTS#PURGE, SK = <purge_after>#<handle> — query due entries by SK without a scan.
+// (Handles are lowercase, so ARTISTS#PURGE cannot collide with an artist partition.)
+
+const purgeQueuePK = "ARTISTS#PURGE"
+
+type purgeQueueRow struct {
+	PK          string `dynamo:"pk"`
+	SK          string `dynamo:"sk"`
+	Handle      string `dynamo:"handle"`
+	OwnerUserID string `dynamo:"owner_user_id"`
+	DeletedAt   string `dynamo:"deleted_at"`
+	PurgeAfter  string `dynamo:"purge_after"`
+}
+
+func purgeQueueSK(purgeAfter, handle string) string {
+	return purgeAfter + "#" + handle
+}
+
+// MarkDeleted puts the artist in pending deletion and queues it for purge, with the activity entry, in one
+// transaction. Any pending ownership transfer is discarded. Fails with a condition check if the page is already
+// pending deletion or changed owner meanwhile.
+func (s *Store) MarkDeleted(ctx context.Context, artist *artistRow, deletedAt, purgeAfter string, activity ActivityEntry) error {
+	cacheFrom(ctx).forget(artist.Handle)
+	queued := purgeQueueRow{
+		PK:          purgeQueuePK,
+		SK:          purgeQueueSK(purgeAfter, artist.Handle),
+		Handle:      artist.Handle,
+		OwnerUserID: artist.OwnerUserID,
+		DeletedAt:   deletedAt,
+		PurgeAfter:  purgeAfter,
+	}
+	return s.db.WriteTx().
+		Update(s.tbl().Update("pk", artistPK(artist.Handle)).Range("sk", artistSK).
+			Set("deleted_at", deletedAt).
+			Set("purge_after", purgeAfter).
+			If("attribute_not_exists(deleted_at) AND owner_user_id = ?", artist.OwnerUserID)).
+		Update(s.tbl().Update("pk", userIndexPK(artist.OwnerUserID)).Range("sk", userIndexSK(artist.Handle)).
+			Set("deleted_at", deletedAt).
+			Set("purge_after", purgeAfter)).
+		Delete(s.tbl().Delete("pk", artistPK(artist.Handle)).Range("sk", transferSK)).
+		Put(s.tbl().Put(queued)).
+		Put(ActivityPut(s.tbl(), artist.Handle, activity)).
+		Run(ctx)
+}
+
+// Restore clears pending deletion and removes the purge queue entry, with the activity entry, in one transaction.
+// Fails with a condition check if the page is no longer pending deletion (restored or purged meanwhile).
+func (s *Store) Restore(ctx context.Context, artist *artistRow, activity ActivityEntry) error {
+	cacheFrom(ctx).forget(artist.Handle)
+	return s.db.WriteTx().
+		Update(s.tbl().Update("pk", artistPK(artist.Handle)).Range("sk", artistSK).
+			Remove("deleted_at", "purge_after").
+			If("deleted_at = ?", artist.DeletedAt)).
+		Update(s.tbl().Update("pk", userIndexPK(artist.OwnerUserID)).Range("sk", userIndexSK(artist.Handle)).
+			Remove("deleted_at", "purge_after")).
+		Delete(s.tbl().Delete("pk", purgeQueuePK).Range("sk", purgeQueueSK(artist.PurgeAfter, artist.Handle))).
+		Put(ActivityPut(s.tbl(), artist.Handle, activity)).
+		Run(ctx)
+}
+
+// ListPurgeDue returns queued pages whose purge_after is before the given RFC 3339 time, oldest first.
+func (s *Store) ListPurgeDue(ctx context.Context, before string, limit int) ([]purgeQueueRow, error) {
+	var out []purgeQueueRow
+	err := s.tbl().Get("pk", purgeQueuePK).Range("sk", dynamo.Less, before).Limit(limit).All(ctx, &out)
+	return out, err
+}
+
+// DeletePartition removes every row in the artist partition except the main row (posts, members, followers,
+// links, invitation index rows, ...). Rows in other partitions are the purgers' job.
+func (s *Store) DeletePartition(ctx context.Context, handle string) error {
+	cacheFrom(ctx).forget(handle)
+	var keys []dynamo.Keyed
+	iter := s.tbl().Get("pk", artistPK(handle)).Project("pk", "sk").Iter()
+	var row struct {
+		PK string `dynamo:"pk"`
+		SK string `dynamo:"sk"`
+	}
+	for iter.Next(ctx, &row) {
+		if row.SK != artistSK {
+			keys = append(keys, dynamo.Keys{row.PK, row.SK})
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
+
+// Purge removes the main row, the owner index row and the purge queue entry, in one transaction, once everything
+// else is gone. Fails with a condition check if the page was restored meanwhile.
+func (s *Store) Purge(ctx context.Context, artist *artistRow) error {
+	cacheFrom(ctx).forget(artist.Handle)
+	return s.db.WriteTx().
+		Delete(s.tbl().Delete("pk", artistPK(artist.Handle)).Range("sk", artistSK).If("deleted_at = ?", artist.DeletedAt)).
+		Delete(s.tbl().Delete("pk", userIndexPK(artist.OwnerUserID)).Range("sk", userIndexSK(artist.Handle))).
+		Delete(s.tbl().Delete("pk", purgeQueuePK).Range("sk", purgeQueueSK(artist.PurgeAfter, artist.Handle))).
+		Run(ctx)
+}
+
+// DeleteQueueEntry removes a purge queue entry whose page no longer exists or is no longer pending deletion.
+func (s *Store) DeleteQueueEntry(ctx context.Context, row purgeQueueRow) error {
+	return s.tbl().Delete("pk", purgeQueuePK).Range("sk", row.SK).Run(ctx)
+}
@@ -2,7 +2,10 @@ package artists
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/sopatech/afterwave.fm/internal/auth"
 )
@@ -38,6 +41,8 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case err == ErrHandleTaken:
 			http.Error(w, "handle already in use", http.StatusConflict)
+		case err == ErrHandleReserved:
+			http.Error(w, err.Error(), http.StatusConflict)
 		default:
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		}
@@ -70,7 +75,8 @@ func (h *Handler) ListMine(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]any{"artists": list})
 }
 
-// GetByHandle returns an artist by handle (public).
+// GetByHandle returns an artist by handle (public; private pages only for members). A former handle redirects (301)
+// to the current one.
 func (h *Handler) GetByHandle(w http.ResponseWriter, r *http.Request) {
 	handle := r.PathValue("handle")
 	if handle == "" {
@@ -78,17 +84,26 @@ func (h *Handler) GetByHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	artist, err := h.svc.GetByHandle(r.Context(), handle)
+	artist, err := h.svc.GetForViewer(r.Context(), handle, auth.UserIDFromContext(r.Context()))
+	var moved *MovedError
+	if errors.As(err, &moved) {
+		writeMoved(w, r, handle, moved.Handle)
+		return
+	}
 	if err != nil || artist == nil {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
+	if artist.Links, err = h.svc.ListLinks(r.Context(), artist.Handle); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(artist)
 }
 
-// Update updates an artist (owner only).
+// Update updates an artist: display name and bio (owner or admin), branding and sections (site:edit).
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	userID := auth.UserIDFromContext(r.Context())
 	if userID == "" {
@@ -102,22 +117,21 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var body struct {
-		DisplayName *string `json:"display_name"`
-		Bio         *string `json:"bio"`
-	}
+	var body ArtistUpdate
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
 
-	artist, err := h.svc.Update(r.Context(), handle, body.DisplayName, body.Bio, userID)
+	artist, err := h.svc.Update(r.Context(), handle, body, userID)
 	if err != nil {
 		switch {
 		case err == ErrArtistNotFound:
 			http.Error(w, "not found", http.StatusNotFound)
 		case err == ErrForbidden:
 			http.Error(w, "forbidden", http.StatusForbidden)
+		case err == ErrInvalidColor, err == ErrInvalidImageURL, err == ErrInvalidSections, err == ErrInvalidVisibility:
+			http.Error(w, err.Error(), http.StatusBadRequest)
 		default:
 			http.Error(w, "internal error", http.StatusInternalServerError)
 		}
@@ -128,7 +142,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(artist)
 }
 
-// Delete deletes an artist page (owner only).
+// Delete puts an artist page in pending deletion (owner only). The owner can restore it for DeletionGracePeriod.
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	userID := auth.UserIDFromContext(r.Context())
 	if userID == "" {
@@ -158,6 +172,38 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// Restore brings back a page pending deletion (owner only).
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	handle := r.PathValue("handle")
+	if handle == "" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	artist, err := h.svc.Restore(r.Context(), handle, userID)
+	if err != nil {
+		switch {
+		case err == ErrArtistNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case err == ErrForbidden:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case err == ErrNotPendingDeletion:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err == ErrRestoreExpired:
+			http.Error(w, err.Error(), http.StatusGone)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(artist)
+}
+
 // ListMembers returns members (user_id + roles) for the artist. Any page member (owner or any role) can list; only owner or admin can add/update/remove.
 func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
 	userID := auth.UserIDFromContext(r.Context())
@@ -296,3 +342,493 @@ func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// NominateOwner nominates an existing member as the new owner. Owner only. Body: {"user_id": "...", "keep_as_admin": true}.
+func (h *Handler) NominateOwner(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	handle := r.PathValue("handle")
+	if handle == "" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	var body struct {
+		UserID      string `json:"user_id"`
+		KeepAsAdmin bool   `json:"keep_as_admin"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	transfer, err := h.svc.NominateOwner(r.Context(), handle, body.UserID, body.KeepAsAdmin, userID)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// GetTransfer returns the pending ownership transfer. Owner or nominee only.
+func (h *Handler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	transfer, err := h.svc.GetTransfer(r.Context(), r.PathValue("handle"), userID)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// AcceptTransfer makes the authenticated nominee the owner.
+func (h *Handler) AcceptTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	artist, err := h.svc.AcceptTransfer(r.Context(), r.PathValue("handle"), userID)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(artist)
+}
+
+// CancelTransfer withdraws (owner) or declines (nominee) the pending ownership transfer.
+func (h *Handler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.svc.CancelTransfer(r.Context(), r.PathValue("handle"), userID); err != nil {
+		writeTransferError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTransferError(w http.ResponseWriter, err error) {
+	switch {
+	case err == ErrArtistNotFound, err == ErrTransferNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err == ErrForbidden:
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case err == ErrNomineeNotMember:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == ErrTransferConflict:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// Rename changes the artist's handle. Owner only. Body: {"handle": "newhandle"}.
+func (h *Handler) Rename(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	handle := r.PathValue("handle")
+	if handle == "" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	var body struct {
+		Handle string `json:"handle"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	artist, err := h.svc.Rename(r.Context(), handle, body.Handle, userID)
+	if err != nil {
+		switch {
+		case err == ErrArtistNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case err == ErrForbidden:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case err == ErrHandleTaken:
+			http.Error(w, "handle already in use", http.StatusConflict)
+		case err == ErrHandleReserved:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err == ErrInvalidHandle, err == ErrSameHandle, err == ErrHandleDisallowed:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(artist)
+}
+
+// Availability reports whether the authenticated user could create an artist with the handle (create-page UI).
+// Always 200; see HandleAvailability for the reasons.
+func (h *Handler) Availability(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	a, err := h.svc.CheckAvailability(r.Context(), r.PathValue("handle"), userID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// ListLinks returns the artist's external links in display order (public; private pages only for members).
+func (h *Handler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.svc.GetForViewer(r.Context(), r.PathValue("handle"), auth.UserIDFromContext(r.Context())); err == ErrArtistNotFound {
+		writeLinkError(w, err)
+		return
+	}
+	links, err := h.svc.ListLinks(r.Context(), r.PathValue("handle"))
+	if err != nil {
+		writeLinkError(w, err)
+		return
+	}
+	if links == nil {
+		links = []Link{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"links": links})
+}
+
+// CreateLink adds a link at the end of the list. Body: {"label": "...", "url": "https://..."}.
+func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Label string `json:"label"`
+		URL   string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	link, err := h.svc.CreateLink(r.Context(), r.PathValue("handle"), body.Label, body.URL, userID)
+	if err != nil {
+		writeLinkError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// UpdateLink changes a link's label and/or URL. Body: {"label": "...", "url": "..."} (both optional).
+func (h *Handler) UpdateLink(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Label *string `json:"label"`
+		URL   *string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	link, err := h.svc.UpdateLink(r.Context(), r.PathValue("handle"), r.PathValue("linkId"), body.Label, body.URL, userID)
+	if err != nil {
+		writeLinkError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// DeleteLink removes a link.
+func (h *Handler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.svc.DeleteLink(r.Context(), r.PathValue("handle"), r.PathValue("linkId"), userID); err != nil {
+		writeLinkError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderLinks sets the display order. Body: {"ids": ["...", ...]} listing every link exactly once.
+func (h *Handler) ReorderLinks(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	links, err := h.svc.ReorderLinks(r.Context(), r.PathValue("handle"), body.IDs, userID)
+	if err != nil {
+		writeLinkError(w, err)
+		return
+	}
+	if links == nil {
+		links = []Link{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"links": links})
+}
+
+func writeLinkError(w http.ResponseWriter, err error) {
+	switch {
+	case err == ErrArtistNotFound, err == ErrLinkNotFound:
+		http.Error(w, "not found", http.StatusNotFound)
+	case err == ErrForbidden:
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case err == ErrInvalidLinkLabel, err == ErrInvalidLinkURL, err == ErrInvalidLinkOrder:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == ErrTooManyLinks:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// Permissions returns the catalog of permissions custom roles can grant and the predefined roles (public).
+func (h *Handler) Permissions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"permissions": Permissions(), "roles": PredefinedRoles()})
+}
+
+// ListRoles returns the predefined roles and the artist's custom roles. Any page member can list.
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	roles, err := h.svc.ListRoles(r.Context(), r.PathValue("handle"), userID)
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"roles": roles})
+}
+
+// CreateRole adds a custom role. Body: {"name": "Tour manager", "permissions": ["gigs:manage", ...]}.
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Name        string   `json:"name"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	role, err := h.svc.CreateRole(r.Context(), r.PathValue("handle"), body.Name, body.Permissions, userID)
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(role)
+}
+
+// UpdateRole renames a custom role and/or replaces its permissions. Body: {"name": "...", "permissions": [...]}
+// (both optional).
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Name        *string  `json:"name"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	role, err := h.svc.UpdateRole(r.Context(), r.PathValue("handle"), r.PathValue("roleId"), body.Name, body.Permissions, userID)
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(role)
+}
+
+// DeleteRole removes a custom role that no member holds.
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.svc.DeleteRole(r.Context(), r.PathValue("handle"), r.PathValue("roleId"), userID); err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case err == ErrArtistNotFound, err == ErrRoleNotFound:
+		http.Error(w, "not found", http.StatusNotFound)
+	case err == ErrForbidden:
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case err == ErrInvalidRoleName, err == ErrInvalidPermissions:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == ErrRoleNameTaken, err == ErrTooManyRoles, err == ErrRoleInUse:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// ListActivity returns the page's activity log (members with artist:list_members), newest first. Cursor-based
+// pagination: limit (default 50, max 100), cursor (from previous next_cursor), has_more, next_cursor.
+func (h *Handler) ListActivity(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	limit := 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	entries, nextCursor, err := h.svc.ListActivity(r.Context(), r.PathValue("handle"), limit, r.URL.Query().Get("cursor"), userID)
+	if err != nil {
+		switch {
+		case err == ErrArtistNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case err == ErrForbidden:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case err == ErrInvalidCursor:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if entries == nil {
+		entries = []ActivityEntry{}
+	}
+	out := map[string]any{"activity": entries, "has_more": nextCursor != ""}
+	if nextCursor != "" {
+		out["next_cursor"] = nextCursor
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// RequestVerification asks platform admins to verify the page (artist:update).
+// Body: {"evidence_links": ["https://..."], "note": "..."}.
+func (h *Handler) RequestVerification(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		EvidenceLinks []string `json:"evidence_links"`
+		Note          string   `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	v, err := h.svc.RequestVerification(r.Context(), r.PathValue("handle"), body.EvidenceLinks, body.Note, userID)
+	if err != nil {
+		writeVerificationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(v)
+}
+
+// GetVerification returns the page's verification request and its review (artist:update).
+func (h *Handler) GetVerification(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	v, err := h.svc.GetVerification(r.Context(), r.PathValue("handle"), userID)
+	if err != nil {
+		writeVerificationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeVerificationError(w http.ResponseWriter, err error) {
+	switch {
+	case err == ErrArtistNotFound, err == ErrVerificationNotFound:
+		http.Error(w, "not found", http.StatusNotFound)
+	case err == ErrForbidden:
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case err == ErrInvalidEvidence, err == ErrInvalidVerificationNote:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == ErrAlreadyVerified, err == ErrVerificationPending:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// writeMoved answers a request for a former handle with 301 to the same path under the current handle.
+func writeMoved(w http.ResponseWriter, r *http.Request, oldHandle, newHandle string) {
+	// RequestURI keeps the /v1 prefix that the router strips from URL.Path.
+	location, _, _ := strings.Cut(r.RequestURI, "?")
+	if location == "" {
+		location = r.URL.Path
+	}
+	if i := strings.Index(location, "/artists/"); i >= 0 {
+		rest := location[i+len("/artists/"):]
+		if j := strings.IndexByte(rest, '/'); j >= 0 {
+			rest = rest[j:]
+		} else {
+			rest = ""
+		}
+		location = location[:i] + "/artists/" + newHandle + rest
+	}
+	w.Header().Set("Location", location)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMovedPermanently)
+	json.NewEncoder(w).Encode(map[string]string{"handle": newHandle, "previous_handle": oldHandle})
+}
@@ -0,0 +1,107 @@
+package artists
+
+import "strings"
+
+// Handle policy: some valid handles cannot be claimed.
+// Reserved handles (exact match) are platform words and well-known names; a platform admin can grant one to a user.
+// Denied terms (substring match) block profanity and impersonation of the platform anywhere in the handle. Allowed
+// words are innocent words that contain a denied term ("scunthorpe"); they are taken out before the terms are matched.
+// All are compared on a confusable skeleton, so "adm1n" is as reserved as "admin" and "aftervvave" contains "afterwave".
+
+// Availability reasons.
+const (
+	HandleAvailable  = "available"
+	HandleInvalid    = "invalid"
+	HandleTaken      = "taken"
+	HandleReserved   = "reserved"
+	HandleDisallowed = "disallowed"
+)
+
+// DefaultReservedHandles are platform paths, subdomains and roles an artist must not own. Deployments add
+// well-known artist names via RESERVED_HANDLES.
+var DefaultReservedHandles = []string{
+	"about", "account", "accounts", "admin", "administrator", "afterwave", "apps", "artist", "artists",
+	"assets", "billing", "blog", "careers", "contact", "docs", "download", "email", "explore", "feed",
+	"handles", "help", "home", "jobs", "legal", "login", "logout", "mail", "media", "moderator", "news",
+	"official", "password", "payments", "player", "press", "privacy", "root", "search", "security",
+	"settings", "signin", "signup", "staff", "static", "status", "store", "support", "system", "team",
+	"terms", "user", "users", "verified", "webmail",
+}
+
+// DefaultDeniedTerms are never allowed inside a handle (without an admin grant).
+var DefaultDeniedTerms = []string{
+	"afterwave", "fuck", "shit", "cunt", "nigger", "faggot",
+}
+
+// DefaultAllowedWords contain a denied term but are not offensive, so a handle containing them can be claimed.
+var DefaultAllowedWords = []string{
+	"scunthorpe", "shitake", "shiitake",
+}
+
+// HandlePolicy decides whether a well-formed handle may be claimed. The zero value allows everything.
+type HandlePolicy struct {
+	reserved map[string]bool
+	denied   []string
+	allowed  []string
+}
+
+// NewHandlePolicy builds a policy from reserved handles, denied terms and allowed words (all case-insensitive).
+func NewHandlePolicy(reserved, denied, allowed []string) *HandlePolicy {
+	p := &HandlePolicy{reserved: make(map[string]bool, len(reserved))}
+	for _, h := range reserved {
+		if h = normalizeHandle(h); h != "" {
+			p.reserved[skeleton(h)] = true
+		}
+	}
+	for _, t := range denied {
+		if t = normalizeHandle(t); t != "" {
+			p.denied = append(p.denied, skeleton(t))
+		}
+	}
+	for _, w := range allowed {
+		if w = normalizeHandle(w); w != "" {
+			p.allowed = append(p.allowed, skeleton(w))
+		}
+	}
+	return p
+}
+
+// DefaultHandlePolicy is NewHandlePolicy with the defaults plus any extra reserved handles and denied terms.
+func DefaultHandlePolicy(extraReserved, extraDenied []string) *HandlePolicy {
+	return NewHandlePolicy(append(append([]string{}, DefaultReservedHandles...), extraReserved...),
+		append(append([]string{}, DefaultDeniedTerms...), extraDenied...), DefaultAllowedWords)
+}
+
+// Check returns HandleReserved or HandleDisallowed if the (normalized, well-formed) handle is blocked, else "".
+func (p *HandlePolicy) Check(handle string) string {
+	if p == nil {
+		return ""
+	}
+	sk := skeleton(handle)
+	// A space is never in a skeleton, so the parts around an allowed word do not join into a denied term.
+	rest := sk
+	for _, w := range p.allowed {
+		rest = strings.ReplaceAll(rest, w, " ")
+	}
+	for _, t := range p.denied {
+		if strings.Contains(rest, t) {
+			return HandleDisallowed
+		}
+	}
+	if p.reserved[sk] {
+		return HandleReserved
+	}
+	return ""
+}
+
+// confusables maps characters that read as another letter in a handle to that letter.
+var confusables = strings.NewReplacer(
+	"rn", "m", "vv", "w", "cl", "d",
+	"0", "o", "1", "i", "l", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "9", "g",
+)
+
+// skeleton reduces a handle to a canonical form for comparison, so look-alikes compare equal.
+// Handles are ASCII (see handleRegex), so only ASCII confusables need mapping.
+func skeleton(handle string) string {
+	return confusables.Replace(handle)
+}
@@ -0,0 +1,52 @@
+package artists
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Handle grants: a platform admin lets one user claim a reserved or disallowed handle.
+// Grant row: PK = ARTISTS#<handle>, SK = GRANT — user_id; removed when the handle is created.
+
+const grantSK = "GRANT"
+
+type grantRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	Handle    string `dynamo:"handle"`
+	UserID    string `dynamo:"user_id"`
+	GrantedBy string `dynamo:"granted_by"`
+	GrantedAt string `dynamo:"granted_at"`
+}
+
+// GetGrant returns the admin grant for the handle, or nil if none.
+func (s *Store) GetGrant(ctx context.Context, handle string) (*grantRow, error) {
+	var row grantRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, grantSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// PutGrant creates or replaces the grant for the handle.
+func (s *Store) PutGrant(ctx context.Context, handle, userID, grantedBy, grantedAt string) error {
+	return s.tbl().Put(grantRow{
+		PK:        artistPK(handle),
+		SK:        grantSK,
+		Handle:    handle,
+		UserID:    userID,
+		GrantedBy: grantedBy,
+		GrantedAt: grantedAt,
+	}).Run(ctx)
+}
+
+// DeleteGrant removes the grant for the handle. Idempotent.
+func (s *Store) DeleteGrant(ctx context.Context, handle string) error {
+	return s.tbl().Delete("pk", artistPK(handle)).Range("sk", grantSK).Run(ctx)
+}
@@ -0,0 +1,262 @@
+package artists
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/guregu/dynamo/v2"
+)
+
+// External links for the Links section (Bandcamp, socials, merch store). Managed with artist:update.
+
+var (
+	ErrLinkNotFound     = errors.New("link not found")
+	ErrInvalidLinkLabel = errors.New("label must be 1–60 characters")
+	ErrInvalidLinkURL   = errors.New("url must be an https URL")
+	ErrTooManyLinks     = errors.New("an artist can have at most 25 links")
+	ErrInvalidLinkOrder = errors.New("ids must list every link exactly once")
+)
+
+const (
+	maxLinks        = 25
+	maxLinkLabelLen = 60
+	maxLinkURLLen   = 2048
+)
+
+// Link is one entry in the artist's Links section. Platform is a known-platform key for the icon
+// (e.g. "bandcamp", "instagram") or "website".
+type Link struct {
+	ID        string `json:"id"`
+	Label     string `json:"label"`
+	URL       string `json:"url"`
+	Platform  string `json:"platform"`
+	CreatedAt string `json:"created_at"`
+}
+
+// PlatformWebsite is the platform key for links to hosts we don't recognize.
+const PlatformWebsite = "website"
+
+// linkPlatforms maps registrable domains to platform keys. Subdomains match too (artist.bandcamp.com).
+var linkPlatforms = map[string]string{
+	"bandcamp.com":    "bandcamp",
+	"spotify.com":     "spotify",
+	"music.apple.com": "apple_music",
+	"soundcloud.com":  "soundcloud",
+	"youtube.com":     "youtube",
+	"youtu.be":        "youtube",
+	"tidal.com":       "tidal",
+	"deezer.com":      "deezer",
+	"instagram.com":   "instagram",
+	"tiktok.com":      "tiktok",
+	"facebook.com":    "facebook",
+	"x.com":           "x",
+	"twitter.com":     "x",
+	"threads.net":     "threads",
+	"bsky.app":        "bluesky",
+	"twitch.tv":       "twitch",
+	"discord.gg":      "discord",
+	"discord.com":     "discord",
+	"patreon.com":     "patreon",
+	"ko-fi.com":       "kofi",
+	"bigcartel.com":   "bigcartel",
+	"etsy.com":        "etsy",
+	"myshopify.com":   "shopify",
+	"songkick.com":    "songkick",
+	"bandsintown.com": "bandsintown",
+}
+
+// LinkPlatform returns the platform key for an https URL's host, or PlatformWebsite.
+func LinkPlatform(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return PlatformWebsite
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for {
+		if p, ok := linkPlatforms[host]; ok {
+			return p
+		}
+		_, rest, ok := strings.Cut(host, ".")
+		if !ok || !strings.Contains(rest, ".") {
+			return PlatformWebsite
+		}
+		host = rest
+	}
+}
+
+func normalizeLinkLabel(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || utf8.RuneCountInString(s) > maxLinkLabelLen {
+		return "", ErrInvalidLinkLabel
+	}
+	return s, nil
+}
+
+func normalizeLinkURL(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	u, err := url.Parse(s)
+	if err != nil || len(s) > maxLinkURLLen || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return "", ErrInvalidLinkURL
+	}
+	return s, nil
+}
+
+func rowToLink(r *linkRow) Link {
+	return Link{ID: r.ID, Label: r.Label, URL: r.URL, Platform: r.Platform, CreatedAt: r.CreatedAt}
+}
+
+// ListLinks returns the artist's links in display order (public).
+func (s *service) ListLinks(ctx context.Context, handle string) ([]Link, error) {
+	handle = normalizeHandle(handle)
+	row, err := s.activeRow(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrArtistNotFound
+	}
+	rows, err := s.store.ListLinks(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Link, len(rows))
+	for i := range rows {
+		out[i] = rowToLink(&rows[i])
+	}
+	return out, nil
+}
+
+// CreateLink appends a link to the end of the list.
+func (s *service) CreateLink(ctx context.Context, handle, label, rawURL, actorUserID string) (*Link, error) {
+	handle = normalizeHandle(handle)
+	if err := s.requireLinkEditor(ctx, handle, actorUserID); err != nil {
+		return nil, err
+	}
+	label, err := normalizeLinkLabel(label)
+	if err != nil {
+		return nil, err
+	}
+	rawURL, err = normalizeLinkURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.store.ListLinks(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxLinks {
+		return nil, ErrTooManyLinks
+	}
+	position := 0
+	if n := len(existing); n > 0 {
+		position = existing[n-1].Position + 1
+	}
+	row := linkRow{
+		ID:        uuid.New().String(),
+		Handle:    handle,
+		Label:     label,
+		URL:       rawURL,
+		Platform:  LinkPlatform(rawURL),
+		Position:  position,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.store.PutLink(ctx, row); err != nil {
+		return nil, err
+	}
+	link := rowToLink(&row)
+	return &link, nil
+}
+
+// UpdateLink changes the label and/or URL; nil fields are left unchanged.
+func (s *service) UpdateLink(ctx context.Context, handle, linkID string, label, rawURL *string, actorUserID string) (*Link, error) {
+	handle = normalizeHandle(handle)
+	if err := s.requireLinkEditor(ctx, handle, actorUserID); err != nil {
+		return nil, err
+	}
+	row, err := s.store.GetLink(ctx, handle, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrLinkNotFound
+	}
+	if label != nil {
+		if row.Label, err = normalizeLinkLabel(*label); err != nil {
+			return nil, err
+		}
+	}
+	if rawURL != nil {
+		if row.URL, err = normalizeLinkURL(*rawURL); err != nil {
+			return nil, err
+		}
+		row.Platform = LinkPlatform(row.URL)
+	}
+	if err := s.store.UpdateLink(ctx, *row); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	link := rowToLink(row)
+	return &link, nil
+}
+
+// DeleteLink removes a link.
+func (s *service) DeleteLink(ctx context.Context, handle, linkID, actorUserID string) error {
+	handle = normalizeHandle(handle)
+	if err := s.requireLinkEditor(ctx, handle, actorUserID); err != nil {
+		return err
+	}
+	if err := s.store.DeleteLink(ctx, handle, linkID); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return ErrLinkNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ReorderLinks sets the display order. linkIDs must list every link exactly once.
+func (s *service) ReorderLinks(ctx context.Context, handle string, linkIDs []string, actorUserID string) ([]Link, error) {
+	handle = normalizeHandle(handle)
+	if err := s.requireLinkEditor(ctx, handle, actorUserID); err != nil {
+		return nil, err
+	}
+	rows, err := s.store.ListLinks(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*linkRow, len(rows))
+	for i := range rows {
+		byID[rows[i].ID] = &rows[i]
+	}
+	if len(linkIDs) != len(rows) {
+		return nil, ErrInvalidLinkOrder
+	}
+	out := make([]Link, len(linkIDs))
+	for i, id := range linkIDs {
+		row, ok := byID[id]
+		if !ok {
+			return nil, ErrInvalidLinkOrder
+		}
+		delete(byID, id)
+		out[i] = rowToLink(row)
+	}
+	if err := s.store.SetLinkPositions(ctx, handle, linkIDs); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrInvalidLinkOrder
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// requireLinkEditor returns ErrArtistNotFound or ErrForbidden unless the user has artist:update.
+func (s *service) requireLinkEditor(ctx context.Context, handle, userID string) error {
+	return s.requirePermission(ctx, handle, userID, PermArtistUpdate)
+}
@@ -0,0 +1,113 @@
+package artists
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// External links: one row per link in the artist partition, ordered by position.
+// Link row: PK = ARTISTS#<handle>, SK = LINK#<id> — label, url, platform, position.
+
+const linkSKPrefix = "LINK#"
+
+type linkRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	ID        string `dynamo:"id"`
+	Handle    string `dynamo:"handle"`
+	Label     string `dynamo:"label"`
+	URL       string `dynamo:"url"`
+	Platform  string `dynamo:"platform"`
+	Position  int    `dynamo:"position"`
+	CreatedAt string `dynamo:"created_at"`
+}
+
+func linkSK(id string) string {
+	return linkSKPrefix + id
+}
+
+// ListLinks returns the artist's links in display order.
+func (s *Store) ListLinks(ctx context.Context, handle string) ([]linkRow, error) {
+	var out []linkRow
+	iter := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, linkSKPrefix).Iter()
+	var row linkRow
+	for iter.Next(ctx, &row) {
+		out = append(out, row)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Position < out[j].Position })
+	return out, nil
+}
+
+// GetLink returns the link, or nil if not found.
+func (s *Store) GetLink(ctx context.Context, handle, id string) (*linkRow, error) {
+	var row linkRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, linkSK(id)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// PutLink creates or replaces the link.
+func (s *Store) PutLink(ctx context.Context, row linkRow) error {
+	row.PK, row.SK = artistPK(row.Handle), linkSK(row.ID)
+	return s.tbl().Put(row).Run(ctx)
+}
+
+// UpdateLink writes the link's label, URL and platform. Fails with a condition check if the link does not exist
+// (deleted meanwhile).
+func (s *Store) UpdateLink(ctx context.Context, row linkRow) error {
+	return s.tbl().Update("pk", artistPK(row.Handle)).Range("sk", linkSK(row.ID)).
+		Set("label", row.Label).
+		Set("url", row.URL).
+		Set("platform", row.Platform).
+		If("attribute_exists(pk)").
+		Run(ctx)
+}
+
+// DeleteLink removes the link. Fails with a condition check if it does not exist.
+func (s *Store) DeleteLink(ctx context.Context, handle, id string) error {
+	return s.tbl().Delete("pk", artistPK(handle)).Range("sk", linkSK(id)).If("attribute_exists(pk)").Run(ctx)
+}
+
+// SetLinkPositions sets position i on the link ids[i], in one transaction. Fails with a condition check if any
+// link was deleted meanwhile.
+func (s *Store) SetLinkPositions(ctx context.Context, handle string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx := s.db.WriteTx()
+	for i, id := range ids {
+		tx.Update(s.tbl().Update("pk", artistPK(handle)).Range("sk", linkSK(id)).Set("position", i).If("attribute_exists(pk)"))
+	}
+	return tx.Run(ctx)
+}
+
+// MoveLinks moves all links from oldHandle to newHandle after a rename. Idempotent.
+func (s *Store) MoveLinks(ctx context.Context, oldHandle, newHandle string) error {
+	rows, err := s.ListLinks(ctx, oldHandle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		moved := row
+		moved.PK, moved.Handle = artistPK(newHandle), newHandle
+		err := s.db.WriteTx().
+			Put(s.tbl().Put(moved)).
+			Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", linkSK(row.ID))).
+			Run(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -64,16 +64,22 @@ func memberUserIndexSK(handle string) string {
 	return artistMembersSKPrefix + handle
 }
 
-// Get returns the member row for (handle, userID), or nil if not found.
+// Get returns the member row for (handle, userID), or nil if not found. Served from the request cache when set.
 func (s *MemberStore) Get(ctx context.Context, handle, userID string) (*memberRow, error) {
+	cache := cacheFrom(ctx)
+	if row, ok := cache.member(handle, userID); ok {
+		return row, nil
+	}
 	var row memberRow
 	err := s.tbl().Get("pk", memberPK(handle)).Range("sk", dynamo.Equal, memberSK(userID)).One(ctx, &row)
 	if err != nil {
 		if errors.Is(err, dynamo.ErrNotFound) {
+			cache.putMember(handle, userID, nil)
 			return nil, nil
 		}
 		return nil, err
 	}
+	cache.putMember(handle, userID, &row)
 	return &row, nil
 }
 
@@ -107,11 +113,23 @@ func (s *MemberStore) ListByUser(ctx context.Context, userID string) ([]memberUs
 	return out, nil
 }
 
-// Put adds or overwrites membership (both rows) in a transaction.
-func (s *MemberStore) Put(ctx context.Context, handle, userID string, roles []string) error {
+// Put adds or overwrites membership (both rows), with the activity entry (nil for none), in a transaction.
+func (s *MemberStore) Put(ctx context.Context, handle, userID string, roles []string, activity *ActivityEntry) error {
 	if len(roles) == 0 {
-		return s.Delete(ctx, handle, userID)
+		return s.Delete(ctx, handle, userID, activity)
 	}
+	return s.putTx(ctx, s.db.WriteTx(), handle, userID, roles, activity, false).Run(ctx)
+}
+
+// AddTx adds a new membership's rows (roles must not be empty) and the activity entry (nil for none) to tx, for a
+// caller that creates the membership together with writes of its own, such as consuming an invitation. The
+// transaction fails with a condition check if the user is already a member.
+func (s *MemberStore) AddTx(ctx context.Context, tx *dynamo.WriteTx, handle, userID string, roles []string, activity *ActivityEntry) *dynamo.WriteTx {
+	return s.putTx(ctx, tx, handle, userID, roles, activity, true)
+}
+
+func (s *MemberStore) putTx(ctx context.Context, tx *dynamo.WriteTx, handle, userID string, roles []string, activity *ActivityEntry, newOnly bool) *dynamo.WriteTx {
+	cacheFrom(ctx).forget(handle)
 	mainRow := memberRow{
 		PK:     memberPK(handle),
 		SK:     memberSK(userID),
@@ -124,16 +142,27 @@ func (s *MemberStore) Put(ctx context.Context, handle, userID string, roles []st
 		Handle: handle,
 		Roles:  roles,
 	}
-	return s.db.WriteTx().
-		Put(s.tbl().Put(mainRow)).
-		Put(s.tbl().Put(idxRow)).
-		Run(ctx)
+	put := s.tbl().Put(mainRow)
+	if newOnly {
+		put = put.If("attribute_not_exists(pk)")
+	}
+	tx = tx.
+		Put(put).
+		Put(s.tbl().Put(idxRow))
+	if activity != nil {
+		tx = tx.Put(ActivityPut(s.tbl(), handle, *activity))
+	}
+	return tx
 }
 
-// Delete removes the membership (both rows) in a transaction.
-func (s *MemberStore) Delete(ctx context.Context, handle, userID string) error {
-	return s.db.WriteTx().
+// Delete removes the membership (both rows), with the activity entry (nil for none), in a transaction.
+func (s *MemberStore) Delete(ctx context.Context, handle, userID string, activity *ActivityEntry) error {
+	cacheFrom(ctx).forget(handle)
+	tx := s.db.WriteTx().
 		Delete(s.tbl().Delete("pk", memberPK(handle)).Range("sk", memberSK(userID))).
-		Delete(s.tbl().Delete("pk", memberUserIndexPK(userID)).Range("sk", memberUserIndexSK(handle))).
-		Run(ctx)
+		Delete(s.tbl().Delete("pk", memberUserIndexPK(userID)).Range("sk", memberUserIndexSK(handle)))
+	if activity != nil {
+		tx = tx.Put(ActivityPut(s.tbl(), handle, *activity))
+	}
+	return tx.Run(ctx)
 }
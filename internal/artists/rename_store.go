@@ -0,0 +1,150 @@
+package artists
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Handle renames: the old handle keeps an alias row so links redirect permanently.
+// Alias row: PK = ARTISTS#<old_handle>, SK = ALIAS — new_handle, and reserved_until (only the renamed artist can
+// take the old handle back before then; afterwards anyone can claim it, which removes the alias).
+
+const aliasSK = "ALIAS"
+
+type aliasRow struct {
+	PK            string `dynamo:"pk"`
+	SK            string `dynamo:"sk"`
+	Handle        string `dynamo:"handle"`
+	NewHandle     string `dynamo:"new_handle"`
+	RenamedAt     string `dynamo:"renamed_at"`
+	ReservedUntil string `dynamo:"reserved_until"`
+}
+
+// GetAlias returns the alias row for a former handle, or nil if the handle was never renamed away.
+func (s *Store) GetAlias(ctx context.Context, handle string) (*aliasRow, error) {
+	var row aliasRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, aliasSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// BatchGetAliases returns the alias rows of the given former handles keyed by handle, in batched reads (one
+// round-trip per 100 keys). Handles never renamed away are absent.
+func (s *Store) BatchGetAliases(ctx context.Context, handles []string) (map[string]*aliasRow, error) {
+	out := make(map[string]*aliasRow, len(handles))
+	if len(handles) == 0 {
+		return out, nil
+	}
+	keys := make([]dynamo.Keyed, len(handles))
+	for i, h := range handles {
+		keys[i] = dynamo.Keys{artistPK(h), aliasSK}
+	}
+	var rows []aliasRow
+	if err := s.tbl().Batch("pk", "sk").Get(keys...).All(ctx, &rows); err != nil && !errors.Is(err, dynamo.ErrNotFound) {
+		return nil, err
+	}
+	for i := range rows {
+		out[rows[i].Handle] = &rows[i]
+	}
+	return out, nil
+}
+
+// Rename moves the main row and the owner index row to newHandle and leaves an alias at the old handle, in one
+// transaction with the activity entry, which goes under newHandle. Any alias or admin grant at newHandle is removed
+// (the caller checks it may be claimed). Fails with a condition check if newHandle is taken or the artist changed
+// owner meanwhile. Child rows (members, posts, followers, ...) are moved afterwards by the handle migrators.
+func (s *Store) Rename(ctx context.Context, artist *artistRow, newHandle, renamedAt, reservedUntil string, activity ActivityEntry) error {
+	cacheFrom(ctx).forget(artist.Handle, newHandle)
+	oldHandle := artist.Handle
+	main := *artist
+	main.PK, main.SK, main.Handle = artistPK(newHandle), artistSK, newHandle
+	main.Verified, main.VerifiedAt = false, "" // re-reviewed under the new handle
+	idxRow := userIndexRow{
+		PK:          userIndexPK(artist.OwnerUserID),
+		SK:          userIndexSK(newHandle),
+		Handle:      newHandle,
+		DisplayName: artist.DisplayName,
+		CreatedAt:   artist.CreatedAt,
+	}
+	alias := aliasRow{
+		PK:            artistPK(oldHandle),
+		SK:            aliasSK,
+		Handle:        oldHandle,
+		NewHandle:     newHandle,
+		RenamedAt:     renamedAt,
+		ReservedUntil: reservedUntil,
+	}
+	return s.db.WriteTx().
+		Put(s.tbl().Put(main).If("attribute_not_exists(pk)")).
+		Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", artistSK).If("owner_user_id = ?", artist.OwnerUserID)).
+		Put(s.tbl().Put(alias)).
+		Delete(s.tbl().Delete("pk", artistPK(newHandle)).Range("sk", aliasSK)).
+		Delete(s.tbl().Delete("pk", artistPK(newHandle)).Range("sk", grantSK)).
+		Delete(s.tbl().Delete("pk", userIndexPK(artist.OwnerUserID)).Range("sk", userIndexSK(oldHandle))).
+		Put(s.tbl().Put(idxRow)).
+		Put(ActivityPut(s.tbl(), newHandle, activity)).
+		Run(ctx)
+}
+
+// MoveTransfer moves a pending ownership transfer to newHandle. Idempotent.
+func (s *Store) MoveTransfer(ctx context.Context, oldHandle, newHandle string) error {
+	t, err := s.GetTransfer(ctx, oldHandle)
+	if err != nil || t == nil {
+		return err
+	}
+	moved := *t
+	moved.PK, moved.Handle = artistPK(newHandle), newHandle
+	return s.db.WriteTx().
+		Put(s.tbl().Put(moved)).
+		Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", transferSK)).
+		Run(ctx)
+}
+
+// PurgeHandle removes all memberships (both rows per member) of a purged artist. Idempotent.
+func (s *MemberStore) PurgeHandle(ctx context.Context, handle string) error {
+	cacheFrom(ctx).forget(handle)
+	rows, err := s.ListByArtist(ctx, handle)
+	if err != nil {
+		return err
+	}
+	for _, m := range rows {
+		err := s.db.WriteTx().
+			Delete(s.tbl().Delete("pk", memberPK(handle)).Range("sk", memberSK(m.UserID))).
+			Delete(s.tbl().Delete("pk", memberUserIndexPK(m.UserID)).Range("sk", memberUserIndexSK(handle))).
+			Run(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateHandle moves all memberships (both rows per member) from oldHandle to newHandle. Idempotent.
+func (s *MemberStore) MigrateHandle(ctx context.Context, oldHandle, newHandle string) error {
+	cacheFrom(ctx).forget(oldHandle, newHandle)
+	rows, err := s.ListByArtist(ctx, oldHandle)
+	if err != nil {
+		return err
+	}
+	for _, m := range rows {
+		mainRow := memberRow{PK: memberPK(newHandle), SK: memberSK(m.UserID), UserID: m.UserID, Roles: m.Roles}
+		idxRow := memberUserIndexRow{PK: memberUserIndexPK(m.UserID), SK: memberUserIndexSK(newHandle), Handle: newHandle, Roles: m.Roles}
+		err := s.db.WriteTx().
+			Put(s.tbl().Put(mainRow)).
+			Put(s.tbl().Put(idxRow)).
+			Delete(s.tbl().Delete("pk", memberPK(oldHandle)).Range("sk", memberSK(m.UserID))).
+			Delete(s.tbl().Delete("pk", memberUserIndexPK(m.UserID)).Range("sk", memberUserIndexSK(oldHandle))).
+			Run(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
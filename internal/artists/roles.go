@@ -1,20 +1,21 @@
 package artists
 
 // Predefined role names. A user on an artist page can have multiple roles.
-// RoleOwner is stored in the member table so ownership can be transferred in the future;
-// it is not assignable via API (only set at artist create).
+// RoleOwner is stored in the member table so ownership can be transferred;
+// it is not assignable via API (only set at artist create and by an accepted ownership transfer).
 const (
-	RoleOwner  = "owner"  // Page creator; stored for future transfer; not assignable via API
+	RoleOwner  = "owner"  // Page creator or transfer recipient; not assignable via API
 	RoleAdmin  = "admin"  // Can manage members, update artist settings; cannot delete page or billing
 	RoleFeed   = "feed"   // Create, edit, delete feed posts
 	RoleMusic  = "music"  // Upload and manage music
 	RolePhotos = "photos" // Upload and manage photos
 	RoleGigs   = "gigs"   // Add, edit, delete gigs
+	RoleSite   = "site"   // Edit branding and site sections (site builder)
 )
 
 // AllPredefinedRoles returns roles that can be assigned via API (excludes owner).
 func AllPredefinedRoles() []string {
-	return []string{RoleAdmin, RoleFeed, RoleMusic, RolePhotos, RoleGigs}
+	return []string{RoleAdmin, RoleFeed, RoleMusic, RolePhotos, RoleGigs, RoleSite}
 }
 
 // Permission constants for authorization checks.
@@ -26,28 +27,74 @@ const (
 	PermFeedCreate          = "feed:create"
 	PermFeedUpdate        = "feed:update"
 	PermFeedDelete        = "feed:delete"
+	PermFeedUpdateOwn     = "feed:update_own" // Edit only posts the member created
+	PermFeedDeleteOwn     = "feed:delete_own" // Delete only posts the member created
 	PermMusicManage       = "music:manage"
 	PermPhotosManage      = "photos:manage"
 	PermGigsManage        = "gigs:manage"
+	PermSiteEdit          = "site:edit" // Branding (logo, cover, accent colour) and sections
 )
 
+// PermissionInfo describes a permission in the GET /permissions catalog.
+type PermissionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// permissionCatalog lists the permissions a custom role can grant. PermArtistDelete is owner-only and never grantable.
+var permissionCatalog = []PermissionInfo{
+	{PermArtistUpdate, "Edit display name, bio and external links"},
+	{PermArtistManageMembers, "Add, update and remove members; manage custom roles and invitations"},
+	{PermArtistListMembers, "See the page's members and roles"},
+	{PermFeedCreate, "Create feed posts"},
+	{PermFeedUpdate, "Edit any feed post"},
+	{PermFeedDelete, "Delete any feed post"},
+	{PermFeedUpdateOwn, "Edit feed posts they created"},
+	{PermFeedDeleteOwn, "Delete feed posts they created"},
+	{PermMusicManage, "Upload and manage music"},
+	{PermPhotosManage, "Upload and manage photos"},
+	{PermGigsManage, "Add, edit and delete gigs"},
+	{PermSiteEdit, "Edit branding and site sections"},
+}
+
+// Permissions returns the catalog of permissions a custom role can grant.
+func Permissions() []PermissionInfo {
+	return append([]PermissionInfo(nil), permissionCatalog...)
+}
+
+// GrantablePermission returns true if a custom role may grant the permission.
+func GrantablePermission(permission string) bool {
+	for _, p := range permissionCatalog {
+		if p.Name == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions returns the permissions a predefined role grants, or nil for unknown roles.
+func RolePermissions(role string) []string {
+	return append([]string(nil), rolePermissions[role]...)
+}
+
 // rolePermissions maps each role to the permissions it grants.
 var rolePermissions = map[string][]string{
 	RoleOwner: {
 		PermArtistUpdate, PermArtistManageMembers, PermArtistListMembers,
 		PermFeedCreate, PermFeedUpdate, PermFeedDelete,
-		PermMusicManage, PermPhotosManage, PermGigsManage,
+		PermMusicManage, PermPhotosManage, PermGigsManage, PermSiteEdit,
 		// PermArtistDelete only for owner; not in map so only artist.OwnerUserID check grants it
 	},
 	RoleAdmin: {
 		PermArtistUpdate, PermArtistManageMembers, PermArtistListMembers,
 		PermFeedCreate, PermFeedUpdate, PermFeedDelete,
-		PermMusicManage, PermPhotosManage, PermGigsManage,
+		PermMusicManage, PermPhotosManage, PermGigsManage, PermSiteEdit,
 	},
 	RoleFeed:   {PermFeedCreate, PermFeedUpdate, PermFeedDelete, PermArtistListMembers},
 	RoleMusic:  {PermMusicManage, PermArtistListMembers},
 	RolePhotos: {PermPhotosManage, PermArtistListMembers},
 	RoleGigs:   {PermGigsManage, PermArtistListMembers},
+	RoleSite:   {PermSiteEdit, PermArtistListMembers},
 }
 
 // RoleGrantsPermission returns true if the given role grants the given permission.
@@ -70,6 +117,12 @@ func RolesGrantPermission(roles []string, permission string) bool {
 	return false
 }
 
+// PredefinedRole returns true if role is a predefined role name (custom role ids never are).
+func PredefinedRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
 // ValidRole returns true if role is a predefined role (including owner, for storage).
 func ValidRole(role string) bool {
 	if role == RoleOwner {
@@ -17,23 +17,93 @@ var (
 	ErrForbidden       = errors.New("forbidden")
 	ErrCannotRemoveOwner = errors.New("cannot remove the owner")
 	ErrInvalidRoles    = errors.New("invalid roles")
+	ErrTransferNotFound = errors.New("no pending ownership transfer")
+	ErrNomineeNotMember = errors.New("new owner must be an existing member")
+	ErrTransferConflict = errors.New("ownership or membership changed; nominate again")
+	ErrInvalidHandle    = errors.New("handle must be 4–64 lowercase letters or numbers, no spaces or special characters")
+	ErrSameHandle       = errors.New("new handle is the same as the current handle")
+	ErrHandleReserved   = errors.New("handle is reserved")
+	ErrHandleDisallowed = errors.New("handle is not allowed")
+	ErrGrantNotFound    = errors.New("no grant for this handle")
 )
 
+// MovedError is returned by GetByHandle for a handle the artist was renamed away from. Handle is the current handle.
+type MovedError struct {
+	Handle string
+}
+
+func (e *MovedError) Error() string {
+	return "artist moved to " + e.Handle
+}
+
+// HandleReservation is how long a former handle stays reserved for the renamed artist after a rename.
+// The alias (and redirect) remains after that until someone else claims the handle.
+const HandleReservation = 30 * 24 * time.Hour
+
+// maxAliasHops bounds alias chains (a→b, b→c) followed when resolving a former handle.
+const maxAliasHops = 5
+
+// HandleMigrator moves data keyed by an artist handle (posts, followers, invitations, ...) to the new handle
+// after a rename. Implementations must be idempotent so an interrupted rename can be resumed by renaming again.
+type HandleMigrator interface {
+	MigrateHandle(ctx context.Context, oldHandle, newHandle string) error
+}
+
+// TransferTTL is how long a nominee has to accept an ownership transfer.
+const TransferTTL = 7 * 24 * time.Hour
+
 // Handle must be lowercase, alphanumeric only, 4–64 chars (min 4 so we can reserve 3-letter subdomains: www, tui, api, etc.).
 var handleRegex = regexp.MustCompile(`^[a-z0-9]{4,64}$`)
 
 type Service interface {
 	Create(ctx context.Context, ownerUserID, handle, displayName, bio string) (*Artist, error)
 	GetByHandle(ctx context.Context, handle string) (*Artist, error)
+	GetForViewer(ctx context.Context, handle, viewerUserID string) (*Artist, error)
+	GetByHandles(ctx context.Context, handles []string) (map[string]*Artist, error)
+	CurrentHandles(ctx context.Context, handles []string) (map[string]string, error)
 	ListByOwner(ctx context.Context, userID string) ([]Artist, error)
 	ListForUser(ctx context.Context, userID string) ([]ArtistWithRole, error)
-	Update(ctx context.Context, handle string, displayName, bio *string, actorUserID string) (*Artist, error)
+	Update(ctx context.Context, handle string, upd ArtistUpdate, actorUserID string) (*Artist, error)
 	Delete(ctx context.Context, handle, actorUserID string) error
+	Restore(ctx context.Context, handle, actorUserID string) (*Artist, error)
+	PurgeDue(ctx context.Context, now time.Time) (int, error)
 	HasPermission(ctx context.Context, handle, userID, permission string) (bool, error)
 	AddMember(ctx context.Context, handle, userID string, roles []string, actorUserID string) error
 	RemoveMember(ctx context.Context, handle, userID string, actorUserID string) error
 	UpdateMemberRoles(ctx context.Context, handle, userID string, roles []string, actorUserID string) error
 	ListMembers(ctx context.Context, handle, actorUserID string) ([]Member, error)
+	NominateOwner(ctx context.Context, handle, nomineeUserID string, keepAsAdmin bool, actorUserID string) (*OwnershipTransfer, error)
+	GetTransfer(ctx context.Context, handle, actorUserID string) (*OwnershipTransfer, error)
+	AcceptTransfer(ctx context.Context, handle, actorUserID string) (*Artist, error)
+	CancelTransfer(ctx context.Context, handle, actorUserID string) error
+	Rename(ctx context.Context, handle, newHandle, actorUserID string) (*Artist, error)
+	CheckAvailability(ctx context.Context, handle, userID string) (*HandleAvailability, error)
+	GrantHandle(ctx context.Context, handle, userID, actorUserID string) error
+	RevokeHandleGrant(ctx context.Context, handle string) (userID string, err error)
+	ListLinks(ctx context.Context, handle string) ([]Link, error)
+	CreateLink(ctx context.Context, handle, label, url, actorUserID string) (*Link, error)
+	UpdateLink(ctx context.Context, handle, linkID string, label, url *string, actorUserID string) (*Link, error)
+	DeleteLink(ctx context.Context, handle, linkID, actorUserID string) error
+	ReorderLinks(ctx context.Context, handle string, linkIDs []string, actorUserID string) ([]Link, error)
+	RecordActivity(ctx context.Context, handle string, e ActivityEntry) error
+	ListActivity(ctx context.Context, handle string, limit int, cursor, actorUserID string) ([]ActivityEntry, string, error)
+	ListRoles(ctx context.Context, handle, actorUserID string) ([]Role, error)
+	CreateRole(ctx context.Context, handle, name string, permissions []string, actorUserID string) (*Role, error)
+	UpdateRole(ctx context.Context, handle, roleID string, name *string, permissions []string, actorUserID string) (*Role, error)
+	DeleteRole(ctx context.Context, handle, roleID, actorUserID string) error
+	RolesAssignable(ctx context.Context, handle string, roles []string) (bool, error)
+	RequestVerification(ctx context.Context, handle string, evidenceLinks []string, note, actorUserID string) (*Verification, error)
+	GetVerification(ctx context.Context, handle, actorUserID string) (*Verification, error)
+	ListVerificationQueue(ctx context.Context, limit int) ([]Verification, error)
+	ReviewVerification(ctx context.Context, handle string, approve bool, note, reviewerUserID string) (*Verification, error)
+}
+
+// HandleAvailability answers whether a handle can be claimed (GET /artists/handles/{handle}/availability).
+// Reason is one of HandleAvailable, HandleInvalid, HandleTaken, HandleReserved, HandleDisallowed.
+type HandleAvailability struct {
+	Handle    string `json:"handle"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason"`
 }
 
 // ArtistWithRole is an artist plus the current user's role(s). Used for GET /artists/me.
@@ -49,22 +119,46 @@ type Member struct {
 	Roles  []string `json:"roles"`
 }
 
-type Artist struct {
+// OwnershipTransfer is a pending nomination of a member as the new owner. Visible to the owner and the nominee.
+type OwnershipTransfer struct {
 	Handle        string `json:"handle"`
-	DisplayName   string `json:"display_name"`
-	Bio           string `json:"bio"`
-	OwnerUserID   string `json:"owner_user_id"`
+	NomineeUserID string `json:"nominee_user_id"`
+	NominatedBy   string `json:"nominated_by"`
+	KeepAsAdmin   bool   `json:"keep_as_admin"` // old owner stays on as admin after accept
 	CreatedAt     string `json:"created_at"`
-	FollowerCount int    `json:"follower_count"`
+	ExpiresAt     string `json:"expires_at"`
+}
+
+type Artist struct {
+	Handle        string    `json:"handle"`
+	DisplayName   string    `json:"display_name"`
+	Bio           string    `json:"bio"`
+	OwnerUserID   string    `json:"owner_user_id"`
+	CreatedAt     string    `json:"created_at"`
+	FollowerCount int       `json:"follower_count"`
+	LogoURL       string    `json:"logo_url,omitempty"`
+	CoverImageURL string    `json:"cover_image_url,omitempty"`
+	AccentColor   string    `json:"accent_color,omitempty"`
+	Sections      []Section `json:"sections"`
+	Links         []Link    `json:"links,omitempty"` // set on GET /artists/{handle}
+	Status        string    `json:"status,omitempty"`      // StatusPendingDeletion; only the owner sees such pages
+	PurgeAfter    string    `json:"purge_after,omitempty"` // restore deadline while pending deletion
+	Verified      bool      `json:"verified"`
+	VerifiedAt    string    `json:"verified_at,omitempty"`
+	Visibility    string    `json:"visibility"` // VisibilityPublic, VisibilityUnlisted or VisibilityPrivate
 }
 
 type service struct {
 	store       *Store
 	memberStore *MemberStore
+	policy      *HandlePolicy
+	handleData  []HandleData
 }
 
-func NewService(store *Store, memberStore *MemberStore) Service {
-	return &service{store: store, memberStore: memberStore}
+// NewService returns the artists service. policy blocks reserved and disallowed handles (nil allows all);
+// handleData is handle-keyed data owned by other packages, moved on rename and removed on purge.
+func NewService(store *Store, memberStore *MemberStore, policy *HandlePolicy, handleData ...HandleData) Service {
+	return &service{store: store, memberStore: memberStore, policy: policy, handleData: handleData}
 }
 
 func normalizeHandle(s string) string {
@@ -85,12 +179,11 @@ func (s *service) Create(ctx context.Context, ownerUserID, handle, displayName,
 		displayName = handle
 	}
 
-	existing, err := s.store.GetByHandle(ctx, handle)
-	if err != nil {
+	if err := s.checkHandlePolicy(ctx, handle, ownerUserID); err != nil {
 		return nil, err
 	}
-	if existing != nil {
-		return nil, ErrHandleTaken
+	if err := s.checkHandleAvailable(ctx, handle, ""); err != nil {
+		return nil, err
 	}
 
 	createdAt := time.Now().UTC().Format(time.RFC3339)
@@ -100,8 +193,8 @@ func (s *service) Create(ctx context.Context, ownerUserID, handle, displayName,
 		}
 		return nil, err
 	}
-	// Store owner in member table so ownership can be transferred in the future (no API to change it yet).
-	if err := s.memberStore.Put(ctx, handle, ownerUserID, []string{RoleOwner}); err != nil {
+	// Store owner in member table so ownership can be transferred (see AcceptTransfer).
+	if err := s.memberStore.Put(ctx, handle, ownerUserID, []string{RoleOwner}, nil); err != nil {
 		return nil, err
 	}
 	return &Artist{
@@ -111,6 +204,8 @@ func (s *service) Create(ctx context.Context, ownerUserID, handle, displayName,
 		OwnerUserID:   ownerUserID,
 		CreatedAt:     createdAt,
 		FollowerCount: 0,
+		Sections:      DefaultSections(),
+		Visibility:    VisibilityPublic,
 	}, nil
 }
 
@@ -119,13 +214,87 @@ func (s *service) GetByHandle(ctx context.Context, handle string) (*Artist, erro
 	if handle == "" {
 		return nil, ErrArtistNotFound
 	}
-	row, err := s.store.GetByHandle(ctx, handle)
-	if err != nil || row == nil {
+	row, err := s.activeRow(ctx, handle)
+	if err != nil {
+		return nil, ErrArtistNotFound
+	}
+	if row == nil {
+		if current, err := s.resolveAlias(ctx, handle); err == nil && current != "" {
+			return nil, &MovedError{Handle: current}
+		}
 		return nil, ErrArtistNotFound
 	}
 	return rowToArtist(row), nil
 }
 
+// GetByHandles returns the artists for the given handles in one batched read, keyed by handle. Handles that are
+// not found, pending deletion or renamed away are absent.
+func (s *service) GetByHandles(ctx context.Context, handles []string) (map[string]*Artist, error) {
+	normalized := make([]string, 0, len(handles))
+	for _, h := range handles {
+		if h = normalizeHandle(h); h != "" {
+			normalized = append(normalized, h)
+		}
+	}
+	rows, err := s.store.BatchGetByHandles(ctx, normalized)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*Artist, len(rows))
+	for h, row := range rows {
+		if row.DeletedAt == "" {
+			out[h] = rowToArtist(row)
+		}
+	}
+	return out, nil
+}
+
+// CurrentHandles maps each of the given handles that was renamed away to the artist's current handle, following
+// aliases like GetByHandle but with batched reads for all handles at once. Handles that were not renamed, or whose
+// artist is gone, are absent.
+func (s *service) CurrentHandles(ctx context.Context, handles []string) (map[string]string, error) {
+	out := make(map[string]string)
+	pending := make(map[string][]string) // handle to look up -> handles asked for that lead to it
+	for _, h := range handles {
+		if h = normalizeHandle(h); h != "" && len(pending[h]) == 0 {
+			pending[h] = []string{h}
+		}
+	}
+	for i := 0; i < maxAliasHops && len(pending) > 0; i++ {
+		lookup := make([]string, 0, len(pending))
+		for h := range pending {
+			lookup = append(lookup, h)
+		}
+		aliases, err := s.store.BatchGetAliases(ctx, lookup)
+		if err != nil {
+			return nil, err
+		}
+		next := make(map[string][]string, len(aliases))
+		for h, alias := range aliases {
+			next[alias.NewHandle] = append(next[alias.NewHandle], pending[h]...)
+		}
+		targets := make([]string, 0, len(next))
+		for target := range next {
+			targets = append(targets, target)
+		}
+		rows, err := s.store.BatchGetByHandles(ctx, targets)
+		if err != nil {
+			return nil, err
+		}
+		pending = make(map[string][]string)
+		for target, asked := range next {
+			if rows[target] == nil {
+				pending[target] = asked
+				continue
+			}
+			for _, h := range asked {
+				out[h] = target
+			}
+		}
+	}
+	return out, nil
+}
+
 func (s *service) ListByOwner(ctx context.Context, userID string) ([]Artist, error) {
 	if userID == "" {
 		return nil, nil
@@ -141,78 +310,124 @@ func (s *service) ListByOwner(ctx context.Context, userID string) ([]Artist, err
 	return out, nil
 }
 
-func (s *service) Update(ctx context.Context, handle string, displayName, bio *string, actorUserID string) (*Artist, error) {
+func (s *service) Update(ctx context.Context, handle string, upd ArtistUpdate, actorUserID string) (*Artist, error) {
 	handle = normalizeHandle(handle)
 	if handle == "" {
 		return nil, ErrArtistNotFound
 	}
 
-	row, err := s.store.GetByHandle(ctx, handle)
+	row, err := s.activeRow(ctx, handle)
 	if err != nil || row == nil {
 		return nil, ErrArtistNotFound
 	}
-	ok, err := s.HasPermission(ctx, handle, actorUserID, PermArtistUpdate)
-	if err != nil || !ok {
-		return nil, ErrForbidden
+	// Display name, bio and visibility (or an empty PATCH) need artist:update; branding and sections need site:edit.
+	if upd.DisplayName != nil || upd.Bio != nil || upd.Visibility != nil || !upd.editsSite() {
+		ok, err := s.HasPermission(ctx, handle, actorUserID, PermArtistUpdate)
+		if err != nil || !ok {
+			return nil, ErrForbidden
+		}
+	}
+	if upd.editsSite() {
+		ok, err := s.HasPermission(ctx, handle, actorUserID, PermSiteEdit)
+		if err != nil || !ok {
+			return nil, ErrForbidden
+		}
 	}
 
-	resolvedDisplayName := row.DisplayName
-	if displayName != nil {
-		resolvedDisplayName = strings.TrimSpace(*displayName)
-		if resolvedDisplayName == "" {
-			resolvedDisplayName = row.DisplayName
+	updated := *row
+	if upd.DisplayName != nil {
+		if name := strings.TrimSpace(*upd.DisplayName); name != "" {
+			updated.DisplayName = name
 		}
 	}
-	resolvedBio := row.Bio
-	if bio != nil {
-		resolvedBio = strings.TrimSpace(*bio)
+	if upd.Bio != nil {
+		updated.Bio = strings.TrimSpace(*upd.Bio)
 	}
-
-	if err := s.store.Update(ctx, handle, resolvedDisplayName, resolvedBio); err != nil {
-		return nil, err
+	if upd.LogoURL != nil {
+		if updated.LogoURL, err = normalizeImageURL(*upd.LogoURL); err != nil {
+			return nil, err
+		}
 	}
-	return &Artist{
-		Handle:      handle,
-		DisplayName: resolvedDisplayName,
-		Bio:         resolvedBio,
-		OwnerUserID: row.OwnerUserID,
-		CreatedAt:   row.CreatedAt,
-	}, nil
-}
-
-func (s *service) Delete(ctx context.Context, handle, actorUserID string) error {
-	handle = normalizeHandle(handle)
-	if handle == "" {
-		return ErrArtistNotFound
+	if upd.CoverImageURL != nil {
+		if updated.CoverImageURL, err = normalizeImageURL(*upd.CoverImageURL); err != nil {
+			return nil, err
+		}
+	}
+	if upd.AccentColor != nil {
+		if updated.AccentColor, err = normalizeColor(*upd.AccentColor); err != nil {
+			return nil, err
+		}
+	}
+	if upd.Sections != nil {
+		if updated.Sections, err = normalizeSections(upd.Sections); err != nil {
+			return nil, err
+		}
+	}
+	before := rowToArtist(row).Visibility
+	after := before
+	if upd.Visibility != nil {
+		if after, err = normalizeVisibility(*upd.Visibility); err != nil {
+			return nil, err
+		}
+		updated.Visibility = after
 	}
 
-	row, err := s.store.GetByHandle(ctx, handle)
-	if err != nil || row == nil {
-		return ErrArtistNotFound
+	var changes []FieldChange
+	changes = AppendChange(changes, "display_name", row.DisplayName, updated.DisplayName)
+	changes = AppendChange(changes, "bio", row.Bio, updated.Bio)
+	changes = AppendChange(changes, "logo_url", row.LogoURL, updated.LogoURL)
+	changes = AppendChange(changes, "cover_image_url", row.CoverImageURL, updated.CoverImageURL)
+	changes = AppendChange(changes, "accent_color", row.AccentColor, updated.AccentColor)
+	changes = AppendChange(changes, "visibility", before, after)
+	if upd.Sections != nil {
+		changes = AppendChange(changes, "sections", sectionsString(rowToArtist(row).Sections), sectionsString(updated.Sections))
 	}
-	ok, err := s.HasPermission(ctx, handle, actorUserID, PermArtistDelete)
-	if err != nil || !ok {
-		return ErrForbidden
+	var activity *ActivityEntry
+	if len(changes) > 0 {
+		activity = &ActivityEntry{Action: ActivityArtistUpdate, ActorUserID: actorUserID, Changes: changes}
 	}
-	// Remove owner's member row so artist delete is consistent.
-	if err := s.memberStore.Delete(ctx, handle, row.OwnerUserID); err != nil {
-		return err
+	if err := s.store.Update(ctx, &updated, activity); err != nil {
+		return nil, err
 	}
-	return s.store.Delete(ctx, handle)
+	// Posts are taken out of the index whenever private is requested, not only when the page becomes private, so
+	// repeating the request finishes a removal that failed after the visibility was saved.
+	if (upd.Visibility != nil && after == VisibilityPrivate) || (before == VisibilityPrivate) != (after == VisibilityPrivate) {
+		if err := s.setIndexed(ctx, handle, after != VisibilityPrivate); err != nil {
+			return nil, err
+		}
+	}
+	return rowToArtist(&updated), nil
 }
 
 func rowToArtist(r *artistRow) *Artist {
 	if r == nil {
 		return nil
 	}
-	return &Artist{
+	a := &Artist{
 		Handle:        r.Handle,
 		DisplayName:   r.DisplayName,
 		Bio:           r.Bio,
 		OwnerUserID:   r.OwnerUserID,
 		CreatedAt:     r.CreatedAt,
 		FollowerCount: r.FollowerCount,
+		LogoURL:       r.LogoURL,
+		CoverImageURL: r.CoverImageURL,
+		AccentColor:   r.AccentColor,
+		Sections:      r.Sections,
+		Verified:      r.Verified,
+		VerifiedAt:    r.VerifiedAt,
+		Visibility:    r.Visibility,
+	}
+	if len(a.Sections) == 0 {
+		a.Sections = DefaultSections()
+	}
+	if a.Visibility == "" {
+		a.Visibility = VisibilityPublic
 	}
+	if r.DeletedAt != "" {
+		a.Status, a.PurgeAfter = StatusPendingDeletion, r.PurgeAfter
+	}
+	return a
 }
 
 func (s *service) HasPermission(ctx context.Context, handle, userID, permission string) (bool, error) {
@@ -220,7 +435,7 @@ func (s *service) HasPermission(ctx context.Context, handle, userID, permission
 	if handle == "" || userID == "" {
 		return false, nil
 	}
-	row, err := s.store.GetByHandle(ctx, handle)
+	row, err := s.activeRow(ctx, handle)
 	if err != nil || row == nil {
 		return false, err
 	}
@@ -231,7 +446,10 @@ func (s *service) HasPermission(ctx context.Context, handle, userID, permission
 	if err != nil || mem == nil {
 		return false, err
 	}
-	return RolesGrantPermission(mem.Roles, permission), nil
+	if RolesGrantPermission(mem.Roles, permission) {
+		return true, nil
+	}
+	return s.customRolesGrantPermission(ctx, handle, mem.Roles, permission)
 }
 
 func (s *service) ListForUser(ctx context.Context, userID string) ([]ArtistWithRole, error) {
@@ -252,12 +470,22 @@ func (s *service) ListForUser(ctx context.Context, userID string) ([]ArtistWithR
 	if err != nil {
 		return nil, err
 	}
+	handles := make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		if !ownedHandles[m.Handle] {
+			handles = append(handles, m.Handle)
+		}
+	}
+	memberOf, err := s.GetByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
 	for _, m := range memberships {
 		if ownedHandles[m.Handle] {
 			continue
 		}
-		artist, err := s.GetByHandle(ctx, m.Handle)
-		if err != nil || artist == nil {
+		artist := memberOf[m.Handle]
+		if artist == nil {
 			continue
 		}
 		out = append(out, ArtistWithRole{Artist: *artist, Role: "member", Roles: m.Roles})
@@ -286,7 +514,7 @@ func (s *service) AddMember(ctx context.Context, handle, userID string, roles []
 	if err != nil || !ok {
 		return ErrForbidden
 	}
-	row, err := s.store.GetByHandle(ctx, handle)
+	row, err := s.activeRow(ctx, handle)
 	if err != nil || row == nil {
 		return ErrArtistNotFound
 	}
@@ -297,10 +525,44 @@ func (s *service) AddMember(ctx context.Context, handle, userID string, roles []
 	if len(roles) == 0 {
 		return ErrInvalidRoles
 	}
-	if !RolesAreAssignable(roles) {
+	if ok, err := s.RolesAssignable(ctx, handle, roles); err != nil {
+		return err
+	} else if !ok {
 		return ErrInvalidRoles
 	}
-	return s.memberStore.Put(ctx, handle, userID, roles)
+	return s.putMember(ctx, handle, userID, roles, actorUserID)
+}
+
+// putMember sets the member's roles and records the change in the activity log (member.add for a new member).
+func (s *service) putMember(ctx context.Context, handle, userID string, roles []string, actorUserID string) error {
+	existing, err := s.memberStore.Get(ctx, handle, userID)
+	if err != nil {
+		return err
+	}
+	action, before := ActivityMemberAdd, ""
+	if existing != nil {
+		action, before = ActivityMemberRoles, joinList(existing.Roles)
+	}
+	return s.memberStore.Put(ctx, handle, userID, roles, &ActivityEntry{
+		Action:      action,
+		ActorUserID: actorUserID,
+		Target:      userID,
+		Changes:     AppendChange(nil, "roles", before, joinList(roles)),
+	})
+}
+
+// removeMember deletes the membership and records member.remove with the roles it had. No-op if not a member.
+func (s *service) removeMember(ctx context.Context, handle, userID, actorUserID string) error {
+	existing, err := s.memberStore.Get(ctx, handle, userID)
+	if err != nil || existing == nil {
+		return err
+	}
+	return s.memberStore.Delete(ctx, handle, userID, &ActivityEntry{
+		Action:      ActivityMemberRemove,
+		ActorUserID: actorUserID,
+		Target:      userID,
+		Changes:     AppendChange(nil, "roles", joinList(existing.Roles), ""),
+	})
 }
 
 func (s *service) RemoveMember(ctx context.Context, handle, userID string, actorUserID string) error {
@@ -312,14 +574,14 @@ func (s *service) RemoveMember(ctx context.Context, handle, userID string, actor
 	if err != nil || !ok {
 		return ErrForbidden
 	}
-	row, err := s.store.GetByHandle(ctx, handle)
+	row, err := s.activeRow(ctx, handle)
 	if err != nil || row == nil {
 		return ErrArtistNotFound
 	}
 	if row.OwnerUserID == userID {
 		return ErrCannotRemoveOwner
 	}
-	return s.memberStore.Delete(ctx, handle, userID)
+	return s.removeMember(ctx, handle, userID, actorUserID)
 }
 
 func (s *service) UpdateMemberRoles(ctx context.Context, handle, userID string, roles []string, actorUserID string) error {
@@ -331,7 +593,7 @@ func (s *service) UpdateMemberRoles(ctx context.Context, handle, userID string,
 	if err != nil || !ok {
 		return ErrForbidden
 	}
-	row, err := s.store.GetByHandle(ctx, handle)
+	row, err := s.activeRow(ctx, handle)
 	if err != nil || row == nil {
 		return ErrArtistNotFound
 	}
@@ -340,12 +602,14 @@ func (s *service) UpdateMemberRoles(ctx context.Context, handle, userID string,
 	}
 	roles = dedupeRoles(roles)
 	if len(roles) == 0 {
-		return s.memberStore.Delete(ctx, handle, userID)
+		return s.removeMember(ctx, handle, userID, actorUserID)
 	}
-	if !RolesAreAssignable(roles) {
+	if ok, err := s.RolesAssignable(ctx, handle, roles); err != nil {
+		return err
+	} else if !ok {
 		return ErrInvalidRoles
 	}
-	return s.memberStore.Put(ctx, handle, userID, roles)
+	return s.putMember(ctx, handle, userID, roles, actorUserID)
 }
 
 func (s *service) ListMembers(ctx context.Context, handle, actorUserID string) ([]Member, error) {
@@ -357,7 +621,7 @@ func (s *service) ListMembers(ctx context.Context, handle, actorUserID string) (
 	if err != nil || !ok {
 		return nil, ErrForbidden
 	}
-	row, err := s.store.GetByHandle(ctx, handle)
+	row, err := s.activeRow(ctx, handle)
 	if err != nil || row == nil {
 		return nil, ErrArtistNotFound
 	}
@@ -381,3 +645,381 @@ func (s *service) ListMembers(ctx context.Context, handle, actorUserID string) (
 	}
 	return out, nil
 }
+
+// NominateOwner records the nominee as pending owner. Owner only; the nominee must already be a member.
+// Replaces any earlier nomination.
+func (s *service) NominateOwner(ctx context.Context, handle, nomineeUserID string, keepAsAdmin bool, actorUserID string) (*OwnershipTransfer, error) {
+	handle = normalizeHandle(handle)
+	if handle == "" {
+		return nil, ErrArtistNotFound
+	}
+	row, err := s.activeRow(ctx, handle)
+	if err != nil || row == nil {
+		return nil, ErrArtistNotFound
+	}
+	if row.OwnerUserID != actorUserID {
+		return nil, ErrForbidden
+	}
+	if nomineeUserID == "" || nomineeUserID == row.OwnerUserID {
+		return nil, ErrNomineeNotMember
+	}
+	mem, err := s.memberStore.Get(ctx, handle, nomineeUserID)
+	if err != nil {
+		return nil, err
+	}
+	if mem == nil {
+		return nil, ErrNomineeNotMember
+	}
+	now := time.Now().UTC()
+	t := &OwnershipTransfer{
+		Handle:        handle,
+		NomineeUserID: nomineeUserID,
+		NominatedBy:   actorUserID,
+		KeepAsAdmin:   keepAsAdmin,
+		CreatedAt:     now.Format(time.RFC3339),
+		ExpiresAt:     now.Add(TransferTTL).Format(time.RFC3339),
+	}
+	activity := ActivityEntry{Action: ActivityTransferNominate, ActorUserID: actorUserID, Target: nomineeUserID}
+	if err := s.store.PutTransfer(ctx, handle, t.NomineeUserID, t.NominatedBy, t.KeepAsAdmin, t.CreatedAt, t.ExpiresAt, activity); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTransfer returns the pending transfer. Only the owner and the nominee can see it.
+func (s *service) GetTransfer(ctx context.Context, handle, actorUserID string) (*OwnershipTransfer, error) {
+	_, t, err := s.pendingTransfer(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	return rowToTransfer(t), nil
+}
+
+// AcceptTransfer makes the nominee the owner. Nominee only.
+func (s *service) AcceptTransfer(ctx context.Context, handle, actorUserID string) (*Artist, error) {
+	row, t, err := s.pendingTransfer(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	if t.NomineeUserID != actorUserID {
+		return nil, ErrForbidden
+	}
+	// The nominating owner may no longer own the page (e.g. an earlier transfer was accepted).
+	if t.NominatedBy != row.OwnerUserID {
+		return nil, ErrTransferConflict
+	}
+	activity := ActivityEntry{
+		Action:      ActivityTransferAccept,
+		ActorUserID: actorUserID,
+		Target:      actorUserID,
+		Changes:     AppendChange(nil, "owner_user_id", row.OwnerUserID, actorUserID),
+	}
+	if err := s.store.TransferOwnership(ctx, row, actorUserID, t.KeepAsAdmin, activity); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrTransferConflict
+		}
+		return nil, err
+	}
+	if err := s.revokeVerification(ctx, row.Handle, VerificationReopenedTransfer, actorUserID); err != nil {
+		return nil, err
+	}
+	a := rowToArtist(row)
+	a.OwnerUserID = actorUserID
+	a.Verified, a.VerifiedAt = false, ""
+	return a, nil
+}
+
+// CancelTransfer withdraws (owner) or declines (nominee) the pending transfer.
+func (s *service) CancelTransfer(ctx context.Context, handle, actorUserID string) error {
+	row, t, err := s.pendingTransfer(ctx, handle, actorUserID)
+	if err != nil {
+		return err
+	}
+	err = s.store.DeleteTransfer(ctx, row.Handle, ActivityEntry{Action: ActivityTransferCancel, ActorUserID: actorUserID, Target: t.NomineeUserID})
+	if dynamo.IsCondCheckFailed(err) {
+		return ErrTransferNotFound
+	}
+	return err
+}
+
+// pendingTransfer loads the artist and its unexpired transfer, and checks the actor is the owner or the nominee.
+func (s *service) pendingTransfer(ctx context.Context, handle, actorUserID string) (*artistRow, *transferRow, error) {
+	handle = normalizeHandle(handle)
+	if handle == "" {
+		return nil, nil, ErrArtistNotFound
+	}
+	row, err := s.activeRow(ctx, handle)
+	if err != nil || row == nil {
+		return nil, nil, ErrArtistNotFound
+	}
+	t, err := s.store.GetTransfer(ctx, handle)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t == nil {
+		if row.OwnerUserID != actorUserID {
+			return nil, nil, ErrForbidden
+		}
+		return nil, nil, ErrTransferNotFound
+	}
+	if actorUserID != row.OwnerUserID && actorUserID != t.NomineeUserID {
+		return nil, nil, ErrForbidden
+	}
+	if exp, err := time.Parse(time.RFC3339, t.ExpiresAt); err == nil && time.Now().After(exp) {
+		return nil, nil, ErrTransferNotFound
+	}
+	return row, t, nil
+}
+
+func rowToTransfer(r *transferRow) *OwnershipTransfer {
+	return &OwnershipTransfer{
+		Handle:        r.Handle,
+		NomineeUserID: r.NomineeUserID,
+		NominatedBy:   r.NominatedBy,
+		KeepAsAdmin:   r.KeepAsAdmin,
+		CreatedAt:     r.CreatedAt,
+		ExpiresAt:     r.ExpiresAt,
+	}
+}
+
+// Rename changes the artist's handle. Owner only. The main row flips to the new handle in one transaction (leaving
+// an alias that redirects from the old handle), then members, the pending transfer and every HandleData's data
+// are moved. If moving fails part way, calling Rename again with the old handle and the same new handle resumes it.
+func (s *service) Rename(ctx context.Context, handle, newHandle, actorUserID string) (*Artist, error) {
+	handle = normalizeHandle(handle)
+	newHandle = normalizeHandle(newHandle)
+	if handle == "" {
+		return nil, ErrArtistNotFound
+	}
+	if !handleRegex.MatchString(newHandle) {
+		return nil, ErrInvalidHandle
+	}
+	if newHandle == handle {
+		return nil, ErrSameHandle
+	}
+	row, err := s.activeRow(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		// Resume: the flip happened but moving child rows did not finish.
+		alias, err := s.store.GetAlias(ctx, handle)
+		if err != nil {
+			return nil, err
+		}
+		if alias == nil || alias.NewHandle != newHandle {
+			return nil, ErrArtistNotFound
+		}
+		renamed, err := s.store.GetByHandle(ctx, newHandle)
+		if err != nil || renamed == nil {
+			return nil, ErrArtistNotFound
+		}
+		if renamed.OwnerUserID != actorUserID {
+			return nil, ErrForbidden
+		}
+		if err := s.migrateHandle(ctx, handle, newHandle); err != nil {
+			return nil, err
+		}
+		if err := s.revokeVerification(ctx, newHandle, VerificationReopenedRename, actorUserID); err != nil {
+			return nil, err
+		}
+		return rowToArtist(renamed), nil
+	}
+	if row.OwnerUserID != actorUserID {
+		return nil, ErrForbidden
+	}
+	if err := s.checkHandlePolicy(ctx, newHandle, actorUserID); err != nil {
+		return nil, err
+	}
+	if err := s.checkHandleAvailable(ctx, newHandle, handle); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	activity := ActivityEntry{
+		Action:      ActivityArtistRename,
+		ActorUserID: actorUserID,
+		Changes:     AppendChange(nil, "handle", handle, newHandle),
+	}
+	if err := s.store.Rename(ctx, row, newHandle, now.Format(time.RFC3339), now.Add(HandleReservation).Format(time.RFC3339), activity); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrHandleTaken
+		}
+		return nil, err
+	}
+	if err := s.migrateHandle(ctx, handle, newHandle); err != nil {
+		return nil, err
+	}
+	if err := s.revokeVerification(ctx, newHandle, VerificationReopenedRename, actorUserID); err != nil {
+		return nil, err
+	}
+	a := rowToArtist(row)
+	a.Handle = newHandle
+	a.Verified, a.VerifiedAt = false, ""
+	return a, nil
+}
+
+func (s *service) migrateHandle(ctx context.Context, oldHandle, newHandle string) error {
+	if err := s.memberStore.MigrateHandle(ctx, oldHandle, newHandle); err != nil {
+		return err
+	}
+	if err := s.store.MoveTransfer(ctx, oldHandle, newHandle); err != nil {
+		return err
+	}
+	if err := s.store.MoveLinks(ctx, oldHandle, newHandle); err != nil {
+		return err
+	}
+	if err := s.store.MoveCustomRoles(ctx, oldHandle, newHandle); err != nil {
+		return err
+	}
+	if err := s.store.MoveActivity(ctx, oldHandle, newHandle); err != nil {
+		return err
+	}
+	if err := s.store.MoveVerification(ctx, oldHandle, newHandle); err != nil {
+		return err
+	}
+	for _, d := range s.handleData {
+		if err := d.MigrateHandle(ctx, oldHandle, newHandle); err != nil {
+			return err
+		}
+	}
+	// Migrating posts indexes them under the new handle; a private page's must stay out of the index.
+	if a, err := s.GetByHandle(ctx, newHandle); err != nil {
+		return err
+	} else if a.Visibility == VisibilityPrivate {
+		return s.setIndexed(ctx, newHandle, false)
+	}
+	return nil
+}
+
+// checkHandleAvailable returns ErrHandleTaken if an artist uses the handle, or if it is a former handle still in
+// its reservation period. A reserved handle can be taken back by the artist it now redirects to (renamedFrom).
+func (s *service) checkHandleAvailable(ctx context.Context, handle, renamedFrom string) error {
+	existing, err := s.store.GetByHandle(ctx, handle)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrHandleTaken
+	}
+	alias, err := s.store.GetAlias(ctx, handle)
+	if err != nil || alias == nil {
+		return err
+	}
+	if until, err := time.Parse(time.RFC3339, alias.ReservedUntil); err == nil && time.Now().After(until) {
+		return nil
+	}
+	if renamedFrom != "" {
+		current, err := s.resolveAlias(ctx, handle)
+		if err != nil {
+			return err
+		}
+		if current == renamedFrom {
+			return nil
+		}
+	}
+	return ErrHandleTaken
+}
+
+// resolveAlias follows aliases from a former handle to the artist's current handle ("" if none).
+func (s *service) resolveAlias(ctx context.Context, handle string) (string, error) {
+	for i := 0; i < maxAliasHops; i++ {
+		alias, err := s.store.GetAlias(ctx, handle)
+		if err != nil || alias == nil {
+			return "", err
+		}
+		handle = alias.NewHandle
+		row, err := s.store.GetByHandle(ctx, handle)
+		if err != nil {
+			return "", err
+		}
+		if row != nil {
+			return handle, nil
+		}
+	}
+	return "", nil
+}
+
+// checkHandlePolicy returns ErrHandleReserved or ErrHandleDisallowed unless the policy allows the handle or a
+// platform admin granted it to userID.
+func (s *service) checkHandlePolicy(ctx context.Context, handle, userID string) error {
+	reason := s.policy.Check(handle)
+	if reason == "" {
+		return nil
+	}
+	grant, err := s.store.GetGrant(ctx, handle)
+	if err != nil {
+		return err
+	}
+	if grant != nil && userID != "" && grant.UserID == userID {
+		return nil
+	}
+	if reason == HandleReserved {
+		return ErrHandleReserved
+	}
+	return ErrHandleDisallowed
+}
+
+// CheckAvailability reports whether userID could create an artist with the handle right now.
+func (s *service) CheckAvailability(ctx context.Context, handle, userID string) (*HandleAvailability, error) {
+	handle = normalizeHandle(handle)
+	out := &HandleAvailability{Handle: handle, Reason: HandleAvailable}
+	if !handleRegex.MatchString(handle) {
+		out.Reason = HandleInvalid
+		return out, nil
+	}
+	switch err := s.checkHandlePolicy(ctx, handle, userID); err {
+	case nil:
+	case ErrHandleReserved:
+		out.Reason = HandleReserved
+		return out, nil
+	case ErrHandleDisallowed:
+		out.Reason = HandleDisallowed
+		return out, nil
+	default:
+		return nil, err
+	}
+	switch err := s.checkHandleAvailable(ctx, handle, ""); err {
+	case nil:
+	case ErrHandleTaken:
+		out.Reason = HandleTaken
+		return out, nil
+	default:
+		return nil, err
+	}
+	out.Available = true
+	return out, nil
+}
+
+// GrantHandle lets userID claim a reserved or disallowed handle (e.g. for a verified artist). Platform admins only;
+// the caller enforces that. Replaces any earlier grant for the handle.
+func (s *service) GrantHandle(ctx context.Context, handle, userID, actorUserID string) error {
+	handle = normalizeHandle(handle)
+	if !handleRegex.MatchString(handle) {
+		return ErrInvalidHandle
+	}
+	existing, err := s.store.GetByHandle(ctx, handle)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrHandleTaken
+	}
+	return s.store.PutGrant(ctx, handle, userID, actorUserID, time.Now().UTC().Format(time.RFC3339))
+}
+
+// RevokeHandleGrant removes the grant and returns the user it was for.
+func (s *service) RevokeHandleGrant(ctx context.Context, handle string) (string, error) {
+	handle = normalizeHandle(handle)
+	grant, err := s.store.GetGrant(ctx, handle)
+	if err != nil {
+		return "", err
+	}
+	if grant == nil {
+		return "", ErrGrantNotFound
+	}
+	if err := s.store.DeleteGrant(ctx, handle); err != nil {
+		return "", err
+	}
+	return grant.UserID, nil
+}
@@ -0,0 +1,112 @@
+package artists
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Site builder: branding (logo, cover image, accent colour) and the ordered list of page sections with
+// per-section visibility. Stored on the artist main row; pages that never changed sections get DefaultSections.
+
+var (
+	ErrInvalidColor    = errors.New("accent_color must be a hex colour like #1a2b3c")
+	ErrInvalidImageURL = errors.New("logo_url and cover_image_url must be http(s) URLs")
+	ErrInvalidSections = errors.New("sections must list each of bio, feed, music, photos, gigs, support, links exactly once")
+)
+
+// Section names, in the default order for new pages.
+const (
+	SectionBio     = "bio"
+	SectionFeed    = "feed"
+	SectionMusic   = "music"
+	SectionPhotos  = "photos"
+	SectionGigs    = "gigs"
+	SectionSupport = "support"
+	SectionLinks   = "links"
+)
+
+const maxImageURLLen = 2048
+
+var colorRegex = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// Section is one block of the artist page. Sections render top to bottom; hidden ones are not rendered.
+type Section struct {
+	Name    string `json:"name" dynamo:"name"`
+	Visible bool   `json:"visible" dynamo:"visible"`
+}
+
+// DefaultSections returns the section order and visibility for new pages (all visible).
+func DefaultSections() []Section {
+	names := allSectionNames()
+	out := make([]Section, len(names))
+	for i, n := range names {
+		out[i] = Section{Name: n, Visible: true}
+	}
+	return out
+}
+
+func allSectionNames() []string {
+	return []string{SectionBio, SectionFeed, SectionMusic, SectionPhotos, SectionGigs, SectionSupport, SectionLinks}
+}
+
+// ArtistUpdate is a partial update (PATCH /artists/{handle}); nil fields are left unchanged.
+// Display name, bio and visibility need PermArtistUpdate; branding and sections need PermSiteEdit.
+type ArtistUpdate struct {
+	DisplayName   *string   `json:"display_name"`
+	Bio           *string   `json:"bio"`
+	LogoURL       *string   `json:"logo_url"`
+	CoverImageURL *string   `json:"cover_image_url"`
+	AccentColor   *string   `json:"accent_color"`
+	Sections      []Section `json:"sections"`
+	Visibility    *string   `json:"visibility"`
+}
+
+func (u *ArtistUpdate) editsSite() bool {
+	return u.LogoURL != nil || u.CoverImageURL != nil || u.AccentColor != nil || u.Sections != nil
+}
+
+// normalizeColor lowercases a hex colour; "" clears it.
+func normalizeColor(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s != "" && !colorRegex.MatchString(s) {
+		return "", ErrInvalidColor
+	}
+	return s, nil
+}
+
+// normalizeImageURL accepts an absolute http(s) URL; "" clears it.
+func normalizeImageURL(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || len(s) > maxImageURLLen || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", ErrInvalidImageURL
+	}
+	return s, nil
+}
+
+// normalizeSections requires every known section exactly once, in the caller's order.
+func normalizeSections(sections []Section) ([]Section, error) {
+	names := allSectionNames()
+	if len(sections) != len(names) {
+		return nil, ErrInvalidSections
+	}
+	known := make(map[string]bool, len(names))
+	for _, n := range names {
+		known[n] = true
+	}
+	out := make([]Section, len(sections))
+	for i, sec := range sections {
+		name := strings.ToLower(strings.TrimSpace(sec.Name))
+		if !known[name] {
+			return nil, ErrInvalidSections
+		}
+		delete(known, name)
+		out[i] = Section{Name: name, Visible: sec.Visible}
+	}
+	return out, nil
+}
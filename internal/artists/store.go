@@ -21,14 +21,23 @@ const (
 )
 
 type artistRow struct {
-	PK            string `dynamo:"pk"`
-	SK            string `dynamo:"sk"`
-	Handle        string `dynamo:"handle"`
-	DisplayName   string `dynamo:"display_name"`
-	Bio           string `dynamo:"bio"`
-	OwnerUserID   string `dynamo:"owner_user_id"`
-	CreatedAt     string `dynamo:"created_at"`
-	FollowerCount int    `dynamo:"follower_count,omitempty"`
+	PK            string    `dynamo:"pk"`
+	SK            string    `dynamo:"sk"`
+	Handle        string    `dynamo:"handle"`
+	DisplayName   string    `dynamo:"display_name"`
+	Bio           string    `dynamo:"bio"`
+	OwnerUserID   string    `dynamo:"owner_user_id"`
+	CreatedAt     string    `dynamo:"created_at"`
+	FollowerCount int       `dynamo:"follower_count,omitempty"`
+	LogoURL       string    `dynamo:"logo_url,omitempty"`
+	CoverImageURL string    `dynamo:"cover_image_url,omitempty"`
+	AccentColor   string    `dynamo:"accent_color,omitempty"`
+	Sections      []Section `dynamo:"sections,omitempty"` // empty = DefaultSections
+	DeletedAt     string    `dynamo:"deleted_at,omitempty"`  // set while pending deletion
+	PurgeAfter    string    `dynamo:"purge_after,omitempty"` // restore deadline while pending deletion
+	Verified      bool      `dynamo:"verified,omitempty"`
+	VerifiedAt    string    `dynamo:"verified_at,omitempty"`
+	Visibility    string    `dynamo:"visibility,omitempty"` // empty = VisibilityPublic
 }
 
 type userIndexRow struct {
@@ -37,6 +46,8 @@ type userIndexRow struct {
 	Handle      string `dynamo:"handle"`
 	DisplayName string `dynamo:"display_name"`
 	CreatedAt   string `dynamo:"created_at"`
+	DeletedAt   string `dynamo:"deleted_at,omitempty"`
+	PurgeAfter  string `dynamo:"purge_after,omitempty"`
 }
 
 type Store struct {
@@ -64,19 +75,62 @@ func userIndexSK(handle string) string {
 	return userIndexSKPrefix + handle
 }
 
-// GetByHandle returns the artist for the given handle, or nil if not found.
+// GetByHandle returns the artist for the given handle, or nil if not found. Served from the request cache when set.
 func (s *Store) GetByHandle(ctx context.Context, handle string) (*artistRow, error) {
+	cache := cacheFrom(ctx)
+	if row, ok := cache.artist(handle); ok {
+		return row, nil
+	}
 	var row artistRow
 	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, artistSK).One(ctx, &row)
 	if err != nil {
 		if errors.Is(err, dynamo.ErrNotFound) {
+			cache.putArtist(handle, nil)
 			return nil, nil
 		}
 		return nil, err
 	}
+	cache.putArtist(handle, &row)
 	return &row, nil
 }
 
+// BatchGetByHandles returns the artists for the given handles keyed by handle; handles not found are absent.
+// Handles not in the request cache are fetched with DynamoDB BatchGetItem (one round-trip per 100 keys).
+func (s *Store) BatchGetByHandles(ctx context.Context, handles []string) (map[string]*artistRow, error) {
+	cache := cacheFrom(ctx)
+	out := make(map[string]*artistRow, len(handles))
+	var keys []dynamo.Keyed
+	missing := make(map[string]bool)
+	for _, h := range handles {
+		if row, ok := cache.artist(h); ok {
+			if row != nil {
+				out[h] = row
+			}
+			continue
+		}
+		if !missing[h] {
+			missing[h] = true
+			keys = append(keys, dynamo.Keys{artistPK(h), artistSK})
+		}
+	}
+	if len(keys) == 0 {
+		return out, nil
+	}
+	var rows []artistRow
+	if err := s.tbl().Batch("pk", "sk").Get(keys...).All(ctx, &rows); err != nil && !errors.Is(err, dynamo.ErrNotFound) {
+		return nil, err
+	}
+	for i := range rows {
+		out[rows[i].Handle] = &rows[i]
+		cache.putArtist(rows[i].Handle, &rows[i])
+		delete(missing, rows[i].Handle)
+	}
+	for h := range missing {
+		cache.putArtist(h, nil)
+	}
+	return out, nil
+}
+
 // ListByOwner returns all artists owned by the user (from user index, denormalized).
 func (s *Store) ListByOwner(ctx context.Context, userID string) ([]artistRow, error) {
 	pk := userIndexPK(userID)
@@ -89,6 +143,8 @@ func (s *Store) ListByOwner(ctx context.Context, userID string) ([]artistRow, er
 			DisplayName: idx.DisplayName,
 			OwnerUserID: userID,
 			CreatedAt:   idx.CreatedAt,
+			DeletedAt:   idx.DeletedAt,
+			PurgeAfter:  idx.PurgeAfter,
 		})
 	}
 	if err := iter.Err(); err != nil {
@@ -97,9 +153,11 @@ func (s *Store) ListByOwner(ctx context.Context, userID string) ([]artistRow, er
 	return out, nil
 }
 
-// Create creates an artist (main row + user index row) in one transaction.
+// Create creates an artist (main row + user index row) in one transaction, removing any alias left by an
+// earlier rename away from the handle and any admin grant (the caller checks both).
 // Fails if handle already exists (conditional put on main row).
 func (s *Store) Create(ctx context.Context, handle, displayName, bio, ownerUserID, createdAt string) error {
+	cacheFrom(ctx).forget(handle)
 	mainRow := artistRow{
 		PK:            artistPK(handle),
 		SK:            artistSK,
@@ -120,39 +178,29 @@ func (s *Store) Create(ctx context.Context, handle, displayName, bio, ownerUserI
 	return s.db.WriteTx().
 		Put(s.tbl().Put(mainRow).If("attribute_not_exists(pk)")).
 		Put(s.tbl().Put(idxRow).If("attribute_not_exists(pk)")).
+		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", aliasSK)).
+		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", grantSK)).
 		Run(ctx)
 }
 
-// Update updates display_name and bio on main row; display_name on user index row.
-func (s *Store) Update(ctx context.Context, handle, displayName, bio string) error {
-	main, err := s.GetByHandle(ctx, handle)
-	if err != nil || main == nil {
-		return err
-	}
-	// Update main row (display_name + bio)
-	if err := s.tbl().Update("pk", artistPK(handle)).Range("sk", artistSK).
-		Set("display_name", displayName).
-		Set("bio", bio).
-		Run(ctx); err != nil {
-		return err
-	}
-	// Update user index row so list shows correct display name
-	return s.tbl().Update("pk", userIndexPK(main.OwnerUserID)).Range("sk", userIndexSK(handle)).
-		Set("display_name", displayName).
-		Run(ctx)
-}
-
-// Delete deletes the artist (main row + user index row). Requires fetching main row to get owner_user_id.
-func (s *Store) Delete(ctx context.Context, handle string) error {
-	main, err := s.GetByHandle(ctx, handle)
-	if err != nil {
-		return err
-	}
-	if main == nil {
-		return nil
+// Update writes the editable fields of row (display name, bio, branding, sections, visibility) to the main row and
+// display_name to the user index row, with the activity entry (nil for none), in one transaction.
+func (s *Store) Update(ctx context.Context, row *artistRow, activity *ActivityEntry) error {
+	cacheFrom(ctx).forget(row.Handle)
+	tx := s.db.WriteTx().
+		Update(s.tbl().Update("pk", artistPK(row.Handle)).Range("sk", artistSK).
+			Set("display_name", row.DisplayName).
+			Set("bio", row.Bio).
+			Set("logo_url", row.LogoURL).
+			Set("cover_image_url", row.CoverImageURL).
+			Set("accent_color", row.AccentColor).
+			Set("sections", row.Sections).
+			Set("visibility", row.Visibility)).
+		// User index row, so the list shows the current display name
+		Update(s.tbl().Update("pk", userIndexPK(row.OwnerUserID)).Range("sk", userIndexSK(row.Handle)).
+			Set("display_name", row.DisplayName))
+	if activity != nil {
+		tx = tx.Put(ActivityPut(s.tbl(), row.Handle, *activity))
 	}
-	return s.db.WriteTx().
-		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", artistSK)).
-		Delete(s.tbl().Delete("pk", userIndexPK(main.OwnerUserID)).Range("sk", userIndexSK(handle))).
-		Run(ctx)
+	return tx.Run(ctx)
 }
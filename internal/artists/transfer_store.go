@@ -0,0 +1,109 @@
+package artists
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Ownership transfer: one pending nomination per artist.
+// Pending row: PK = ARTISTS#<handle>, SK = TRANSFER — nominee, who nominated, whether the old owner stays as admin.
+
+const transferSK = "TRANSFER"
+
+type transferRow struct {
+	PK            string `dynamo:"pk"`
+	SK            string `dynamo:"sk"`
+	Handle        string `dynamo:"handle"`
+	NomineeUserID string `dynamo:"nominee_user_id"`
+	NominatedBy   string `dynamo:"nominated_by"`
+	KeepAsAdmin   bool   `dynamo:"keep_as_admin"`
+	CreatedAt     string `dynamo:"created_at"`
+	ExpiresAt     string `dynamo:"expires_at"`
+}
+
+// GetTransfer returns the pending ownership transfer for the artist, or nil if none.
+func (s *Store) GetTransfer(ctx context.Context, handle string) (*transferRow, error) {
+	var row transferRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, transferSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// PutTransfer creates or replaces the pending ownership transfer, with the activity entry, in one transaction.
+func (s *Store) PutTransfer(ctx context.Context, handle, nomineeUserID, nominatedBy string, keepAsAdmin bool, createdAt, expiresAt string, activity ActivityEntry) error {
+	row := transferRow{
+		PK:            artistPK(handle),
+		SK:            transferSK,
+		Handle:        handle,
+		NomineeUserID: nomineeUserID,
+		NominatedBy:   nominatedBy,
+		KeepAsAdmin:   keepAsAdmin,
+		CreatedAt:     createdAt,
+		ExpiresAt:     expiresAt,
+	}
+	return s.db.WriteTx().
+		Put(s.tbl().Put(row)).
+		Put(ActivityPut(s.tbl(), handle, activity)).
+		Run(ctx)
+}
+
+// DeleteTransfer removes the pending ownership transfer, with the activity entry, in one transaction. Fails with a
+// condition check if the transfer is gone (cancelled or accepted meanwhile).
+func (s *Store) DeleteTransfer(ctx context.Context, handle string, activity ActivityEntry) error {
+	return s.db.WriteTx().
+		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", transferSK).If("attribute_exists(pk)")).
+		Put(ActivityPut(s.tbl(), handle, activity)).
+		Run(ctx)
+}
+
+// TransferOwnership makes newOwnerUserID the owner in one transaction: swaps owner_user_id on the main row,
+// moves the owner index row, sets the new owner's member rows to owner, sets the old owner's member rows to
+// admin (or removes them), clears the verified flag, deletes the pending transfer and appends the activity entry.
+// Fails with a condition check if the owner changed, the nominee is no longer a member, or the pending transfer was
+// replaced or cancelled.
+func (s *Store) TransferOwnership(ctx context.Context, artist *artistRow, newOwnerUserID string, keepAsAdmin bool, activity ActivityEntry) error {
+	cacheFrom(ctx).forget(artist.Handle)
+	handle := artist.Handle
+	oldOwnerUserID := artist.OwnerUserID
+	newIdx := userIndexRow{
+		PK:          userIndexPK(newOwnerUserID),
+		SK:          userIndexSK(handle),
+		Handle:      handle,
+		DisplayName: artist.DisplayName,
+		CreatedAt:   artist.CreatedAt,
+	}
+	ownerRoles := []string{RoleOwner}
+	newMember := memberRow{PK: memberPK(handle), SK: memberSK(newOwnerUserID), UserID: newOwnerUserID, Roles: ownerRoles}
+	newMemberIdx := memberUserIndexRow{PK: memberUserIndexPK(newOwnerUserID), SK: memberUserIndexSK(handle), Handle: handle, Roles: ownerRoles}
+
+	tx := s.db.WriteTx().
+		Update(s.tbl().Update("pk", artistPK(handle)).Range("sk", artistSK).
+			Set("owner_user_id", newOwnerUserID).
+			Remove("verified", "verified_at").
+			If("owner_user_id = ?", oldOwnerUserID)).
+		Delete(s.tbl().Delete("pk", userIndexPK(oldOwnerUserID)).Range("sk", userIndexSK(handle))).
+		Put(s.tbl().Put(newIdx)).
+		Put(s.tbl().Put(newMember).If("attribute_exists(pk)")).
+		Put(s.tbl().Put(newMemberIdx)).
+		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", transferSK).
+			If("nominee_user_id = ?", newOwnerUserID)).
+		Put(ActivityPut(s.tbl(), handle, activity))
+	if keepAsAdmin {
+		adminRoles := []string{RoleAdmin}
+		tx = tx.
+			Put(s.tbl().Put(memberRow{PK: memberPK(handle), SK: memberSK(oldOwnerUserID), UserID: oldOwnerUserID, Roles: adminRoles})).
+			Put(s.tbl().Put(memberUserIndexRow{PK: memberUserIndexPK(oldOwnerUserID), SK: memberUserIndexSK(handle), Handle: handle, Roles: adminRoles}))
+	} else {
+		tx = tx.
+			Delete(s.tbl().Delete("pk", memberPK(handle)).Range("sk", memberSK(oldOwnerUserID))).
+			Delete(s.tbl().Delete("pk", memberUserIndexPK(oldOwnerUserID)).Range("sk", memberUserIndexSK(handle)))
+	}
+	return tx.Run(ctx)
+}
@@ -0,0 +1,222 @@
+package artists
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Verification: a page asks to be marked as the real artist, with links that prove it (official site, label page,
+// socials). Platform admins review the queue. A rename or ownership transfer revokes the badge and puts the request
+// back in the queue until it is reviewed again.
+
+var (
+	ErrInvalidEvidence         = errors.New("evidence_links must be 1–5 https URLs")
+	ErrInvalidVerificationNote = errors.New("note must be at most 1000 characters")
+	ErrAlreadyVerified         = errors.New("artist is already verified")
+	ErrVerificationPending     = errors.New("a verification request is already pending")
+	ErrVerificationNotFound    = errors.New("no verification request")
+	ErrVerificationNotPending  = errors.New("verification request is not pending")
+)
+
+// Verification statuses.
+const (
+	VerificationPending  = "pending"
+	VerificationApproved = "approved"
+	VerificationRejected = "rejected"
+)
+
+// Reasons an approved request was put back in the queue.
+const (
+	VerificationReopenedRename   = "handle_renamed"
+	VerificationReopenedTransfer = "ownership_transferred"
+)
+
+const (
+	maxEvidenceLinks         = 5
+	maxVerificationNoteLen   = 1000
+	defaultVerificationLimit = 50
+	maxVerificationLimit     = 100
+)
+
+// Verification is an artist's verification request and its review.
+type Verification struct {
+	Handle         string   `json:"handle"`
+	Status         string   `json:"status"` // VerificationPending, VerificationApproved or VerificationRejected
+	EvidenceLinks  []string `json:"evidence_links"`
+	Note           string   `json:"note,omitempty"`
+	RequestedBy    string   `json:"requested_by"`
+	RequestedAt    string   `json:"requested_at"`
+	ReopenedReason string   `json:"reopened_reason,omitempty"` // why a verified page is back in review
+	ReviewedBy     string   `json:"reviewed_by,omitempty"`
+	ReviewedAt     string   `json:"reviewed_at,omitempty"`
+	ReviewNote     string   `json:"review_note,omitempty"`
+}
+
+func rowToVerification(r *verificationRow) *Verification {
+	return &Verification{
+		Handle:         r.Handle,
+		Status:         r.Status,
+		EvidenceLinks:  r.EvidenceLinks,
+		Note:           r.Note,
+		RequestedBy:    r.RequestedBy,
+		RequestedAt:    r.RequestedAt,
+		ReopenedReason: r.ReopenedReason,
+		ReviewedBy:     r.ReviewedBy,
+		ReviewedAt:     r.ReviewedAt,
+		ReviewNote:     r.ReviewNote,
+	}
+}
+
+// RequestVerification submits the page for review (artist:update). A rejected request can be replaced by a new one.
+func (s *service) RequestVerification(ctx context.Context, handle string, evidenceLinks []string, note, actorUserID string) (*Verification, error) {
+	handle = normalizeHandle(handle)
+	row, err := s.verificationArtist(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(evidenceLinks) == 0 || len(evidenceLinks) > maxEvidenceLinks {
+		return nil, ErrInvalidEvidence
+	}
+	links := make([]string, len(evidenceLinks))
+	for i, l := range evidenceLinks {
+		if links[i], err = normalizeLinkURL(l); err != nil {
+			return nil, ErrInvalidEvidence
+		}
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxVerificationNoteLen {
+		return nil, ErrInvalidVerificationNote
+	}
+	if row.Verified {
+		return nil, ErrAlreadyVerified
+	}
+	req := verificationRow{
+		Handle:        handle,
+		EvidenceLinks: links,
+		Note:          note,
+		RequestedBy:   actorUserID,
+		RequestedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.store.PutVerificationRequest(ctx, req, ActivityEntry{Action: ActivityVerificationRequest, ActorUserID: actorUserID}); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrVerificationPending
+		}
+		return nil, err
+	}
+	req.Status = VerificationPending
+	return rowToVerification(&req), nil
+}
+
+// GetVerification returns the page's verification request (artist:update).
+func (s *service) GetVerification(ctx context.Context, handle, actorUserID string) (*Verification, error) {
+	handle = normalizeHandle(handle)
+	if _, err := s.verificationArtist(ctx, handle, actorUserID); err != nil {
+		return nil, err
+	}
+	req, err := s.store.GetVerification(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, ErrVerificationNotFound
+	}
+	return rowToVerification(req), nil
+}
+
+// verificationArtist returns the active artist row, or ErrArtistNotFound or ErrForbidden unless the user has
+// artist:update.
+func (s *service) verificationArtist(ctx context.Context, handle, userID string) (*artistRow, error) {
+	if handle == "" {
+		return nil, ErrArtistNotFound
+	}
+	row, err := s.activeRow(ctx, handle)
+	if err != nil || row == nil {
+		return nil, ErrArtistNotFound
+	}
+	ok, err := s.HasPermission(ctx, handle, userID, PermArtistUpdate)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+	return row, nil
+}
+
+// ListVerificationQueue returns pending requests, oldest first. For platform admins; the caller checks.
+func (s *service) ListVerificationQueue(ctx context.Context, limit int) ([]Verification, error) {
+	if limit <= 0 {
+		limit = defaultVerificationLimit
+	}
+	limit = min(limit, maxVerificationLimit)
+	rows, err := s.store.ListVerificationQueue(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Verification, len(rows))
+	for i := range rows {
+		out[i] = *rowToVerification(&rows[i])
+	}
+	return out, nil
+}
+
+// ReviewVerification approves or rejects the page's pending request. For platform admins; the caller checks.
+// Approval marks the artist verified.
+func (s *service) ReviewVerification(ctx context.Context, handle string, approve bool, note, reviewerUserID string) (*Verification, error) {
+	handle = normalizeHandle(handle)
+	if handle == "" {
+		return nil, ErrArtistNotFound
+	}
+	row, err := s.activeRow(ctx, handle)
+	if err != nil || row == nil {
+		return nil, ErrArtistNotFound
+	}
+	req, err := s.store.GetVerification(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, ErrVerificationNotFound
+	}
+	if req.Status != VerificationPending {
+		return nil, ErrVerificationNotPending
+	}
+	status, action := VerificationRejected, ActivityVerificationReject
+	if approve {
+		status, action = VerificationApproved, ActivityVerificationApprove
+	}
+	note = strings.TrimSpace(note)
+	reviewedAt := time.Now().UTC().Format(time.RFC3339)
+	activity := ActivityEntry{Action: action, ActorUserID: reviewerUserID}
+	if err := s.store.ReviewVerification(ctx, req, status, reviewerUserID, reviewedAt, note, activity); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrVerificationNotPending
+		}
+		return nil, err
+	}
+	req.Status, req.ReviewedBy, req.ReviewedAt, req.ReviewNote = status, reviewerUserID, reviewedAt, note
+	return rowToVerification(req), nil
+}
+
+// revokeVerification puts an approved request back in the queue after a rename or transfer cleared the verified
+// flag. No-op if the page had no approved request, so a resumed rename can call it again.
+func (s *service) revokeVerification(ctx context.Context, handle, reason, actorUserID string) error {
+	req, err := s.store.GetVerification(ctx, handle)
+	if err != nil || req == nil || req.Status != VerificationApproved {
+		return err
+	}
+	err = s.store.ReopenVerification(ctx, req, reason, time.Now().UTC().Format(time.RFC3339), ActivityEntry{
+		Action:      ActivityVerificationRevoke,
+		ActorUserID: actorUserID,
+		Changes:     AppendChange(nil, "verified", "true", "false"),
+	})
+	if dynamo.IsCondCheckFailed(err) {
+		return nil
+	}
+	return err
+}
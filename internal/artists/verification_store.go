@@ -0,0 +1,147 @@
+package artists
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Verification: one request per artist, and a queue of pending requests for platform admins.
+// Request row: PK = ARTISTS#<handle>, SK = VERIFICATION — status, evidence links, who asked and who reviewed.
+// Queue row: PK = ARTISTS#VERIFY, SK = <requested_at>#<handle> — a copy of a pending request, oldest first.
+// (Handles are lowercase, so ARTISTS#VERIFY cannot collide with an artist partition.)
+// The verified flag itself lives on the main row (verified, verified_at).
+
+const (
+	verificationSK      = "VERIFICATION"
+	verificationQueuePK = "ARTISTS#VERIFY"
+)
+
+type verificationRow struct {
+	PK             string   `dynamo:"pk"`
+	SK             string   `dynamo:"sk"`
+	Handle         string   `dynamo:"handle"`
+	Status         string   `dynamo:"status"`
+	EvidenceLinks  []string `dynamo:"evidence_links"`
+	Note           string   `dynamo:"note,omitempty"`
+	RequestedBy    string   `dynamo:"requested_by"`
+	RequestedAt    string   `dynamo:"requested_at"`
+	ReopenedReason string   `dynamo:"reopened_reason,omitempty"` // set when a rename or transfer revoked verification
+	ReviewedBy     string   `dynamo:"reviewed_by,omitempty"`
+	ReviewedAt     string   `dynamo:"reviewed_at,omitempty"`
+	ReviewNote     string   `dynamo:"review_note,omitempty"`
+}
+
+func verificationQueueSK(requestedAt, handle string) string {
+	return requestedAt + "#" + handle
+}
+
+// queued returns the queue row for a pending request.
+func (r verificationRow) queued() verificationRow {
+	q := r
+	q.PK, q.SK = verificationQueuePK, verificationQueueSK(r.RequestedAt, r.Handle)
+	return q
+}
+
+// GetVerification returns the artist's verification request, or nil if none.
+func (s *Store) GetVerification(ctx context.Context, handle string) (*verificationRow, error) {
+	var row verificationRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, verificationSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// PutVerificationRequest creates or replaces the artist's request as pending and queues it, with the activity entry,
+// in one transaction. Fails with a condition check if a request is already pending.
+func (s *Store) PutVerificationRequest(ctx context.Context, row verificationRow, activity ActivityEntry) error {
+	row.PK, row.SK, row.Status = artistPK(row.Handle), verificationSK, VerificationPending
+	return s.db.WriteTx().
+		Put(s.tbl().Put(row).If("attribute_not_exists(pk) OR $ <> ?", "status", VerificationPending)).
+		Put(s.tbl().Put(row.queued())).
+		Put(ActivityPut(s.tbl(), row.Handle, activity)).
+		Run(ctx)
+}
+
+// ReviewVerification records the decision on a pending request and removes it from the queue, with the activity
+// entry, in one transaction; on approval it also sets verified and verified_at on the main row. Fails with a condition
+// check if the request was replaced or reviewed meanwhile, or the page was deleted.
+func (s *Store) ReviewVerification(ctx context.Context, req *verificationRow, status, reviewedBy, reviewedAt, note string, activity ActivityEntry) error {
+	cacheFrom(ctx).forget(req.Handle)
+	upd := s.tbl().Update("pk", artistPK(req.Handle)).Range("sk", verificationSK).
+		Set("status", status).
+		Set("reviewed_by", reviewedBy).
+		Set("reviewed_at", reviewedAt).
+		If("$ = ? AND requested_at = ?", "status", VerificationPending, req.RequestedAt)
+	if note != "" {
+		upd = upd.Set("review_note", note)
+	} else {
+		upd = upd.Remove("review_note")
+	}
+	tx := s.db.WriteTx().
+		Update(upd).
+		Delete(s.tbl().Delete("pk", verificationQueuePK).Range("sk", verificationQueueSK(req.RequestedAt, req.Handle))).
+		Put(ActivityPut(s.tbl(), req.Handle, activity))
+	if status == VerificationApproved {
+		tx = tx.Update(s.tbl().Update("pk", artistPK(req.Handle)).Range("sk", artistSK).
+			Set("verified", true).
+			Set("verified_at", reviewedAt).
+			If("attribute_exists(pk) AND attribute_not_exists(deleted_at)"))
+	}
+	return tx.Run(ctx)
+}
+
+// ReopenVerification puts an approved request back in the queue as pending (the main row's verified flag was
+// already cleared by the rename or transfer), with the activity entry, in one transaction. Fails with a condition
+// check if the request is no longer approved, e.g. it was already reopened.
+func (s *Store) ReopenVerification(ctx context.Context, req *verificationRow, reason, reopenedAt string, activity ActivityEntry) error {
+	row := *req
+	row.Status, row.RequestedAt, row.ReopenedReason = VerificationPending, reopenedAt, reason
+	row.ReviewedBy, row.ReviewedAt, row.ReviewNote = "", "", ""
+	return s.db.WriteTx().
+		Put(s.tbl().Put(row).If("$ = ?", "status", VerificationApproved)).
+		Put(s.tbl().Put(row.queued())).
+		Put(ActivityPut(s.tbl(), req.Handle, activity)).
+		Run(ctx)
+}
+
+// ListVerificationQueue returns pending requests, oldest first.
+func (s *Store) ListVerificationQueue(ctx context.Context, limit int) ([]verificationRow, error) {
+	var out []verificationRow
+	err := s.tbl().Get("pk", verificationQueuePK).Limit(limit).All(ctx, &out)
+	return out, err
+}
+
+// MoveVerification moves the request, and its queue entry if pending, to newHandle. Idempotent.
+func (s *Store) MoveVerification(ctx context.Context, oldHandle, newHandle string) error {
+	req, err := s.GetVerification(ctx, oldHandle)
+	if err != nil || req == nil {
+		return err
+	}
+	moved := *req
+	moved.PK, moved.Handle = artistPK(newHandle), newHandle
+	tx := s.db.WriteTx().
+		Put(s.tbl().Put(moved)).
+		Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", verificationSK))
+	if req.Status == VerificationPending {
+		tx = tx.
+			Delete(s.tbl().Delete("pk", verificationQueuePK).Range("sk", verificationQueueSK(req.RequestedAt, oldHandle))).
+			Put(s.tbl().Put(moved.queued()))
+	}
+	return tx.Run(ctx)
+}
+
+// DeleteVerificationQueueEntry removes the artist's queue entry, if its request is pending. The request row itself
+// goes with the partition. Idempotent.
+func (s *Store) DeleteVerificationQueueEntry(ctx context.Context, handle string) error {
+	req, err := s.GetVerification(ctx, handle)
+	if err != nil || req == nil || req.Status != VerificationPending {
+		return err
+	}
+	return s.tbl().Delete("pk", verificationQueuePK).Range("sk", verificationQueueSK(req.RequestedAt, handle)).Run(ctx)
+}
@@ -0,0 +1,100 @@
+package artists
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Page visibility. Public pages are listed everywhere. Unlisted pages are reachable by URL but left out of
+// discovery and follow suggestions. Private pages (e.g. while a band builds its page before launch) and their posts
+// are only visible to the owner and members; everyone else gets not found, and their posts are kept out of the feed
+// index. Stored on the artist main row; pages that never set it are public.
+
+var ErrInvalidVisibility = errors.New("visibility must be one of public, unlisted, private")
+
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
+// FeedIndexToggler is HandleData that keeps search documents for the page's posts. It is told when a page goes
+// private (remove the documents) and when it stops being private (index them again). Implementations must be
+// idempotent.
+type FeedIndexToggler interface {
+	SetIndexed(ctx context.Context, handle string, indexed bool) error
+}
+
+// normalizeVisibility lowercases a visibility; "" means public.
+func normalizeVisibility(s string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(s)); v {
+	case "", VisibilityPublic:
+		return VisibilityPublic, nil
+	case VisibilityUnlisted, VisibilityPrivate:
+		return v, nil
+	default:
+		return "", ErrInvalidVisibility
+	}
+}
+
+// Discoverable reports whether the page may appear in discovery and follow suggestions (public pages only). Every
+// path that lists pages to people who did not ask for them by handle (discovery, suggestions, artist search) must
+// leave out pages for which it is false.
+func (a *Artist) Discoverable() bool {
+	return a.Visibility == VisibilityPublic
+}
+
+// GetForViewer is GetByHandle for a (possibly anonymous) viewer: private pages are ErrArtistNotFound unless the
+// viewer is the owner or a member, and a former handle of a private page does not reveal the current one.
+func (s *service) GetForViewer(ctx context.Context, handle, viewerUserID string) (*Artist, error) {
+	a, err := s.GetByHandle(ctx, handle)
+	var moved *MovedError
+	if errors.As(err, &moved) {
+		if _, viewErr := s.GetForViewer(ctx, moved.Handle, viewerUserID); viewErr != nil {
+			return nil, ErrArtistNotFound
+		}
+		return nil, moved
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.Visibility != VisibilityPrivate {
+		return a, nil
+	}
+	ok, err := s.isMember(ctx, a, viewerUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrArtistNotFound
+	}
+	return a, nil
+}
+
+// isMember reports whether userID is the owner or a member of the page, whatever their roles.
+func (s *service) isMember(ctx context.Context, a *Artist, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	if a.OwnerUserID == userID {
+		return true, nil
+	}
+	mem, err := s.memberStore.Get(ctx, a.Handle, userID)
+	if err != nil {
+		return false, err
+	}
+	return mem != nil, nil
+}
+
+// setIndexed tells every FeedIndexToggler whether the page's posts belong in the feed index.
+func (s *service) setIndexed(ctx context.Context, handle string, indexed bool) error {
+	for _, d := range s.handleData {
+		if t, ok := d.(FeedIndexToggler); ok {
+			if err := t.SetIndexed(ctx, handle, indexed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -37,6 +37,10 @@ func (h *Handler) Token(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid or expired authorization code", http.StatusUnauthorized)
 			return
 		}
+		if err == ErrUserSuspended {
+			http.Error(w, "account suspended", http.StatusForbidden)
+			return
+		}
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -76,6 +80,10 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid or expired refresh token", http.StatusUnauthorized)
 			return
 		}
+		if err == ErrUserSuspended {
+			http.Error(w, "account suspended", http.StatusForbidden)
+			return
+		}
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -12,8 +12,14 @@ import (
 type contextKey struct{}
 type sessionKey struct{}
 
+// SuspensionChecker reports whether a user is suspended. Implemented by *Service.
+type SuspensionChecker interface {
+	IsSuspended(ctx context.Context, userID string) (bool, error)
+}
+
 // Authenticate validates the session token (from Cookie or Authorization Bearer) with RS256 and sets the user ID (sub) and session ID (jti) in the request context.
-func Authenticate(publicKey *rsa.PublicKey) func(http.Handler) http.Handler {
+// If suspensions is non-nil, requests from suspended users are rejected with 403 even while their token is unexpired.
+func Authenticate(publicKey *rsa.PublicKey, suspensions SuspensionChecker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			tokenString := SessionTokenFromRequest(r)
@@ -21,22 +27,57 @@ func Authenticate(publicKey *rsa.PublicKey) func(http.Handler) http.Handler {
 				http.Error(w, "missing or invalid authorization", http.StatusUnauthorized)
 				return
 			}
-			tok, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (any, error) {
-				if t.Method != jwt.SigningMethodRS256 {
-					return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-				}
-				return publicKey, nil
-			}, jwt.WithExpirationRequired())
-			if err != nil || !tok.Valid {
+			claims, err := parseSessionToken(publicKey, tokenString)
+			if err != nil {
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
-			claims, ok := tok.Claims.(*jwt.RegisteredClaims)
-			if !ok || claims.Subject == "" {
-				http.Error(w, "invalid token", http.StatusUnauthorized)
+			ctx := r.Context()
+			if suspensions != nil {
+				suspended, err := suspensions.IsSuspended(ctx, claims.Subject)
+				if err != nil {
+					http.Error(w, "internal error", http.StatusInternalServerError)
+					return
+				}
+				if suspended {
+					http.Error(w, "account suspended", http.StatusForbidden)
+					return
+				}
+			}
+			ctx = context.WithValue(ctx, contextKey{}, claims.Subject)
+			ctx = context.WithValue(ctx, sessionKey{}, claims.ID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// OptionalAuthenticate is Authenticate for public routes that show more to signed-in users (e.g. private artist
+// pages to their members). A missing, invalid or expired token, or a suspended user, is served anonymously.
+func OptionalAuthenticate(publicKey *rsa.PublicKey, suspensions SuspensionChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := SessionTokenFromRequest(r)
+			if tokenString == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			claims, err := parseSessionToken(publicKey, tokenString)
+			if err != nil {
+				next.ServeHTTP(w, r)
 				return
 			}
 			ctx := r.Context()
+			if suspensions != nil {
+				suspended, err := suspensions.IsSuspended(ctx, claims.Subject)
+				if err != nil {
+					http.Error(w, "internal error", http.StatusInternalServerError)
+					return
+				}
+				if suspended {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
 			ctx = context.WithValue(ctx, contextKey{}, claims.Subject)
 			ctx = context.WithValue(ctx, sessionKey{}, claims.ID)
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -44,6 +85,24 @@ func Authenticate(publicKey *rsa.PublicKey) func(http.Handler) http.Handler {
 	}
 }
 
+// parseSessionToken verifies an RS256 session token and returns its claims (subject required).
+func parseSessionToken(publicKey *rsa.PublicKey, tokenString string) (*jwt.RegisteredClaims, error) {
+	tok, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (any, error) {
+		if t.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return publicKey, nil
+	}, jwt.WithExpirationRequired())
+	if err != nil || !tok.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	claims, ok := tok.Claims.(*jwt.RegisteredClaims)
+	if !ok || claims.Subject == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
 // UserIDFromContext returns the authenticated user ID from the request context, or "" if not set.
 func UserIDFromContext(ctx context.Context) string {
 	v, _ := ctx.Value(contextKey{}).(string)
@@ -10,7 +10,17 @@ import (
 	"github.com/google/uuid"
 )
 
-var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrUserSuspended       = errors.New("account suspended")
+)
+
+// Suspension records why and by whom a user was suspended. Suspended users cannot sign in or use existing sessions.
+type Suspension struct {
+	Reason      string `json:"reason"`
+	SuspendedBy string `json:"suspended_by"`
+	SuspendedAt string `json:"suspended_at"`
+}
 
 // ActiveMonthRecorder records that a user was active this month (for MAU). Implemented by metrics.MAURecorder.
 // Called whenever we issue a new session — both login (code exchange) and refresh.
@@ -53,7 +63,11 @@ func (s *Service) GetClientTTLs(ctx context.Context, clientID string) (*ClientTT
 }
 
 // CreateAuthCode creates a one-time auth code for the user/client and PKCE challenge. Returns code and expires_in seconds.
+// Returns ErrUserSuspended if the user is suspended.
 func (s *Service) CreateAuthCode(ctx context.Context, userID, clientID, codeChallenge, codeChallengeMethod string) (code string, expiresIn int, err error) {
+	if err := s.ensureNotSuspended(ctx, userID); err != nil {
+		return "", 0, err
+	}
 	code = uuid.New().String()
 	if err := s.store.CreateAuthCode(ctx, code, codeChallenge, codeChallengeMethod, userID, clientID, AuthCodeTTL); err != nil {
 		return "", 0, err
@@ -84,7 +98,11 @@ func (s *Service) ExchangeCode(ctx context.Context, code, codeVerifier, clientID
 }
 
 // NewSession creates a session and refresh token for the user with the given TTLs (from auth client row).
+// Returns ErrUserSuspended if the user is suspended.
 func (s *Service) NewSession(ctx context.Context, userID string, ttls *ClientTTLs) (*TokenPair, error) {
+	if err := s.ensureNotSuspended(ctx, userID); err != nil {
+		return nil, err
+	}
 	sessionID, refreshID, expiresAt, err := s.store.CreateSession(ctx, userID, ttls.SessionTTL, ttls.RefreshTTL)
 	if err != nil {
 		return nil, err
@@ -127,6 +145,57 @@ func (s *Service) RevokeAllSessionsForUser(ctx context.Context, userID string) e
 	return s.store.RevokeAllSessionsForUser(ctx, userID)
 }
 
+// CountSessions returns how many sessions the user has (one per signed-in device or client).
+func (s *Service) CountSessions(ctx context.Context, userID string) (int, error) {
+	return s.store.CountSessionsForUser(ctx, userID)
+}
+
+// Suspend blocks the user from signing in and from using existing session tokens, and revokes all their sessions.
+func (s *Service) Suspend(ctx context.Context, userID, actorUserID, reason string) (*Suspension, error) {
+	susp := Suspension{
+		Reason:      reason,
+		SuspendedBy: actorUserID,
+		SuspendedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.store.PutSuspension(ctx, userID, susp); err != nil {
+		return nil, err
+	}
+	if err := s.store.RevokeAllSessionsForUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return &susp, nil
+}
+
+// Unsuspend lifts a suspension. The user must sign in again (sessions were revoked on suspend).
+func (s *Service) Unsuspend(ctx context.Context, userID string) error {
+	return s.store.DeleteSuspension(ctx, userID)
+}
+
+// GetSuspension returns the user's suspension, or nil if not suspended.
+func (s *Service) GetSuspension(ctx context.Context, userID string) (*Suspension, error) {
+	return s.store.GetSuspension(ctx, userID)
+}
+
+// IsSuspended reports whether the user is suspended. Implements SuspensionChecker for Authenticate.
+func (s *Service) IsSuspended(ctx context.Context, userID string) (bool, error) {
+	susp, err := s.store.GetSuspension(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return susp != nil, nil
+}
+
+func (s *Service) ensureNotSuspended(ctx context.Context, userID string) error {
+	suspended, err := s.IsSuspended(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if suspended {
+		return ErrUserSuspended
+	}
+	return nil
+}
+
 func (s *Service) signSession(userID, sessionID string, sessionTTL time.Duration) (string, error) {
 	now := time.Now().UTC()
 	claims := jwt.RegisteredClaims{
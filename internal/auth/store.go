@@ -17,6 +17,7 @@ import (
 // Refresh: PK = AUTH#REFRESH#<id>, SK = REFRESH
 // User session index (no GSI): PK = AUTH#USER#<user_id>, SK = SESSION#<session_id> or REFRESH#<refresh_id> — for RevokeAllSessionsForUser
 // Auth code: PK = AUTH#CODE#<code>, SK = CODE (one-time use, short-lived)
+// Suspension: PK = AUTH#USER#<user_id>, SK = SUSPENDED — present while a platform admin has suspended the user
 // Client:  PK = AUTH#CLIENT, SK = CLIENT#<client_id>
 
 var ErrAuthCodeInvalid = errors.New("invalid or expired authorization code")
@@ -33,6 +34,7 @@ const (
 	sessionSK         = "SESSION"
 	refreshSK         = "REFRESH"
 	codeSK            = "CODE"
+	suspensionSK      = "SUSPENDED"
 )
 
 type sessionRow struct {
@@ -65,6 +67,14 @@ type userSessionIndexRow struct {
 	SK string `dynamo:"sk"`
 }
 
+type suspensionRow struct {
+	PK          string `dynamo:"pk"`
+	SK          string `dynamo:"sk"`
+	Reason      string `dynamo:"reason"`
+	SuspendedBy string `dynamo:"suspended_by"`
+	SuspendedAt string `dynamo:"suspended_at"`
+}
+
 type authCodeRow struct {
 	PK                  string `dynamo:"pk"`
 	SK                  string `dynamo:"sk"`
@@ -211,6 +221,42 @@ func (s *Store) RevokeAllSessionsForUser(ctx context.Context, userID string) err
 	return iter.Err()
 }
 
+// CountSessionsForUser returns the number of sessions indexed for the user (expired sessions not yet cleaned up are included).
+func (s *Store) CountSessionsForUser(ctx context.Context, userID string) (int, error) {
+	n, err := s.tbl().Get("pk", userIndexPKPrefix+userID).Range("sk", dynamo.BeginsWith, userIndexSession).Count(ctx)
+	return int(n), err
+}
+
+// GetSuspension returns the user's suspension, or nil if the user is not suspended.
+func (s *Store) GetSuspension(ctx context.Context, userID string) (*Suspension, error) {
+	var row suspensionRow
+	err := s.tbl().Get("pk", userIndexPKPrefix+userID).Range("sk", dynamo.Equal, suspensionSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &Suspension{Reason: row.Reason, SuspendedBy: row.SuspendedBy, SuspendedAt: row.SuspendedAt}, nil
+}
+
+// PutSuspension marks the user suspended (overwrites an existing suspension).
+func (s *Store) PutSuspension(ctx context.Context, userID string, susp Suspension) error {
+	row := suspensionRow{
+		PK:          userIndexPKPrefix + userID,
+		SK:          suspensionSK,
+		Reason:      susp.Reason,
+		SuspendedBy: susp.SuspendedBy,
+		SuspendedAt: susp.SuspendedAt,
+	}
+	return s.tbl().Put(row).Run(ctx)
+}
+
+// DeleteSuspension lifts the user's suspension (no-op if not suspended).
+func (s *Store) DeleteSuspension(ctx context.Context, userID string) error {
+	return s.tbl().Delete("pk", userIndexPKPrefix+userID).Range("sk", suspensionSK).Run(ctx)
+}
+
 // ClientTTLs is the session and refresh TTL for an auth client (from DynamoDB).
 type ClientTTLs struct {
 	SessionTTL time.Duration
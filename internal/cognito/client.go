@@ -16,6 +16,8 @@ type Client interface {
 	SignUp(ctx context.Context, email, password string) (sub string, err error)
 	InitiateAuth(ctx context.Context, email, password string) (sub string, err error)
 	AdminDeleteUser(ctx context.Context, email string) error
+	AdminUpdateEmail(ctx context.Context, sub, newEmail string) error
+	IsFederated(ctx context.Context, sub string) (bool, error)
 }
 
 // AWSClient implements Client using the AWS Cognito Identity Provider SDK.
@@ -155,3 +157,38 @@ func (c *AWSClient) AdminDeleteUser(ctx context.Context, email string) error {
 	return err
 }
 
+
+// AdminUpdateEmail changes the email (and sign-in username alias) of the user with the given sub and marks it
+// verified; the backend has already verified ownership of newEmail. The user is addressed by the sub, which does not
+// change with the email.
+func (c *AWSClient) AdminUpdateEmail(ctx context.Context, sub, newEmail string) error {
+	_, err := c.svc.AdminUpdateUserAttributes(ctx, &cognitoidentityprovider.AdminUpdateUserAttributesInput{
+		UserPoolId: aws.String(c.userPoolID),
+		Username:   aws.String(sub),
+		UserAttributes: []types.AttributeType{
+			{
+				Name:  aws.String("email"),
+				Value: aws.String(newEmail),
+			},
+			{
+				Name:  aws.String("email_verified"),
+				Value: aws.String("true"),
+			},
+		},
+	})
+	return err
+}
+
+// IsFederated reports whether the user with the given sub signed up through an external IdP (Google/Apple) rather
+// than with a password. A sub unknown to the pool is not federated.
+func (c *AWSClient) IsFederated(ctx context.Context, sub string) (bool, error) {
+	out, err := c.svc.ListUsers(ctx, &cognitoidentityprovider.ListUsersInput{
+		UserPoolId: aws.String(c.userPoolID),
+		Filter:     aws.String(`sub = "` + sub + `"`),
+		Limit:      aws.Int32(1),
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(out.Users) > 0 && out.Users[0].UserStatus == types.UserStatusTypeExternalProvider, nil
+}
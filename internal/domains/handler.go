@@ -0,0 +1,118 @@
+package domains
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/auth"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Register adds a custom domain to the artist page and returns the TXT record to create. Body: {"host": "..."}.
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Host string `json:"host"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	d, err := h.svc.Register(r.Context(), r.PathValue("handle"), body.Host, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(d)
+}
+
+// List returns the artist page's custom domains.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	list, err := h.svc.List(r.Context(), r.PathValue("handle"), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if list == nil {
+		list = []Domain{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"domains": list})
+}
+
+// Verify checks the domain's TXT record now and returns the domain with its resulting status.
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	d, err := h.svc.Verify(r.Context(), r.PathValue("handle"), r.PathValue("host"), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// Remove removes the custom domain from the artist page.
+func (h *Handler) Remove(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.svc.Remove(r.Context(), r.PathValue("handle"), r.PathValue("host"), userID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Lookup returns the artist handle a verified custom domain serves. Public; used by the frontend and edge to route
+// requests for custom hosts.
+func (h *Handler) Lookup(w http.ResponseWriter, r *http.Request) {
+	l, err := h.svc.Lookup(r.Context(), r.PathValue("host"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(l)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case err == artists.ErrArtistNotFound, err == ErrDomainNotFound:
+		http.Error(w, "not found", http.StatusNotFound)
+	case err == artists.ErrForbidden:
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case err == ErrInvalidDomain:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == ErrDomainTaken, err == ErrTooManyDomains, err == ErrDomainNotPending, err == ErrDomainConflict:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
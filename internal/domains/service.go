@@ -0,0 +1,395 @@
+package domains
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+var (
+	ErrInvalidDomain    = errors.New("domain must be a hostname like band.example.com, not an afterwave.fm address")
+	ErrDomainTaken      = errors.New("domain is already registered")
+	ErrDomainNotFound   = errors.New("domain not found")
+	ErrDomainNotPending = errors.New("verification failed; register the domain again for a new token")
+	ErrTooManyDomains   = errors.New("an artist can have at most 5 domains")
+	ErrDomainConflict   = errors.New("domain changed while it was being removed; try again")
+
+	// errStateChanged: the domain row changed between check's read and its write; the check is stale and skipped.
+	errStateChanged = errors.New("domain state changed")
+)
+
+// Domain statuses.
+const (
+	StatusPending  = "pending"  // registered; waiting for the TXT record
+	StatusVerified = "verified" // TXT record found; the domain serves the artist page
+	StatusFailed   = "failed"   // not verified within VerifyWindow; another artist may register it
+	StatusRemoved  = "removed"  // removed by the artist
+)
+
+// PlatformDomain is where artist pages live by default ({handle}.afterwave.fm); it cannot be registered.
+const PlatformDomain = "afterwave.fm"
+
+// VerifyWindow is how long a registration has to pass verification before it fails.
+const VerifyWindow = 72 * time.Hour
+
+// TXT record: name TXTPrefix + host, value TXTValuePrefix + token.
+const (
+	TXTPrefix      = "_afterwave-verify."
+	TXTValuePrefix = "afterwave-verify="
+)
+
+const (
+	maxDomainsPerArtist = 5
+	maxHostLen          = 253
+	verifyBatchSize     = 100
+	maxRemoveAttempts   = 3
+)
+
+var labelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ArtistResolver resolves artist pages and checks member permissions. Implemented by artists.Service.
+type ArtistResolver interface {
+	GetByHandle(ctx context.Context, handle string) (*artists.Artist, error)
+	HasPermission(ctx context.Context, handle, userID, permission string) (bool, error)
+}
+
+// Resolver looks up DNS TXT records. *net.Resolver implements it.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type Service interface {
+	Register(ctx context.Context, handle, host, actorUserID string) (*Domain, error)
+	List(ctx context.Context, handle, actorUserID string) ([]Domain, error)
+	Verify(ctx context.Context, handle, host, actorUserID string) (*Domain, error)
+	Remove(ctx context.Context, handle, host, actorUserID string) error
+	Lookup(ctx context.Context, host string) (*Lookup, error)
+	VerifyPending(ctx context.Context, now time.Time) (int, error)
+}
+
+// Domain is a custom domain registered for an artist page. TXTName and TXTValue are the DNS record that proves
+// ownership.
+type Domain struct {
+	Host       string `json:"host"`
+	Handle     string `json:"handle"`
+	Status     string `json:"status"`
+	TXTName    string `json:"txt_name"`
+	TXTValue   string `json:"txt_value"`
+	CreatedAt  string `json:"created_at"`
+	VerifyBy   string `json:"verify_by"`
+	CheckedAt  string `json:"checked_at,omitempty"`
+	VerifiedAt string `json:"verified_at,omitempty"`
+	FailedAt   string `json:"failed_at,omitempty"`
+}
+
+// Lookup maps a verified host to the artist page it serves (GET /domains/{host}).
+type Lookup struct {
+	Host   string `json:"host"`
+	Handle string `json:"handle"`
+}
+
+type service struct {
+	store    *Store
+	artist   ArtistResolver
+	resolver Resolver
+}
+
+func NewService(store *Store, artist ArtistResolver, resolver Resolver) Service {
+	return &service{store: store, artist: artist, resolver: resolver}
+}
+
+func rowToDomain(r *domainRow) *Domain {
+	return &Domain{
+		Host:       r.Host,
+		Handle:     r.Handle,
+		Status:     r.Status,
+		TXTName:    TXTPrefix + r.Host,
+		TXTValue:   TXTValuePrefix + r.Token,
+		CreatedAt:  r.CreatedAt,
+		VerifyBy:   r.VerifyBy,
+		CheckedAt:  r.CheckedAt,
+		VerifiedAt: r.VerifiedAt,
+		FailedAt:   r.FailedAt,
+	}
+}
+
+// NormalizeHost lowercases the host and drops a trailing dot. It must have at least two labels, not be an IP
+// address and not be on PlatformDomain.
+func NormalizeHost(s string) (string, error) {
+	s = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+	if s == "" || len(s) > maxHostLen {
+		return "", ErrInvalidDomain
+	}
+	labels := strings.Split(s, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidDomain
+	}
+	for _, l := range labels {
+		if !labelRegex.MatchString(l) {
+			return "", ErrInvalidDomain
+		}
+	}
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", ErrInvalidDomain
+	}
+	if s == PlatformDomain || strings.HasSuffix(s, "."+PlatformDomain) {
+		return "", ErrInvalidDomain
+	}
+	return s, nil
+}
+
+// Register adds a domain to the page (site:edit) as pending and issues its TXT token. A host that failed
+// verification or was removed can be registered again, by any artist.
+func (s *service) Register(ctx context.Context, handle, host, actorUserID string) (*Domain, error) {
+	handle, err := s.requireSiteEditor(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	host, err = NormalizeHost(host)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := s.store.Get(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil && (prev.Status == StatusPending || prev.Status == StatusVerified) {
+		return nil, ErrDomainTaken
+	}
+	existing, err := s.store.ListByArtist(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	active := 0
+	for _, d := range existing {
+		if d.Status != StatusFailed {
+			active++
+		}
+	}
+	if active >= maxDomainsPerArtist {
+		return nil, ErrTooManyDomains
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	row := domainRow{
+		Host:      host,
+		Handle:    handle,
+		Status:    StatusPending,
+		Token:     token,
+		CreatedBy: actorUserID,
+		CreatedAt: now.Format(time.RFC3339),
+		VerifyBy:  now.Add(VerifyWindow).Format(time.RFC3339),
+	}
+	activity := artists.ActivityEntry{Action: artists.ActivityDomainAdd, ActorUserID: actorUserID, Target: host}
+	if err := s.store.Register(ctx, row, prev, activity); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrDomainTaken
+		}
+		return nil, err
+	}
+	return rowToDomain(&row), nil
+}
+
+// List returns the page's domains (site:edit), by host.
+func (s *service) List(ctx context.Context, handle, actorUserID string) ([]Domain, error) {
+	handle, err := s.requireSiteEditor(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.store.ListByArtist(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Domain, len(rows))
+	for i := range rows {
+		out[i] = *rowToDomain(&rows[i])
+	}
+	return out, nil
+}
+
+// Verify checks the domain's TXT record now (site:edit) rather than waiting for the background verifier. A pending
+// domain stays pending if the record is not found yet, and fails once VerifyWindow has passed.
+func (s *service) Verify(ctx context.Context, handle, host, actorUserID string) (*Domain, error) {
+	handle, err := s.requireSiteEditor(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.artistDomain(ctx, handle, host)
+	if err != nil {
+		return nil, err
+	}
+	switch row.Status {
+	case StatusVerified:
+		return rowToDomain(row), nil
+	case StatusFailed:
+		return nil, ErrDomainNotPending
+	}
+	if err := s.check(ctx, row, time.Now().UTC()); err != nil {
+		if err != errStateChanged {
+			return nil, err
+		}
+		// Verified, failed or removed meanwhile
+		if row, err = s.artistDomain(ctx, handle, host); err != nil {
+			return nil, err
+		}
+	}
+	return rowToDomain(row), nil
+}
+
+// Remove stops serving the page on the domain (site:edit) and frees the host.
+func (s *service) Remove(ctx context.Context, handle, host, actorUserID string) error {
+	handle, err := s.requireSiteEditor(ctx, handle, actorUserID)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		row, err := s.artistDomain(ctx, handle, host)
+		if err != nil {
+			return err
+		}
+		prevStatus := row.Status
+		row.Status, row.RemovedAt = StatusRemoved, time.Now().UTC().Format(time.RFC3339)
+		err = s.store.PutState(ctx, *row, prevStatus, &artists.ActivityEntry{
+			Action:      artists.ActivityDomainRemove,
+			ActorUserID: actorUserID,
+			Target:      row.Host,
+		})
+		// The verifier changed the status since the read: read it again
+		if !dynamo.IsCondCheckFailed(err) {
+			return err
+		}
+		if attempt == maxRemoveAttempts-1 {
+			return ErrDomainConflict
+		}
+	}
+}
+
+// Lookup returns the artist page a verified host serves. Public (frontend and edge routing); any other state, or a
+// page that is gone or pending deletion, is ErrDomainNotFound.
+func (s *service) Lookup(ctx context.Context, host string) (*Lookup, error) {
+	host, err := NormalizeHost(host)
+	if err != nil {
+		return nil, ErrDomainNotFound
+	}
+	row, err := s.store.Get(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil || row.Status != StatusVerified {
+		return nil, ErrDomainNotFound
+	}
+	if a, err := s.artist.GetByHandle(ctx, row.Handle); err != nil || a == nil {
+		return nil, ErrDomainNotFound
+	}
+	return &Lookup{Host: row.Host, Handle: row.Handle}, nil
+}
+
+// VerifyPending checks up to verifyBatchSize pending domains: each is verified if its TXT record is found and fails
+// once its VerifyWindow has passed. Returns how many were verified. A domain that errors stays queued.
+func (s *service) VerifyPending(ctx context.Context, now time.Time) (int, error) {
+	queued, err := s.store.ListPending(ctx, verifyBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	verified := 0
+	var errs []error
+	for _, q := range queued {
+		row, err := s.store.Get(ctx, q.Host)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if row == nil || row.Status != StatusPending {
+			if err := s.store.DeletePending(ctx, q.Host); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		err = s.check(ctx, row, now.UTC())
+		switch {
+		case err == ErrDomainNotFound: // registered again meanwhile
+		case err != nil:
+			errs = append(errs, err)
+		case row.Status == StatusVerified:
+			verified++
+		}
+	}
+	return verified, errors.Join(errs...)
+}
+
+// check looks up the pending domain's TXT record and saves the outcome on row: verified if the token is there,
+// failed if not and the deadline has passed, otherwise still pending with checked_at updated. Lookup errors (no
+// such name, DNS not propagated yet) count as not found. Returns errStateChanged, and saves nothing, if the row
+// changed since it was read (removed, checked or registered again meanwhile).
+func (s *service) check(ctx context.Context, row *domainRow, now time.Time) error {
+	prevStatus := row.Status
+	records, _ := s.resolver.LookupTXT(ctx, TXTPrefix+row.Host)
+	row.CheckedAt = now.Format(time.RFC3339)
+	switch {
+	case slices.Contains(records, TXTValuePrefix+row.Token):
+		row.Status, row.VerifiedAt = StatusVerified, row.CheckedAt
+	case row.VerifyBy != "" && row.VerifyBy <= row.CheckedAt:
+		row.Status, row.FailedAt = StatusFailed, row.CheckedAt
+	}
+	err := s.store.PutState(ctx, *row, prevStatus, nil)
+	if dynamo.IsCondCheckFailed(err) {
+		return errStateChanged
+	}
+	return err
+}
+
+// requireSiteEditor normalizes the handle and returns artists.ErrArtistNotFound or artists.ErrForbidden unless the
+// user has site:edit on the page.
+func (s *service) requireSiteEditor(ctx context.Context, handle, userID string) (string, error) {
+	handle = strings.ToLower(strings.TrimSpace(handle))
+	if handle == "" {
+		return "", artists.ErrArtistNotFound
+	}
+	if a, err := s.artist.GetByHandle(ctx, handle); err != nil || a == nil {
+		return "", artists.ErrArtistNotFound
+	}
+	ok, err := s.artist.HasPermission(ctx, handle, userID, artists.PermSiteEdit)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", artists.ErrForbidden
+	}
+	return handle, nil
+}
+
+// artistDomain returns the host's row if it is registered to the artist and not removed.
+func (s *service) artistDomain(ctx context.Context, handle, host string) (*domainRow, error) {
+	host, err := NormalizeHost(host)
+	if err != nil {
+		return nil, ErrDomainNotFound
+	}
+	row, err := s.store.Get(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil || row.Handle != handle || row.Status == StatusRemoved {
+		return nil, ErrDomainNotFound
+	}
+	return row, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
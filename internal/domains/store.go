@@ -0,0 +1,206 @@
+package domains
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/infra"
+)
+
+// Custom domains. The domain row is keyed by host, so a host belongs to at most one artist.
+// - Domain row: PK = DOMAINS#<host>, SK = DOMAIN — handle, status, verification token and deadline.
+// - Artist index row: PK = ARTISTS#<handle>, SK = DOMAIN#<host> — a copy of the domain row, to list an artist's
+//   domains. Removed domains have no index row.
+// - Pending queue row: PK = DOMAINS#PENDING, SK = <host> — domains awaiting verification, for the background verifier.
+//   (Hosts are lowercase, so DOMAINS#PENDING cannot collide with a domain partition.)
+// A new registration carries a new token; writes to an existing registration are conditioned on its token.
+
+const (
+	domainPKPrefix       = "DOMAINS#"
+	domainSK             = "DOMAIN"
+	artistPKPrefix       = "ARTISTS#"
+	artistDomainSKPrefix = "DOMAIN#"
+	pendingPK            = "DOMAINS#PENDING"
+)
+
+type domainRow struct {
+	PK         string `dynamo:"pk"`
+	SK         string `dynamo:"sk"`
+	Host       string `dynamo:"host"`
+	Handle     string `dynamo:"handle"`
+	Status     string `dynamo:"status"`
+	Token      string `dynamo:"token"`
+	CreatedBy  string `dynamo:"created_by"`
+	CreatedAt  string `dynamo:"created_at"`
+	VerifyBy   string `dynamo:"verify_by"`
+	CheckedAt  string `dynamo:"checked_at,omitempty"`
+	VerifiedAt string `dynamo:"verified_at,omitempty"`
+	FailedAt   string `dynamo:"failed_at,omitempty"`
+	RemovedAt  string `dynamo:"removed_at,omitempty"`
+}
+
+type pendingRow struct {
+	PK   string `dynamo:"pk"`
+	SK   string `dynamo:"sk"`
+	Host string `dynamo:"host"`
+}
+
+type Store struct {
+	db        *infra.Dynamo
+	tableName string
+}
+
+func NewStore(db *infra.Dynamo, tableName string) *Store {
+	return &Store{db: db, tableName: tableName}
+}
+
+func (s *Store) tbl() dynamo.Table {
+	return s.db.Table(s.tableName)
+}
+
+func domainPK(host string) string {
+	return domainPKPrefix + host
+}
+
+func artistPK(handle string) string {
+	return artistPKPrefix + handle
+}
+
+func artistDomainSK(host string) string {
+	return artistDomainSKPrefix + host
+}
+
+// Get returns the domain row for the host, or nil if the host was never registered.
+func (s *Store) Get(ctx context.Context, host string) (*domainRow, error) {
+	var row domainRow
+	err := s.tbl().Get("pk", domainPK(host)).Range("sk", dynamo.Equal, domainSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// ListByArtist returns the artist's domains (not removed), by host.
+func (s *Store) ListByArtist(ctx context.Context, handle string) ([]domainRow, error) {
+	var out []domainRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, artistDomainSKPrefix).All(ctx, &out)
+	return out, err
+}
+
+// Register writes a new pending registration (domain row, artist index row, queue entry) and the activity entry in one
+// transaction. prev is the host's current row, if any, which the caller checked may be replaced (failed or removed);
+// its artist index row is removed. Fails with a condition check if the host's row changed meanwhile.
+func (s *Store) Register(ctx context.Context, row domainRow, prev *domainRow, activity artists.ActivityEntry) error {
+	main := row
+	main.PK, main.SK = domainPK(row.Host), domainSK
+	idx := row
+	idx.PK, idx.SK = artistPK(row.Handle), artistDomainSK(row.Host)
+	put := s.tbl().Put(main)
+	if prev == nil {
+		put = put.If("attribute_not_exists(pk)")
+	} else {
+		put = put.If("token = ? AND $ = ?", prev.Token, "status", prev.Status)
+	}
+	tx := s.db.WriteTx().
+		Put(put).
+		Put(s.tbl().Put(idx)).
+		Put(s.tbl().Put(pendingRow{PK: pendingPK, SK: row.Host, Host: row.Host})).
+		Put(artists.ActivityPut(s.tbl(), row.Handle, activity))
+	if prev != nil && prev.Handle != row.Handle {
+		tx = tx.Delete(s.tbl().Delete("pk", artistPK(prev.Handle)).Range("sk", artistDomainSK(prev.Host)))
+	}
+	return tx.Run(ctx)
+}
+
+// PutState writes a registration's new state to the domain row and the artist index row (removed: deletes the index
+// row), drops the queue entry unless still pending and appends the activity entry (nil for none), in one transaction.
+// prevStatus is the status the row was read with. Fails with a condition check if the host was registered again or
+// its status changed meanwhile (a removal racing the verifier).
+func (s *Store) PutState(ctx context.Context, row domainRow, prevStatus string, activity *artists.ActivityEntry) error {
+	main := row
+	main.PK, main.SK = domainPK(row.Host), domainSK
+	tx := s.db.WriteTx().
+		Put(s.tbl().Put(main).If("handle = ? AND token = ? AND $ = ?", row.Handle, row.Token, "status", prevStatus))
+	if row.Status == StatusRemoved {
+		tx = tx.Delete(s.tbl().Delete("pk", artistPK(row.Handle)).Range("sk", artistDomainSK(row.Host)))
+	} else {
+		idx := row
+		idx.PK, idx.SK = artistPK(row.Handle), artistDomainSK(row.Host)
+		tx = tx.Put(s.tbl().Put(idx))
+	}
+	if row.Status != StatusPending {
+		tx = tx.Delete(s.tbl().Delete("pk", pendingPK).Range("sk", row.Host))
+	}
+	if activity != nil {
+		tx = tx.Put(artists.ActivityPut(s.tbl(), row.Handle, *activity))
+	}
+	return tx.Run(ctx)
+}
+
+// ListPending returns up to limit hosts awaiting verification.
+func (s *Store) ListPending(ctx context.Context, limit int) ([]pendingRow, error) {
+	var out []pendingRow
+	err := s.tbl().Get("pk", pendingPK).Limit(limit).All(ctx, &out)
+	return out, err
+}
+
+// DeletePending removes a stale queue entry (the host is no longer pending).
+func (s *Store) DeletePending(ctx context.Context, host string) error {
+	return s.tbl().Delete("pk", pendingPK).Range("sk", host).Run(ctx)
+}
+
+// MigrateHandle points the artist's domains at newHandle after a rename: the handle is rewritten on each domain row
+// and the index rows move. Idempotent.
+func (s *Store) MigrateHandle(ctx context.Context, oldHandle, newHandle string) error {
+	rows, err := s.ListByArtist(ctx, oldHandle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		idx := row
+		idx.PK, idx.Handle = artistPK(newHandle), newHandle
+		err := s.db.WriteTx().
+			Update(s.tbl().Update("pk", domainPK(row.Host)).Range("sk", domainSK).
+				Set("handle", newHandle).
+				If("handle = ? AND token = ?", oldHandle, row.Token)).
+			Put(s.tbl().Put(idx)).
+			Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", artistDomainSK(row.Host))).
+			Run(ctx)
+		if dynamo.IsCondCheckFailed(err) {
+			// Registered by another artist meanwhile (this one had failed); just drop the stale index row.
+			err = s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", artistDomainSK(row.Host)).Run(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeHandle releases every domain of a purged artist (domain row, index row, queue entry). Idempotent.
+func (s *Store) PurgeHandle(ctx context.Context, handle string) error {
+	rows, err := s.ListByArtist(ctx, handle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := s.db.WriteTx().
+			Delete(s.tbl().Delete("pk", domainPK(row.Host)).Range("sk", domainSK).If("handle = ? AND token = ?", handle, row.Token)).
+			Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", artistDomainSK(row.Host))).
+			Delete(s.tbl().Delete("pk", pendingPK).Range("sk", row.Host)).
+			Run(ctx)
+		if dynamo.IsCondCheckFailed(err) {
+			err = s.tbl().Delete("pk", artistPK(handle)).Range("sk", artistDomainSK(row.Host)).Run(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
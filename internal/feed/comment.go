@@ -0,0 +1,344 @@
+package feed
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Comments on published posts. Any signed-in user who can see the post can comment or reply to a comment; replies
+// nest up to MaxCommentDepth levels. Authors can edit and delete their comments; members with feed:update can hide
+// (and unhide) or delete any comment on the artist's posts. Hidden comments are only listed for their author and
+// those members. Users' block lists (preferences.Preferences.BlockedUsers) are honored: comments by users the
+// viewer blocked are not listed, and users can't reply to someone who blocked them. Comments are rate limited per
+// user.
+
+// MaxCommentLength is the longest comment, in characters.
+const MaxCommentLength = 2000
+
+// MaxCommentDepth is the deepest reply level (0 is a comment on the post).
+const MaxCommentDepth = 5
+
+// DefaultCommentRateLimit applies when no limit is configured.
+var DefaultCommentRateLimit = CommentRateLimit{Max: 10, Window: time.Minute}
+
+var (
+	ErrCommentNotFound    = errors.New("comment not found")
+	ErrInvalidComment     = errors.New("comment must be 1–2000 characters")
+	ErrCommentTooDeep     = errors.New("replies can't be nested deeper")
+	ErrCommentBlocked     = errors.New("you can't reply to this user")
+	ErrCommentRateLimited = errors.New("too many comments; try again later")
+	ErrCommentChanged     = errors.New("comment changed while updating; try again")
+)
+
+// CommentRateLimit is how many comments a user can create per window.
+type CommentRateLimit struct {
+	Max    int
+	Window time.Duration
+}
+
+// Comment is a comment on a post, or a reply to another comment (ParentID).
+type Comment struct {
+	CommentID      string `json:"comment_id"`
+	PostID         string `json:"post_id"`
+	ParentID       string `json:"parent_id,omitempty"`
+	Depth          int    `json:"depth"`
+	AuthorUserID   string `json:"author_user_id,omitempty"` // empty once deleted
+	Body           string `json:"body"`                     // plain text; empty once deleted
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at,omitempty"`
+	Edited         bool   `json:"edited"`
+	Hidden         bool   `json:"hidden"`
+	HiddenByUserID string `json:"hidden_by_user_id,omitempty"`
+	Deleted        bool   `json:"deleted"` // kept because it has replies
+	ReplyCount     int    `json:"reply_count"`
+}
+
+func commentToAPI(r *commentRow) *Comment {
+	c := &Comment{
+		CommentID:      r.CommentID,
+		PostID:         r.PostID,
+		ParentID:       r.ParentID,
+		Depth:          r.Depth,
+		AuthorUserID:   r.AuthorUserID,
+		Body:           r.Body,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+		Edited:         r.UpdatedAt != "",
+		Hidden:         r.Hidden,
+		HiddenByUserID: r.HiddenBy,
+		Deleted:        r.Deleted,
+		ReplyCount:     r.ReplyCount,
+	}
+	if r.Deleted {
+		c.AuthorUserID, c.Body = "", ""
+	}
+	return c
+}
+
+// newCommentID returns an ID that sorts by creation time.
+func newCommentID(now time.Time) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%016x%s", now.UnixNano(), hex.EncodeToString(b))
+}
+
+func normalizeCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > MaxCommentLength {
+		return "", ErrInvalidComment
+	}
+	return body, nil
+}
+
+// canModerateComments reports whether the user can hide and delete any comment on the artist's posts.
+func (s *service) canModerateComments(ctx context.Context, handle, userID string) bool {
+	if userID == "" {
+		return false
+	}
+	return s.ensureCanManageFeed(ctx, handle, userID, artists.PermFeedUpdate) == nil
+}
+
+// blocks reports whether userID has blocked otherUserID. Without preferences, nobody is blocked.
+func (s *service) blocks(ctx context.Context, userID, otherUserID string) (bool, error) {
+	if s.prefs == nil || userID == "" {
+		return false, nil
+	}
+	p, err := s.prefs.GetPreferences(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return p.Blocks(otherUserID), nil
+}
+
+// takeCommentQuota counts a comment against the user's rate limit.
+func (s *service) takeCommentQuota(ctx context.Context, userID string, now time.Time) error {
+	limit := s.commentRate
+	if limit.Max <= 0 || limit.Window <= 0 {
+		limit = DefaultCommentRateLimit
+	}
+	windowStart := now.Truncate(limit.Window).Format(time.RFC3339Nano)
+	ok, err := s.store.TakeCommentQuota(ctx, userID, windowStart, limit.Max)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCommentRateLimited
+	}
+	return nil
+}
+
+// CreateComment comments on a published post, or replies to parentID.
+func (s *service) CreateComment(ctx context.Context, handle, postID, parentID, body, actorUserID string) (*Comment, error) {
+	handle = normalizeHandle(handle)
+	if _, err := s.publishedPost(ctx, handle, postID, actorUserID); err != nil {
+		return nil, err
+	}
+	body, err := normalizeCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+	depth := 0
+	if parentID != "" {
+		parent, err := s.store.GetComment(ctx, handle, postID, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.Deleted || (parent.Hidden && !s.canModerateComments(ctx, handle, actorUserID)) {
+			return nil, ErrCommentNotFound
+		}
+		if parent.Depth >= MaxCommentDepth {
+			return nil, ErrCommentTooDeep
+		}
+		blocked, err := s.blocks(ctx, parent.AuthorUserID, actorUserID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrCommentBlocked
+		}
+		depth = parent.Depth + 1
+	}
+	now := time.Now().UTC()
+	if err := s.takeCommentQuota(ctx, actorUserID, now); err != nil {
+		return nil, err
+	}
+	row := commentRow{
+		CommentID:    newCommentID(now),
+		PostID:       postID,
+		ParentID:     parentID,
+		Depth:        depth,
+		AuthorUserID: actorUserID,
+		Body:         body,
+		CreatedAt:    now.Format(time.RFC3339),
+	}
+	if err := s.store.CreateComment(ctx, handle, row); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			if parentID != "" {
+				return nil, ErrCommentNotFound
+			}
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	return commentToAPI(&row), nil
+}
+
+// ListComments returns a page of the post's top-level comments (newest first) or, with parentID, of a comment's
+// replies (oldest first). Hidden comments are left out except for their author and members with feed:update;
+// comments by users the viewer blocked are left out. A page can have fewer than limit comments even when more follow.
+func (s *service) ListComments(ctx context.Context, handle, postID, parentID string, limit int, cursor, viewerUserID string) ([]Comment, string, error) {
+	handle = normalizeHandle(handle)
+	if _, err := s.publishedPost(ctx, handle, postID, viewerUserID); err != nil {
+		return nil, "", err
+	}
+	if parentID != "" {
+		parent, err := s.store.GetComment(ctx, handle, postID, parentID)
+		if err != nil {
+			return nil, "", err
+		}
+		if parent == nil || (parent.Hidden && parent.AuthorUserID != viewerUserID && !s.canModerateComments(ctx, handle, viewerUserID)) {
+			return nil, "", ErrCommentNotFound
+		}
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	ids, nextCursor, err := s.store.ListCommentThreadPage(ctx, handle, postID, parentID, limit, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := s.store.BatchGetComments(ctx, handle, postID, ids)
+	if err != nil {
+		return nil, "", err
+	}
+	moderator := false
+	for _, r := range rows {
+		if r.Hidden && r.AuthorUserID != viewerUserID {
+			moderator = s.canModerateComments(ctx, handle, viewerUserID)
+			break
+		}
+	}
+	var blocked map[string]bool
+	if s.prefs != nil && viewerUserID != "" {
+		p, err := s.prefs.GetPreferences(ctx, viewerUserID)
+		if err != nil {
+			return nil, "", err
+		}
+		blocked = make(map[string]bool, len(p.BlockedUsers))
+		for _, id := range p.BlockedUsers {
+			blocked[id] = true
+		}
+	}
+	out := make([]Comment, 0, len(rows))
+	for _, r := range rows {
+		if r.Hidden && r.AuthorUserID != viewerUserID && !moderator {
+			continue
+		}
+		if blocked[r.AuthorUserID] && !r.Deleted {
+			continue
+		}
+		out = append(out, *commentToAPI(r))
+	}
+	return out, nextCursor, nil
+}
+
+// UpdateComment changes the comment's body. Author only.
+func (s *service) UpdateComment(ctx context.Context, handle, postID, commentID, body, actorUserID string) (*Comment, error) {
+	handle = normalizeHandle(handle)
+	if _, err := s.publishedPost(ctx, handle, postID, actorUserID); err != nil {
+		return nil, err
+	}
+	row, err := s.store.GetComment(ctx, handle, postID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil || row.Deleted {
+		return nil, ErrCommentNotFound
+	}
+	if row.AuthorUserID != actorUserID {
+		return nil, ErrForbidden
+	}
+	body, err = normalizeCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+	if body == row.Body {
+		return commentToAPI(row), nil
+	}
+	updatedAt := time.Now().UTC().Format(time.RFC3339)
+	if err := s.store.UpdateCommentBody(ctx, handle, row, body, updatedAt); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	row.Body, row.UpdatedAt = body, updatedAt
+	return commentToAPI(row), nil
+}
+
+// SetCommentHidden hides or unhides a comment. Members with feed:update only.
+func (s *service) SetCommentHidden(ctx context.Context, handle, postID, commentID string, hidden bool, actorUserID string) (*Comment, error) {
+	handle = normalizeHandle(handle)
+	if _, err := s.publishedPost(ctx, handle, postID, actorUserID); err != nil {
+		return nil, err
+	}
+	if err := s.ensureCanManageFeed(ctx, handle, actorUserID, artists.PermFeedUpdate); err != nil {
+		return nil, err
+	}
+	row, err := s.store.GetComment(ctx, handle, postID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil || row.Deleted {
+		return nil, ErrCommentNotFound
+	}
+	if row.Hidden == hidden {
+		return commentToAPI(row), nil
+	}
+	if err := s.store.SetCommentHidden(ctx, handle, row, hidden, actorUserID); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrCommentChanged
+		}
+		return nil, err
+	}
+	row.Hidden, row.HiddenBy = hidden, ""
+	if hidden {
+		row.HiddenBy = actorUserID
+	}
+	return commentToAPI(row), nil
+}
+
+// DeleteComment deletes a comment. Its author or members with feed:update.
+func (s *service) DeleteComment(ctx context.Context, handle, postID, commentID, actorUserID string) error {
+	handle = normalizeHandle(handle)
+	if _, err := s.publishedPost(ctx, handle, postID, actorUserID); err != nil {
+		return err
+	}
+	row, err := s.store.GetComment(ctx, handle, postID, commentID)
+	if err != nil {
+		return err
+	}
+	if row == nil || row.Deleted {
+		return ErrCommentNotFound
+	}
+	if row.AuthorUserID != actorUserID && !s.canModerateComments(ctx, handle, actorUserID) {
+		return ErrForbidden
+	}
+	if err := s.store.DeleteComment(ctx, handle, row); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return ErrCommentChanged
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/guregu/dynamo/v2"
+)
+
+// Comments live in the artist partition next to their post. Two-row pattern, like posts:
+// - Main row: PK = ARTISTS#<handle>, SK = POSTCOMMENT#<post_id>#<comment_id> — full comment data.
+// - Thread row: PK = ARTISTS#<handle>, SK = POSTTHREAD#<post_id>#<parent_id>#<comment_id> — for listing a post's
+//   top-level comments (empty parent_id) or a comment's replies in time order. Comment IDs sort by creation time.
+// Creating or deleting a comment updates the post's comment_count and the parent's reply_count in the same
+// transaction. A deleted comment with replies is kept as a tombstone (deleted, body removed) so its thread stays
+// reachable.
+// Rate limit row: PK = COMMENTRATE#<user_id>, SK = COMMENTRATE — comments used in the current window.
+
+const (
+	commentSKPrefix     = "POSTCOMMENT#"
+	commentThreadPrefix = "POSTTHREAD#"
+	commentRatePKPrefix = "COMMENTRATE#"
+	commentRateSK       = "COMMENTRATE"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+type commentRow struct {
+	PK           string `dynamo:"pk"`
+	SK           string `dynamo:"sk"`
+	CommentID    string `dynamo:"comment_id"`
+	PostID       string `dynamo:"post_id"`
+	ParentID     string `dynamo:"parent_id,omitempty"`
+	Depth        int    `dynamo:"depth"` // 0 for top-level comments
+	AuthorUserID string `dynamo:"author_user_id"`
+	Body         string `dynamo:"body,omitempty"`
+	CreatedAt    string `dynamo:"created_at"`
+	UpdatedAt    string `dynamo:"updated_at,omitempty"`
+	Hidden       bool   `dynamo:"hidden"`
+	HiddenBy     string `dynamo:"hidden_by_user_id,omitempty"`
+	Deleted      bool   `dynamo:"deleted"`
+	ReplyCount   int    `dynamo:"reply_count"`
+}
+
+type commentThreadRow struct {
+	PK        string `dynamo:"pk" dynamodbav:"pk"`
+	SK        string `dynamo:"sk" dynamodbav:"sk"`
+	CommentID string `dynamo:"comment_id" dynamodbav:"comment_id"`
+}
+
+type commentRateRow struct {
+	PK          string `dynamo:"pk"`
+	SK          string `dynamo:"sk"`
+	WindowStart string `dynamo:"window_start"`
+	Used        int    `dynamo:"used"`
+}
+
+func commentPrefix(postID string) string {
+	return commentSKPrefix + postID + "#"
+}
+
+func commentSK(postID, commentID string) string {
+	return commentPrefix(postID) + commentID
+}
+
+func commentThreadPostPrefix(postID string) string {
+	return commentThreadPrefix + postID + "#"
+}
+
+func commentThreadListPrefix(postID, parentID string) string {
+	return commentThreadPostPrefix(postID) + parentID + "#"
+}
+
+func commentThreadSK(postID, parentID, commentID string) string {
+	return commentThreadListPrefix(postID, parentID) + commentID
+}
+
+// visible reports whether the comment counts toward the post's comment_count.
+func (c *commentRow) visible() bool {
+	return !c.Hidden && !c.Deleted
+}
+
+// GetComment returns the comment, or nil if not found.
+func (s *Store) GetComment(ctx context.Context, handle, postID, commentID string) (*commentRow, error) {
+	var row commentRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, commentSK(postID, commentID)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// CreateComment writes the comment and its thread row, and bumps the post's comment_count and the parent's
+// reply_count, in one transaction. Fails with a condition check if the post or the parent (deleted or gone) no
+// longer accepts comments.
+func (s *Store) CreateComment(ctx context.Context, handle string, row commentRow) error {
+	pk := artistPK(handle)
+	row.PK, row.SK = pk, commentSK(row.PostID, row.CommentID)
+	thread := commentThreadRow{PK: pk, SK: commentThreadSK(row.PostID, row.ParentID, row.CommentID), CommentID: row.CommentID}
+	tx := s.db.WriteTx().
+		Put(s.tbl().Put(row).If("attribute_not_exists(pk)")).
+		Put(s.tbl().Put(thread)).
+		Update(s.tbl().Update("pk", pk).Range("sk", postSK(row.PostID)).Add("comment_count", 1).
+			If("attribute_exists(pk) AND (attribute_not_exists($) OR $ = ?)", "status", "status", StatusPublished))
+	if row.ParentID != "" {
+		tx = tx.Update(s.tbl().Update("pk", pk).Range("sk", commentSK(row.PostID, row.ParentID)).Add("reply_count", 1).
+			If("attribute_exists(pk) AND $ = ?", "deleted", false))
+	}
+	return tx.Run(ctx)
+}
+
+// UpdateCommentBody sets the comment's body and updated_at. Fails with a condition check if it was deleted.
+func (s *Store) UpdateCommentBody(ctx context.Context, handle string, row *commentRow, body, updatedAt string) error {
+	return s.tbl().Update("pk", artistPK(handle)).Range("sk", commentSK(row.PostID, row.CommentID)).
+		Set("body", body).
+		Set("updated_at", updatedAt).
+		If("attribute_exists(pk) AND $ = ?", "deleted", false).
+		Run(ctx)
+}
+
+// SetCommentHidden hides or unhides the comment and moves the post's comment_count with it, in one transaction.
+// Fails with a condition check if the comment was deleted or is already in that state.
+func (s *Store) SetCommentHidden(ctx context.Context, handle string, row *commentRow, hidden bool, byUserID string) error {
+	pk := artistPK(handle)
+	upd := s.tbl().Update("pk", pk).Range("sk", commentSK(row.PostID, row.CommentID)).
+		Set("hidden", hidden).
+		If("attribute_exists(pk) AND $ = ? AND $ = ?", "deleted", false, "hidden", !hidden)
+	delta := 1
+	if hidden {
+		upd = upd.Set("hidden_by_user_id", byUserID)
+		delta = -1
+	} else {
+		upd = upd.Remove("hidden_by_user_id")
+	}
+	return s.db.WriteTx().
+		Update(upd).
+		Update(s.tbl().Update("pk", pk).Range("sk", postSK(row.PostID)).Add("comment_count", delta).If("attribute_exists(pk)")).
+		Run(ctx)
+}
+
+// DeleteComment removes the comment. A comment with replies becomes a tombstone; one without is removed with its
+// thread row and no longer counts as a reply of its parent. The post's comment_count drops if the comment counted.
+// Fails with a condition check if the comment changed since row was read.
+func (s *Store) DeleteComment(ctx context.Context, handle string, row *commentRow) error {
+	pk := artistPK(handle)
+	cond, args := "attribute_exists(pk) AND $ = ? AND $ = ? AND $ = ?", []any{"deleted", false, "hidden", row.Hidden, "reply_count", row.ReplyCount}
+	tx := s.db.WriteTx()
+	if row.ReplyCount > 0 {
+		tx = tx.Update(s.tbl().Update("pk", pk).Range("sk", commentSK(row.PostID, row.CommentID)).
+			Set("deleted", true).
+			Remove("body").
+			If(cond, args...))
+	} else {
+		tx = tx.Delete(s.tbl().Delete("pk", pk).Range("sk", commentSK(row.PostID, row.CommentID)).If(cond, args...)).
+			Delete(s.tbl().Delete("pk", pk).Range("sk", commentThreadSK(row.PostID, row.ParentID, row.CommentID)))
+		if row.ParentID != "" {
+			tx = tx.Update(s.tbl().Update("pk", pk).Range("sk", commentSK(row.PostID, row.ParentID)).Add("reply_count", -1))
+		}
+	}
+	if row.visible() {
+		tx = tx.Update(s.tbl().Update("pk", pk).Range("sk", postSK(row.PostID)).Add("comment_count", -1).If("attribute_exists(pk)"))
+	}
+	return tx.Run(ctx)
+}
+
+// ListCommentThreadPage returns the IDs of the post's top-level comments (parentID empty; newest first) or of a
+// comment's replies (oldest first), using cursor-based pagination. nextCursor is non-empty when more results exist.
+func (s *Store) ListCommentThreadPage(ctx context.Context, handle, postID, parentID string, limit int, cursor string) ([]string, string, error) {
+	pk := artistPK(handle)
+	prefix := commentThreadListPrefix(postID, parentID)
+	var startKey map[string]types.AttributeValue
+	if cursor != "" {
+		decodedPk, decodedSk, err := decodeListByTimeCursor(cursor)
+		if err != nil || decodedPk != pk || !strings.HasPrefix(decodedSk, prefix) {
+			return nil, "", errInvalidCursor
+		}
+		startKey = map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: decodedPk},
+			"sk": &types.AttributeValueMemberS{Value: decodedSk},
+		}
+	}
+	req := &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: pk},
+			":prefix": &types.AttributeValueMemberS{Value: prefix},
+		},
+		ScanIndexForward:  aws.Bool(parentID != ""),
+		Limit:             aws.Int32(int32(limit + 1)),
+		ExclusiveStartKey: startKey,
+	}
+	out, err := s.db.Client().Query(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	rows := make([]commentThreadRow, 0, len(out.Items))
+	for _, item := range out.Items {
+		var row commentThreadRow
+		if err := attributevalue.UnmarshalMap(item, &row); err != nil {
+			return nil, "", err
+		}
+		rows = append(rows, row)
+	}
+	var next string
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		next = encodeListByTimeCursor(last.PK, last.SK)
+	}
+	ids := make([]string, len(rows))
+	for i := range rows {
+		ids[i] = rows[i].CommentID
+	}
+	return ids, next, nil
+}
+
+// BatchGetComments returns the post's comments with the given IDs, in the same order as commentIDs.
+func (s *Store) BatchGetComments(ctx context.Context, handle, postID string, commentIDs []string) ([]*commentRow, error) {
+	if len(commentIDs) == 0 {
+		return nil, nil
+	}
+	pk := artistPK(handle)
+	keys := make([]dynamo.Keyed, len(commentIDs))
+	for i, id := range commentIDs {
+		keys[i] = dynamo.Keys{pk, commentSK(postID, id)}
+	}
+	var rows []commentRow
+	if err := s.tbl().Batch("pk", "sk").Get(keys...).All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*commentRow, len(rows))
+	for i := range rows {
+		byID[rows[i].CommentID] = &rows[i]
+	}
+	ordered := make([]*commentRow, 0, len(commentIDs))
+	for _, id := range commentIDs {
+		if r := byID[id]; r != nil {
+			ordered = append(ordered, r)
+		}
+	}
+	return ordered, nil
+}
+
+// commentKeys returns the keys of every comment and thread row of the post.
+func (s *Store) commentKeys(ctx context.Context, handle, postID string) ([]dynamo.Keyed, error) {
+	var keys []dynamo.Keyed
+	for _, prefix := range []string{commentPrefix(postID), commentThreadPostPrefix(postID)} {
+		var rows []commentThreadRow
+		if err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, prefix).All(ctx, &rows); err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			keys = append(keys, dynamo.Keys{r.PK, r.SK})
+		}
+	}
+	return keys, nil
+}
+
+// moveComments copies the post's comment and thread rows to newHandle and newPostID (a handle rename or a new slug),
+// then removes the old rows, so an interrupted run can be repeated.
+func (s *Store) moveComments(ctx context.Context, oldHandle, oldPostID, newHandle, newPostID string) error {
+	var comments []commentRow
+	if err := s.tbl().Get("pk", artistPK(oldHandle)).Range("sk", dynamo.BeginsWith, commentPrefix(oldPostID)).All(ctx, &comments); err != nil {
+		return err
+	}
+	var threads []commentThreadRow
+	if err := s.tbl().Get("pk", artistPK(oldHandle)).Range("sk", dynamo.BeginsWith, commentThreadPostPrefix(oldPostID)).All(ctx, &threads); err != nil {
+		return err
+	}
+	if len(comments) == 0 && len(threads) == 0 {
+		return nil
+	}
+	var puts []any
+	var keys []dynamo.Keyed
+	for _, row := range comments {
+		keys = append(keys, dynamo.Keys{row.PK, row.SK})
+		row.PK, row.SK, row.PostID = artistPK(newHandle), commentSK(newPostID, row.CommentID), newPostID
+		puts = append(puts, row)
+	}
+	for _, row := range threads {
+		keys = append(keys, dynamo.Keys{row.PK, row.SK})
+		row.PK = artistPK(newHandle)
+		row.SK = commentThreadPostPrefix(newPostID) + strings.TrimPrefix(row.SK, commentThreadPostPrefix(oldPostID))
+		puts = append(puts, row)
+	}
+	if _, err := s.tbl().Batch("pk", "sk").Write().Put(puts...).Run(ctx); err != nil {
+		return err
+	}
+	_, err := s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
+
+// deleteComments removes every comment of the post. Called before the post itself is deleted.
+func (s *Store) deleteComments(ctx context.Context, handle, postID string) error {
+	keys, err := s.commentKeys(ctx, handle, postID)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	_, err = s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
+
+// TakeCommentQuota counts one comment against the user's limit for the window starting at windowStart and reports
+// whether the user was under the limit. The counter is one row per user, reset when a new window starts; conditional
+// writes keep it exact across API instances.
+func (s *Store) TakeCommentQuota(ctx context.Context, userID, windowStart string, limit int) (bool, error) {
+	pk := commentRatePKPrefix + userID
+	for attempt := 0; attempt < 2; attempt++ {
+		err := s.tbl().Update("pk", pk).Range("sk", commentRateSK).Add("used", 1).
+			If("$ = ? AND $ < ?", "window_start", windowStart, "used", limit).
+			Run(ctx)
+		if err == nil {
+			return true, nil
+		}
+		if !dynamo.IsCondCheckFailed(err) {
+			return false, err
+		}
+		// Over the limit, or the stored window is an older one: start the new window unless another request just did.
+		err = s.tbl().Put(commentRateRow{PK: pk, SK: commentRateSK, WindowStart: windowStart, Used: 1}).
+			If("attribute_not_exists(pk) OR $ <> ?", "window_start", windowStart).
+			Run(ctx)
+		if err == nil {
+			return true, nil
+		}
+		if !dynamo.IsCondCheckFailed(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
@@ -2,10 +2,13 @@ package feed
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/sopatech/afterwave.fm/internal/auth"
+	"github.com/sopatech/afterwave.fm/internal/media"
 )
 
 type Handler struct {
@@ -16,7 +19,8 @@ func NewHandler(svc Service) *Handler {
 	return &Handler{svc: svc}
 }
 
-// CreatePost creates a post on the artist's feed (owner only).
+// CreatePost creates a post on the artist's feed (owner only). status is draft, scheduled (with publish_at) or
+// published (default). media lists finalized uploads, in display order, with alt text.
 func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	userID := auth.UserIDFromContext(r.Context())
 	if userID == "" {
@@ -31,18 +35,25 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body struct {
-		Title      string `json:"title"`
-		Body       string `json:"body"`
-		ImageURL   string `json:"image_url"`
-		YouTubeURL string `json:"youtube_url"`
-		Explicit   bool   `json:"explicit"`
+		Title      string     `json:"title"`
+		Body       string     `json:"body"`
+		ImageURL   string     `json:"image_url"`
+		Media      []MediaRef `json:"media"`
+		YouTubeURL string     `json:"youtube_url"`
+		Explicit   bool       `json:"explicit"`
+		Status     string     `json:"status"`
+		PublishAt  string     `json:"publish_at"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
+	if body.ImageURL != "" {
+		http.Error(w, errImageURLUnsupported, http.StatusBadRequest)
+		return
+	}
 
-	post, err := h.svc.CreatePost(r.Context(), handle, body.Title, body.Body, body.ImageURL, body.YouTubeURL, body.Explicit, userID)
+	post, err := h.svc.CreatePost(r.Context(), handle, body.Title, body.Body, body.YouTubeURL, body.Media, body.Explicit, body.Status, body.PublishAt, userID)
 	if err != nil {
 		switch {
 		case err == ErrArtistNotFound:
@@ -51,6 +62,8 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "forbidden", http.StatusForbidden)
 		case err == ErrSlugConflict:
 			http.Error(w, "a post with this title already exists for this artist", http.StatusConflict)
+		case err == media.ErrMediaAttached:
+			http.Error(w, err.Error(), http.StatusConflict)
 		default:
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		}
@@ -62,7 +75,7 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(post)
 }
 
-// ListPosts returns posts for the artist (public), newest first. Cursor-based pagination: limit (default 10), cursor (from previous next_cursor), has_more, next_cursor.
+// ListPosts returns posts for the artist (public; private pages only for members), newest first. Cursor-based pagination: limit (default 10), cursor (from previous next_cursor), has_more, next_cursor.
 func (h *Handler) ListPosts(w http.ResponseWriter, r *http.Request) {
 	handle := r.PathValue("handle")
 	if handle == "" {
@@ -78,7 +91,7 @@ func (h *Handler) ListPosts(w http.ResponseWriter, r *http.Request) {
 	}
 	cursor := r.URL.Query().Get("cursor")
 
-	list, nextCursor, err := h.svc.ListPosts(r.Context(), handle, limit, cursor)
+	list, nextCursor, err := h.svc.ListPosts(r.Context(), handle, limit, cursor, auth.UserIDFromContext(r.Context()))
 	if err != nil {
 		if err == ErrArtistNotFound {
 			http.Error(w, "not found", http.StatusNotFound)
@@ -99,7 +112,34 @@ func (h *Handler) ListPosts(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(out)
 }
 
-// GetPost returns a single post (public).
+// ListDrafts returns the artist's drafts and scheduled posts (members with a feed permission).
+func (h *Handler) ListDrafts(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	list, err := h.svc.ListDrafts(r.Context(), r.PathValue("handle"), userID)
+	if err != nil {
+		switch {
+		case err == ErrArtistNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case err == ErrForbidden:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if list == nil {
+		list = []Post{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"posts": list})
+}
+
+// GetPost returns a single post (public; private pages only for members; drafts and scheduled posts only for
+// members with a feed permission). A former slug of the post redirects (301) to the current one.
 func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 	handle := r.PathValue("handle")
 	postID := r.PathValue("postId")
@@ -108,7 +148,12 @@ func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.svc.GetPost(r.Context(), handle, postID)
+	post, err := h.svc.GetPost(r.Context(), handle, postID, auth.UserIDFromContext(r.Context()))
+	var moved *PostMovedError
+	if errors.As(err, &moved) {
+		writePostMoved(w, r, postID, moved.PostID)
+		return
+	}
 	if err != nil || post == nil {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
@@ -118,7 +163,7 @@ func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(post)
 }
 
-// UpdatePost updates a post (owner only).
+// UpdatePost updates a post (owner only). A new title can change the post ID (slug).
 func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	userID := auth.UserIDFromContext(r.Context())
 	if userID == "" {
@@ -134,17 +179,53 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body struct {
-		Body       *string `json:"body"`
-		ImageURL   *string `json:"image_url"`
-		YouTubeURL *string `json:"youtube_url"`
-		Explicit   *bool   `json:"explicit"`
+		Title      *string     `json:"title"`
+		Body       *string     `json:"body"`
+		ImageURL   *string     `json:"image_url"`
+		Media      *[]MediaRef `json:"media"` // replaces the post's images; [] removes them
+		YouTubeURL *string     `json:"youtube_url"`
+		Explicit   *bool       `json:"explicit"`
+		Status     *string     `json:"status"`
+		PublishAt  *string     `json:"publish_at"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
+	if body.ImageURL != nil && *body.ImageURL != "" {
+		http.Error(w, errImageURLUnsupported, http.StatusBadRequest)
+		return
+	}
+
+	post, err := h.svc.UpdatePost(r.Context(), handle, postID, body.Title, body.Body, body.YouTubeURL, body.Media, body.Explicit, body.Status, body.PublishAt, userID)
+	if err != nil {
+		switch {
+		case err == ErrArtistNotFound || err == ErrPostNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case err == ErrForbidden:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case err == ErrTitleRequired, err == ErrInvalidTitle, err == ErrInvalidStatus, err == ErrInvalidPublishAt, isMediaError(err):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err == ErrSlugConflict, err == ErrPostPublished, err == ErrPostChanged, err == media.ErrMediaAttached:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
 
-	post, err := h.svc.UpdatePost(r.Context(), handle, postID, body.Body, body.ImageURL, body.YouTubeURL, body.Explicit, userID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
+
+// ListRevisions returns the post's revisions, newest first (members with a feed permission and platform admins).
+func (h *Handler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	list, err := h.svc.ListRevisions(r.Context(), r.PathValue("handle"), r.PathValue("postId"), userID)
 	if err != nil {
 		switch {
 		case err == ErrArtistNotFound || err == ErrPostNotFound:
@@ -156,7 +237,84 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if list == nil {
+		list = []Revision{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"revisions": list})
+}
+
+// RevertPost restores a post's content to an earlier revision (0 for the original), as a new revision.
+func (h *Handler) RevertPost(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	revision, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	post, err := h.svc.RevertPost(r.Context(), r.PathValue("handle"), r.PathValue("postId"), revision, userID)
+	if err != nil {
+		switch {
+		case err == ErrArtistNotFound || err == ErrPostNotFound || err == ErrRevisionNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case err == ErrForbidden:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case isMediaError(err):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err == ErrPostChanged, err == media.ErrMediaAttached:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
 
+// PinPost pins a post to the top of the artist's feed (members with feed:update). Idempotent.
+func (h *Handler) PinPost(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, true)
+}
+
+// UnpinPost unpins a post (members with feed:update). Idempotent.
+func (h *Handler) UnpinPost(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, false)
+}
+
+func (h *Handler) setPinned(w http.ResponseWriter, r *http.Request, pin bool) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	handle, postID := r.PathValue("handle"), r.PathValue("postId")
+	var post *Post
+	var err error
+	if pin {
+		post, err = h.svc.PinPost(r.Context(), handle, postID, userID)
+	} else {
+		post, err = h.svc.UnpinPost(r.Context(), handle, postID, userID)
+	}
+	if err != nil {
+		switch err {
+		case ErrArtistNotFound, ErrPostNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case ErrForbidden:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case ErrPinUnpublished:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case ErrTooManyPins, ErrPinsChanged:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(post)
 }
@@ -221,3 +379,197 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// CreateComment comments on a published post, or replies to parent_id (signed-in users who can see the post).
+func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Body     string `json:"body"`
+		ParentID string `json:"parent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	comment, err := h.svc.CreateComment(r.Context(), r.PathValue("handle"), r.PathValue("postId"), body.ParentID, body.Body, userID)
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// ListComments returns the post's top-level comments, newest first, or with parent_id a comment's replies, oldest
+// first. Same visibility as the post. Cursor-based pagination: limit (default 20), cursor, has_more, next_cursor.
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 20
+	if s := q.Get("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	list, nextCursor, err := h.svc.ListComments(r.Context(), r.PathValue("handle"), r.PathValue("postId"), q.Get("parent_id"), limit, q.Get("cursor"), auth.UserIDFromContext(r.Context()))
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	if list == nil {
+		list = []Comment{}
+	}
+	out := map[string]any{"comments": list, "has_more": nextCursor != ""}
+	if nextCursor != "" {
+		out["next_cursor"] = nextCursor
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// UpdateComment edits a comment's body (its author only).
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	comment, err := h.svc.UpdateComment(r.Context(), r.PathValue("handle"), r.PathValue("postId"), r.PathValue("commentId"), body.Body, userID)
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// HideComment hides a comment from everyone but its author and members with feed:update.
+func (h *Handler) HideComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentHidden(w, r, true)
+}
+
+// UnhideComment shows a hidden comment again (members with feed:update).
+func (h *Handler) UnhideComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentHidden(w, r, false)
+}
+
+func (h *Handler) setCommentHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	comment, err := h.svc.SetCommentHidden(r.Context(), r.PathValue("handle"), r.PathValue("postId"), r.PathValue("commentId"), hidden, userID)
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// DeleteComment deletes a comment (its author, or members with feed:update).
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.svc.DeleteComment(r.Context(), r.PathValue("handle"), r.PathValue("postId"), r.PathValue("commentId"), userID); err != nil {
+		writeCommentError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCommentError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrArtistNotFound, ErrPostNotFound, ErrCommentNotFound:
+		http.Error(w, "not found", http.StatusNotFound)
+	case ErrForbidden, ErrCommentBlocked:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case ErrInvalidComment, ErrCommentTooDeep, errInvalidCursor:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case ErrCommentChanged:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case ErrCommentRateLimited:
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// React adds the current user's reaction of the path's type to the post. Idempotent.
+func (h *Handler) React(w http.ResponseWriter, r *http.Request) {
+	h.setReaction(w, r, true)
+}
+
+// Unreact removes the current user's reaction of the path's type from the post. Idempotent.
+func (h *Handler) Unreact(w http.ResponseWriter, r *http.Request) {
+	h.setReaction(w, r, false)
+}
+
+func (h *Handler) setReaction(w http.ResponseWriter, r *http.Request, on bool) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	handle, postID, reactionType := r.PathValue("handle"), r.PathValue("postId"), r.PathValue("type")
+	var err error
+	if on {
+		err = h.svc.React(r.Context(), handle, postID, reactionType, userID)
+	} else {
+		err = h.svc.Unreact(r.Context(), handle, postID, reactionType, userID)
+	}
+	if err != nil {
+		switch err {
+		case ErrArtistNotFound, ErrPostNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case ErrInvalidReaction:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const errImageURLUnsupported = "image_url is no longer accepted; upload images to /artists/{handle}/media and pass them as media"
+
+// isMediaError reports whether err is a client error about the post's images.
+func isMediaError(err error) bool {
+	switch err {
+	case ErrMediaUnavailable, ErrTooManyMedia, ErrInvalidMedia, media.ErrMediaNotFound, media.ErrNotFinalized, media.ErrUploadExpired:
+		return true
+	}
+	return false
+}
+
+// writePostMoved redirects a request for a former slug to the post's current one.
+func writePostMoved(w http.ResponseWriter, r *http.Request, oldPostID, newPostID string) {
+	// RequestURI keeps the /v1 prefix that the router strips from URL.Path.
+	location, _, _ := strings.Cut(r.RequestURI, "?")
+	if location == "" {
+		location = r.URL.Path
+	}
+	if i := strings.LastIndex(location, "/posts/"); i >= 0 {
+		location = location[:i] + "/posts/" + newPostID
+	}
+	w.Header().Set("Location", location)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMovedPermanently)
+	json.NewEncoder(w).Encode(map[string]string{"post_id": newPostID, "previous_post_id": oldPostID})
+}
@@ -0,0 +1,39 @@
+package feed
+
+import (
+	"github.com/sopatech/afterwave.fm/internal/markdown"
+)
+
+// Post bodies are Markdown. They are rendered when a post is written: the sanitized HTML, a plain-text excerpt (also
+// the feed index's body_excerpt), and the links and mentions in the body are stored with the post, so clients and
+// readers never render or sanitize user content themselves. Posts written before rendering are rendered on read.
+
+// ExcerptLength is the longest post excerpt, in characters.
+const ExcerptLength = 200
+
+// renderBody sets r's rendered fields from r.Body.
+func (s *service) renderBody(r *postRow) {
+	renderer := s.markdown
+	if renderer == nil {
+		renderer = markdown.Default
+	}
+	setRendered(r, renderer.Render(r.Body))
+}
+
+func setRendered(r *postRow, res markdown.Result) {
+	r.BodyHTML = res.HTML
+	r.Excerpt = markdown.Excerpt(res.Text, ExcerptLength)
+	r.Links = res.Links
+	r.Mentions = res.Mentions
+}
+
+// rendered returns r with its rendered fields set, rendering with the default policy if the post was written before
+// bodies were rendered.
+func rendered(r *postRow) *postRow {
+	if r.BodyHTML != "" || r.Body == "" {
+		return r
+	}
+	out := *r
+	setRendered(&out, markdown.Default.Render(r.Body))
+	return &out
+}
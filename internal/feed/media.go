@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sopatech/afterwave.fm/internal/media"
+)
+
+// Post images are hosted uploads (internal/media), referenced by media ID in order, each with its own alt text.
+// Media is attached to the post before the post is written, so an image is only ever used by one post and the orphan
+// cleaner leaves it alone; images a post stops using are detached and cleaned up once the orphan timeout passes.
+
+// MaxPostMedia is the most images a post can have.
+const MaxPostMedia = 10
+
+// MaxAltTextLength is the longest alt text, in characters.
+const MaxAltTextLength = 1000
+
+var (
+	ErrMediaUnavailable = errors.New("image uploads are not configured")
+	ErrTooManyMedia     = errors.New("a post can have at most 10 images")
+	ErrInvalidMedia     = errors.New("each image needs a media_id, used once, with alt text of at most 1000 characters")
+)
+
+// MediaAttacher attaches hosted uploads to posts. When nil, posts cannot have images. Implemented by media.Service.
+type MediaAttacher interface {
+	Attach(ctx context.Context, handle, postID string, mediaIDs []string) ([]media.Media, error)
+	Detach(ctx context.Context, handle, postID string, mediaIDs []string) error
+	Reattach(ctx context.Context, handle, postID, newPostID string, mediaIDs []string) error
+}
+
+// MediaRef is an image requested for a post: an upload's media ID and its alt text.
+type MediaRef struct {
+	MediaID string `json:"media_id"`
+	AltText string `json:"alt_text"`
+}
+
+// PostMedia is an image of a post, in display order.
+type PostMedia struct {
+	MediaID     string `json:"media_id" dynamo:"media_id"`
+	URL         string `json:"url" dynamo:"url"`
+	ContentType string `json:"content_type" dynamo:"content_type"`
+	AltText     string `json:"alt_text" dynamo:"alt_text,omitempty"`
+}
+
+// attachMedia validates refs and attaches the media to the post, returning the post's images in order. prev is the
+// post's current images; on error, media attached by this call (not in prev) is detached again.
+func (s *service) attachMedia(ctx context.Context, handle, postID string, prev []PostMedia, refs []MediaRef) ([]PostMedia, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	if s.media == nil {
+		return nil, ErrMediaUnavailable
+	}
+	if len(refs) > MaxPostMedia {
+		return nil, ErrTooManyMedia
+	}
+	ids := make([]string, len(refs))
+	seen := make(map[string]bool, len(refs))
+	for i, ref := range refs {
+		ids[i] = strings.TrimSpace(ref.MediaID)
+		if ids[i] == "" || seen[ids[i]] || utf8.RuneCountInString(strings.TrimSpace(ref.AltText)) > MaxAltTextLength {
+			return nil, ErrInvalidMedia
+		}
+		seen[ids[i]] = true
+	}
+	attached, err := s.media.Attach(ctx, handle, postID, ids)
+	if err != nil {
+		_ = s.media.Detach(ctx, handle, postID, mediaIDs(withoutMedia(toPostMedia(ids), prev)))
+		return nil, err
+	}
+	out := make([]PostMedia, len(attached))
+	for i, m := range attached {
+		out[i] = PostMedia{MediaID: m.MediaID, URL: m.URL, ContentType: m.ContentType, AltText: strings.TrimSpace(refs[i].AltText)}
+	}
+	return out, nil
+}
+
+// detachMedia releases images the post no longer uses. Best effort: media left attached is only kept longer.
+func (s *service) detachMedia(ctx context.Context, handle, postID string, list []PostMedia) {
+	if s.media == nil || len(list) == 0 {
+		return
+	}
+	_ = s.media.Detach(ctx, handle, postID, mediaIDs(list))
+}
+
+// withoutMedia returns the images in list that are not in other.
+func withoutMedia(list, other []PostMedia) []PostMedia {
+	skip := make(map[string]bool, len(other))
+	for _, m := range other {
+		skip[m.MediaID] = true
+	}
+	var out []PostMedia
+	for _, m := range list {
+		if !skip[m.MediaID] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func mediaIDs(list []PostMedia) []string {
+	out := make([]string, len(list))
+	for i, m := range list {
+		out[i] = m.MediaID
+	}
+	return out
+}
+
+func toPostMedia(ids []string) []PostMedia {
+	out := make([]PostMedia, len(ids))
+	for i, id := range ids {
+		out[i] = PostMedia{MediaID: id}
+	}
+	return out
+}
+
+// toMediaRefs returns the refs that reproduce list (for reverting to a revision).
+func toMediaRefs(list []PostMedia) []MediaRef {
+	out := make([]MediaRef, len(list))
+	for i, m := range list {
+		out[i] = MediaRef{MediaID: m.MediaID, AltText: m.AltText}
+	}
+	return out
+}
+
+// mediaString is the images as JSON, for revision and activity changes; "" when there are none.
+func mediaString(list []PostMedia) string {
+	if len(list) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// parseMediaString is the inverse of mediaString.
+func parseMediaString(s string) []PostMedia {
+	var out []PostMedia
+	if s != "" {
+		_ = json.Unmarshal([]byte(s), &out)
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+package feed
+
+import (
+	"context"
+	"strings"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// listPosts returns all main post rows for the artist (BYTIME index rows are skipped).
+func (s *Store) listPosts(ctx context.Context, handle string) ([]postRow, error) {
+	var rows []postRow
+	if err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, postSKPrefix).All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	out := rows[:0]
+	for _, r := range rows {
+		if !strings.HasPrefix(r.SK, postByTimePrefix) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// movePost moves the post (main row + BYTIME or schedule and unpublished index rows) to newHandle in one transaction.
+func (s *Store) movePost(ctx context.Context, oldHandle, newHandle string, row postRow) error {
+	moved := row
+	moved.PK = artistPK(newHandle)
+	moved.ArtistHandle = normalizeHandle(newHandle)
+	tx := s.db.WriteTx().
+		Put(s.tbl().Put(moved)).
+		Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", postSK(row.PostID)))
+	switch {
+	case row.published():
+		tx = tx.Put(s.tbl().Put(row.byTimeRow(newHandle))).
+			Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", postByTimeSK(row.listedAt(), row.PostID)))
+	case row.Status == StatusScheduled:
+		tx = tx.Put(s.tbl().Put(scheduleRowFor(newHandle, &row))).
+			Delete(s.tbl().Delete("pk", schedulePK(row.PublishAt)).Range("sk", scheduleSK(row.PublishAt, normalizeHandle(oldHandle), row.PostID)))
+	}
+	if !row.published() {
+		tx = tx.Put(s.tbl().Put(unpublishedRow(newHandle, row.PostID))).
+			Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", postUnpublishedSK(row.PostID)))
+	}
+	return tx.Run(ctx)
+}
+
+// deletePost removes the post (main row + BYTIME or schedule and unpublished index rows) in one transaction.
+func (s *Store) deletePost(ctx context.Context, handle string, row postRow, activity *artists.ActivityEntry) error {
+	tx := s.db.WriteTx().
+		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", postSK(row.PostID)))
+	switch {
+	case row.published():
+		tx = tx.Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", postByTimeSK(row.listedAt(), row.PostID)))
+	case row.Status == StatusScheduled:
+		tx = tx.Delete(s.tbl().Delete("pk", schedulePK(row.PublishAt)).Range("sk", scheduleSK(row.PublishAt, normalizeHandle(handle), row.PostID)))
+	}
+	if !row.published() {
+		tx = tx.Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", postUnpublishedSK(row.PostID)))
+	}
+	return s.withActivity(tx, handle, activity).Run(ctx)
+}
+
+// HandleData moves an artist's posts and their feed index documents to a new handle after a rename, removes
+// them when the artist is purged, and keeps private pages' posts out of the index. Implements artists.HandleData.
+type HandleData struct {
+	store   *Store
+	indexer FeedIndexer
+}
+
+// NewHandleData returns the handle data for posts. indexer may be nil when search is not configured.
+func NewHandleData(store *Store, indexer FeedIndexer) *HandleData {
+	return &HandleData{store: store, indexer: indexer}
+}
+
+// MigrateHandle moves every post with its revisions, comments and reactions, then the aliases of former slugs and the
+// pinned posts. Each post is indexed under the new handle before its rows move, so an interrupted run can be repeated.
+// Idempotent.
+func (m *HandleData) MigrateHandle(ctx context.Context, oldHandle, newHandle string) error {
+	rows, err := m.store.listPosts(ctx, oldHandle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if m.indexer != nil && row.published() {
+			if err := m.indexer.IndexPost(ctx, feedDoc(newHandle, &row)); err != nil {
+				return err
+			}
+		}
+		if err := m.store.moveRevisions(ctx, oldHandle, row.PostID, newHandle, row.PostID); err != nil {
+			return err
+		}
+		if err := m.store.moveComments(ctx, oldHandle, row.PostID, newHandle, row.PostID); err != nil {
+			return err
+		}
+		if err := m.store.moveReactions(ctx, oldHandle, row.PostID, newHandle, row.PostID); err != nil {
+			return err
+		}
+		if err := m.store.movePost(ctx, oldHandle, newHandle, row); err != nil {
+			return err
+		}
+		if m.indexer != nil && row.published() {
+			if err := m.indexer.DeletePost(ctx, normalizeHandle(oldHandle), row.PostID); err != nil {
+				return err
+			}
+		}
+	}
+	if err := m.store.moveAliases(ctx, oldHandle, newHandle); err != nil {
+		return err
+	}
+	return m.store.movePins(ctx, oldHandle, newHandle)
+}
+
+// PurgeHandle removes every post, its revisions, comments and reactions, and its feed index document, then the aliases
+// of former slugs and the pinned posts. The document goes first so an interrupted run never leaves a search hit for a
+// deleted post. Idempotent.
+func (m *HandleData) PurgeHandle(ctx context.Context, handle string) error {
+	rows, err := m.store.listPosts(ctx, handle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if m.indexer != nil {
+			if err := m.indexer.DeletePost(ctx, normalizeHandle(handle), row.PostID); err != nil {
+				return err
+			}
+		}
+		if err := m.store.deleteRevisions(ctx, handle, row.PostID); err != nil {
+			return err
+		}
+		if err := m.store.deleteComments(ctx, handle, row.PostID); err != nil {
+			return err
+		}
+		if err := m.store.deleteReactions(ctx, handle, row.PostID); err != nil {
+			return err
+		}
+		if err := m.store.deletePost(ctx, handle, row, nil); err != nil {
+			return err
+		}
+	}
+	if err := m.store.deleteAliases(ctx, handle); err != nil {
+		return err
+	}
+	return m.store.deletePins(ctx, handle)
+}
+
+// SetIndexed adds every post's feed index document when a page stops being private, or removes them when it goes
+// private. Implements artists.FeedIndexToggler. Idempotent.
+func (m *HandleData) SetIndexed(ctx context.Context, handle string, indexed bool) error {
+	if m.indexer == nil {
+		return nil
+	}
+	rows, err := m.store.listPosts(ctx, handle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if !row.published() {
+			continue
+		}
+		if indexed {
+			err = m.indexer.IndexPost(ctx, feedDoc(handle, &row))
+		} else {
+			err = m.indexer.DeletePost(ctx, normalizeHandle(handle), row.PostID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
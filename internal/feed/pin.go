@@ -0,0 +1,104 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Pinned posts stay at the top of the artist's feed: ListPosts returns them first on the first page, most recently
+// pinned first, and leaves them out of the time-ordered pages. Members with feed:update pin published posts, up to
+// MaxPinnedPosts per artist.
+
+var (
+	ErrTooManyPins    = errors.New("too many pinned posts; unpin one first")
+	ErrPinUnpublished = errors.New("only published posts can be pinned")
+	ErrPinsChanged    = errors.New("pinned posts changed meanwhile; try again")
+)
+
+// MaxPinnedPosts is how many posts an artist can pin at once.
+const MaxPinnedPosts = 3
+
+// PinPost pins the post. Idempotent. Members with feed:update only.
+func (s *service) PinPost(ctx context.Context, handle, postID, actorUserID string) (*Post, error) {
+	return s.setPinned(ctx, handle, postID, true, actorUserID)
+}
+
+// UnpinPost unpins the post. Idempotent. Members with feed:update only.
+func (s *service) UnpinPost(ctx context.Context, handle, postID, actorUserID string) (*Post, error) {
+	return s.setPinned(ctx, handle, postID, false, actorUserID)
+}
+
+func (s *service) setPinned(ctx context.Context, handle, postID string, pin bool, actorUserID string) (*Post, error) {
+	handle = normalizeHandle(handle)
+	artist, err := s.artist.GetByHandle(ctx, handle)
+	if err != nil || artist == nil {
+		return nil, ErrArtistNotFound
+	}
+	if err := s.ensureCanManageFeed(ctx, handle, actorUserID, artists.PermFeedUpdate); err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		row, err := s.store.Get(ctx, handle, postID)
+		if err != nil || row == nil {
+			return nil, ErrPostNotFound
+		}
+		pins, err := s.store.GetPins(ctx, handle)
+		if err != nil {
+			return nil, err
+		}
+		if pins.pinned(postID) == pin {
+			return rowToPost(row), nil
+		}
+		action := artists.ActivityPostUnpin
+		if pin {
+			action = artists.ActivityPostPin
+		}
+		activity := s.activityEntry(artists.ActivityEntry{Action: action, ActorUserID: actorUserID, Target: postID})
+		pinnedAt := ""
+		if pin {
+			if !row.published() {
+				return nil, ErrPinUnpublished
+			}
+			if pins != nil && len(pins.PostIDs) >= MaxPinnedPosts {
+				return nil, ErrTooManyPins
+			}
+			pinnedAt = time.Now().UTC().Format(time.RFC3339)
+			err = s.store.Pin(ctx, handle, postID, pins, pinnedAt, activity)
+		} else {
+			err = s.store.Unpin(ctx, handle, postID, pins, activity)
+		}
+		if dynamo.IsCondCheckFailed(err) {
+			// Pinned or unpinned meanwhile, or the post was deleted: read both again.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		row.PinnedAt = pinnedAt
+		return rowToPost(row), nil
+	}
+	return nil, ErrPinsChanged
+}
+
+// pinnedPosts returns the artist's pinned posts, most recently pinned first.
+func (s *service) pinnedPosts(ctx context.Context, handle string, pins *pinsRow) ([]*postRow, error) {
+	if pins == nil {
+		return nil, nil
+	}
+	rows, err := s.store.BatchGetPosts(ctx, handle, pins.PostIDs)
+	if err != nil {
+		return nil, err
+	}
+	out := rows[:0]
+	for _, r := range rows {
+		if r.published() {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
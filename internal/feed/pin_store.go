@@ -0,0 +1,134 @@
+package feed
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Pinned posts: one row per artist listing the pinned post IDs, most recently pinned first, so ListPosts reads the
+// pins with a single get.
+// - Pins row: PK = ARTISTS#<handle>, SK = POSTPINS — post_ids and a version for optimistic writes. The row is kept
+//   (with no post_ids) after the last unpin, so the version never goes back to one a stale write could match.
+// The main post row carries pinned_at; it changes in the same transaction as the pins row.
+
+const postPinsSK = "POSTPINS"
+
+type pinsRow struct {
+	PK      string   `dynamo:"pk"`
+	SK      string   `dynamo:"sk"`
+	PostIDs []string `dynamo:"post_ids"`
+	Version int      `dynamo:"version"`
+}
+
+// pinned reports whether postID is among the pins.
+func (r *pinsRow) pinned(postID string) bool {
+	if r == nil {
+		return false
+	}
+	for _, id := range r.PostIDs {
+		if id == postID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPins returns the artist's pins row (possibly with no post IDs), or nil if nothing was ever pinned.
+func (s *Store) GetPins(ctx context.Context, handle string) (*pinsRow, error) {
+	var row pinsRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, postPinsSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// withPins adds to tx the write of postIDs as the artist's pins at the next version, conditioned on the pins still
+// being at prev's version.
+func (s *Store) withPins(tx *dynamo.WriteTx, handle string, prev *pinsRow, postIDs []string) *dynamo.WriteTx {
+	pk := artistPK(handle)
+	version := 0
+	if prev != nil {
+		version = prev.Version
+	}
+	put := s.tbl().Put(pinsRow{PK: pk, SK: postPinsSK, PostIDs: postIDs, Version: version + 1})
+	if prev == nil {
+		put = put.If("attribute_not_exists(pk)")
+	} else {
+		put = put.If("$ = ?", "version", version)
+	}
+	return tx.Put(put)
+}
+
+// Pin adds the post to the front of the artist's pins and sets its pinned_at, with the activity entry, in one
+// transaction. Fails with a condition check if the pins changed since prev was read (nil: nothing pinned) or the post
+// is gone or unpublished.
+func (s *Store) Pin(ctx context.Context, handle, postID string, prev *pinsRow, pinnedAt string, activity *artists.ActivityEntry) error {
+	postIDs := []string{postID}
+	if prev != nil {
+		postIDs = append(postIDs, prev.PostIDs...)
+	}
+	tx := s.withPins(s.db.WriteTx(), handle, prev, postIDs).
+		Update(s.tbl().Update("pk", artistPK(handle)).Range("sk", postSK(postID)).
+			Set("pinned_at", pinnedAt).
+			If("attribute_exists(pk) AND (attribute_not_exists($) OR $ = ?)", "status", "status", StatusPublished))
+	return s.withActivity(tx, handle, activity).Run(ctx)
+}
+
+// Unpin removes the post from the artist's pins and clears its pinned_at, with the activity entry, in one
+// transaction. Fails with a condition check if the pins changed since prev was read.
+func (s *Store) Unpin(ctx context.Context, handle, postID string, prev *pinsRow, activity *artists.ActivityEntry) error {
+	var postIDs []string
+	for _, id := range prev.PostIDs {
+		if id != postID {
+			postIDs = append(postIDs, id)
+		}
+	}
+	tx := s.withPins(s.db.WriteTx(), handle, prev, postIDs).
+		Update(s.tbl().Update("pk", artistPK(handle)).Range("sk", postSK(postID)).
+			Remove("pinned_at").
+			If("attribute_exists(pk)"))
+	return s.withActivity(tx, handle, activity).Run(ctx)
+}
+
+// unpinDeleted drops a post that is about to be deleted from the artist's pins, retrying if the pins change
+// meanwhile.
+func (s *Store) unpinDeleted(ctx context.Context, handle, postID string) error {
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		var pins *pinsRow
+		if pins, err = s.GetPins(ctx, handle); err != nil || !pins.pinned(postID) {
+			return err
+		}
+		if err = s.Unpin(ctx, handle, postID, pins, nil); !dynamo.IsCondCheckFailed(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// movePins moves the artist's pins row to newHandle.
+func (s *Store) movePins(ctx context.Context, oldHandle, newHandle string) error {
+	pins, err := s.GetPins(ctx, oldHandle)
+	if err != nil || pins == nil {
+		return err
+	}
+	moved := *pins
+	moved.PK = artistPK(newHandle)
+	return s.db.WriteTx().
+		Put(s.tbl().Put(moved)).
+		Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", postPinsSK)).
+		Run(ctx)
+}
+
+// deletePins removes the artist's pins row.
+func (s *Store) deletePins(ctx context.Context, handle string) error {
+	return s.tbl().Delete("pk", artistPK(handle)).Range("sk", postPinsSK).Run(ctx)
+}
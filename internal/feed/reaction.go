@@ -0,0 +1,133 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Reactions: a fixed set of reaction types per post, at most one of each per user. Signed-in users react to
+// published posts they can see; posts carry a count per type, and the signed-in viewer's own reactions on post lists.
+// ReconcileReactions recounts the posts that had reaction writes recently, in case a count drifted.
+
+var ErrInvalidReaction = errors.New("unknown reaction type")
+
+// Reaction types.
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionFire  = "fire"
+	ReactionLaugh = "laugh"
+	ReactionSad   = "sad"
+)
+
+// ReactionTypes lists the reaction types, in display order.
+var ReactionTypes = []string{ReactionLike, ReactionLove, ReactionFire, ReactionLaugh, ReactionSad}
+
+// ReactionReconcileLookback is how far back ReconcileReactions looks for posts with reaction writes. Posts reacted
+// to earlier (the reconciler was down for longer) are not recounted.
+const ReactionReconcileLookback = 48 * time.Hour
+
+// reconcileBatchSize bounds how many posts one ReconcileReactions call recounts per bucket.
+const reconcileBatchSize = 100
+
+func validReaction(reactionType string) bool {
+	for _, t := range ReactionTypes {
+		if t == reactionType {
+			return true
+		}
+	}
+	return false
+}
+
+// React adds the user's reaction of the given type to the post. Idempotent.
+func (s *service) React(ctx context.Context, handle, postID, reactionType, userID string) error {
+	handle = normalizeHandle(handle)
+	if !validReaction(reactionType) {
+		return ErrInvalidReaction
+	}
+	if _, err := s.publishedPost(ctx, handle, postID, userID); err != nil {
+		return err
+	}
+	_, err := s.store.React(ctx, handle, postID, reactionType, userID, time.Now())
+	if dynamo.IsCondCheckFailed(err) {
+		// Added by a concurrent request, or the post was unpublished or deleted meanwhile.
+		if r, getErr := s.store.getReaction(ctx, handle, postID, reactionType, userID); getErr == nil && r != nil {
+			return nil
+		}
+		return ErrPostNotFound
+	}
+	return err
+}
+
+// Unreact removes the user's reaction of the given type from the post. Idempotent.
+func (s *service) Unreact(ctx context.Context, handle, postID, reactionType, userID string) error {
+	handle = normalizeHandle(handle)
+	if !validReaction(reactionType) {
+		return ErrInvalidReaction
+	}
+	if _, err := s.publishedPost(ctx, handle, postID, userID); err != nil {
+		return err
+	}
+	_, err := s.store.Unreact(ctx, handle, postID, reactionType, userID, time.Now())
+	if dynamo.IsCondCheckFailed(err) {
+		// Removed by a concurrent request, or the post was deleted meanwhile.
+		return nil
+	}
+	return err
+}
+
+// setViewerReactions fills in the viewer's reactions on posts of one artist. Signed-out viewers have none.
+func (s *service) setViewerReactions(ctx context.Context, handle string, posts []*Post, viewerUserID string) error {
+	if viewerUserID == "" || len(posts) == 0 {
+		return nil
+	}
+	postIDs := make([]string, len(posts))
+	for i, p := range posts {
+		postIDs[i] = p.PostID
+	}
+	reacted, err := s.store.UserReactions(ctx, viewerUserID, handle, postIDs)
+	if err != nil {
+		return err
+	}
+	for _, p := range posts {
+		p.ViewerReactions = reacted[p.PostID]
+	}
+	return nil
+}
+
+// ReconcileReactions recounts the reactions of posts with reaction writes in the last ReactionReconcileLookback
+// (every shard of every hour) and corrects counts that drifted. Returns how many posts this call corrected. Safe to
+// run on several tasks at once: a recount that races a reaction write fails and the post is recounted on the next run.
+func (s *service) ReconcileReactions(ctx context.Context, now time.Time) (int, error) {
+	corrected := 0
+	var errs []error
+	last := now.UTC().Truncate(reactionBucketSize)
+	for t := now.UTC().Add(-ReactionReconcileLookback).Truncate(reactionBucketSize); !t.After(last); t = t.Add(reactionBucketSize) {
+		for shard := 0; shard < reactionReconcileShards; shard++ {
+			reacted, err := s.store.ListReacted(ctx, reactionBucket(t, shard), reconcileBatchSize)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, e := range reacted {
+				fixed, err := s.store.ReconcileReactionCounts(ctx, e.Handle, e.PostID)
+				if err != nil {
+					if !dynamo.IsCondCheckFailed(err) {
+						errs = append(errs, err)
+					}
+					continue
+				}
+				if fixed {
+					corrected++
+				}
+				if err := s.store.DeleteReacted(ctx, e); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return corrected, errors.Join(errs...)
+}
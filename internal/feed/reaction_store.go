@@ -0,0 +1,343 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Reactions. Two-row pattern, like follows:
+// - User index: PK = REACTIONS#USER#<user_id>, SK = <handle>#<post_id>#<type> — read by key for the viewer's
+//   reactions on a page of posts.
+// - Post side: PK = ARTISTS#<handle>, SK = POSTREACTION#<post_id>#<type>#<user_id> — for recounting, and to move or
+//   delete a post's reactions with it.
+// Counts are attributes of the main post row (reactions_<type>), changed in the same transaction as the two rows.
+// Every reaction write also marks the post for the reconciler, bucketed by the hour like the schedule index and split
+// over reactionReconcileShards partitions by a hash of the post, so one hour's writes do not all land on one partition:
+// - Reconcile row: PK = POSTS#REACTED#<yyyy-mm-ddThh>#<shard>, SK = <handle>#<post_id>.
+
+const (
+	reactionUserPKPrefix    = "REACTIONS#USER#"
+	reactionSKPrefix        = "POSTREACTION#"
+	reactionReconcilePrefix = "POSTS#REACTED#"
+	reactionBucketSize      = time.Hour
+	reactionReconcileShards = 16
+)
+
+// reactionCounts is embedded in postRow: one count attribute per type in ReactionTypes.
+type reactionCounts struct {
+	Like  int `dynamo:"reactions_like,omitempty"`
+	Love  int `dynamo:"reactions_love,omitempty"`
+	Fire  int `dynamo:"reactions_fire,omitempty"`
+	Laugh int `dynamo:"reactions_laugh,omitempty"`
+	Sad   int `dynamo:"reactions_sad,omitempty"`
+}
+
+// byType returns the counts keyed by reaction type, every type included. Counts never go below zero.
+func (c reactionCounts) byType() map[string]int {
+	out := make(map[string]int, len(ReactionTypes))
+	for _, t := range ReactionTypes {
+		out[t] = max(c.count(t), 0)
+	}
+	return out
+}
+
+// count is the stored count for reactionType.
+func (c reactionCounts) count(reactionType string) int {
+	switch reactionType {
+	case ReactionLike:
+		return c.Like
+	case ReactionLove:
+		return c.Love
+	case ReactionFire:
+		return c.Fire
+	case ReactionLaugh:
+		return c.Laugh
+	case ReactionSad:
+		return c.Sad
+	}
+	return 0
+}
+
+// reactionCountAttr is the post row attribute holding the count for reactionType.
+func reactionCountAttr(reactionType string) string {
+	return "reactions_" + reactionType
+}
+
+type reactionUserRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	Handle    string `dynamo:"handle"`
+	PostID    string `dynamo:"post_id"`
+	Type      string `dynamo:"type"`
+	ReactedAt string `dynamo:"reacted_at"`
+}
+
+type reactionPostRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	PostID    string `dynamo:"post_id"`
+	Type      string `dynamo:"type"`
+	UserID    string `dynamo:"user_id"`
+	ReactedAt string `dynamo:"reacted_at"`
+}
+
+type reactionReconcileRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	Handle    string `dynamo:"handle"`
+	PostID    string `dynamo:"post_id"`
+	ReactedAt string `dynamo:"reacted_at"` // last reaction write; the row is only removed if unchanged
+}
+
+func reactionUserPK(userID string) string {
+	return reactionUserPKPrefix + userID
+}
+
+func reactionUserSK(handle, postID, reactionType string) string {
+	return normalizeHandle(handle) + "#" + postID + "#" + reactionType
+}
+
+func reactionPrefix(postID string) string {
+	return reactionSKPrefix + postID + "#"
+}
+
+func reactionSK(postID, reactionType, userID string) string {
+	return reactionPrefix(postID) + reactionType + "#" + userID
+}
+
+// reactionBucket is the reconcile partition for t (the hour it falls in, UTC) and shard.
+func reactionBucket(t time.Time, shard int) string {
+	return reactionReconcilePrefix + t.UTC().Truncate(reactionBucketSize).Format("2006-01-02T15") + "#" + strconv.Itoa(shard)
+}
+
+// reactionShard is the reconcile shard of the post: a hash of its key, so the same post always lands on one shard.
+func reactionShard(handle, postID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(handle + "#" + postID))
+	return int(h.Sum32() % reactionReconcileShards)
+}
+
+func (r reactionPostRow) userRow(handle string) reactionUserRow {
+	return reactionUserRow{
+		PK:        reactionUserPK(r.UserID),
+		SK:        reactionUserSK(handle, r.PostID, r.Type),
+		Handle:    normalizeHandle(handle),
+		PostID:    r.PostID,
+		Type:      r.Type,
+		ReactedAt: r.ReactedAt,
+	}
+}
+
+func reconcileRowFor(handle, postID string, now time.Time) reactionReconcileRow {
+	handle = normalizeHandle(handle)
+	return reactionReconcileRow{
+		PK:        reactionBucket(now, reactionShard(handle, postID)),
+		SK:        handle + "#" + postID,
+		Handle:    handle,
+		PostID:    postID,
+		ReactedAt: now.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func (s *Store) getReaction(ctx context.Context, handle, postID, reactionType, userID string) (*reactionUserRow, error) {
+	var row reactionUserRow
+	err := s.tbl().Get("pk", reactionUserPK(userID)).Range("sk", dynamo.Equal, reactionUserSK(handle, postID, reactionType)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// React adds the user's reaction (both rows) and bumps the post's count for the type, in one transaction. Idempotent
+// (no-op if the user already reacted with this type). Returns inserted=true only when a new reaction was written.
+// Fails with a condition check if the post is gone or not published, or the same reaction was added meanwhile.
+func (s *Store) React(ctx context.Context, handle, postID, reactionType, userID string, now time.Time) (inserted bool, err error) {
+	handle = normalizeHandle(handle)
+	existing, err := s.getReaction(ctx, handle, postID, reactionType, userID)
+	if err != nil || existing != nil {
+		return false, err
+	}
+	postSide := reactionPostRow{
+		PK:        artistPK(handle),
+		SK:        reactionSK(postID, reactionType, userID),
+		PostID:    postID,
+		Type:      reactionType,
+		UserID:    userID,
+		ReactedAt: now.UTC().Format(time.RFC3339),
+	}
+	err = s.db.WriteTx().
+		Put(s.tbl().Put(postSide.userRow(handle)).If("attribute_not_exists(pk)")).
+		Put(s.tbl().Put(postSide)).
+		Update(s.tbl().Update("pk", artistPK(handle)).Range("sk", postSK(postID)).Add(reactionCountAttr(reactionType), 1).
+			If("attribute_exists(pk) AND (attribute_not_exists($) OR $ = ?)", "status", "status", StatusPublished)).
+		Put(s.tbl().Put(reconcileRowFor(handle, postID, now))).
+		Run(ctx)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Unreact removes the user's reaction (both rows) and lowers the post's count for the type, in one transaction.
+// Idempotent. Returns removed=true only when a reaction was removed. A count that had drifted low can go below zero
+// here; it reads as zero until the reconciler recounts it.
+func (s *Store) Unreact(ctx context.Context, handle, postID, reactionType, userID string, now time.Time) (removed bool, err error) {
+	handle = normalizeHandle(handle)
+	existing, err := s.getReaction(ctx, handle, postID, reactionType, userID)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	err = s.db.WriteTx().
+		Delete(s.tbl().Delete("pk", reactionUserPK(userID)).Range("sk", reactionUserSK(handle, postID, reactionType)).If("attribute_exists(pk)")).
+		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", reactionSK(postID, reactionType, userID))).
+		Update(s.tbl().Update("pk", artistPK(handle)).Range("sk", postSK(postID)).Add(reactionCountAttr(reactionType), -1).
+			If("attribute_exists(pk)")).
+		Put(s.tbl().Put(reconcileRowFor(handle, postID, now))).
+		Run(ctx)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UserReactions returns the reaction types the user added to each of the artist's posts, keyed by post ID, in
+// ReactionTypes order. Posts without reactions are left out.
+func (s *Store) UserReactions(ctx context.Context, userID, handle string, postIDs []string) (map[string][]string, error) {
+	if userID == "" || len(postIDs) == 0 {
+		return nil, nil
+	}
+	pk := reactionUserPK(userID)
+	keys := make([]dynamo.Keyed, 0, len(postIDs)*len(ReactionTypes))
+	for _, id := range postIDs {
+		for _, t := range ReactionTypes {
+			keys = append(keys, dynamo.Keys{pk, reactionUserSK(handle, id, t)})
+		}
+	}
+	var rows []reactionUserRow
+	if err := s.tbl().Batch("pk", "sk").Get(keys...).All(ctx, &rows); err != nil && !errors.Is(err, dynamo.ErrNotFound) {
+		return nil, err
+	}
+	reacted := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		reacted[r.SK] = true
+	}
+	out := make(map[string][]string)
+	for _, id := range postIDs {
+		for _, t := range ReactionTypes {
+			if reacted[reactionUserSK(handle, id, t)] {
+				out[id] = append(out[id], t)
+			}
+		}
+	}
+	return out, nil
+}
+
+// listReactions returns every post-side reaction row of the post.
+func (s *Store) listReactions(ctx context.Context, handle, postID string) ([]reactionPostRow, error) {
+	var rows []reactionPostRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, reactionPrefix(postID)).All(ctx, &rows)
+	return rows, err
+}
+
+// moveReactions copies the post's reactions to newHandle and newPostID (post-side rows and the users' index rows),
+// then removes the old rows, so an interrupted run can be repeated.
+func (s *Store) moveReactions(ctx context.Context, oldHandle, oldPostID, newHandle, newPostID string) error {
+	rows, err := s.listReactions(ctx, oldHandle, oldPostID)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	var puts []any
+	var keys []dynamo.Keyed
+	for _, row := range rows {
+		keys = append(keys, dynamo.Keys{row.PK, row.SK}, dynamo.Keys{reactionUserPK(row.UserID), reactionUserSK(oldHandle, row.PostID, row.Type)})
+		row.PK, row.SK, row.PostID = artistPK(newHandle), reactionSK(newPostID, row.Type, row.UserID), newPostID
+		puts = append(puts, row, row.userRow(newHandle))
+	}
+	if _, err := s.tbl().Batch("pk", "sk").Write().Put(puts...).Run(ctx); err != nil {
+		return err
+	}
+	_, err = s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
+
+// deleteReactions removes every reaction of the post (both rows). Called before the post itself is deleted.
+func (s *Store) deleteReactions(ctx context.Context, handle, postID string) error {
+	rows, err := s.listReactions(ctx, handle, postID)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	keys := make([]dynamo.Keyed, 0, 2*len(rows))
+	for _, row := range rows {
+		keys = append(keys, dynamo.Keys{row.PK, row.SK}, dynamo.Keys{reactionUserPK(row.UserID), reactionUserSK(handle, row.PostID, row.Type)})
+	}
+	_, err = s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
+
+// ListReacted returns up to limit reconcile rows in the bucket: posts with reaction writes in that hour and shard.
+func (s *Store) ListReacted(ctx context.Context, bucket string, limit int) ([]reactionReconcileRow, error) {
+	var out []reactionReconcileRow
+	err := s.tbl().Get("pk", bucket).Limit(limit).All(ctx, &out)
+	return out, err
+}
+
+// DeleteReacted removes a reconcile row unless the post had another reaction write since it was read.
+func (s *Store) DeleteReacted(ctx context.Context, e reactionReconcileRow) error {
+	err := s.tbl().Delete("pk", e.PK).Range("sk", e.SK).If("$ = ?", "reacted_at", e.ReactedAt).Run(ctx)
+	if dynamo.IsCondCheckFailed(err) {
+		return nil
+	}
+	return err
+}
+
+// ReconcileReactionCounts recounts the post's reaction rows and overwrites the counts that differ. The write is
+// conditioned on the counts read before recounting, so a reaction written meanwhile makes it fail with a condition
+// check instead of being lost. Returns whether any count was corrected; a missing post has nothing to correct.
+func (s *Store) ReconcileReactionCounts(ctx context.Context, handle, postID string) (bool, error) {
+	row, err := s.Get(ctx, handle, postID)
+	if err != nil || row == nil {
+		return false, err
+	}
+	rows, err := s.listReactions(ctx, handle, postID)
+	if err != nil {
+		return false, err
+	}
+	actual := make(map[string]int, len(ReactionTypes))
+	for _, r := range rows {
+		actual[r.Type]++
+	}
+	upd := s.tbl().Update("pk", artistPK(handle)).Range("sk", postSK(postID))
+	var conds []string
+	var args []any
+	for _, t := range ReactionTypes {
+		n := row.count(t)
+		if actual[t] == n {
+			continue
+		}
+		attr := reactionCountAttr(t)
+		upd = upd.Set(attr, actual[t])
+		if n == 0 {
+			conds = append(conds, "(attribute_not_exists($) OR $ = ?)")
+			args = append(args, attr, attr, 0)
+		} else {
+			conds = append(conds, "$ = ?")
+			args = append(args, attr, n)
+		}
+	}
+	if len(conds) == 0 {
+		return false, nil
+	}
+	if err := upd.If("attribute_exists(pk) AND "+strings.Join(conds, " AND "), args...).Run(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
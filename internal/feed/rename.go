@@ -0,0 +1,86 @@
+package feed
+
+import (
+	"context"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Title edits. The post ID is the slug of the title, so a title edit that changes the slug moves the post to the new
+// slug (rename_store.go) and GetPost on the old slug returns a PostMovedError; the handler redirects to the new one.
+// After the main row moves, the revisions, comments, reactions, images and feed index document kept under the old
+// slug follow. If that is interrupted, repeating the same title edit on the old slug finishes it.
+
+// PostMovedError is returned by GetPost for a slug the post moved away from. PostID is the current slug.
+type PostMovedError struct {
+	PostID string
+}
+
+func (e *PostMovedError) Error() string {
+	return "post moved to " + e.PostID
+}
+
+// maxAliasHops bounds alias chains (a→b, b→c) followed when resolving a former slug.
+const maxAliasHops = 5
+
+// resolveAlias returns the post a former slug now leads to, or nil if there is none.
+func (s *service) resolveAlias(ctx context.Context, handle, postID string) (*postRow, error) {
+	for i := 0; i < maxAliasHops; i++ {
+		alias, err := s.store.GetAlias(ctx, handle, postID)
+		if err != nil || alias == nil {
+			return nil, err
+		}
+		postID = alias.NewPostID
+		row, err := s.store.Get(ctx, handle, postID)
+		if err != nil || row != nil {
+			return row, err
+		}
+	}
+	return nil, nil
+}
+
+// resumeSlugChange finishes an edit that moved the post from oldPostID to postID but did not move everything kept
+// under the old slug.
+func (s *service) resumeSlugChange(ctx context.Context, artist *artists.Artist, oldPostID, postID, actorUserID string) (*Post, error) {
+	handle := normalizeHandle(artist.Handle)
+	if err := s.ensureCanEditPost(ctx, handle, postID, actorUserID, artists.PermFeedUpdate, artists.PermFeedUpdateOwn); err != nil {
+		return nil, err
+	}
+	row, err := s.store.Get(ctx, handle, postID)
+	if err != nil || row == nil {
+		return nil, ErrPostNotFound
+	}
+	if err := s.moveSlugData(ctx, artist, oldPostID, row); err != nil {
+		return nil, err
+	}
+	return rowToPost(row), nil
+}
+
+// moveSlugData moves the images, revisions, comments and reactions of the post from oldPostID to row's slug, and
+// replaces its feed index document. Idempotent.
+func (s *service) moveSlugData(ctx context.Context, artist *artists.Artist, oldPostID string, row *postRow) error {
+	handle := normalizeHandle(artist.Handle)
+	if s.media != nil && len(row.Media) > 0 {
+		if err := s.media.Reattach(ctx, handle, oldPostID, row.PostID, mediaIDs(row.Media)); err != nil {
+			return err
+		}
+	}
+	if err := s.store.moveRevisions(ctx, handle, oldPostID, handle, row.PostID); err != nil {
+		return err
+	}
+	if err := s.store.moveComments(ctx, handle, oldPostID, handle, row.PostID); err != nil {
+		return err
+	}
+	if err := s.store.moveReactions(ctx, handle, oldPostID, handle, row.PostID); err != nil {
+		return err
+	}
+	if s.indexer == nil {
+		return nil
+	}
+	if row.published() && artist.Visibility != artists.VisibilityPrivate {
+		if err := s.indexer.IndexPost(ctx, feedDoc(handle, row)); err != nil {
+			return err
+		}
+	}
+	return s.indexer.DeletePost(ctx, handle, oldPostID)
+}
@@ -0,0 +1,163 @@
+package feed
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Slug changes: a title edit that changes the slug moves the main row, its BYTIME or schedule index row and its pin to
+// the new slug in one transaction, and leaves an alias at the old slug so links to it keep working. Revisions,
+// comments, reactions and images follow afterwards (rename.go).
+// - Alias row: PK = ARTISTS#<handle>, SK = POSTALIAS#<old_post_id> — the slug the post moved to. A post created or
+//   moved to the old slug later removes the alias. (POSTALIAS# is not under POST#, so post listings never see it.)
+
+const postAliasSKPrefix = "POSTALIAS#"
+
+type postAliasRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	PostID    string `dynamo:"post_id"`
+	NewPostID string `dynamo:"new_post_id"`
+	CreatedAt string `dynamo:"created_at"`
+}
+
+func postAliasSK(postID string) string {
+	return postAliasSKPrefix + postID
+}
+
+// GetAlias returns the alias left at a former slug, or nil if there is none.
+func (s *Store) GetAlias(ctx context.Context, handle, postID string) (*postAliasRow, error) {
+	var row postAliasRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, postAliasSK(postID)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// slugChangeCondition is updateCondition plus the attributes other writes change on the main row (comment and
+// reaction counts, pinned_at), which the moved row copies from prev.
+func slugChangeCondition(r *postRow) (string, []any) {
+	cond, args := updateCondition(r)
+	if r.PinnedAt == "" {
+		cond, args = cond+" AND attribute_not_exists($)", append(args, "pinned_at")
+	} else {
+		cond, args = cond+" AND $ = ?", append(args, "pinned_at", r.PinnedAt)
+	}
+	attrs, counts := []string{"comment_count"}, []int{r.CommentCount}
+	for _, t := range ReactionTypes {
+		attrs, counts = append(attrs, reactionCountAttr(t)), append(counts, r.count(t))
+	}
+	for i, attr := range attrs {
+		if counts[i] == 0 {
+			cond, args = cond+" AND (attribute_not_exists($) OR $ = ?)", append(args, attr, attr, 0)
+		} else {
+			cond, args = cond+" AND $ = ?", append(args, attr, counts[i])
+		}
+	}
+	return cond, args
+}
+
+// ChangeSlug moves the post from prev's slug to next's: it writes next as the main row under the new slug with its
+// BYTIME or schedule index row and rev (nil when the content did not change), removes the old rows, points the pin at
+// the new slug and leaves an alias at the old one, with the activity entry, in one transaction. Fails with a
+// condition check if a post already has the new slug, or the post changed since prev was read.
+func (s *Store) ChangeSlug(ctx context.Context, handle string, prev *postRow, next postRow, rev *revisionRow, changedAt string, activity *artists.ActivityEntry) error {
+	handle = normalizeHandle(handle)
+	pk := artistPK(handle)
+	next.PK, next.SK, next.ArtistHandle = pk, postSK(next.PostID), handle
+	cond, args := slugChangeCondition(prev)
+	tx := s.db.WriteTx().
+		Put(s.tbl().Put(next).If("attribute_not_exists(pk)")).
+		Delete(s.tbl().Delete("pk", pk).Range("sk", postSK(prev.PostID)).If(cond, args...)).
+		Put(s.tbl().Put(postAliasRow{PK: pk, SK: postAliasSK(prev.PostID), PostID: prev.PostID, NewPostID: next.PostID, CreatedAt: changedAt})).
+		Delete(s.tbl().Delete("pk", pk).Range("sk", postAliasSK(next.PostID)))
+	if rev != nil {
+		rev.PK, rev.SK, rev.PostID = pk, revisionSK(next.PostID, rev.Revision), next.PostID
+		tx = tx.Put(s.tbl().Put(*rev).If("attribute_not_exists(pk)"))
+	}
+	switch {
+	case prev.published():
+		tx = tx.Delete(s.tbl().Delete("pk", pk).Range("sk", postByTimeSK(prev.listedAt(), prev.PostID)))
+	case prev.Status == StatusScheduled:
+		tx = tx.Delete(s.tbl().Delete("pk", schedulePK(prev.PublishAt)).Range("sk", scheduleSK(prev.PublishAt, handle, prev.PostID)))
+	}
+	switch {
+	case next.published():
+		tx = tx.Put(s.tbl().Put(next.byTimeRow(handle)))
+	case next.Status == StatusScheduled:
+		tx = tx.Put(s.tbl().Put(scheduleRowFor(handle, &next)))
+	}
+	if !prev.published() {
+		tx = tx.Delete(s.tbl().Delete("pk", pk).Range("sk", postUnpublishedSK(prev.PostID)))
+	}
+	if !next.published() {
+		tx = tx.Put(s.tbl().Put(unpublishedRow(handle, next.PostID)))
+	}
+	if prev.PinnedAt != "" {
+		pins, err := s.GetPins(ctx, handle)
+		if err != nil {
+			return err
+		}
+		if pins.pinned(prev.PostID) {
+			postIDs := make([]string, len(pins.PostIDs))
+			for i, id := range pins.PostIDs {
+				if id == prev.PostID {
+					id = next.PostID
+				}
+				postIDs[i] = id
+			}
+			tx = s.withPins(tx, handle, pins, postIDs)
+		}
+	}
+	return s.withActivity(tx, handle, activity).Run(ctx)
+}
+
+// listAliases returns every alias of the artist's posts.
+func (s *Store) listAliases(ctx context.Context, handle string) ([]postAliasRow, error) {
+	var rows []postAliasRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, postAliasSKPrefix).All(ctx, &rows)
+	return rows, err
+}
+
+// moveAliases copies the artist's post aliases to newHandle, then removes the old rows, so an interrupted run can be
+// repeated.
+func (s *Store) moveAliases(ctx context.Context, oldHandle, newHandle string) error {
+	rows, err := s.listAliases(ctx, oldHandle)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	puts := make([]any, len(rows))
+	keys := make([]dynamo.Keyed, len(rows))
+	for i, row := range rows {
+		keys[i] = dynamo.Keys{row.PK, row.SK}
+		row.PK = artistPK(newHandle)
+		puts[i] = row
+	}
+	if _, err := s.tbl().Batch("pk", "sk").Write().Put(puts...).Run(ctx); err != nil {
+		return err
+	}
+	_, err = s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
+
+// deleteAliases removes every alias of the artist's posts.
+func (s *Store) deleteAliases(ctx context.Context, handle string) error {
+	rows, err := s.listAliases(ctx, handle)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	keys := make([]dynamo.Keyed, len(rows))
+	for i, row := range rows {
+		keys[i] = dynamo.Keys{row.PK, row.SK}
+	}
+	_, err = s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
@@ -0,0 +1,196 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Post revisions. Every update that changes a post's content (body, image_url, media, youtube_url, explicit) writes an
+// immutable revision with the new content, the editor, the time and the changed fields, so moderators can see what
+// was originally published and the artist can undo an edit. Revision 0 is the content the post was created with;
+// it is not stored, but rebuilt from revision 1's before values. Reverting writes a new revision; history is never
+// rewritten.
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// ModeratorChecker reports whether a user is a platform admin (moderators can read any post's revisions).
+// Implemented by admin.Store.
+type ModeratorChecker interface {
+	IsAdmin(ctx context.Context, userID string) (bool, error)
+}
+
+// Revision is one edit of a post's content: the content after the edit and what changed.
+type Revision struct {
+	Revision       int                   `json:"revision"`
+	Body           string                `json:"body"`
+	ImageURL       string                `json:"image_url,omitempty"`
+	Media          []PostMedia           `json:"media"`
+	YouTubeURL     string                `json:"youtube_url,omitempty"`
+	Explicit       bool                  `json:"explicit"`
+	EditedByUserID string                `json:"edited_by_user_id"`
+	EditedAt       string                `json:"edited_at"`
+	Changes        []artists.FieldChange `json:"changes"`
+	RevertedTo     *int                  `json:"reverted_to,omitempty"` // the revision this edit reverted to
+}
+
+// contentChanges lists the content fields that differ between prev and next.
+func contentChanges(prev, next *postRow) []artists.FieldChange {
+	var changes []artists.FieldChange
+	changes = artists.AppendChange(changes, "body", prev.Body, next.Body)
+	changes = artists.AppendChange(changes, "image_url", prev.ImageURL, next.ImageURL)
+	changes = artists.AppendChange(changes, "media", mediaString(prev.Media), mediaString(next.Media))
+	changes = artists.AppendChange(changes, "youtube_url", prev.YouTubeURL, next.YouTubeURL)
+	changes = artists.AppendChange(changes, "explicit", strconv.FormatBool(prev.Explicit), strconv.FormatBool(next.Explicit))
+	return changes
+}
+
+// originalRevision rebuilds revision 0 (the content the post was created with) from revision 1.
+func originalRevision(first *revisionRow, post *postRow) Revision {
+	orig := Revision{
+		Body:           first.Body,
+		ImageURL:       first.ImageURL,
+		Media:          first.Media,
+		YouTubeURL:     first.YouTubeURL,
+		Explicit:       first.Explicit,
+		EditedByUserID: post.CreatedByUserID,
+		EditedAt:       post.CreatedAt,
+		Changes:        []artists.FieldChange{},
+	}
+	for _, c := range first.Changes {
+		switch c.Field {
+		case "body":
+			orig.Body = c.Before
+		case "image_url":
+			orig.ImageURL = c.Before
+		case "media":
+			orig.Media = parseMediaString(c.Before)
+		case "youtube_url":
+			orig.YouTubeURL = c.Before
+		case "explicit":
+			orig.Explicit = c.Before == "true"
+		}
+	}
+	if orig.Media == nil {
+		orig.Media = []PostMedia{}
+	}
+	return orig
+}
+
+func revisionToAPI(r *revisionRow) Revision {
+	changes := r.Changes
+	if changes == nil {
+		changes = []artists.FieldChange{}
+	}
+	media := r.Media
+	if media == nil {
+		media = []PostMedia{}
+	}
+	return Revision{
+		Revision:       r.Revision,
+		Body:           r.Body,
+		ImageURL:       r.ImageURL,
+		Media:          media,
+		YouTubeURL:     r.YouTubeURL,
+		Explicit:       r.Explicit,
+		EditedByUserID: r.EditedByUserID,
+		EditedAt:       r.EditedAt,
+		Changes:        changes,
+		RevertedTo:     r.RevertedTo,
+	}
+}
+
+// ensureCanSeeRevisions allows members with a feed permission and platform admins.
+func (s *service) ensureCanSeeRevisions(ctx context.Context, handle, userID string) error {
+	err := s.ensureCanSeeUnpublished(ctx, handle, userID)
+	if err != ErrForbidden || s.moderators == nil || userID == "" {
+		return err
+	}
+	ok, modErr := s.moderators.IsAdmin(ctx, userID)
+	if modErr != nil {
+		return modErr
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ListRevisions returns the post's revisions newest first, ending with revision 0 (the original content) once the
+// post has been edited. Members with a feed permission and platform admins only.
+func (s *service) ListRevisions(ctx context.Context, handle, postID, actorUserID string) ([]Revision, error) {
+	handle = normalizeHandle(handle)
+	artist, err := s.artist.GetByHandle(ctx, handle)
+	if err != nil || artist == nil {
+		return nil, ErrArtistNotFound
+	}
+	if err := s.ensureCanSeeRevisions(ctx, handle, actorUserID); err != nil {
+		return nil, err
+	}
+	row, err := s.store.Get(ctx, handle, postID)
+	if err != nil || row == nil {
+		return nil, ErrPostNotFound
+	}
+	rows, err := s.store.ListRevisions(ctx, handle, postID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Revision, 0, len(rows)+1)
+	for i := range rows {
+		out = append(out, revisionToAPI(&rows[i]))
+	}
+	if len(rows) > 0 && rows[len(rows)-1].Revision == 1 {
+		out = append(out, originalRevision(&rows[len(rows)-1], row))
+	}
+	return out, nil
+}
+
+// RevertPost restores the post's content to that of revision (0 for the original content), as a new revision.
+// Same permissions as UpdatePost. A revert to the current content is a no-op.
+func (s *service) RevertPost(ctx context.Context, handle, postID string, revision int, actorUserID string) (*Post, error) {
+	handle = normalizeHandle(handle)
+	artist, err := s.artist.GetByHandle(ctx, handle)
+	if err != nil || artist == nil {
+		return nil, ErrArtistNotFound
+	}
+	if err := s.ensureCanEditPost(ctx, handle, postID, actorUserID, artists.PermFeedUpdate, artists.PermFeedUpdateOwn); err != nil {
+		return nil, err
+	}
+	row, err := s.store.Get(ctx, handle, postID)
+	if err != nil || row == nil {
+		return nil, ErrPostNotFound
+	}
+	if revision < 0 || revision > row.RevisionCount {
+		return nil, ErrRevisionNotFound
+	}
+	lookup := revision
+	if revision == 0 {
+		lookup = 1
+	}
+	rev, err := s.store.GetRevision(ctx, handle, postID, lookup)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, ErrRevisionNotFound
+	}
+	target := revisionToAPI(rev)
+	if revision == 0 {
+		target = originalRevision(rev, row)
+	}
+	updated := *row
+	updated.Body, updated.ImageURL, updated.YouTubeURL, updated.Explicit = target.Body, target.ImageURL, target.YouTubeURL, target.Explicit
+	updated.Media = target.Media
+	if len(contentChanges(row, &updated)) == 0 {
+		return rowToPost(row), nil
+	}
+	// Images the post no longer uses may have been deleted by the orphan cleaner; then the revert fails.
+	if updated.Media, err = s.attachMedia(ctx, handle, postID, row.Media, toMediaRefs(target.Media)); err != nil {
+		return nil, err
+	}
+	updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return s.saveUpdate(ctx, artist, row, updated, actorUserID, &revision)
+}
@@ -0,0 +1,101 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Revisions: one immutable row per edit of a post's content, in the artist partition next to the post.
+// - Revision row: PK = ARTISTS#<handle>, SK = POSTREV#<post_id>#<revision> (zero-padded, so SK order is revision
+//   order) — the content after the edit (body, image_url, media, youtube_url, explicit), editor, time and changed fields.
+// The post's revision_count is bumped in the same transaction that writes the row, conditioned on its old value, so
+// two concurrent edits can't both claim the same revision number. (POSTREV# is not under POST#, so post listings
+// never see revision rows.)
+
+const revisionSKPrefix = "POSTREV#"
+
+type revisionRow struct {
+	PK             string                `dynamo:"pk"`
+	SK             string                `dynamo:"sk"`
+	PostID         string                `dynamo:"post_id"`
+	Revision       int                   `dynamo:"revision"`
+	Body           string                `dynamo:"body"`
+	ImageURL       string                `dynamo:"image_url,omitempty"`
+	Media          []PostMedia           `dynamo:"media,omitempty"`
+	YouTubeURL     string                `dynamo:"youtube_url,omitempty"`
+	Explicit       bool                  `dynamo:"explicit"`
+	EditedByUserID string                `dynamo:"edited_by_user_id"`
+	EditedAt       string                `dynamo:"edited_at"`
+	Changes        []artists.FieldChange `dynamo:"changes,omitempty"`
+	RevertedTo     *int                  `dynamo:"reverted_to,omitempty"` // set when the edit was a revert
+}
+
+func revisionPrefix(postID string) string {
+	return revisionSKPrefix + postID + "#"
+}
+
+func revisionSK(postID string, revision int) string {
+	return fmt.Sprintf("%s%06d", revisionPrefix(postID), revision)
+}
+
+// GetRevision returns the post's revision, or nil if not found.
+func (s *Store) GetRevision(ctx context.Context, handle, postID string, revision int) (*revisionRow, error) {
+	var row revisionRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, revisionSK(postID, revision)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// ListRevisions returns every revision of the post, newest first.
+func (s *Store) ListRevisions(ctx context.Context, handle, postID string) ([]revisionRow, error) {
+	var out []revisionRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, revisionPrefix(postID)).
+		Order(dynamo.Descending).All(ctx, &out)
+	return out, err
+}
+
+// moveRevisions copies the post's revisions to newHandle and newPostID (a handle rename or a new slug), then removes
+// the old rows, so an interrupted run can be repeated.
+func (s *Store) moveRevisions(ctx context.Context, oldHandle, oldPostID, newHandle, newPostID string) error {
+	rows, err := s.ListRevisions(ctx, oldHandle, oldPostID)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	puts := make([]any, len(rows))
+	keys := make([]dynamo.Keyed, len(rows))
+	for i, row := range rows {
+		keys[i] = dynamo.Keys{row.PK, row.SK}
+		row.PK, row.SK, row.PostID = artistPK(newHandle), revisionSK(newPostID, row.Revision), newPostID
+		puts[i] = row
+	}
+	if _, err := s.tbl().Batch("pk", "sk").Write().Put(puts...).Run(ctx); err != nil {
+		return err
+	}
+	_, err = s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
+
+// deleteRevisions removes every revision of the post. Called before the post itself is deleted, so a later post
+// with the same slug starts from revision 1.
+func (s *Store) deleteRevisions(ctx context.Context, handle, postID string) error {
+	rows, err := s.ListRevisions(ctx, handle, postID)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	keys := make([]dynamo.Keyed, len(rows))
+	for i, row := range rows {
+		keys[i] = dynamo.Keys{row.PK, row.SK}
+	}
+	_, err = s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
@@ -0,0 +1,185 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Drafts and scheduled posts. A post is created as a draft, scheduled (publish_at in the future) or published
+// (the default). Until published it is visible only to members with a feed permission and is left out of
+// ListPosts, the BYTIME index and the feed index. PublishDue publishes scheduled posts once they are due.
+
+var (
+	ErrInvalidStatus    = errors.New("status must be one of draft, scheduled, published")
+	ErrInvalidPublishAt = errors.New("publish_at must be an RFC 3339 time in the future for scheduled posts")
+	ErrPostPublished    = errors.New("post is already published; it can't become a draft or be scheduled")
+	ErrPostChanged      = errors.New("post was published, rescheduled or edited meanwhile; try again")
+)
+
+// Post statuses.
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+)
+
+// ScheduleLookback is how far back PublishDue looks for scheduled posts that are due. Posts due earlier (the
+// publisher was down for longer) stay scheduled until they are rescheduled or published by hand.
+const ScheduleLookback = 48 * time.Hour
+
+// publishBatchSize bounds how many due posts one PublishDue call reads per schedule bucket.
+const publishBatchSize = 100
+
+// resolveStatus validates a post's status and publish_at; "" status means published. publish_at is kept (as UTC
+// RFC 3339) only for scheduled posts.
+func resolveStatus(status, publishAt string, now time.Time) (string, string, error) {
+	switch status = strings.ToLower(strings.TrimSpace(status)); status {
+	case "", StatusPublished:
+		return StatusPublished, "", nil
+	case StatusDraft:
+		return StatusDraft, "", nil
+	case StatusScheduled:
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(publishAt))
+		if err != nil || !t.After(now) {
+			return "", "", ErrInvalidPublishAt
+		}
+		return StatusScheduled, t.UTC().Format(time.RFC3339), nil
+	default:
+		return "", "", ErrInvalidStatus
+	}
+}
+
+// ensureCanSeeUnpublished allows members with any feed permission to see drafts and scheduled posts.
+func (s *service) ensureCanSeeUnpublished(ctx context.Context, handle, userID string) error {
+	if userID == "" {
+		return ErrForbidden
+	}
+	if s.permChecker == nil {
+		return s.ensureOwner(ctx, handle, userID)
+	}
+	for _, perm := range []string{artists.PermFeedCreate, artists.PermFeedUpdate, artists.PermFeedUpdateOwn, artists.PermFeedDelete, artists.PermFeedDeleteOwn} {
+		ok, err := s.permChecker.HasPermission(ctx, handle, userID, perm)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// ListDrafts returns the artist's drafts and scheduled posts: scheduled first by publish_at, then drafts newest
+// first. Members with a feed permission only.
+func (s *service) ListDrafts(ctx context.Context, handle, actorUserID string) ([]Post, error) {
+	handle = normalizeHandle(handle)
+	artist, err := s.artist.GetByHandle(ctx, handle)
+	if err != nil || artist == nil {
+		return nil, ErrArtistNotFound
+	}
+	if err := s.ensureCanSeeUnpublished(ctx, handle, actorUserID); err != nil {
+		return nil, err
+	}
+	rows, err := s.store.ListUnpublished(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Status != b.Status {
+			return a.Status == StatusScheduled
+		}
+		if a.Status == StatusScheduled {
+			return a.PublishAt < b.PublishAt
+		}
+		return a.CreatedAt > b.CreatedAt
+	})
+	out := make([]Post, len(rows))
+	for i := range rows {
+		out[i] = *rowToPost(&rows[i])
+	}
+	return out, nil
+}
+
+// PublishDue publishes scheduled posts whose publish_at is at or before now, from the schedule buckets of the last
+// ScheduleLookback. Returns how many posts this call published. Safe to run on several tasks at once: each post is
+// published by exactly one of them. A post that fails stays scheduled for the next run.
+func (s *service) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	published := 0
+	var errs []error
+	last := now.UTC().Truncate(scheduleBucketSize)
+	for t := now.UTC().Add(-ScheduleLookback).Truncate(scheduleBucketSize); !t.After(last); t = t.Add(scheduleBucketSize) {
+		due, err := s.store.ListScheduledDue(ctx, scheduleBucket(t), now, publishBatchSize)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, e := range due {
+			ok, err := s.publish(ctx, e, now)
+			if err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				published++
+			}
+		}
+	}
+	return published, errors.Join(errs...)
+}
+
+// publish publishes one due post. ok is false when the entry was stale or another task published the post first.
+func (s *service) publish(ctx context.Context, e scheduleRow, now time.Time) (ok bool, err error) {
+	row, err := s.store.Get(ctx, e.Handle, e.PostID)
+	if err != nil {
+		return false, err
+	}
+	if row == nil || row.Status != StatusScheduled || row.PublishAt != e.PublishAt {
+		return false, s.store.DeleteScheduleEntry(ctx, e)
+	}
+	// The post is published either way, but it only goes into the feed index for an artist that is neither private nor
+	// pending deletion (GetByHandle finds no such artist).
+	indexed := false
+	if artist, err := s.artist.GetByHandle(ctx, e.Handle); err == nil && artist != nil {
+		indexed = artist.Status != artists.StatusPendingDeletion && artist.Visibility != artists.VisibilityPrivate
+	}
+	publishedAt := now.UTC().Format(time.RFC3339)
+	published := *row
+	published.Status, published.PublishAt, published.PublishedAt = StatusPublished, "", publishedAt
+	// Index first so a crash after the write never leaves a published post out of the feed; the document is undone
+	// below if the post turns out not to be ours to publish.
+	if s.indexer != nil && indexed {
+		if err := s.indexer.IndexPost(ctx, feedDoc(e.Handle, &published)); err != nil {
+			return false, err
+		}
+	}
+	activity := s.activityEntry(artists.ActivityEntry{
+		Action:  artists.ActivityPostPublish,
+		Target:  row.PostID,
+		Changes: artists.AppendChange(nil, "status", StatusScheduled, StatusPublished),
+	})
+	if err := s.store.Publish(ctx, row, publishedAt, activity); err != nil {
+		if !dynamo.IsCondCheckFailed(err) {
+			return false, err
+		}
+		// Published by another task, or rescheduled, unpublished or deleted meanwhile.
+		current, getErr := s.store.Get(ctx, e.Handle, e.PostID)
+		if getErr != nil {
+			return false, getErr
+		}
+		if s.indexer != nil && indexed {
+			if current != nil && current.published() {
+				_ = s.indexer.IndexPost(ctx, feedDoc(e.Handle, current))
+			} else {
+				_ = s.indexer.DeletePost(ctx, normalizeHandle(e.Handle), e.PostID)
+			}
+		}
+		return false, s.store.DeleteScheduleEntry(ctx, e)
+	}
+	return true, nil
+}
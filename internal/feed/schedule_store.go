@@ -0,0 +1,131 @@
+package feed
+
+import (
+	"context"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Schedule index: scheduled posts, bucketed by the hour they are due so no single partition takes every write.
+// - Schedule row: PK = POSTS#SCHEDULED#<yyyy-mm-ddThh>, SK = <publish_at>#<handle>#<post_id>.
+// The publisher queries the buckets from ScheduleLookback ago up to now for rows due by now. Publishing flips the
+// main row from scheduled to published only if it is still scheduled for the same time, so several tasks running
+// the publisher at once publish each post once; the loser just drops the schedule row.
+// (Handles are lowercase, so POSTS#SCHEDULED#... cannot collide with an artist partition.)
+// Unpublished index: drafts and scheduled posts of an artist, so the drafts list reads only those, not every post.
+// - Unpublished row: PK = ARTISTS#<handle>, SK = POSTUNPUB#<post_id>. Written and removed with the main row.
+//   (POSTUNPUB# is not under POST#, so post listings never see it.)
+
+const (
+	schedulePKPrefix        = "POSTS#SCHEDULED#"
+	scheduleBucketSize      = time.Hour
+	postUnpublishedSKPrefix = "POSTUNPUB#"
+)
+
+type scheduleRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	Handle    string `dynamo:"handle"`
+	PostID    string `dynamo:"post_id"`
+	PublishAt string `dynamo:"publish_at"`
+}
+
+// scheduleBucket is the schedule partition for t (the hour it falls in, UTC).
+func scheduleBucket(t time.Time) string {
+	return schedulePKPrefix + t.UTC().Truncate(scheduleBucketSize).Format("2006-01-02T15")
+}
+
+// schedulePK is the schedule partition for an RFC 3339 publish_at.
+func schedulePK(publishAt string) string {
+	t, _ := time.Parse(time.RFC3339, publishAt)
+	return scheduleBucket(t)
+}
+
+func scheduleSK(publishAt, handle, postID string) string {
+	return publishAt + "#" + handle + "#" + postID
+}
+
+type postUnpublishedRow struct {
+	PK     string `dynamo:"pk"`
+	SK     string `dynamo:"sk"`
+	PostID string `dynamo:"post_id"`
+}
+
+func postUnpublishedSK(postID string) string {
+	return postUnpublishedSKPrefix + postID
+}
+
+// unpublishedRow returns the post's unpublished index row under handle.
+func unpublishedRow(handle, postID string) postUnpublishedRow {
+	return postUnpublishedRow{PK: artistPK(handle), SK: postUnpublishedSK(postID), PostID: postID}
+}
+
+func scheduleRowFor(handle string, r *postRow) scheduleRow {
+	handle = normalizeHandle(handle)
+	return scheduleRow{
+		PK:        schedulePK(r.PublishAt),
+		SK:        scheduleSK(r.PublishAt, handle, r.PostID),
+		Handle:    handle,
+		PostID:    r.PostID,
+		PublishAt: r.PublishAt,
+	}
+}
+
+// ListScheduledDue returns up to limit schedule rows in the bucket that are due by now, oldest first.
+func (s *Store) ListScheduledDue(ctx context.Context, bucket string, now time.Time, limit int) ([]scheduleRow, error) {
+	// publish_at has second precision: everything before the next second is due.
+	before := now.UTC().Truncate(time.Second).Add(time.Second).Format(time.RFC3339)
+	var out []scheduleRow
+	err := s.tbl().Get("pk", bucket).Range("sk", dynamo.Less, before).Limit(limit).All(ctx, &out)
+	return out, err
+}
+
+// Publish flips a scheduled post to published (published_at set, publish_at cleared), adds its BYTIME index row and
+// drops its schedule and unpublished index rows, with the activity entry, in one transaction. Fails with a condition check if the post is
+// gone or no longer scheduled for row.PublishAt.
+func (s *Store) Publish(ctx context.Context, row *postRow, publishedAt string, activity *artists.ActivityEntry) error {
+	handle := normalizeHandle(row.ArtistHandle)
+	published := *row
+	published.Status, published.PublishAt, published.PublishedAt = StatusPublished, "", publishedAt
+	tx := s.db.WriteTx().
+		Update(s.tbl().Update("pk", artistPK(handle)).Range("sk", postSK(row.PostID)).
+			Set("status", StatusPublished).
+			Set("published_at", publishedAt).
+			Remove("publish_at").
+			If("$ = ? AND publish_at = ?", "status", StatusScheduled, row.PublishAt)).
+		Put(s.tbl().Put(published.byTimeRow(handle))).
+		Delete(s.tbl().Delete("pk", schedulePK(row.PublishAt)).Range("sk", scheduleSK(row.PublishAt, handle, row.PostID))).
+		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", postUnpublishedSK(row.PostID)))
+	return s.withActivity(tx, handle, activity).Run(ctx)
+}
+
+// DeleteScheduleEntry removes a stale schedule row (the post was published, rescheduled, moved or deleted).
+func (s *Store) DeleteScheduleEntry(ctx context.Context, e scheduleRow) error {
+	return s.tbl().Delete("pk", e.PK).Range("sk", e.SK).Run(ctx)
+}
+
+// ListUnpublished returns the artist's drafts and scheduled posts, from the unpublished index.
+func (s *Store) ListUnpublished(ctx context.Context, handle string) ([]postRow, error) {
+	var idx []postUnpublishedRow
+	if err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, postUnpublishedSKPrefix).All(ctx, &idx); err != nil {
+		return nil, err
+	}
+	postIDs := make([]string, len(idx))
+	for i, r := range idx {
+		postIDs[i] = r.PostID
+	}
+	rows, err := s.BatchGetPosts(ctx, handle, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]postRow, 0, len(rows))
+	for _, r := range rows {
+		if !r.published() {
+			out = append(out, *r)
+		}
+	}
+	return out, nil
+}
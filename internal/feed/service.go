@@ -3,10 +3,15 @@ package feed
 import (
 	"context"
 	"errors"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/guregu/dynamo/v2"
+
 	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/markdown"
+	"github.com/sopatech/afterwave.fm/internal/preferences"
 	"github.com/sopatech/afterwave.fm/internal/search"
 )
 
@@ -15,11 +20,17 @@ var (
 	ErrPostNotFound   = errors.New("post not found")
 	ErrForbidden      = errors.New("not the owner of this artist page")
 	ErrSlugConflict   = errors.New("a post with this title already exists for this artist")
+	ErrTitleRequired  = errors.New("title is required")
+	ErrInvalidTitle   = errors.New("title must contain at least one letter or number")
 )
 
-// ArtistResolver is used to resolve artist by handle (for ownership check). Implemented by artists.Service.
+// ArtistResolver is used to resolve artist by handle (for ownership check), by handle for a viewer (private pages are
+// not found for non-members), and many handles at once for MyFeed. Implemented by artists.Service.
 type ArtistResolver interface {
 	GetByHandle(ctx context.Context, handle string) (*artists.Artist, error)
+	GetForViewer(ctx context.Context, handle, viewerUserID string) (*artists.Artist, error)
+	GetByHandles(ctx context.Context, handles []string) (map[string]*artists.Artist, error)
+	CurrentHandles(ctx context.Context, handles []string) (map[string]string, error)
 }
 
 // FollowingLister returns the list of artist handles a user follows. Implemented by follows.Service.
@@ -28,40 +39,82 @@ type FollowingLister interface {
 }
 
 // FeedPermissionChecker checks if a user has a feed permission on an artist. When nil, feed falls back to owner-only.
-// Implemented by artists.Service (HasPermission with PermFeedCreate/PermFeedUpdate/PermFeedDelete).
+// Implemented by artists.Service (HasPermission with PermFeedCreate/PermFeedUpdate/PermFeedDelete and the _own variants).
 type FeedPermissionChecker interface {
 	HasPermission(ctx context.Context, handle, userID, permission string) (bool, error)
 }
 
+// ActivityRecorder appends post changes to the artist's activity log. When nil, nothing is recorded. Post changes are
+// written by the store together with their entry (artists.ActivityPut), so RecordActivity is only for changes that
+// are not. Implemented by artists.Service.
+type ActivityRecorder interface {
+	RecordActivity(ctx context.Context, handle string, e artists.ActivityEntry) error
+}
+
 type Service interface {
-	CreatePost(ctx context.Context, handle string, title, body, imageURL, youtubeURL string, explicit bool, actorUserID string) (*Post, error)
-	ListPosts(ctx context.Context, handle string, limit int, cursor string) ([]Post, string, error)
-	GetPost(ctx context.Context, handle, postID string) (*Post, error)
-	UpdatePost(ctx context.Context, handle, postID string, body, imageURL, youtubeURL *string, explicit *bool, actorUserID string) (*Post, error)
+	CreatePost(ctx context.Context, handle string, title, body, youtubeURL string, media []MediaRef, explicit bool, status, publishAt string, actorUserID string) (*Post, error)
+	ListPosts(ctx context.Context, handle string, limit int, cursor, viewerUserID string) ([]Post, string, error)
+	ListDrafts(ctx context.Context, handle, actorUserID string) ([]Post, error)
+	GetPost(ctx context.Context, handle, postID, viewerUserID string) (*Post, error)
+	UpdatePost(ctx context.Context, handle, postID string, title, body, youtubeURL *string, media *[]MediaRef, explicit *bool, status, publishAt *string, actorUserID string) (*Post, error)
 	DeletePost(ctx context.Context, handle, postID string, actorUserID string) error
+	ListRevisions(ctx context.Context, handle, postID, actorUserID string) ([]Revision, error)
+	RevertPost(ctx context.Context, handle, postID string, revision int, actorUserID string) (*Post, error)
 	MyFeed(ctx context.Context, userID string, limit int, cursor string) ([]Post, string, error)
+	PublishDue(ctx context.Context, now time.Time) (int, error)
+	CreateComment(ctx context.Context, handle, postID, parentID, body, actorUserID string) (*Comment, error)
+	ListComments(ctx context.Context, handle, postID, parentID string, limit int, cursor, viewerUserID string) ([]Comment, string, error)
+	UpdateComment(ctx context.Context, handle, postID, commentID, body, actorUserID string) (*Comment, error)
+	SetCommentHidden(ctx context.Context, handle, postID, commentID string, hidden bool, actorUserID string) (*Comment, error)
+	DeleteComment(ctx context.Context, handle, postID, commentID, actorUserID string) error
+	React(ctx context.Context, handle, postID, reactionType, userID string) error
+	Unreact(ctx context.Context, handle, postID, reactionType, userID string) error
+	ReconcileReactions(ctx context.Context, now time.Time) (int, error)
+	PinPost(ctx context.Context, handle, postID, actorUserID string) (*Post, error)
+	UnpinPost(ctx context.Context, handle, postID, actorUserID string) (*Post, error)
 }
 
 type Post struct {
-	PostID          string `json:"post_id"` // slug (unique per artist, derived from title)
-	ArtistHandle    string `json:"artist_handle"`
-	Title           string `json:"title"`
-	Body            string `json:"body"`
-	ImageURL        string `json:"image_url,omitempty"`
-	YouTubeURL      string `json:"youtube_url,omitempty"`
-	Explicit        bool   `json:"explicit"`
-	CreatedAt       string `json:"created_at"`
-	UpdatedAt       string `json:"updated_at,omitempty"`
-	CreatedByUserID string `json:"created_by_user_id"`
+	PostID          string         `json:"post_id"` // slug (unique per artist, derived from the title; rename.go)
+	ArtistHandle    string         `json:"artist_handle"`
+	Title           string         `json:"title"`
+	Body            string         `json:"body"`                // Markdown
+	BodyHTML        string         `json:"body_html"`           // Body rendered to sanitized HTML (markdown.go)
+	Excerpt         string         `json:"excerpt"`             // plain text, at most ExcerptLength characters
+	Links           []string       `json:"links"`               // URLs linked from the body, in order
+	Mentions        []string       `json:"mentions"`            // handles mentioned in the body, in order
+	ImageURL        string         `json:"image_url,omitempty"` // external image of posts from before hosted uploads
+	Media           []PostMedia    `json:"media"`               // hosted images, in display order
+	YouTubeURL      string         `json:"youtube_url,omitempty"`
+	Explicit        bool           `json:"explicit"`
+	CreatedAt       string         `json:"created_at"`
+	UpdatedAt       string         `json:"updated_at,omitempty"`
+	CreatedByUserID string         `json:"created_by_user_id"`
+	Status          string         `json:"status"`                     // StatusDraft, StatusScheduled or StatusPublished
+	PublishAt       string         `json:"publish_at,omitempty"`       // while scheduled
+	PublishedAt     string         `json:"published_at,omitempty"`     // when a draft or scheduled post was published
+	Edited          bool           `json:"edited"`                     // the content was edited after the post was created
+	RevisionCount   int            `json:"revision_count"`             // content edits so far; see ListRevisions
+	CommentCount    int            `json:"comment_count"`              // comments not hidden or deleted, replies included
+	ReactionCounts  map[string]int `json:"reaction_counts"`            // per type in ReactionTypes, zeros included
+	ViewerReactions []string       `json:"viewer_reactions,omitempty"` // the signed-in viewer's reaction types (lists and GetPost)
+	Pinned          bool           `json:"pinned"`                     // listed first by ListPosts (pin.go)
+	PinnedAt        string         `json:"pinned_at,omitempty"`
 }
 
 type service struct {
-	store        *Store
-	artist       ArtistResolver
-	permChecker  FeedPermissionChecker
-	indexer      FeedIndexer
-	following    FollowingLister
-	feedIndex    *search.FeedIndex
+	store       *Store
+	artist      ArtistResolver
+	permChecker FeedPermissionChecker
+	activity    ActivityRecorder
+	indexer     FeedIndexer
+	following   FollowingLister
+	feedIndex   *search.FeedIndex
+	prefs       preferences.Reader
+	moderators  ModeratorChecker
+	media       MediaAttacher
+	markdown    *markdown.Renderer
+	commentRate CommentRateLimit
 }
 
 // FeedIndexer indexes post refs to OpenSearch (optional; when nil, indexing is skipped).
@@ -76,8 +129,23 @@ func NewService(store *Store, artist ArtistResolver) Service {
 
 // NewServiceWithSearch returns a Service that indexes to OpenSearch on create/update/delete and supports MyFeed.
 // If permChecker is non-nil, Create/Update/Delete post use it for feed permissions; otherwise owner-only.
-func NewServiceWithSearch(store *Store, artist ArtistResolver, permChecker FeedPermissionChecker, indexer FeedIndexer, following FollowingLister, feedIndex *search.FeedIndex) Service {
-	return &service{store: store, artist: artist, permChecker: permChecker, indexer: indexer, following: following, feedIndex: feedIndex}
+// If activity is non-nil, post create/update/delete are recorded in the artist's activity log.
+// If prefs is non-nil, MyFeed applies the user's feed filters (muted artists, explicit visibility).
+// If moderators is non-nil, platform admins can read post revisions.
+// If mediaAttacher is non-nil, posts can have hosted images; otherwise requests with images fail.
+// If renderer is nil, post bodies are rendered with markdown.Default.
+// If commentRate is the zero value, comments are limited by DefaultCommentRateLimit.
+func NewServiceWithSearch(store *Store, artist ArtistResolver, permChecker FeedPermissionChecker, activity ActivityRecorder, indexer FeedIndexer, following FollowingLister, feedIndex *search.FeedIndex, prefs preferences.Reader, moderators ModeratorChecker, mediaAttacher MediaAttacher, renderer *markdown.Renderer, commentRate CommentRateLimit) Service {
+	return &service{store: store, artist: artist, permChecker: permChecker, activity: activity, indexer: indexer, following: following, feedIndex: feedIndex, prefs: prefs, moderators: moderators, media: mediaAttacher, markdown: renderer, commentRate: commentRate}
+}
+
+// activityEntry returns e for the store to write in the transaction of the change it records, or nil when activity
+// is not recorded.
+func (s *service) activityEntry(e artists.ActivityEntry) *artists.ActivityEntry {
+	if s.activity == nil {
+		return nil
+	}
+	return &e
 }
 
 func (s *service) ensureCanManageFeed(ctx context.Context, handle string, actorUserID string, permission string) error {
@@ -94,6 +162,20 @@ func (s *service) ensureCanManageFeed(ctx context.Context, handle string, actorU
 	return s.ensureOwner(ctx, handle, actorUserID)
 }
 
+// ensureCanEditPost allows permission on any post, or ownPermission (feed:update_own / feed:delete_own) on posts the
+// actor created.
+func (s *service) ensureCanEditPost(ctx context.Context, handle, postID, actorUserID, permission, ownPermission string) error {
+	err := s.ensureCanManageFeed(ctx, handle, actorUserID, permission)
+	if err != ErrForbidden || s.permChecker == nil {
+		return err
+	}
+	row, getErr := s.store.Get(ctx, handle, postID)
+	if getErr != nil || row == nil || row.CreatedByUserID != actorUserID {
+		return ErrForbidden
+	}
+	return s.ensureCanManageFeed(ctx, handle, actorUserID, ownPermission)
+}
+
 func (s *service) ensureOwner(ctx context.Context, handle string, actorUserID string) error {
 	artist, err := s.artist.GetByHandle(ctx, handle)
 	if err != nil || artist == nil {
@@ -105,61 +187,94 @@ func (s *service) ensureOwner(ctx context.Context, handle string, actorUserID st
 	return nil
 }
 
-func (s *service) CreatePost(ctx context.Context, handle string, title, body, imageURL, youtubeURL string, explicit bool, actorUserID string) (*Post, error) {
+// titleSlug trims title and returns it with its slug, the post ID.
+func titleSlug(title string) (string, string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", "", ErrTitleRequired
+	}
+	slug := Slugify(title)
+	if slug == "" {
+		return "", "", ErrInvalidTitle
+	}
+	return title, slug, nil
+}
+
+func (s *service) CreatePost(ctx context.Context, handle string, title, body, youtubeURL string, mediaRefs []MediaRef, explicit bool, status, publishAt string, actorUserID string) (*Post, error) {
 	handle = normalizeHandle(handle)
 	artist, err := s.artist.GetByHandle(ctx, handle)
 	if err != nil || artist == nil {
 		return nil, ErrArtistNotFound
 	}
-	_ = artist
 	if err := s.ensureCanManageFeed(ctx, handle, actorUserID, artists.PermFeedCreate); err != nil {
 		return nil, err
 	}
-	title = strings.TrimSpace(title)
-	if title == "" {
-		return nil, errors.New("title is required")
+	title, slug, err := titleSlug(title)
+	if err != nil {
+		return nil, err
 	}
-	slug := Slugify(title)
-	if slug == "" {
-		return nil, errors.New("title must contain at least one letter or number")
+	existing, err := s.store.Get(ctx, handle, slug)
+	if err != nil {
+		return nil, err
 	}
-	existing, _ := s.store.Get(ctx, handle, slug)
 	if existing != nil {
 		return nil, ErrSlugConflict
 	}
+	now := time.Now().UTC()
+	status, publishAt, err = resolveStatus(status, publishAt, now)
+	if err != nil {
+		return nil, err
+	}
 	body = strings.TrimSpace(body)
-	createdAt := time.Now().UTC().Format(time.RFC3339)
+	postMedia, err := s.attachMedia(ctx, handle, slug, nil, mediaRefs)
+	if err != nil {
+		return nil, err
+	}
+	createdAt := now.Format(time.RFC3339)
 	row := postRow{
 		PostID:          slug,
 		ArtistHandle:    handle,
 		Title:           title,
 		Body:            body,
-		ImageURL:        strings.TrimSpace(imageURL),
+		Media:           postMedia,
 		YouTubeURL:      strings.TrimSpace(youtubeURL),
 		Explicit:        explicit,
 		CreatedAt:       createdAt,
 		CreatedByUserID: actorUserID,
-	}
-	if err := s.store.Create(ctx, handle, row); err != nil {
+		Status:          status,
+		PublishAt:       publishAt,
+	}
+	s.renderBody(&row)
+	var changes []artists.FieldChange
+	changes = artists.AppendChange(changes, "title", "", row.Title)
+	changes = artists.AppendChange(changes, "body", "", row.Body)
+	changes = artists.AppendChange(changes, "media", "", mediaString(row.Media))
+	changes = artists.AppendChange(changes, "youtube_url", "", row.YouTubeURL)
+	changes = artists.AppendChange(changes, "explicit", "false", strconv.FormatBool(row.Explicit))
+	changes = artists.AppendChange(changes, "status", "", row.Status)
+	changes = artists.AppendChange(changes, "publish_at", "", row.PublishAt)
+	activity := s.activityEntry(artists.ActivityEntry{Action: artists.ActivityPostCreate, ActorUserID: actorUserID, Target: row.PostID, Changes: changes})
+	if err := s.store.Create(ctx, handle, row, activity); err != nil {
+		s.detachMedia(ctx, handle, slug, postMedia)
+		if dynamo.IsCondCheckFailed(err) {
+			return nil, ErrSlugConflict // created concurrently
+		}
 		return nil, err
 	}
-	if s.indexer != nil {
-		if err := s.indexer.IndexPost(ctx, search.FeedDoc{
-			PostID:       row.PostID,
-			ArtistHandle: handle,
-			CreatedAt:    row.CreatedAt,
-			BodyExcerpt:  truncateBody(row.Body, 200),
-			Explicit:     row.Explicit,
-		}); err != nil {
+	// Drafts, scheduled posts and posts of private pages stay out of the feed index.
+	if s.indexer != nil && row.published() && artist.Visibility != artists.VisibilityPrivate {
+		if err := s.indexer.IndexPost(ctx, feedDoc(handle, &row)); err != nil {
 			return nil, err
 		}
 	}
 	return rowToPost(&row), nil
 }
 
-func (s *service) ListPosts(ctx context.Context, handle string, limit int, cursor string) ([]Post, string, error) {
+// ListPosts returns a page of the artist's published posts, newest first. The first page (empty cursor) starts with
+// the pinned posts, which the time-ordered pages leave out, so a page can have fewer than limit posts.
+func (s *service) ListPosts(ctx context.Context, handle string, limit int, cursor, viewerUserID string) ([]Post, string, error) {
 	handle = normalizeHandle(handle)
-	artist, err := s.artist.GetByHandle(ctx, handle)
+	artist, err := s.artist.GetForViewer(ctx, handle, viewerUserID)
 	if err != nil || artist == nil {
 		return nil, "", ErrArtistNotFound
 	}
@@ -167,59 +282,132 @@ func (s *service) ListPosts(ctx context.Context, handle string, limit int, curso
 	if limit <= 0 || limit > 100 {
 		limit = 10
 	}
-	byTimeRows, nextCursor, err := s.store.ListByTimePage(ctx, handle, limit, cursor)
+	pins, err := s.store.GetPins(ctx, handle)
 	if err != nil {
 		return nil, "", err
 	}
-	if len(byTimeRows) == 0 {
-		return nil, "", nil
+	var rows []*postRow
+	if cursor == "" {
+		if rows, err = s.pinnedPosts(ctx, handle, pins); err != nil {
+			return nil, "", err
+		}
+	}
+	byTimeRows, nextCursor, err := s.store.ListByTimePage(ctx, handle, limit, cursor)
+	if err != nil {
+		return nil, "", err
 	}
-	postIDs := make([]string, len(byTimeRows))
+	postIDs := make([]string, 0, len(byTimeRows))
 	for i := range byTimeRows {
-		postIDs[i] = byTimeRows[i].PostID
+		if !pins.pinned(byTimeRows[i].PostID) {
+			postIDs = append(postIDs, byTimeRows[i].PostID)
+		}
 	}
-	rows, err := s.store.BatchGetPosts(ctx, handle, postIDs)
+	byTime, err := s.store.BatchGetPosts(ctx, handle, postIDs)
 	if err != nil {
 		return nil, "", err
 	}
+	rows = append(rows, byTime...)
+	if len(rows) == 0 {
+		return nil, nextCursor, nil
+	}
 	out := make([]Post, len(rows))
+	posts := make([]*Post, len(rows))
 	for i := range rows {
 		out[i] = *rowToPost(rows[i])
+		posts[i] = &out[i]
+	}
+	if err := s.setViewerReactions(ctx, handle, posts, viewerUserID); err != nil {
+		return nil, "", err
 	}
 	return out, nextCursor, nil
 }
 
-func (s *service) GetPost(ctx context.Context, handle, postID string) (*Post, error) {
+// GetPost returns the post. A former slug of the post returns a PostMovedError with the current one.
+func (s *service) GetPost(ctx context.Context, handle, postID, viewerUserID string) (*Post, error) {
 	handle = normalizeHandle(handle)
+	if artist, err := s.artist.GetForViewer(ctx, handle, viewerUserID); err != nil || artist == nil {
+		return nil, ErrPostNotFound
+	}
 	row, err := s.store.Get(ctx, handle, postID)
-	if err != nil || row == nil {
+	if err != nil {
+		return nil, ErrPostNotFound
+	}
+	moved := row == nil
+	if moved {
+		if row, err = s.resolveAlias(ctx, handle, postID); err != nil || row == nil {
+			return nil, ErrPostNotFound
+		}
+	}
+	if !row.published() {
+		if err := s.ensureCanSeeUnpublished(ctx, handle, viewerUserID); err != nil {
+			return nil, ErrPostNotFound
+		}
+	}
+	if moved {
+		return nil, &PostMovedError{PostID: row.PostID}
+	}
+	p := rowToPost(row)
+	if err := s.setViewerReactions(ctx, handle, []*Post{p}, viewerUserID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// publishedPost returns the published post the viewer can see (to comment on or react to), or ErrPostNotFound.
+func (s *service) publishedPost(ctx context.Context, handle, postID, viewerUserID string) (*postRow, error) {
+	if artist, err := s.artist.GetForViewer(ctx, handle, viewerUserID); err != nil || artist == nil {
 		return nil, ErrPostNotFound
 	}
-	return rowToPost(row), nil
+	row, err := s.store.Get(ctx, handle, postID)
+	if err != nil || row == nil || !row.published() {
+		return nil, ErrPostNotFound
+	}
+	return row, nil
 }
 
-func (s *service) UpdatePost(ctx context.Context, handle, postID string, body, imageURL, youtubeURL *string, explicit *bool, actorUserID string) (*Post, error) {
+// UpdatePost changes the fields that are not nil. A title whose slug differs from the post ID moves the post to the
+// new slug (rename.go).
+func (s *service) UpdatePost(ctx context.Context, handle, postID string, title, body, youtubeURL *string, mediaRefs *[]MediaRef, explicit *bool, status, publishAt *string, actorUserID string) (*Post, error) {
 	handle = normalizeHandle(handle)
 	artist, err := s.artist.GetByHandle(ctx, handle)
 	if err != nil || artist == nil {
 		return nil, ErrArtistNotFound
 	}
-	_ = artist
-	if err := s.ensureCanManageFeed(ctx, handle, actorUserID, artists.PermFeedUpdate); err != nil {
+	row, err := s.store.Get(ctx, handle, postID)
+	if err != nil {
+		return nil, ErrPostNotFound
+	}
+	if row == nil && title != nil {
+		// Resume: an edit to this title moved the post but did not finish moving what it keeps under its slug.
+		moved, err := s.resolveAlias(ctx, handle, postID)
+		if err != nil {
+			return nil, err
+		}
+		if moved != nil && moved.PostID == Slugify(*title) {
+			return s.resumeSlugChange(ctx, artist, postID, moved.PostID, actorUserID)
+		}
+	}
+	if err := s.ensureCanEditPost(ctx, handle, postID, actorUserID, artists.PermFeedUpdate, artists.PermFeedUpdateOwn); err != nil {
 		return nil, err
 	}
-	row, err := s.store.Get(ctx, handle, postID)
-	if err != nil || row == nil {
+	if row == nil {
 		return nil, ErrPostNotFound
 	}
+	resolvedTitle, slug := row.Title, row.PostID
+	if title != nil {
+		if resolvedTitle, slug, err = titleSlug(*title); err != nil {
+			return nil, err
+		}
+		if slug != row.PostID {
+			if existing, _ := s.store.Get(ctx, handle, slug); existing != nil {
+				return nil, ErrSlugConflict
+			}
+		}
+	}
 	resolvedBody := row.Body
 	if body != nil {
 		resolvedBody = strings.TrimSpace(*body)
 	}
-	resolvedImageURL := row.ImageURL
-	if imageURL != nil {
-		resolvedImageURL = strings.TrimSpace(*imageURL)
-	}
 	resolvedYouTubeURL := row.YouTubeURL
 	if youtubeURL != nil {
 		resolvedYouTubeURL = strings.TrimSpace(*youtubeURL)
@@ -228,31 +416,102 @@ func (s *service) UpdatePost(ctx context.Context, handle, postID string, body, i
 	if explicit != nil {
 		resolvedExplicit = *explicit
 	}
-	updatedAt := time.Now().UTC().Format(time.RFC3339)
-	if err := s.store.Update(ctx, handle, postID, resolvedBody, resolvedImageURL, resolvedYouTubeURL, resolvedExplicit, updatedAt); err != nil {
+	now := time.Now().UTC()
+	updated := *row
+	updated.PostID, updated.Title = slug, resolvedTitle
+	updated.Body, updated.YouTubeURL, updated.Explicit = resolvedBody, resolvedYouTubeURL, resolvedExplicit
+	updated.UpdatedAt = now.Format(time.RFC3339)
+	if status != nil || publishAt != nil {
+		if row.published() {
+			return nil, ErrPostPublished
+		}
+		nextStatus, nextPublishAt := row.Status, row.PublishAt
+		if status != nil {
+			nextStatus = *status
+		}
+		if publishAt != nil {
+			nextPublishAt = *publishAt
+		}
+		if updated.Status, updated.PublishAt, err = resolveStatus(nextStatus, nextPublishAt, now); err != nil {
+			return nil, err
+		}
+		if updated.Status == StatusPublished {
+			updated.PublishedAt = updated.UpdatedAt
+		}
+	}
+	if mediaRefs != nil {
+		if updated.Media, err = s.attachMedia(ctx, handle, postID, row.Media, *mediaRefs); err != nil {
+			return nil, err
+		}
+	}
+	return s.saveUpdate(ctx, artist, row, updated, actorUserID, nil)
+}
+
+// saveUpdate writes updated over row, with a new revision when the content changed, re-indexes the post and records
+// the change. When updated has a new slug, the post moves to it (rename.go). revertedTo is set when the update reverts
+// to an earlier revision. updated's images must already be attached to row's slug; images the post stops using are
+// detached once it is saved, and images it would have started using are detached again if it is not.
+func (s *service) saveUpdate(ctx context.Context, artist *artists.Artist, row *postRow, updated postRow, actorUserID string, revertedTo *int) (*Post, error) {
+	handle := normalizeHandle(artist.Handle)
+	s.renderBody(&updated)
+	var rev *revisionRow
+	edits := contentChanges(row, &updated)
+	if len(edits) > 0 {
+		updated.RevisionCount = row.RevisionCount + 1
+		rev = &revisionRow{
+			Revision:       updated.RevisionCount,
+			Body:           updated.Body,
+			ImageURL:       updated.ImageURL,
+			Media:          updated.Media,
+			YouTubeURL:     updated.YouTubeURL,
+			Explicit:       updated.Explicit,
+			EditedByUserID: actorUserID,
+			EditedAt:       updated.UpdatedAt,
+			Changes:        edits,
+			RevertedTo:     revertedTo,
+		}
+	}
+	changes := artists.AppendChange(nil, "title", row.Title, updated.Title)
+	changes = artists.AppendChange(changes, "post_id", row.PostID, updated.PostID)
+	changes = append(changes, edits...)
+	changes = artists.AppendChange(changes, "status", rowToPost(row).Status, rowToPost(&updated).Status)
+	changes = artists.AppendChange(changes, "publish_at", row.PublishAt, updated.PublishAt)
+	if revertedTo != nil {
+		changes = artists.AppendChange(changes, "reverted_to", "", strconv.Itoa(*revertedTo))
+	}
+	var activity *artists.ActivityEntry
+	if len(changes) > 0 {
+		activity = s.activityEntry(artists.ActivityEntry{Action: artists.ActivityPostUpdate, ActorUserID: actorUserID, Target: updated.PostID, Changes: changes})
+	}
+	movedSlug := updated.PostID != row.PostID
+	var err error
+	if movedSlug {
+		err = s.store.ChangeSlug(ctx, handle, row, updated, rev, updated.UpdatedAt, activity)
+	} else {
+		err = s.store.Update(ctx, handle, row, updated, rev, activity)
+	}
+	if err != nil {
+		s.detachMedia(ctx, handle, row.PostID, withoutMedia(updated.Media, row.Media))
+		if dynamo.IsCondCheckFailed(err) {
+			if movedSlug {
+				if existing, _ := s.store.Get(ctx, handle, updated.PostID); existing != nil {
+					return nil, ErrSlugConflict
+				}
+			}
+			return nil, ErrPostChanged
+		}
 		return nil, err
 	}
-	if s.indexer != nil {
-		_ = s.indexer.IndexPost(ctx, search.FeedDoc{
-			PostID:       postID,
-			ArtistHandle: handle,
-			CreatedAt:    row.CreatedAt,
-			BodyExcerpt:  truncateBody(resolvedBody, 200),
-			Explicit:     resolvedExplicit,
-		})
-	}
-	return &Post{
-		PostID:          postID,
-		ArtistHandle:    handle,
-		Title:           row.Title,
-		Body:            resolvedBody,
-		ImageURL:        resolvedImageURL,
-		YouTubeURL:      resolvedYouTubeURL,
-		Explicit:        resolvedExplicit,
-		CreatedAt:       row.CreatedAt,
-		UpdatedAt:       updatedAt,
-		CreatedByUserID: row.CreatedByUserID,
-	}, nil
+	s.detachMedia(ctx, handle, row.PostID, withoutMedia(row.Media, updated.Media))
+	if s.indexer != nil && !movedSlug && updated.published() && artist.Visibility != artists.VisibilityPrivate {
+		_ = s.indexer.IndexPost(ctx, feedDoc(handle, &updated))
+	}
+	if movedSlug {
+		if err := s.moveSlugData(ctx, artist, row.PostID, &updated); err != nil {
+			return nil, err
+		}
+	}
+	return rowToPost(&updated), nil
 }
 
 func (s *service) DeletePost(ctx context.Context, handle, postID string, actorUserID string) error {
@@ -262,27 +521,38 @@ func (s *service) DeletePost(ctx context.Context, handle, postID string, actorUs
 		return ErrArtistNotFound
 	}
 	_ = artist
-	if err := s.ensureCanManageFeed(ctx, handle, actorUserID, artists.PermFeedDelete); err != nil {
+	if err := s.ensureCanEditPost(ctx, handle, postID, actorUserID, artists.PermFeedDelete, artists.PermFeedDeleteOwn); err != nil {
 		return err
 	}
 	row, err := s.store.Get(ctx, handle, postID)
 	if err != nil || row == nil {
 		return ErrPostNotFound
 	}
-	if err := s.store.Delete(ctx, handle, postID); err != nil {
+	activity := s.activityEntry(artists.ActivityEntry{
+		Action:      artists.ActivityPostDelete,
+		ActorUserID: actorUserID,
+		Target:      postID,
+		Changes:     artists.AppendChange(nil, "title", row.Title, ""),
+	})
+	if err := s.store.Delete(ctx, handle, postID, activity); err != nil {
 		return err
 	}
+	s.detachMedia(ctx, handle, postID, row.Media)
 	if s.indexer != nil {
 		_ = s.indexer.DeletePost(ctx, handle, postID)
 	}
 	return nil
 }
 
-func truncateBody(s string, max int) string {
-	if len(s) <= max {
-		return s
+// feedDoc is the post's feed index document under handle.
+func feedDoc(handle string, r *postRow) search.FeedDoc {
+	return search.FeedDoc{
+		PostID:       r.PostID,
+		ArtistHandle: normalizeHandle(handle),
+		CreatedAt:    r.listedAt(),
+		BodyExcerpt:  rendered(r).Excerpt,
+		Explicit:     r.Explicit,
 	}
-	return s[:max]
 }
 
 // MyFeed returns the collated feed for the user (posts from artists they follow), hydrated from DynamoDB.
@@ -295,8 +565,24 @@ func (s *service) MyFeed(ctx context.Context, userID string, limit int, cursor s
 	if err != nil || len(handles) == 0 {
 		return nil, "", err
 	}
+	excludeExplicit := false
+	if s.prefs != nil {
+		p, err := s.prefs.GetPreferences(ctx, userID)
+		if err != nil {
+			return nil, "", err
+		}
+		muted, err := s.currentHandles(ctx, handles, p.FeedFilters.MutedArtists)
+		if err != nil {
+			return nil, "", err
+		}
+		handles = withoutMuted(handles, muted)
+		excludeExplicit = !p.ShowExplicit
+		if len(handles) == 0 {
+			return nil, "", nil
+		}
+	}
 	// Request limit+1 to detect if there are more results
-	refs, nextCursor, err := s.feedIndex.SearchFeed(ctx, handles, limit+1, cursor)
+	refs, nextCursor, err := s.feedIndex.SearchFeed(ctx, handles, limit+1, cursor, excludeExplicit)
 	if err != nil {
 		return nil, "", err
 	}
@@ -311,9 +597,24 @@ func (s *service) MyFeed(ctx context.Context, userID string, limit int, cursor s
 	for _, r := range refs {
 		byHandle[r.ArtistHandle] = append(byHandle[r.ArtistHandle], r.PostID)
 	}
+	// Skip artists that are gone or pending deletion (their posts stay indexed until purge), and private pages whose
+	// documents are still being removed.
+	var live map[string]*artists.Artist
+	if s.artist != nil {
+		handles := make([]string, 0, len(byHandle))
+		for handle := range byHandle {
+			handles = append(handles, handle)
+		}
+		if live, err = s.artist.GetByHandles(ctx, handles); err != nil {
+			return nil, "", err
+		}
+	}
 	// Fetch full posts from DynamoDB per artist
 	postMap := make(map[string]map[string]*Post) // handle -> postID -> Post
 	for handle, postIDs := range byHandle {
+		if s.artist != nil && (live[handle] == nil || live[handle].Visibility == artists.VisibilityPrivate) {
+			continue
+		}
 		rows, err := s.store.BatchGetPosts(ctx, handle, postIDs)
 		if err != nil {
 			return nil, "", err
@@ -321,9 +622,17 @@ func (s *service) MyFeed(ctx context.Context, userID string, limit int, cursor s
 		if postMap[handle] == nil {
 			postMap[handle] = make(map[string]*Post)
 		}
+		var posts []*Post
 		for _, row := range rows {
+			if !row.published() {
+				continue
+			}
 			p := rowToPost(row)
 			postMap[handle][p.PostID] = p
+			posts = append(posts, p)
+		}
+		if err := s.setViewerReactions(ctx, handle, posts, userID); err != nil {
+			return nil, "", err
 		}
 	}
 	// Assemble in refs order
@@ -336,20 +645,98 @@ func (s *service) MyFeed(ctx context.Context, userID string, limit int, cursor s
 	return out, nextCursor, nil
 }
 
+// currentHandles maps muted handles the user no longer follows under that name to the artist's current handle,
+// so a mute survives an artist rename (follows move to the new handle; the muted list is not rewritten). The
+// handles are resolved together in batched reads.
+func (s *service) currentHandles(ctx context.Context, followed, muted []string) ([]string, error) {
+	if len(muted) == 0 || s.artist == nil {
+		return muted, nil
+	}
+	isFollowed := make(map[string]bool, len(followed))
+	for _, h := range followed {
+		isFollowed[h] = true
+	}
+	var unfollowed []string
+	for _, h := range muted {
+		if !isFollowed[h] {
+			unfollowed = append(unfollowed, h)
+		}
+	}
+	if len(unfollowed) == 0 {
+		return muted, nil
+	}
+	current, err := s.artist.CurrentHandles(ctx, unfollowed)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]string(nil), muted...)
+	for _, h := range unfollowed {
+		if c, ok := current[h]; ok {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func withoutMuted(handles, muted []string) []string {
+	if len(muted) == 0 {
+		return handles
+	}
+	skip := make(map[string]bool, len(muted))
+	for _, h := range muted {
+		skip[h] = true
+	}
+	out := make([]string, 0, len(handles))
+	for _, h := range handles {
+		if !skip[h] {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
 func rowToPost(r *postRow) *Post {
 	if r == nil {
 		return nil
 	}
-	return &Post{
+	r = rendered(r)
+	p := &Post{
 		PostID:          r.PostID,
 		ArtistHandle:    r.ArtistHandle,
 		Title:           r.Title,
 		Body:            r.Body,
+		BodyHTML:        r.BodyHTML,
+		Excerpt:         r.Excerpt,
+		Links:           r.Links,
+		Mentions:        r.Mentions,
 		ImageURL:        r.ImageURL,
+		Media:           r.Media,
 		YouTubeURL:      r.YouTubeURL,
 		Explicit:        r.Explicit,
 		CreatedAt:       r.CreatedAt,
 		UpdatedAt:       r.UpdatedAt,
 		CreatedByUserID: r.CreatedByUserID,
+		Status:          StatusPublished,
+		PublishAt:       r.PublishAt,
+		PublishedAt:     r.PublishedAt,
+		Edited:          r.RevisionCount > 0,
+		RevisionCount:   r.RevisionCount,
+		CommentCount:    r.CommentCount,
+		ReactionCounts:  r.byType(),
+		Pinned:          r.PinnedAt != "",
+		PinnedAt:        r.PinnedAt,
+	}
+	if r.Status != "" {
+		p.Status = r.Status
+	}
+	if p.Media == nil {
+		p.Media = []PostMedia{}
+	}
+	if p.Links == nil {
+		p.Links = []string{}
+	}
+	if p.Mentions == nil {
+		p.Mentions = []string{}
 	}
+	return p
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/guregu/dynamo/v2"
 
+	"github.com/sopatech/afterwave.fm/internal/artists"
 	"github.com/sopatech/afterwave.fm/internal/infra"
 )
 
@@ -47,7 +48,12 @@ func lastRune(s string) rune {
 
 // Posts live under the same table as artists. Two-row pattern (no GSI):
 // - Main row: PK = ARTISTS#<handle>, SK = POST#<post_id> — full post data.
-// - Index row: PK = ARTISTS#<handle>, SK = POST#BYTIME#<created_at>#<post_id> — for List by time (desc).
+// - Index row: PK = ARTISTS#<handle>, SK = POST#BYTIME#<listed_at>#<post_id> — for List by time (desc). Published
+//   posts only; listed_at is published_at, or created_at for posts published on creation.
+// Drafts and scheduled posts are in the unpublished index instead; scheduled posts are also in the schedule index
+// (schedule_store.go).
+// Content edits are kept as revision rows (revision_store.go). A post whose slug changed leaves an alias row at the old
+// slug (rename_store.go).
 
 const (
 	artistPKPrefix   = "ARTISTS#"
@@ -68,18 +74,69 @@ func postByTimeSK(createdAt, postID string) string {
 }
 
 type postRow struct {
-	PK              string `dynamo:"pk"`
-	SK              string `dynamo:"sk"`
-	PostID          string `dynamo:"post_id"` // slug (unique per artist)
-	ArtistHandle    string `dynamo:"artist_handle"`
-	Title           string `dynamo:"title"`
-	Body            string `dynamo:"body"`
-	ImageURL        string `dynamo:"image_url,omitempty"`
-	YouTubeURL      string `dynamo:"youtube_url,omitempty"`
-	Explicit        bool   `dynamo:"explicit"`
-	CreatedAt       string `dynamo:"created_at"`
-	UpdatedAt       string `dynamo:"updated_at,omitempty"`
-	CreatedByUserID string `dynamo:"created_by_user_id"`
+	PK              string      `dynamo:"pk"`
+	SK              string      `dynamo:"sk"`
+	PostID          string      `dynamo:"post_id"` // slug (unique per artist)
+	ArtistHandle    string      `dynamo:"artist_handle"`
+	Title           string      `dynamo:"title"`
+	Body            string      `dynamo:"body"`
+	BodyHTML        string      `dynamo:"body_html,omitempty"` // rendered on write (markdown.go)
+	Excerpt         string      `dynamo:"excerpt,omitempty"`
+	Links           []string    `dynamo:"links,omitempty"`
+	Mentions        []string    `dynamo:"mentions,omitempty"`
+	ImageURL        string      `dynamo:"image_url,omitempty"`
+	Media           []PostMedia `dynamo:"media,omitempty"` // hosted images, in order (media.go)
+	YouTubeURL      string      `dynamo:"youtube_url,omitempty"`
+	Explicit        bool        `dynamo:"explicit"`
+	CreatedAt       string      `dynamo:"created_at"`
+	UpdatedAt       string      `dynamo:"updated_at,omitempty"`
+	CreatedByUserID string      `dynamo:"created_by_user_id"`
+	Status          string      `dynamo:"status,omitempty"`         // empty = StatusPublished (posts from before drafts)
+	PublishAt       string      `dynamo:"publish_at,omitempty"`     // while scheduled
+	PublishedAt     string      `dynamo:"published_at,omitempty"`   // set when a draft or scheduled post is published
+	RevisionCount   int         `dynamo:"revision_count,omitempty"` // content edits so far (revision_store.go)
+	CommentCount    int         `dynamo:"comment_count,omitempty"`  // kept by comment writes (comment_store.go)
+	PinnedAt        string      `dynamo:"pinned_at,omitempty"`      // while pinned (pin_store.go)
+	reactionCounts              // kept by reaction writes (reaction_store.go)
+}
+
+// published reports whether the post is public (in the BYTIME index and the feed index).
+func (r *postRow) published() bool {
+	return r.Status == "" || r.Status == StatusPublished
+}
+
+// listedAt is when the post appeared in the feed: its BYTIME and feed index timestamp.
+func (r *postRow) listedAt() string {
+	if r.PublishedAt != "" {
+		return r.PublishedAt
+	}
+	return r.CreatedAt
+}
+
+// byTimeRow returns the post's BYTIME index row under handle.
+func (r *postRow) byTimeRow(handle string) postByTimeRow {
+	return postByTimeRow{
+		PK:        artistPK(handle),
+		SK:        postByTimeSK(r.listedAt(), r.PostID),
+		PostID:    r.PostID,
+		CreatedAt: r.listedAt(),
+	}
+}
+
+// updateCondition guards a write against a concurrent status change (e.g. the scheduler publishing the post) or a
+// concurrent edit (another revision).
+func updateCondition(r *postRow) (string, []any) {
+	var cond string
+	var args []any
+	if r.Status == "" {
+		cond, args = "attribute_exists(pk) AND attribute_not_exists($)", []any{"status"}
+	} else {
+		cond, args = "$ = ?", []any{"status", r.Status}
+	}
+	if r.RevisionCount == 0 {
+		return cond + " AND attribute_not_exists($)", append(args, "revision_count")
+	}
+	return cond + " AND $ = ?", append(args, "revision_count", r.RevisionCount)
 }
 
 type postByTimeRow struct {
@@ -102,8 +159,18 @@ func (s *Store) tbl() dynamo.Table {
 	return s.db.Table(s.tableName)
 }
 
-// Create writes the main post row and the BYTIME index row in one transaction.
-func (s *Store) Create(ctx context.Context, handle string, row postRow) error {
+// withActivity adds the activity entry (nil for none) to tx, so the change and its entry are saved together.
+func (s *Store) withActivity(tx *dynamo.WriteTx, handle string, activity *artists.ActivityEntry) *dynamo.WriteTx {
+	if activity == nil {
+		return tx
+	}
+	return tx.Put(artists.ActivityPut(s.tbl(), handle, *activity))
+}
+
+// Create writes the main post row and, for a published post, the BYTIME index row (scheduled: the schedule index
+// row) with the activity entry in one transaction, removing any alias left at the slug by a post that moved away
+// from it. Fails with a condition check if a post already has the slug.
+func (s *Store) Create(ctx context.Context, handle string, row postRow, activity *artists.ActivityEntry) error {
 	handle = normalizeHandle(handle)
 	pk := artistPK(handle)
 	mainRow := postRow{
@@ -113,23 +180,34 @@ func (s *Store) Create(ctx context.Context, handle string, row postRow) error {
 		ArtistHandle:    handle,
 		Title:           row.Title,
 		Body:            row.Body,
+		BodyHTML:        row.BodyHTML,
+		Excerpt:         row.Excerpt,
+		Links:           row.Links,
+		Mentions:        row.Mentions,
 		ImageURL:        row.ImageURL,
+		Media:           row.Media,
 		YouTubeURL:      row.YouTubeURL,
 		Explicit:        row.Explicit,
 		CreatedAt:       row.CreatedAt,
 		UpdatedAt:       row.UpdatedAt,
 		CreatedByUserID: row.CreatedByUserID,
+		Status:          row.Status,
+		PublishAt:       row.PublishAt,
+		PublishedAt:     row.PublishedAt,
 	}
-	byTimeRow := postByTimeRow{
-		PK:        pk,
-		SK:        postByTimeSK(row.CreatedAt, row.PostID),
-		PostID:    row.PostID,
-		CreatedAt: row.CreatedAt,
+	tx := s.db.WriteTx().
+		Put(s.tbl().Put(mainRow).If("attribute_not_exists(pk)")).
+		Delete(s.tbl().Delete("pk", pk).Range("sk", postAliasSK(row.PostID)))
+	switch {
+	case mainRow.published():
+		tx = tx.Put(s.tbl().Put(mainRow.byTimeRow(handle)))
+	case mainRow.Status == StatusScheduled:
+		tx = tx.Put(s.tbl().Put(scheduleRowFor(handle, &mainRow)))
 	}
-	return s.db.WriteTx().
-		Put(s.tbl().Put(mainRow)).
-		Put(s.tbl().Put(byTimeRow)).
-		Run(ctx)
+	if !mainRow.published() {
+		tx = tx.Put(s.tbl().Put(unpublishedRow(handle, mainRow.PostID)))
+	}
+	return s.withActivity(tx, handle, activity).Run(ctx)
 }
 
 // Get returns the post by handle and post ID, or nil if not found.
@@ -219,28 +297,94 @@ func (s *Store) ListByTimePage(ctx context.Context, handle string, limit int, cu
 	return rows, next, nil
 }
 
-// Update updates the main post row (body, image_url, youtube_url, explicit, updated_at).
-func (s *Store) Update(ctx context.Context, handle, postID, body, imageURL, youtubeURL string, explicit bool, updatedAt string) error {
+// Update writes next's editable fields (title, body, image_url, media, youtube_url, explicit, updated_at, status, publish_at,
+// published_at, revision_count) to the main post row, writes rev (nil when the content did not change), and moves
+// the post between the schedule index and the BYTIME index when its status changes, with the activity entry, in one
+// transaction. Fails with a condition check if the post's status or revision changed since prev was read.
+func (s *Store) Update(ctx context.Context, handle string, prev *postRow, next postRow, rev *revisionRow, activity *artists.ActivityEntry) error {
 	handle = normalizeHandle(handle)
-	return s.tbl().Update("pk", artistPK(handle)).Range("sk", postSK(postID)).
-		Set("body", body).
-		Set("image_url", imageURL).
-		Set("youtube_url", youtubeURL).
-		Set("explicit", explicit).
-		Set("updated_at", updatedAt).
-		Run(ctx)
+	cond, args := updateCondition(prev)
+	upd := s.tbl().Update("pk", artistPK(handle)).Range("sk", postSK(prev.PostID)).
+		Set("title", next.Title).
+		Set("body", next.Body).
+		Set("image_url", next.ImageURL).
+		Set("youtube_url", next.YouTubeURL).
+		Set("explicit", next.Explicit).
+		Set("updated_at", next.UpdatedAt).
+		If(cond, args...)
+	if len(next.Media) > 0 {
+		upd = upd.Set("media", next.Media)
+	} else {
+		upd = upd.Remove("media")
+	}
+	for name, value := range map[string]string{"body_html": next.BodyHTML, "excerpt": next.Excerpt} {
+		if value != "" {
+			upd = upd.Set(name, value)
+		} else {
+			upd = upd.Remove(name)
+		}
+	}
+	for name, values := range map[string][]string{"links": next.Links, "mentions": next.Mentions} {
+		if len(values) > 0 {
+			upd = upd.Set(name, values)
+		} else {
+			upd = upd.Remove(name)
+		}
+	}
+	if next.Status != "" {
+		upd = upd.Set("status", next.Status)
+	}
+	if next.PublishAt != "" {
+		upd = upd.Set("publish_at", next.PublishAt)
+	} else {
+		upd = upd.Remove("publish_at")
+	}
+	if next.PublishedAt != "" {
+		upd = upd.Set("published_at", next.PublishedAt)
+	}
+	if rev != nil {
+		upd = upd.Set("revision_count", next.RevisionCount)
+	}
+	tx := s.db.WriteTx().Update(upd)
+	if rev != nil {
+		rev.PK, rev.SK, rev.PostID = artistPK(handle), revisionSK(prev.PostID, rev.Revision), prev.PostID
+		tx = tx.Put(s.tbl().Put(*rev).If("attribute_not_exists(pk)"))
+	}
+	if prev.Status == StatusScheduled && (next.Status != StatusScheduled || next.PublishAt != prev.PublishAt) {
+		tx = tx.Delete(s.tbl().Delete("pk", schedulePK(prev.PublishAt)).Range("sk", scheduleSK(prev.PublishAt, handle, prev.PostID)))
+	}
+	if next.Status == StatusScheduled && (prev.Status != StatusScheduled || next.PublishAt != prev.PublishAt) {
+		tx = tx.Put(s.tbl().Put(scheduleRowFor(handle, &next)))
+	}
+	if !prev.published() && next.published() {
+		tx = tx.Put(s.tbl().Put(next.byTimeRow(handle))).
+			Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", postUnpublishedSK(prev.PostID)))
+	}
+	return s.withActivity(tx, handle, activity).Run(ctx)
 }
 
-// Delete removes the main post row and the BYTIME index row.
-func (s *Store) Delete(ctx context.Context, handle, postID string) error {
+// Delete removes the post's revisions, comments and reactions and unpins it, then the main post row and its BYTIME or
+// schedule index row with the activity entry.
+func (s *Store) Delete(ctx context.Context, handle, postID string, activity *artists.ActivityEntry) error {
 	main, err := s.Get(ctx, handle, postID)
 	if err != nil || main == nil {
 		return err
 	}
-	return s.db.WriteTx().
-		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", postSK(postID))).
-		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", postByTimeSK(main.CreatedAt, postID))).
-		Run(ctx)
+	if err := s.deleteRevisions(ctx, handle, postID); err != nil {
+		return err
+	}
+	if err := s.deleteComments(ctx, handle, postID); err != nil {
+		return err
+	}
+	if err := s.deleteReactions(ctx, handle, postID); err != nil {
+		return err
+	}
+	if main.PinnedAt != "" {
+		if err := s.unpinDeleted(ctx, handle, postID); err != nil {
+			return err
+		}
+	}
+	return s.deletePost(ctx, handle, *main, activity)
 }
 
 func normalizeHandle(s string) string {
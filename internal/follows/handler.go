@@ -3,6 +3,8 @@ package follows
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/sopatech/afterwave.fm/internal/auth"
 )
@@ -75,3 +77,49 @@ func (h *Handler) ListFollowing(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{"handles": handles})
 }
+
+// Insights returns aggregate follower counts for the artist (page members only). Query: days (daily series length,
+// default 30), windows (comma-separated net growth windows in days, default 7,30,90); each 1–365.
+func (h *Handler) Insights(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	days := 0
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, ErrInvalidWindow.Error(), http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	var windows []int
+	if s := r.URL.Query().Get("windows"); s != "" {
+		for _, part := range strings.Split(s, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				http.Error(w, ErrInvalidWindow.Error(), http.StatusBadRequest)
+				return
+			}
+			windows = append(windows, n)
+		}
+	}
+	out, err := h.svc.Insights(r.Context(), r.PathValue("handle"), days, windows, userID)
+	if err != nil {
+		switch {
+		case err == ErrArtistNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		case err == ErrForbidden:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case err == ErrInvalidWindow:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
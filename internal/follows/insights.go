@@ -0,0 +1,177 @@
+package follows
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// Follower insights: aggregate follow/unfollow counts per UTC day for page members. Closed days are rolled up into
+// daily rows the first time they are read; today is counted live from the follow rows and unfollow events.
+
+var (
+	ErrForbidden     = errors.New("forbidden")
+	ErrInvalidWindow = errors.New("days and windows must be between 1 and 365")
+)
+
+const (
+	defaultInsightDays = 30
+	maxInsightDays     = 365
+	dateLayout         = "2006-01-02"
+)
+
+// DefaultInsightWindows are the net growth windows (in days) returned when none are requested.
+var DefaultInsightWindows = []int{7, 30, 90}
+
+// DayCount is one UTC day's follows and unfollows.
+type DayCount struct {
+	Date      string `json:"date"`
+	Follows   int    `json:"follows"`
+	Unfollows int    `json:"unfollows"`
+	Net       int    `json:"net"`
+}
+
+// WindowCount is the follows, unfollows and net growth over the last Days days, today included.
+type WindowCount struct {
+	Days      int `json:"days"`
+	Follows   int `json:"follows"`
+	Unfollows int `json:"unfollows"`
+	Net       int `json:"net"`
+}
+
+// Insights is what page members see about followers: totals and counts, never who.
+type Insights struct {
+	TotalFollowers int           `json:"total_followers"`
+	Daily          []DayCount    `json:"daily"`
+	Windows        []WindowCount `json:"windows"`
+}
+
+// Insights returns the last days days of follow/unfollow counts (oldest first, zero-filled) and net growth over each
+// window. Any page member (artist:list_members) can read them. days 0 and nil windows use the defaults.
+func (s *service) Insights(ctx context.Context, handle string, days int, windows []int, actorUserID string) (*Insights, error) {
+	handle = normalizeHandle(handle)
+	artist, err := s.artist.GetByHandle(ctx, handle)
+	if err != nil || artist == nil {
+		return nil, ErrArtistNotFound
+	}
+	ok, err := s.artist.HasPermission(ctx, handle, actorUserID, artists.PermArtistListMembers)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+	if days == 0 {
+		days = defaultInsightDays
+	}
+	if windows == nil {
+		windows = DefaultInsightWindows
+	}
+	span := days
+	for _, w := range append([]int{days}, windows...) {
+		if w < 1 || w > maxInsightDays {
+			return nil, ErrInvalidWindow
+		}
+		span = max(span, w)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	live, err := s.rollUp(ctx, handle, today)
+	if err != nil {
+		return nil, err
+	}
+	from := today.AddDate(0, 0, -(span - 1)).Format(dateLayout)
+	rows, err := s.store.dailyStatsFrom(ctx, handle, from)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]DayCount, len(rows)+1)
+	for _, r := range rows {
+		counts[r.Date] = DayCount{Date: r.Date, Follows: r.Follows, Unfollows: r.Unfollows}
+	}
+	counts[live.Date] = live
+
+	series := make([]DayCount, span) // oldest first
+	for i := range series {
+		date := today.AddDate(0, 0, i-(span-1)).Format(dateLayout)
+		c := counts[date]
+		c.Date, c.Net = date, c.Follows-c.Unfollows
+		series[i] = c
+	}
+	out := &Insights{TotalFollowers: artist.FollowerCount, Daily: series[span-days:]}
+	for _, w := range windows {
+		wc := WindowCount{Days: w}
+		for _, c := range series[span-w:] {
+			wc.Follows += c.Follows
+			wc.Unfollows += c.Unfollows
+		}
+		wc.Net = wc.Follows - wc.Unfollows
+		out.Windows = append(out.Windows, wc)
+	}
+	return out, nil
+}
+
+// rollUp writes daily rollups for closed days not rolled up yet and returns today's live counts.
+//
+// A day's follows are the follow rows plus the unfollow events whose followed_at falls on it: unfollowing moves a
+// follow from one to the other in a transaction, so once a day has ended its counts never change.
+func (s *service) rollUp(ctx context.Context, handle string, today time.Time) (DayCount, error) {
+	through, err := s.store.rolledThrough(ctx, handle)
+	if err != nil {
+		return DayCount{}, err
+	}
+	since := ""
+	if through != "" {
+		t, err := time.Parse(dateLayout, through)
+		if err != nil {
+			return DayCount{}, err
+		}
+		since = t.AddDate(0, 0, 1).Format(dateLayout)
+	}
+	followTimes, err := s.store.followTimesSince(ctx, handle, since)
+	if err != nil {
+		return DayCount{}, err
+	}
+	unfollows, err := s.store.unfollowsSince(ctx, handle, since)
+	if err != nil {
+		return DayCount{}, err
+	}
+	byDay := make(map[string]*dailyStatsRow)
+	day := func(ts string) *dailyStatsRow {
+		date := ts[:min(len(ts), len(dateLayout))]
+		if byDay[date] == nil {
+			byDay[date] = &dailyStatsRow{Date: date}
+		}
+		return byDay[date]
+	}
+	for _, ts := range followTimes {
+		day(ts).Follows++
+	}
+	for _, e := range unfollows {
+		if e.FollowedAt >= since {
+			day(e.FollowedAt).Follows++
+		}
+		day(e.UnfollowedAt).Unfollows++
+	}
+
+	todayDate := today.Format(dateLayout)
+	live := DayCount{Date: todayDate}
+	var closed []dailyStatsRow
+	for date, r := range byDay {
+		switch {
+		case date == todayDate:
+			live.Follows, live.Unfollows = r.Follows, r.Unfollows
+		case date < todayDate:
+			closed = append(closed, *r)
+		}
+	}
+	yesterday := today.AddDate(0, 0, -1).Format(dateLayout)
+	if through < yesterday {
+		if err := s.store.putDailyStats(ctx, handle, closed, yesterday); err != nil {
+			return DayCount{}, err
+		}
+	}
+	return live, nil
+}
@@ -0,0 +1,156 @@
+package follows
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+)
+
+// Follower insights, in the artist partition. Artists only ever see aggregates, so unfollow events carry no user id.
+// - Unfollow event: PK = ARTISTS#<handle>, SK = UNFOLLOWED#<unfollowed_at>#<id> — followed_at, unfollowed_at.
+// - Daily rollup: PK = ARTISTS#<handle>, SK = FOLLOWSTATS#<YYYY-MM-DD> — follows, unfollows for a closed UTC day
+//   (only days with activity have a row).
+// - Rollup marker: PK = ARTISTS#<handle>, SK = FOLLOWSTATS — rolled_through, the last day rollups were written for.
+
+const (
+	unfollowedSKPrefix = "UNFOLLOWED#"
+	followStatsSK      = "FOLLOWSTATS"
+	followStatsPrefix  = "FOLLOWSTATS#"
+	skRangeEnd         = "~" // sorts after every timestamp and date in an SK
+)
+
+type unfollowEventRow struct {
+	PK           string `dynamo:"pk"`
+	SK           string `dynamo:"sk"`
+	FollowedAt   string `dynamo:"followed_at"`
+	UnfollowedAt string `dynamo:"unfollowed_at"`
+}
+
+type dailyStatsRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	Date      string `dynamo:"date"`
+	Follows   int    `dynamo:"follows"`
+	Unfollows int    `dynamo:"unfollows"`
+}
+
+type statsMarkerRow struct {
+	PK            string `dynamo:"pk"`
+	SK            string `dynamo:"sk"`
+	RolledThrough string `dynamo:"rolled_through"`
+}
+
+func unfollowedSK(unfollowedAt, id string) string {
+	return unfollowedSKPrefix + unfollowedAt + "#" + id
+}
+
+func dailyStatsSK(date string) string {
+	return followStatsPrefix + date
+}
+
+// followTimesSince returns followed_at of current follows at or after since (a date or timestamp; empty for all).
+func (s *Store) followTimesSince(ctx context.Context, handle, since string) ([]string, error) {
+	var rows []struct {
+		FollowedAt string `dynamo:"followed_at"`
+	}
+	err := s.tbl().Get("pk", artistPK(handle)).
+		Range("sk", dynamo.Between, followedSKPrefix+since, followedSKPrefix+skRangeEnd).
+		Project("followed_at").All(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(rows))
+	for i, r := range rows {
+		out[i] = r.FollowedAt
+	}
+	return out, nil
+}
+
+// unfollowsSince returns unfollow events at or after since (a date or timestamp; empty for all).
+func (s *Store) unfollowsSince(ctx context.Context, handle, since string) ([]unfollowEventRow, error) {
+	var rows []unfollowEventRow
+	err := s.tbl().Get("pk", artistPK(handle)).
+		Range("sk", dynamo.Between, unfollowedSKPrefix+since, unfollowedSKPrefix+skRangeEnd).
+		All(ctx, &rows)
+	return rows, err
+}
+
+// rolledThrough returns the last day daily rollups were written for, or "" if none yet.
+func (s *Store) rolledThrough(ctx context.Context, handle string) (string, error) {
+	var row statsMarkerRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, followStatsSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return row.RolledThrough, nil
+}
+
+// putDailyStats writes the rollups, then moves the marker to through. Rollups for closed days never change, so an
+// interrupted run is simply repeated by the next read.
+func (s *Store) putDailyStats(ctx context.Context, handle string, rows []dailyStatsRow, through string) error {
+	if len(rows) > 0 {
+		items := make([]any, len(rows))
+		for i, r := range rows {
+			r.PK, r.SK = artistPK(handle), dailyStatsSK(r.Date)
+			items[i] = r
+		}
+		if _, err := s.tbl().Batch("pk", "sk").Write().Put(items...).Run(ctx); err != nil {
+			return err
+		}
+	}
+	return s.tbl().Put(statsMarkerRow{PK: artistPK(handle), SK: followStatsSK, RolledThrough: through}).Run(ctx)
+}
+
+// dailyStatsFrom returns the daily rollups from the given date on, oldest first.
+func (s *Store) dailyStatsFrom(ctx context.Context, handle, from string) ([]dailyStatsRow, error) {
+	var rows []dailyStatsRow
+	err := s.tbl().Get("pk", artistPK(handle)).
+		Range("sk", dynamo.Between, dailyStatsSK(from), followStatsPrefix+skRangeEnd).
+		All(ctx, &rows)
+	return rows, err
+}
+
+// moveInsights moves unfollow events, daily rollups and the marker from oldHandle to newHandle. Rows are copied
+// before the old ones are removed, so an interrupted run can be repeated.
+func (s *Store) moveInsights(ctx context.Context, oldHandle, newHandle string) error {
+	var events []unfollowEventRow
+	if err := s.tbl().Get("pk", artistPK(oldHandle)).Range("sk", dynamo.BeginsWith, unfollowedSKPrefix).All(ctx, &events); err != nil {
+		return err
+	}
+	var stats []dailyStatsRow
+	if err := s.tbl().Get("pk", artistPK(oldHandle)).Range("sk", dynamo.BeginsWith, followStatsPrefix).All(ctx, &stats); err != nil {
+		return err
+	}
+	through, err := s.rolledThrough(ctx, oldHandle)
+	if err != nil {
+		return err
+	}
+	var puts []any
+	var keys []dynamo.Keyed
+	for _, r := range events {
+		keys = append(keys, dynamo.Keys{r.PK, r.SK})
+		r.PK = artistPK(newHandle)
+		puts = append(puts, r)
+	}
+	for _, r := range stats {
+		keys = append(keys, dynamo.Keys{r.PK, r.SK})
+		r.PK = artistPK(newHandle)
+		puts = append(puts, r)
+	}
+	if through != "" {
+		keys = append(keys, dynamo.Keys{artistPK(oldHandle), followStatsSK})
+		puts = append(puts, statsMarkerRow{PK: artistPK(newHandle), SK: followStatsSK, RolledThrough: through})
+	}
+	if len(puts) == 0 {
+		return nil
+	}
+	if _, err := s.tbl().Batch("pk", "sk").Write().Put(puts...).Run(ctx); err != nil {
+		return err
+	}
+	_, err = s.tbl().Batch("pk", "sk").Write().Delete(keys...).Run(ctx)
+	return err
+}
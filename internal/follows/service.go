@@ -9,9 +9,12 @@ import (
 
 var ErrArtistNotFound = errors.New("artist not found")
 
-// ArtistResolver is used to verify artist exists when following.
+// ArtistResolver is used to verify artist exists when following (private pages only for members), and that the actor
+// is a page member for follower insights. Implemented by artists.Service.
 type ArtistResolver interface {
 	GetByHandle(ctx context.Context, handle string) (*artists.Artist, error)
+	GetForViewer(ctx context.Context, handle, viewerUserID string) (*artists.Artist, error)
+	HasPermission(ctx context.Context, handle, userID, permission string) (bool, error)
 }
 
 type Service interface {
@@ -19,6 +22,7 @@ type Service interface {
 	Unfollow(ctx context.Context, userID string, handle string) error
 	ListFollowing(ctx context.Context, userID string) ([]string, error)
 	IsFollowing(ctx context.Context, userID string, handle string) (bool, error)
+	Insights(ctx context.Context, handle string, days int, windows []int, actorUserID string) (*Insights, error)
 }
 
 type service struct {
@@ -32,7 +36,7 @@ func NewService(store *Store, artist ArtistResolver) Service {
 
 func (s *service) Follow(ctx context.Context, userID string, handle string) error {
 	handle = normalizeHandle(handle)
-	artist, err := s.artist.GetByHandle(ctx, handle)
+	artist, err := s.artist.GetForViewer(ctx, handle, userID)
 	if err != nil || artist == nil {
 		return ErrArtistNotFound
 	}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/guregu/dynamo/v2"
 
 	"github.com/sopatech/afterwave.fm/internal/infra"
@@ -107,7 +108,8 @@ func (s *Store) getUserFollowRow(ctx context.Context, userID string, handle stri
 	return &row, nil
 }
 
-// Unfollow removes both rows. Idempotent. Returns true if a follow was actually removed (so caller can decrement count).
+// Unfollow removes both rows and records an unfollow event for follower insights. Idempotent. Returns true if a
+// follow was actually removed (so caller can decrement count).
 func (s *Store) Unfollow(ctx context.Context, userID string, handle string) (removed bool, err error) {
 	handle = normalizeHandle(handle)
 	if userID == "" || handle == "" {
@@ -118,10 +120,18 @@ func (s *Store) Unfollow(ctx context.Context, userID string, handle string) (rem
 		return false, err
 	}
 	sk := followedSK(userRow.FollowedAt, userID)
-	// Single transaction: delete both follow rows + decrement artist follower_count (condition: count >= 1)
+	unfollowedAt := time.Now().UTC().Format(time.RFC3339)
+	event := unfollowEventRow{
+		PK:           artistPK(handle),
+		SK:           unfollowedSK(unfollowedAt, uuid.New().String()),
+		FollowedAt:   userRow.FollowedAt,
+		UnfollowedAt: unfollowedAt,
+	}
+	// Single transaction: delete both follow rows + unfollow event + decrement artist follower_count (condition: count >= 1)
 	err = s.db.WriteTx().
 		Delete(s.tbl().Delete("pk", userIndexPK(userID)).Range("sk", handle)).
 		Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", sk)).
+		Put(s.tbl().Put(event)).
 		Update(s.tbl().Update("pk", artistPK(handle)).Range("sk", artistMainSK).
 			SetExpr("follower_count = follower_count + ?", -1).
 			If("follower_count >= ?", 1)).
@@ -186,3 +196,62 @@ func (s *Store) IsFollowing(ctx context.Context, userID string, handle string) (
 	}
 	return true, nil
 }
+
+// MigrateHandle moves every follow of oldHandle (artist-side row and the follower's user index row) and the follower
+// insights rows to newHandle, after an artist rename. follower_count lives on the main artist row, which the rename
+// already moved. Idempotent.
+func (s *Store) MigrateHandle(ctx context.Context, oldHandle, newHandle string) error {
+	oldHandle, newHandle = normalizeHandle(oldHandle), normalizeHandle(newHandle)
+	type artistFollowerRow struct {
+		PK         string `dynamo:"pk"`
+		SK         string `dynamo:"sk"`
+		UserID     string `dynamo:"user_id"`
+		FollowedAt string `dynamo:"followed_at"`
+	}
+	var rows []artistFollowerRow
+	if err := s.tbl().Get("pk", artistPK(oldHandle)).Range("sk", dynamo.BeginsWith, followedSKPrefix).All(ctx, &rows); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		moved := r
+		moved.PK = artistPK(newHandle)
+		userRow := struct {
+			PK         string `dynamo:"pk"`
+			SK         string `dynamo:"sk"`
+			Handle     string `dynamo:"handle"`
+			FollowedAt string `dynamo:"followed_at"`
+		}{userIndexPK(r.UserID), newHandle, newHandle, r.FollowedAt}
+		err := s.db.WriteTx().
+			Put(s.tbl().Put(moved)).
+			Put(s.tbl().Put(userRow)).
+			Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", r.SK)).
+			Delete(s.tbl().Delete("pk", userIndexPK(r.UserID)).Range("sk", oldHandle)).
+			Run(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return s.moveInsights(ctx, oldHandle, newHandle)
+}
+
+// PurgeHandle removes every follow of a purged artist (artist-side row and the follower's user index row). Idempotent.
+func (s *Store) PurgeHandle(ctx context.Context, handle string) error {
+	handle = normalizeHandle(handle)
+	var rows []struct {
+		SK     string `dynamo:"sk"`
+		UserID string `dynamo:"user_id"`
+	}
+	if err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, followedSKPrefix).All(ctx, &rows); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		err := s.db.WriteTx().
+			Delete(s.tbl().Delete("pk", artistPK(handle)).Range("sk", r.SK)).
+			Delete(s.tbl().Delete("pk", userIndexPK(r.UserID)).Range("sk", handle)).
+			Run(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
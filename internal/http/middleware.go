@@ -8,6 +8,9 @@ import (
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/infra"
 )
 
 // RealIP sets r.RemoteAddr to the client IP from X-Real-IP or X-Forwarded-For
@@ -103,3 +106,30 @@ func Recoverer(logger *slog.Logger) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// ReadObserver records DynamoDB reads per request. Implemented by *metrics.DynamoReadRecorder.
+type ReadObserver interface {
+	ObserveReads(route string, reads int64)
+}
+
+// DynamoReads counts the DynamoDB read requests made while serving the request and reports them to obs under
+// the matched route pattern. No-op when obs is nil.
+func DynamoReads(obs ReadObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if obs == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, counter := infra.WithReadCounter(r.Context())
+			next.ServeHTTP(w, r.WithContext(ctx))
+			obs.ObserveReads(r.Pattern, counter.Reads())
+		})
+	}
+}
+
+// RequestCache gives each request its own artist and member row cache, shared by every service it calls.
+func RequestCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(artists.WithRequestCache(r.Context())))
+	})
+}
@@ -5,10 +5,14 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/sopatech/afterwave.fm/internal/admin"
 	authmw "github.com/sopatech/afterwave.fm/internal/auth"
 	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/domains"
 	"github.com/sopatech/afterwave.fm/internal/feed"
 	"github.com/sopatech/afterwave.fm/internal/follows"
+	"github.com/sopatech/afterwave.fm/internal/invitations"
+	"github.com/sopatech/afterwave.fm/internal/media"
 	"github.com/sopatech/afterwave.fm/internal/users"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
@@ -20,7 +24,7 @@ func chain(h http.Handler, mws ...func(http.Handler) http.Handler) http.Handler
 	return h
 }
 
-func NewRouter(logger *slog.Logger, userH *users.Handler, authH *authmw.Handler, artistH *artists.Handler, followH *follows.Handler, feedH *feed.Handler, metricsH http.Handler, jwtPublicKey *rsa.PublicKey) http.Handler {
+func NewRouter(logger *slog.Logger, userH *users.Handler, authH *authmw.Handler, artistH *artists.Handler, followH *follows.Handler, feedH *feed.Handler, inviteH *invitations.Handler, domainH *domains.Handler, mediaH *media.Handler, mediaFiles http.Handler, adminH *admin.Handler, metricsH http.Handler, dynamoReads ReadObserver, jwtPublicKey *rsa.PublicKey, suspensions authmw.SuspensionChecker) http.Handler {
 	mux := http.NewServeMux()
 
 	wrap := func(h http.Handler) http.Handler {
@@ -28,10 +32,14 @@ func NewRouter(logger *slog.Logger, userH *users.Handler, authH *authmw.Handler,
 			Recoverer(logger),
 			RealIP,
 			RequestLogger(logger),
+			DynamoReads(dynamoReads),
+			RequestCache,
 		)
 	}
 
-	auth := authmw.Authenticate(jwtPublicKey)
+	auth := authmw.Authenticate(jwtPublicKey, suspensions)
+	viewer := authmw.OptionalAuthenticate(jwtPublicKey, suspensions)
+	adminOnly := func(h http.Handler) http.Handler { return auth(adminH.RequireAdmin(h)) }
 
 	// v1 API
 	v1 := http.NewServeMux()
@@ -50,33 +58,121 @@ func NewRouter(logger *slog.Logger, userH *users.Handler, authH *authmw.Handler,
 
 	// Protected
 	v1.Handle("GET /users/me", wrap(auth(http.HandlerFunc(userH.Me))))
+	v1.Handle("GET /users/me/settings", wrap(auth(http.HandlerFunc(userH.GetSettings))))
+	v1.Handle("PATCH /users/me/settings", wrap(auth(http.HandlerFunc(userH.UpdateSettings))))
+	v1.Handle("POST /users/me/email", wrap(auth(http.HandlerFunc(userH.RequestEmailChange))))
+	v1.Handle("POST /users/me/email/confirm", wrap(auth(http.HandlerFunc(userH.ConfirmEmailChange))))
 	v1.Handle("DELETE /account", wrap(auth(http.HandlerFunc(userH.DeleteAccount))))
 
 	// Following and my feed
 	v1.Handle("POST /users/me/following/{handle}", wrap(auth(http.HandlerFunc(followH.Follow))))
 	v1.Handle("DELETE /users/me/following/{handle}", wrap(auth(http.HandlerFunc(followH.Unfollow))))
 	v1.Handle("GET /users/me/following", wrap(auth(http.HandlerFunc(followH.ListFollowing))))
+	v1.Handle("GET /artists/{handle}/followers/insights", wrap(auth(http.HandlerFunc(followH.Insights))))
 	v1.Handle("GET /feed", wrap(auth(http.HandlerFunc(feedH.MyFeed))))
 
 	// Artists: protected create/list-mine; public get-by-handle; protected update/delete (owner or admin); members (owner or admin)
 	v1.Handle("POST /artists", wrap(auth(http.HandlerFunc(artistH.Create))))
 	v1.Handle("GET /artists/me", wrap(auth(http.HandlerFunc(artistH.ListMine))))
-	v1.Handle("GET /artists/{handle}", wrap(http.HandlerFunc(artistH.GetByHandle)))
+	v1.Handle("GET /artists/{handle}", wrap(viewer(http.HandlerFunc(artistH.GetByHandle))))
 	v1.Handle("PATCH /artists/{handle}", wrap(auth(http.HandlerFunc(artistH.Update))))
 	v1.Handle("DELETE /artists/{handle}", wrap(auth(http.HandlerFunc(artistH.Delete))))
+	v1.Handle("POST /artists/{handle}/restore", wrap(auth(http.HandlerFunc(artistH.Restore))))
 	v1.Handle("GET /artists/{handle}/members", wrap(auth(http.HandlerFunc(artistH.ListMembers))))
 	v1.Handle("POST /artists/{handle}/members", wrap(auth(http.HandlerFunc(artistH.AddMember))))
 	v1.Handle("PATCH /artists/{handle}/members/{userId}", wrap(auth(http.HandlerFunc(artistH.UpdateMemberRoles))))
 	v1.Handle("DELETE /artists/{handle}/members/{userId}", wrap(auth(http.HandlerFunc(artistH.RemoveMember))))
+	v1.Handle("POST /artists/{handle}/transfer", wrap(auth(http.HandlerFunc(artistH.NominateOwner))))
+	v1.Handle("GET /artists/{handle}/transfer", wrap(auth(http.HandlerFunc(artistH.GetTransfer))))
+	v1.Handle("DELETE /artists/{handle}/transfer", wrap(auth(http.HandlerFunc(artistH.CancelTransfer))))
+	v1.Handle("POST /artists/{handle}/transfer/accept", wrap(auth(http.HandlerFunc(artistH.AcceptTransfer))))
+	v1.Handle("POST /artists/{handle}/rename", wrap(auth(http.HandlerFunc(artistH.Rename))))
+
+	// External links (Links section): public list; protected create/update/delete/reorder (artist:update)
+	v1.Handle("GET /artists/{handle}/links", wrap(viewer(http.HandlerFunc(artistH.ListLinks))))
+	v1.Handle("POST /artists/{handle}/links", wrap(auth(http.HandlerFunc(artistH.CreateLink))))
+	v1.Handle("PUT /artists/{handle}/links/order", wrap(auth(http.HandlerFunc(artistH.ReorderLinks))))
+	v1.Handle("PATCH /artists/{handle}/links/{linkId}", wrap(auth(http.HandlerFunc(artistH.UpdateLink))))
+	v1.Handle("DELETE /artists/{handle}/links/{linkId}", wrap(auth(http.HandlerFunc(artistH.DeleteLink))))
+
+	// Custom roles: any member lists; owner or admin (artist:manage_members) creates/updates/deletes. Public permission catalog.
+	v1.Handle("GET /artists/{handle}/roles", wrap(auth(http.HandlerFunc(artistH.ListRoles))))
+	v1.Handle("POST /artists/{handle}/roles", wrap(auth(http.HandlerFunc(artistH.CreateRole))))
+	v1.Handle("PATCH /artists/{handle}/roles/{roleId}", wrap(auth(http.HandlerFunc(artistH.UpdateRole))))
+	v1.Handle("DELETE /artists/{handle}/roles/{roleId}", wrap(auth(http.HandlerFunc(artistH.DeleteRole))))
+	v1.Handle("GET /permissions", wrap(http.HandlerFunc(artistH.Permissions)))
+
+	// Activity log: who changed what on the page (artist:list_members)
+	v1.Handle("GET /artists/{handle}/activity", wrap(auth(http.HandlerFunc(artistH.ListActivity))))
+
+	// Verification: owner or admin (artist:update) requests and checks status; platform admins review
+	v1.Handle("POST /artists/{handle}/verification", wrap(auth(http.HandlerFunc(artistH.RequestVerification))))
+	v1.Handle("GET /artists/{handle}/verification", wrap(auth(http.HandlerFunc(artistH.GetVerification))))
+
+	// Custom domains: owner or admin (site:edit) registers, verifies and removes; public host lookup for the frontend and edge
+	v1.Handle("POST /artists/{handle}/domains", wrap(auth(http.HandlerFunc(domainH.Register))))
+	v1.Handle("GET /artists/{handle}/domains", wrap(auth(http.HandlerFunc(domainH.List))))
+	v1.Handle("POST /artists/{handle}/domains/{host}/verify", wrap(auth(http.HandlerFunc(domainH.Verify))))
+	v1.Handle("DELETE /artists/{handle}/domains/{host}", wrap(auth(http.HandlerFunc(domainH.Remove))))
+	v1.Handle("GET /domains/{host}", wrap(http.HandlerFunc(domainH.Lookup)))
+
+	// Media: members who can write posts upload images (presigned PUT), then finalize them to attach to posts
+	v1.Handle("POST /artists/{handle}/media", wrap(auth(http.HandlerFunc(mediaH.CreateUpload))))
+	v1.Handle("GET /artists/{handle}/media/{mediaId}", wrap(auth(http.HandlerFunc(mediaH.Get))))
+	v1.Handle("POST /artists/{handle}/media/{mediaId}/finalize", wrap(auth(http.HandlerFunc(mediaH.Finalize))))
+
+	// Member invitations: owner or admin invites by email; invitee accepts or declines
+	v1.Handle("POST /artists/{handle}/invitations", wrap(auth(http.HandlerFunc(inviteH.Create))))
+	v1.Handle("GET /artists/{handle}/invitations", wrap(auth(http.HandlerFunc(inviteH.ListForArtist))))
+	v1.Handle("DELETE /artists/{handle}/invitations/{invitationId}", wrap(auth(http.HandlerFunc(inviteH.Revoke))))
+	v1.Handle("GET /users/me/invitations", wrap(auth(http.HandlerFunc(inviteH.ListMine))))
+	v1.Handle("POST /users/me/invitations/{invitationId}/accept", wrap(auth(http.HandlerFunc(inviteH.Accept))))
+	v1.Handle("POST /users/me/invitations/{invitationId}/decline", wrap(auth(http.HandlerFunc(inviteH.Decline))))
 
 	// Feed (posts): public list/get; protected create/update/delete (owner only)
 	v1.Handle("POST /artists/{handle}/posts", wrap(auth(http.HandlerFunc(feedH.CreatePost))))
-	v1.Handle("GET /artists/{handle}/posts", wrap(http.HandlerFunc(feedH.ListPosts)))
-	v1.Handle("GET /artists/{handle}/posts/{postId}", wrap(http.HandlerFunc(feedH.GetPost)))
+	v1.Handle("GET /artists/{handle}/posts", wrap(viewer(http.HandlerFunc(feedH.ListPosts))))
+	v1.Handle("GET /artists/{handle}/drafts", wrap(auth(http.HandlerFunc(feedH.ListDrafts))))
+	v1.Handle("GET /artists/{handle}/posts/{postId}", wrap(viewer(http.HandlerFunc(feedH.GetPost))))
 	v1.Handle("PATCH /artists/{handle}/posts/{postId}", wrap(auth(http.HandlerFunc(feedH.UpdatePost))))
 	v1.Handle("DELETE /artists/{handle}/posts/{postId}", wrap(auth(http.HandlerFunc(feedH.DeletePost))))
+	v1.Handle("GET /artists/{handle}/posts/{postId}/revisions", wrap(auth(http.HandlerFunc(feedH.ListRevisions))))
+	v1.Handle("POST /artists/{handle}/posts/{postId}/revisions/{revision}/revert", wrap(auth(http.HandlerFunc(feedH.RevertPost))))
+	v1.Handle("POST /artists/{handle}/posts/{postId}/pin", wrap(auth(http.HandlerFunc(feedH.PinPost))))
+	v1.Handle("DELETE /artists/{handle}/posts/{postId}/pin", wrap(auth(http.HandlerFunc(feedH.UnpinPost))))
+
+	// Comments: signed-in users comment on posts they can see; authors edit and delete; feed:update members hide or delete any
+	v1.Handle("POST /artists/{handle}/posts/{postId}/comments", wrap(auth(http.HandlerFunc(feedH.CreateComment))))
+	v1.Handle("GET /artists/{handle}/posts/{postId}/comments", wrap(viewer(http.HandlerFunc(feedH.ListComments))))
+	v1.Handle("PATCH /artists/{handle}/posts/{postId}/comments/{commentId}", wrap(auth(http.HandlerFunc(feedH.UpdateComment))))
+	v1.Handle("DELETE /artists/{handle}/posts/{postId}/comments/{commentId}", wrap(auth(http.HandlerFunc(feedH.DeleteComment))))
+	v1.Handle("POST /artists/{handle}/posts/{postId}/comments/{commentId}/hide", wrap(auth(http.HandlerFunc(feedH.HideComment))))
+	v1.Handle("POST /artists/{handle}/posts/{postId}/comments/{commentId}/unhide", wrap(auth(http.HandlerFunc(feedH.UnhideComment))))
+	v1.Handle("PUT /artists/{handle}/posts/{postId}/reactions/{type}", wrap(auth(http.HandlerFunc(feedH.React))))
+	v1.Handle("DELETE /artists/{handle}/posts/{postId}/reactions/{type}", wrap(auth(http.HandlerFunc(feedH.Unreact))))
+
+	// Platform admin (support tooling): platform admins only; every action is audited
+	v1.Handle("GET /admin/users", wrap(adminOnly(http.HandlerFunc(adminH.FindUser))))
+	v1.Handle("GET /admin/users/{userId}", wrap(adminOnly(http.HandlerFunc(adminH.GetUser))))
+	v1.Handle("POST /admin/users/{userId}/suspend", wrap(adminOnly(http.HandlerFunc(adminH.Suspend))))
+	v1.Handle("POST /admin/users/{userId}/unsuspend", wrap(adminOnly(http.HandlerFunc(adminH.Unsuspend))))
+	v1.Handle("POST /admin/users/{userId}/sign-out", wrap(adminOnly(http.HandlerFunc(adminH.SignOut))))
+	v1.Handle("GET /admin/users/{userId}/audit", wrap(adminOnly(http.HandlerFunc(adminH.ListAudit))))
+	v1.Handle("POST /admin/handles/{handle}/grant", wrap(adminOnly(http.HandlerFunc(adminH.GrantHandle))))
+	v1.Handle("DELETE /admin/handles/{handle}/grant", wrap(adminOnly(http.HandlerFunc(adminH.RevokeHandleGrant))))
+	v1.Handle("GET /admin/verifications", wrap(adminOnly(http.HandlerFunc(adminH.ListVerifications))))
+	v1.Handle("POST /admin/verifications/{handle}/approve", wrap(adminOnly(http.HandlerFunc(adminH.ApproveVerification))))
+	v1.Handle("POST /admin/verifications/{handle}/reject", wrap(adminOnly(http.HandlerFunc(adminH.RejectVerification))))
 
 	mux.Handle("/v1/", http.StripPrefix("/v1", v1))
+	// Registered on the top-level mux: on v1 it would conflict with GET /artists/{handle}/posts/{postId}
+	// (neither pattern is more specific for /artists/handles/posts/availability).
+	mux.Handle("GET /v1/artists/handles/{handle}/availability", wrap(auth(http.HandlerFunc(artistH.Availability))))
+
+	// Uploaded files when stored on the local filesystem (no bucket configured): signed PUT, public GET
+	if mediaFiles != nil {
+		mux.Handle("/files/", http.StripPrefix("/files", mediaFiles))
+	}
 
 	// Prometheus metrics on default path (GET /metrics)
 	if metricsH != nil {
@@ -17,13 +17,16 @@ type Dynamo struct {
 }
 
 // NewDynamo creates a DynamoDB client using guregu/dynamo. If endpointURL is non-empty
-// (e.g. http://localhost:8001 for DynamoDB Local), the client uses that endpoint.
+// (e.g. http://localhost:8001 for DynamoDB Local), the client uses that endpoint. Read requests are counted
+// on contexts from WithReadCounter.
 func NewDynamo(ctx context.Context, region, endpointURL string) (*Dynamo, error) {
 	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
 	if err != nil {
 		return nil, err
 	}
-	opts := []func(*dynamodb.Options){}
+	opts := []func(*dynamodb.Options){
+		func(o *dynamodb.Options) { o.APIOptions = append(o.APIOptions, countReads) },
+	}
 	if endpointURL != "" {
 		u, err := url.Parse(endpointURL)
 		if err != nil {
@@ -0,0 +1,43 @@
+package infra
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ReadCounter counts DynamoDB read requests (GetItem, BatchGetItem, TransactGetItems, Query, Scan) made with a
+// context from WithReadCounter. A BatchGetItem is one read however many keys it fetches; retries are not counted.
+type ReadCounter struct {
+	n atomic.Int64
+}
+
+// Reads returns the number of read requests so far.
+func (c *ReadCounter) Reads() int64 {
+	return c.n.Load()
+}
+
+type readCounterKey struct{}
+
+// WithReadCounter returns ctx carrying a new ReadCounter. Set once per HTTP request (router middleware).
+func WithReadCounter(ctx context.Context) (context.Context, *ReadCounter) {
+	c := &ReadCounter{}
+	return context.WithValue(ctx, readCounterKey{}, c), c
+}
+
+// countReads is a DynamoDB client middleware that increments the context's ReadCounter on read operations.
+func countReads(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CountReads",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			if c, ok := ctx.Value(readCounterKey{}).(*ReadCounter); ok {
+				switch in.Parameters.(type) {
+				case *dynamodb.GetItemInput, *dynamodb.BatchGetItemInput, *dynamodb.TransactGetItemsInput,
+					*dynamodb.QueryInput, *dynamodb.ScanInput:
+					c.n.Add(1)
+				}
+			}
+			return next.HandleInitialize(ctx, in)
+		}), middleware.After)
+}
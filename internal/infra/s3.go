@@ -0,0 +1,151 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty body, for signing requests without one.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// ObjectInfo is what an object store reports about a stored object.
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// S3 is a minimal client for an S3-compatible bucket (AWS S3, MinIO): presigned uploads, HEAD and DELETE, signed
+// with SigV4. Create via NewS3.
+type S3 struct {
+	endpoint      string // e.g. http://localhost:9000 for MinIO; "" for AWS
+	bucket        string
+	region        string
+	publicBaseURL string
+	creds         aws.CredentialsProvider
+	signer        *v4.Signer
+	client        *http.Client
+}
+
+// NewS3 returns a client for bucket. If endpoint is non-empty (e.g. http://localhost:9000 for MinIO), requests use
+// path-style addressing against it; otherwise the bucket's AWS virtual-hosted endpoint. Objects are served from
+// publicBaseURL + "/" + key (e.g. a CDN in front of the bucket); when empty, from the bucket URL. Credentials come
+// from the default AWS chain.
+func NewS3(ctx context.Context, region, endpoint, bucket, publicBaseURL string) (*S3, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	s := &S3{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		bucket:   bucket,
+		region:   region,
+		creds:    cfg.Credentials,
+		signer:   v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true }),
+		client:   http.DefaultClient,
+	}
+	s.publicBaseURL = strings.TrimSuffix(publicBaseURL, "/")
+	if s.publicBaseURL == "" {
+		s.publicBaseURL = s.bucketURL()
+	}
+	return s, nil
+}
+
+func (s *S3) bucketURL() string {
+	if s.endpoint != "" {
+		return s.endpoint + "/" + s.bucket
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", s.bucket, s.region)
+}
+
+func (s *S3) objectURL(key string) string {
+	return s.bucketURL() + "/" + key
+}
+
+// URL is where the object is served from.
+func (s *S3) URL(key string) string {
+	return s.publicBaseURL + "/" + key
+}
+
+// PresignPut returns a URL the client can PUT the object to until expires passes, and the headers it must send.
+// The content type and exact size are part of the signature, so the store rejects any other upload.
+func (s *S3) PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (string, map[string]string, error) {
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	q := url.Values{}
+	q.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	req.URL.RawQuery = q.Encode()
+	req.Header.Set("Content-Type", contentType)
+	req.ContentLength = size
+	signed, signedHeaders, err := s.signer.PresignHTTP(ctx, creds, req, "UNSIGNED-PAYLOAD", "s3", s.region, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	headers := make(map[string]string, len(signedHeaders))
+	for k, v := range signedHeaders {
+		if k != "Host" && len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	return signed, headers, nil
+}
+
+// Stat returns the object's size and content type, or nil if it does not exist.
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("s3 head %s: %s", key, resp.Status)
+	}
+	return &ObjectInfo{Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}, nil
+}
+
+// Delete removes the object. Deleting a missing object is not an error.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("s3 delete %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// do sends a signed request without a body for the object.
+func (s *S3) do(ctx context.Context, method, key string) (*http.Response, error) {
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if err := s.signer.SignHTTP(ctx, creds, req, emptyPayloadHash, "s3", s.region, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.client.Do(req)
+}
@@ -0,0 +1,154 @@
+package invitations
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/sopatech/afterwave.fm/internal/auth"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Create invites an email to the artist page. Owner or admin only. Body: {"email": "...", "roles": ["feed"]}.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Email string   `json:"email"`
+		Roles []string `json:"roles"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	inv, err := h.svc.Create(r.Context(), r.PathValue("handle"), body.Email, body.Roles, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(inv)
+}
+
+// ListForArtist returns pending invitations for the artist page. Owner or admin only.
+func (h *Handler) ListForArtist(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	list, err := h.svc.ListForArtist(r.Context(), r.PathValue("handle"), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"invitations": list})
+}
+
+// Revoke withdraws a pending invitation. Owner or admin only.
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.svc.Revoke(r.Context(), r.PathValue("handle"), r.PathValue("invitationId"), userID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMine returns pending invitations addressed to the authenticated user's email.
+func (h *Handler) ListMine(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	list, err := h.svc.ListForUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"invitations": list})
+}
+
+// Accept accepts an invitation. Optional body: {"token": "..."} (the emailed code) when signed in with a different email.
+func (h *Handler) Accept(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	token, ok := decodeToken(w, r)
+	if !ok {
+		return
+	}
+	inv, err := h.svc.Accept(r.Context(), r.PathValue("invitationId"), token, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inv)
+}
+
+// Decline declines an invitation. Same optional body as Accept.
+func (h *Handler) Decline(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	token, ok := decodeToken(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.Decline(r.Context(), r.PathValue("invitationId"), token, userID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeToken reads the optional {"token": "..."} body; an empty body is allowed.
+func decodeToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return "", false
+	}
+	return body.Token, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case err == ErrArtistNotFound, err == ErrInvitationNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err == ErrForbidden:
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case err == ErrInvalidEmail, err == ErrInvalidRoles:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == ErrAlreadyInvited, err == ErrAlreadyOwner, err == ErrAlreadyMember:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,345 @@
+package invitations
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/mail"
+	"github.com/sopatech/afterwave.fm/internal/users"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found or expired")
+	ErrInvalidEmail       = errors.New("invalid email address")
+	ErrInvalidRoles       = errors.New("invalid roles")
+	ErrAlreadyInvited     = errors.New("an invitation is already pending for this email")
+	ErrAlreadyOwner       = errors.New("user is already the owner")
+	ErrAlreadyMember      = errors.New("user is already a member")
+	ErrArtistNotFound     = errors.New("artist not found")
+	ErrForbidden          = errors.New("forbidden")
+)
+
+// InvitationTTL is how long an invitation can be accepted.
+const InvitationTTL = 7 * 24 * time.Hour
+
+// ArtistAccess resolves artist pages, checks member permissions and validates roles (predefined or the artist's
+// custom roles). Implemented by artists.Service.
+type ArtistAccess interface {
+	GetByHandle(ctx context.Context, handle string) (*artists.Artist, error)
+	HasPermission(ctx context.Context, handle, userID, permission string) (bool, error)
+	RolesAssignable(ctx context.Context, handle string, roles []string) (bool, error)
+}
+
+// MemberWriter adds a new membership, with its entry in the artist's activity log, to a transaction that fails if the
+// user is already a member. Implemented by *artists.MemberStore.
+type MemberWriter interface {
+	AddTx(ctx context.Context, tx *dynamo.WriteTx, handle, userID string, roles []string, activity *artists.ActivityEntry) *dynamo.WriteTx
+}
+
+// UserDirectory looks up users. Implemented by users.Service.
+type UserDirectory interface {
+	GetByID(ctx context.Context, userID string) (*users.User, error)
+	GetByEmail(ctx context.Context, email string) (*users.User, error)
+}
+
+type Service interface {
+	Create(ctx context.Context, handle, email string, roles []string, actorUserID string) (*Invitation, error)
+	ListForArtist(ctx context.Context, handle, actorUserID string) ([]Invitation, error)
+	Revoke(ctx context.Context, handle, invitationID, actorUserID string) error
+	ListForUser(ctx context.Context, userID string) ([]Invitation, error)
+	Accept(ctx context.Context, invitationID, token, userID string) (*Invitation, error)
+	Decline(ctx context.Context, invitationID, token, userID string) error
+}
+
+// Invitation is a pending offer of roles on an artist page, addressed to an email. The token is only ever
+// sent in the email and is never returned by the API.
+type Invitation struct {
+	ID        string   `json:"id"`
+	Handle    string   `json:"handle"`
+	Email     string   `json:"email"`
+	Roles     []string `json:"roles"`
+	InvitedBy string   `json:"invited_by"`
+	CreatedAt string   `json:"created_at"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+type service struct {
+	store   *Store
+	artists ArtistAccess
+	members MemberWriter
+	users   UserDirectory
+	mailer  mail.Sender
+}
+
+func NewService(store *Store, artists ArtistAccess, members MemberWriter, users UserDirectory, mailer mail.Sender) Service {
+	return &service{store: store, artists: artists, members: members, users: users, mailer: mailer}
+}
+
+// Create invites the email to the artist page with the given roles and emails a single-use token.
+// The address does not need an account yet; the invitation shows up for it after signup.
+func (s *service) Create(ctx context.Context, handle, email string, roles []string, actorUserID string) (*Invitation, error) {
+	if s.mailer == nil {
+		return nil, fmt.Errorf("mail sender not configured")
+	}
+	email = users.NormalizeEmail(email)
+	if !users.ValidEmail(email) {
+		return nil, ErrInvalidEmail
+	}
+	artist, err := s.manageableArtist(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	roles = dedupe(roles)
+	if len(roles) == 0 {
+		return nil, ErrInvalidRoles
+	}
+	if ok, err := s.artists.RolesAssignable(ctx, artist.Handle, roles); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrInvalidRoles
+	}
+	if u, err := s.users.GetByEmail(ctx, email); err == nil && u.ID == artist.OwnerUserID {
+		return nil, ErrAlreadyOwner
+	} else if err != nil && !errors.Is(err, users.ErrUserNotFound) {
+		return nil, err
+	}
+	pending, err := s.store.ListByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for i := range pending {
+		if pending[i].Handle == artist.Handle && !expired(&pending[i], now) {
+			return nil, ErrAlreadyInvited
+		}
+	}
+
+	token := uuid.New().String()
+	row := invitationRow{
+		ID:        uuid.New().String(),
+		Handle:    artist.Handle,
+		Email:     email,
+		Roles:     roles,
+		InvitedBy: actorUserID,
+		TokenHash: hashToken(token),
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: now.Add(InvitationTTL).Format(time.RFC3339),
+	}
+	if err := s.store.Put(ctx, row); err != nil {
+		return nil, err
+	}
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "You've been invited to help manage " + artist.DisplayName + " on Afterwave",
+		Body: "You've been invited to join " + artist.DisplayName + " (" + artist.Handle + ") on Afterwave.\n\n" +
+			"Invitation: " + row.ID + "\nCode: " + token + "\n\n" +
+			"Sign in or sign up with this email address to accept. The invitation expires in 7 days. If you weren't expecting this, ignore this email.",
+	})
+	if err != nil {
+		// An invitation nobody received can't be accepted; don't leave it blocking a retry.
+		_ = s.store.Delete(ctx, &row)
+		return nil, err
+	}
+	return rowToInvitation(&row), nil
+}
+
+// ListForArtist returns pending invitations for the artist page. Owner or admin only.
+func (s *service) ListForArtist(ctx context.Context, handle, actorUserID string) ([]Invitation, error) {
+	artist, err := s.manageableArtist(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.store.ListByArtist(ctx, artist.Handle)
+	if err != nil {
+		return nil, err
+	}
+	return pendingInvitations(rows), nil
+}
+
+// Revoke withdraws a pending invitation. Owner or admin only.
+func (s *service) Revoke(ctx context.Context, handle, invitationID, actorUserID string) error {
+	artist, err := s.manageableArtist(ctx, handle, actorUserID)
+	if err != nil {
+		return err
+	}
+	row, err := s.store.Get(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if row == nil || row.Handle != artist.Handle {
+		return ErrInvitationNotFound
+	}
+	return s.consume(ctx, row)
+}
+
+// ListForUser returns pending invitations addressed to the user's current email.
+func (s *service) ListForUser(ctx context.Context, userID string) ([]Invitation, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.store.ListByEmail(ctx, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return pendingInvitations(rows), nil
+}
+
+// Accept adds the user as a member with the invited roles and consumes the invitation, in one transaction. The user
+// must either be signed in with the invited email or present the emailed token. A user who is already a member gets
+// ErrAlreadyMember and keeps their roles; the invitation stays pending until declined or revoked.
+func (s *service) Accept(ctx context.Context, invitationID, token, userID string) (*Invitation, error) {
+	row, err := s.invitationFor(ctx, invitationID, token, userID)
+	if err != nil {
+		return nil, err
+	}
+	artist, err := s.artists.GetByHandle(ctx, row.Handle)
+	if err != nil {
+		if errors.Is(err, artists.ErrArtistNotFound) {
+			_ = s.store.Delete(ctx, row)
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if artist.OwnerUserID == userID {
+		return nil, ErrAlreadyOwner
+	}
+	tx := s.members.AddTx(ctx, s.store.DeleteTx(row), row.Handle, userID, row.Roles, &artists.ActivityEntry{
+		Action:      artists.ActivityMemberAdd,
+		ActorUserID: userID,
+		Target:      userID,
+		Changes:     artists.AppendChange(nil, "roles", "", strings.Join(row.Roles, ",")),
+	})
+	if err := tx.Run(ctx); err != nil {
+		if !dynamo.IsCondCheckFailed(err) {
+			return nil, err
+		}
+		// Either the invitation was accepted, declined or revoked meanwhile, or the user is already a member.
+		current, getErr := s.store.Get(ctx, row.ID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		if current == nil {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, ErrAlreadyMember
+	}
+	return rowToInvitation(row), nil
+}
+
+// Decline consumes the invitation without creating a membership.
+func (s *service) Decline(ctx context.Context, invitationID, token, userID string) error {
+	row, err := s.invitationFor(ctx, invitationID, token, userID)
+	if err != nil {
+		return err
+	}
+	return s.consume(ctx, row)
+}
+
+// invitationFor loads an unexpired invitation the user may act on: addressed to their email, or token matches.
+func (s *service) invitationFor(ctx context.Context, invitationID, token, userID string) (*invitationRow, error) {
+	if invitationID == "" {
+		return nil, ErrInvitationNotFound
+	}
+	row, err := s.store.Get(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil || expired(row, time.Now()) {
+		return nil, ErrInvitationNotFound
+	}
+	if token != "" {
+		if subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(row.TokenHash)) == 1 {
+			return row, nil
+		}
+		return nil, ErrInvitationNotFound
+	}
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email != row.Email {
+		return nil, ErrInvitationNotFound
+	}
+	return row, nil
+}
+
+// consume deletes the invitation; a concurrent accept/decline/revoke loses with ErrInvitationNotFound.
+func (s *service) consume(ctx context.Context, row *invitationRow) error {
+	if err := s.store.Delete(ctx, row); err != nil {
+		if dynamo.IsCondCheckFailed(err) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) manageableArtist(ctx context.Context, handle, actorUserID string) (*artists.Artist, error) {
+	artist, err := s.artists.GetByHandle(ctx, handle)
+	if err != nil {
+		return nil, ErrArtistNotFound
+	}
+	ok, err := s.artists.HasPermission(ctx, artist.Handle, actorUserID, artists.PermArtistManageMembers)
+	if err != nil || !ok {
+		return nil, ErrForbidden
+	}
+	return artist, nil
+}
+
+func pendingInvitations(rows []invitationRow) []Invitation {
+	now := time.Now()
+	out := make([]Invitation, 0, len(rows))
+	for i := range rows {
+		if !expired(&rows[i], now) {
+			out = append(out, *rowToInvitation(&rows[i]))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
+	return out
+}
+
+func expired(r *invitationRow, now time.Time) bool {
+	exp, err := time.Parse(time.RFC3339, r.ExpiresAt)
+	return err != nil || now.After(exp)
+}
+
+func rowToInvitation(r *invitationRow) *Invitation {
+	return &Invitation{
+		ID:        r.ID,
+		Handle:    r.Handle,
+		Email:     r.Email,
+		Roles:     r.Roles,
+		InvitedBy: r.InvitedBy,
+		CreatedAt: r.CreatedAt,
+		ExpiresAt: r.ExpiresAt,
+	}
+}
+
+func dedupe(roles []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, r := range roles {
+		if !seen[r] {
+			seen[r] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,180 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/infra"
+)
+
+// Invitation domain: three-row pattern (no GSI), same attributes on each row.
+// Main row:     PK = INVITES#<id>, SK = INVITE — lookup by ID (accept, decline, revoke).
+// Artist index: PK = ARTISTS#<handle>, SK = INVITE#<id> — pending invitations for the artist page.
+// Email index:  PK = INVITES#EMAIL#<email>, SK = INVITE#<id> — pending invitations for an address, registered or not.
+
+const (
+	invitePKPrefix      = "INVITES#"
+	inviteSK            = "INVITE"
+	artistPKPrefix      = "ARTISTS#"
+	emailIndexPKPrefix  = "INVITES#EMAIL#"
+	inviteIndexSKPrefix = "INVITE#"
+)
+
+type invitationRow struct {
+	PK        string   `dynamo:"pk"`
+	SK        string   `dynamo:"sk"`
+	ID        string   `dynamo:"id"`
+	Handle    string   `dynamo:"handle"`
+	Email     string   `dynamo:"email"`
+	Roles     []string `dynamo:"roles"`
+	InvitedBy string   `dynamo:"invited_by"`
+	TokenHash string   `dynamo:"token_hash"`
+	CreatedAt string   `dynamo:"created_at"`
+	ExpiresAt string   `dynamo:"expires_at"`
+}
+
+type Store struct {
+	db        *infra.Dynamo
+	tableName string
+}
+
+func NewStore(db *infra.Dynamo, tableName string) *Store {
+	return &Store{db: db, tableName: tableName}
+}
+
+func (s *Store) tbl() dynamo.Table {
+	return s.db.Table(s.tableName)
+}
+
+func invitePK(id string) string {
+	return invitePKPrefix + id
+}
+
+func artistPK(handle string) string {
+	return artistPKPrefix + handle
+}
+
+func emailIndexPK(email string) string {
+	return emailIndexPKPrefix + email
+}
+
+func inviteIndexSK(id string) string {
+	return inviteIndexSKPrefix + id
+}
+
+// Get returns the invitation by ID, or nil if not found.
+func (s *Store) Get(ctx context.Context, id string) (*invitationRow, error) {
+	var row invitationRow
+	err := s.tbl().Get("pk", invitePK(id)).Range("sk", dynamo.Equal, inviteSK).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// ListByArtist returns all invitations for the artist (including expired ones; the service filters).
+func (s *Store) ListByArtist(ctx context.Context, handle string) ([]invitationRow, error) {
+	return s.list(ctx, artistPK(handle))
+}
+
+// ListByEmail returns all invitations sent to the address (including expired ones; the service filters).
+func (s *Store) ListByEmail(ctx context.Context, email string) ([]invitationRow, error) {
+	return s.list(ctx, emailIndexPK(email))
+}
+
+func (s *Store) list(ctx context.Context, pk string) ([]invitationRow, error) {
+	var out []invitationRow
+	iter := s.tbl().Get("pk", pk).Range("sk", dynamo.BeginsWith, inviteIndexSKPrefix).Iter()
+	var row invitationRow
+	for iter.Next(ctx, &row) {
+		out = append(out, row)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Put creates the invitation (all three rows) in one transaction.
+func (s *Store) Put(ctx context.Context, row invitationRow) error {
+	main := row
+	main.PK, main.SK = invitePK(row.ID), inviteSK
+	byArtist := row
+	byArtist.PK, byArtist.SK = artistPK(row.Handle), inviteIndexSK(row.ID)
+	byEmail := row
+	byEmail.PK, byEmail.SK = emailIndexPK(row.Email), inviteIndexSK(row.ID)
+	return s.db.WriteTx().
+		Put(s.tbl().Put(main).If("attribute_not_exists(pk)")).
+		Put(s.tbl().Put(byArtist)).
+		Put(s.tbl().Put(byEmail)).
+		Run(ctx)
+}
+
+// Delete removes the invitation (all three rows) in one transaction. Fails with a condition check if the
+// invitation was already removed, so accept/decline/revoke consume it exactly once.
+func (s *Store) Delete(ctx context.Context, row *invitationRow) error {
+	return s.DeleteTx(row).Run(ctx)
+}
+
+// DeleteTx returns the transaction Delete runs, for Accept to add the membership to.
+func (s *Store) DeleteTx(row *invitationRow) *dynamo.WriteTx {
+	return s.db.WriteTx().
+		Delete(s.tbl().Delete("pk", invitePK(row.ID)).Range("sk", inviteSK).If("attribute_exists(pk)")).
+		Delete(s.tbl().Delete("pk", artistPK(row.Handle)).Range("sk", inviteIndexSK(row.ID))).
+		Delete(s.tbl().Delete("pk", emailIndexPK(row.Email)).Range("sk", inviteIndexSK(row.ID)))
+}
+
+// MigrateHandle moves pending invitations from oldHandle to newHandle after an artist rename: the artist index row
+// moves and the handle is rewritten on the main and email index rows. Idempotent.
+func (s *Store) MigrateHandle(ctx context.Context, oldHandle, newHandle string) error {
+	rows, err := s.ListByArtist(ctx, oldHandle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		main := row
+		main.PK, main.SK, main.Handle = invitePK(row.ID), inviteSK, newHandle
+		byArtist := row
+		byArtist.PK, byArtist.Handle = artistPK(newHandle), newHandle
+		byEmail := row
+		byEmail.PK, byEmail.SK, byEmail.Handle = emailIndexPK(row.Email), inviteIndexSK(row.ID), newHandle
+		err := s.db.WriteTx().
+			Put(s.tbl().Put(main).If("attribute_exists(pk)")).
+			Put(s.tbl().Put(byArtist)).
+			Put(s.tbl().Put(byEmail)).
+			Delete(s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", inviteIndexSK(row.ID))).
+			Run(ctx)
+		if dynamo.IsCondCheckFailed(err) {
+			// Accepted, declined or revoked meanwhile; just drop the stale index row.
+			err = s.tbl().Delete("pk", artistPK(oldHandle)).Range("sk", inviteIndexSK(row.ID)).Run(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeHandle removes every pending invitation (all three rows) of a purged artist. Idempotent.
+func (s *Store) PurgeHandle(ctx context.Context, handle string) error {
+	rows, err := s.ListByArtist(ctx, handle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := s.db.WriteTx().
+			Delete(s.tbl().Delete("pk", invitePK(row.ID)).Range("sk", inviteSK)).
+			Delete(s.tbl().Delete("pk", artistPK(row.Handle)).Range("sk", inviteIndexSK(row.ID))).
+			Delete(s.tbl().Delete("pk", emailIndexPK(row.Email)).Range("sk", inviteIndexSK(row.ID))).
+			Run(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
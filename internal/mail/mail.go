@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Message is a plain-text transactional email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers transactional email (verification codes, notices). Implementations can be a real
+// provider or a test fake that records messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogSender writes messages to the logger instead of delivering them. Used in local development
+// until a provider is configured.
+type LogSender struct {
+	logger *slog.Logger
+}
+
+func NewLogSender(logger *slog.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.logger.InfoContext(ctx, "mail", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -0,0 +1,30 @@
+package markdown
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Excerpt shortens plain text (such as Result.Text) to at most max runes, cutting at a word boundary and ending with
+// an ellipsis when shortened. Whitespace runs, including line breaks, become single spaces.
+func Excerpt(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	if max <= 1 {
+		return "…"
+	}
+	// Byte offset of the rune that would be cut off, leaving room for the ellipsis.
+	cut := 0
+	for i := 0; i < max-1; i++ {
+		_, size := utf8.DecodeRuneInString(text[cut:])
+		cut += size
+	}
+	if text[cut] != ' ' {
+		if space := strings.LastIndexByte(text[:cut], ' '); space > 0 {
+			cut = space
+		}
+	}
+	return strings.TrimRight(text[:cut], " .,;:!?-") + "…"
+}
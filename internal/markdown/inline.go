@@ -0,0 +1,494 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxLinkLabel limits how far a [ looks for its ], so unmatched brackets cannot make rendering quadratic.
+const maxLinkLabel = 1000
+
+// linkRel is set on every link: posts are user content.
+const linkRel = ` rel="nofollow ugc noopener"`
+
+// inlineState caches failed delimiter searches within one inline run: when no closer is found from a position, none
+// is found from any later position either, so each kind of delimiter scans the text at most once.
+type inlineState struct {
+	noCloser map[string]int
+}
+
+func (is *inlineState) failedFrom(key string, from int) bool {
+	pos, ok := is.noCloser[key]
+	return ok && from >= pos
+}
+
+func (is *inlineState) fail(key string, from int) {
+	if pos, ok := is.noCloser[key]; !ok || from < pos {
+		is.noCloser[key] = from
+	}
+}
+
+// inline renders inline Markdown. Inside link text (inLink), links, bare URLs and mentions are not recognized.
+func (st *state) inline(s string, inLink bool) {
+	is := &inlineState{noCloser: make(map[string]int)}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			st.lineBreak()
+			i += 2
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			st.literal(s[i+1 : i+2])
+			i += 2
+		case c == ' ' && isHardBreak(s, i):
+			st.lineBreak()
+			i = strings.IndexByte(s[i:], '\n') + i + 1
+		case c == ' ' && strings.HasPrefix(strings.TrimLeft(s[i:], " "), "\n"):
+			i += len(s[i:]) - len(strings.TrimLeft(s[i:], " ")) // trailing spaces before a soft break
+		case c == '`':
+			i = st.codeSpan(s, i, is)
+		case c == '*' || c == '_' || c == '~':
+			i = st.emphasis(s, i, is, inLink)
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if end, ok := st.link(s, i+1, true, inLink); ok {
+				i = end
+			} else {
+				st.literal("!")
+				i++
+			}
+		case c == '[' && !inLink:
+			if end, ok := st.link(s, i, false, inLink); ok {
+				i = end
+			} else {
+				st.literal("[")
+				i++
+			}
+		case c == '<' && !inLink:
+			if end, ok := st.autolink(s, i); ok {
+				i = end
+			} else {
+				st.literal("<")
+				i++
+			}
+		case (c == 'h' || c == 'H') && !inLink && wordStart(s, i):
+			if end, ok := st.bareURL(s, i); ok {
+				i = end
+			} else {
+				st.literal(s[i : i+1])
+				i++
+			}
+		case c == '@' && !inLink && mentionStart(s, i):
+			if end, ok := st.mention(s, i); ok {
+				i = end
+			} else {
+				st.literal("@")
+				i++
+			}
+		default:
+			_, size := utf8.DecodeRuneInString(s[i:])
+			st.literal(s[i : i+size])
+			i += size
+		}
+	}
+}
+
+// literal writes text, escaped.
+func (st *state) literal(text string) {
+	st.html.WriteString(html.EscapeString(text))
+	st.text.WriteString(text)
+}
+
+func (st *state) lineBreak() {
+	if st.r.allowed("br") {
+		st.html.WriteString("<br>")
+	}
+	st.html.WriteByte('\n')
+	st.text.WriteByte('\n')
+}
+
+// isHardBreak reports whether the spaces at s[i] are two or more followed by a newline.
+func isHardBreak(s string, i int) bool {
+	j := i
+	for j < len(s) && s[j] == ' ' {
+		j++
+	}
+	return j-i >= 2 && j < len(s) && s[j] == '\n'
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// prevRune returns the rune before s[i], or ' ' at the start.
+func prevRune(s string, i int) rune {
+	if i == 0 {
+		return ' '
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return r
+}
+
+func nextRune(s string, i int) rune {
+	if i >= len(s) {
+		return ' '
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return r
+}
+
+func isAlnum(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func wordStart(s string, i int) bool {
+	return !isAlnum(prevRune(s, i))
+}
+
+// codeSpan renders the code span starting with the backtick run at s[i] and returns the index after it. Without a
+// closing run of the same length, the backticks are literal.
+func (st *state) codeSpan(s string, i int, is *inlineState) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == '`' {
+		n++
+	}
+	fence := s[i : i+n]
+	key := "code" + string(rune('0'+min(n, 64)))
+	if !is.failedFrom(key, i+n) {
+		for j := i + n; j < len(s); {
+			k := strings.Index(s[j:], fence)
+			if k < 0 {
+				break
+			}
+			k += j
+			m := 0
+			for k+m < len(s) && s[k+m] == '`' {
+				m++
+			}
+			if m == n {
+				code := strings.ReplaceAll(s[i+n:k], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				st.open("code", "")
+				st.literal(code)
+				st.close("code")
+				return k + m
+			}
+			j = k + m
+		}
+		is.fail(key, i+n)
+	}
+	st.literal(fence)
+	return i + n
+}
+
+// emphasis renders **strong**, __strong__, *em*, _em_ or ~~del~~ starting at s[i] and returns the index after it.
+// Underscores inside words (snake_case) are literal. Without a closer, the delimiters are literal.
+func (st *state) emphasis(s string, i int, is *inlineState, inLink bool) int {
+	c := s[i]
+	run := 0
+	for i+run < len(s) && s[i+run] == c {
+		run++
+	}
+	try := func(n int, element string) (int, bool) {
+		if run < n || i+n >= len(s) || unicode.IsSpace(nextRune(s, i+n)) {
+			return 0, false
+		}
+		if c == '_' && isAlnum(prevRune(s, i)) {
+			return 0, false
+		}
+		key := string(c) + string(rune('0'+n))
+		if is.failedFrom(key, i+n) {
+			return 0, false
+		}
+		end := findCloser(s, i+n, c, n)
+		if end < 0 {
+			is.fail(key, i+n)
+			return 0, false
+		}
+		st.open(element, "")
+		st.inline(s[i+n:end], inLink)
+		st.close(element)
+		return end + n, true
+	}
+	if c == '~' {
+		if end, ok := try(2, "del"); ok {
+			return end
+		}
+	} else {
+		if end, ok := try(2, "strong"); ok {
+			return end
+		}
+		if end, ok := try(1, "em"); ok {
+			return end
+		}
+	}
+	st.literal(s[i : i+run])
+	return i + run
+}
+
+// findCloser returns the index of the closing delimiter (n of c, not preceded by a space) after from, or -1.
+// Escaped characters and code spans are skipped; for n == 1, longer runs are skipped (they close other emphasis).
+func findCloser(s string, from int, c byte, n int) int {
+	for k := from; k < len(s); {
+		switch s[k] {
+		case '\\':
+			k += 2
+			continue
+		case '`':
+			m := 0
+			for k+m < len(s) && s[k+m] == '`' {
+				m++
+			}
+			if end := strings.Index(s[k+m:], s[k:k+m]); end >= 0 {
+				k += m + end + m
+			} else {
+				k += m
+			}
+			continue
+		case c:
+			m := 0
+			for k+m < len(s) && s[k+m] == c {
+				m++
+			}
+			closes := (m == n || (n == 2 && m > 2)) && k > from && !unicode.IsSpace(prevRune(s, k))
+			if closes && c == '_' && isAlnum(nextRune(s, k+m)) {
+				closes = false
+			}
+			if closes {
+				return k
+			}
+			k += m
+			continue
+		}
+		k++
+	}
+	return -1
+}
+
+// link renders the link (or image) whose [ is at s[i] and returns the index after it.
+func (st *state) link(s string, i int, image, inLink bool) (int, bool) {
+	labelEnd := matchBracket(s, i)
+	if labelEnd < 0 || labelEnd+1 >= len(s) || s[labelEnd+1] != '(' {
+		return 0, false
+	}
+	dest, title, end, ok := parseDestination(s, labelEnd+2)
+	if !ok {
+		return 0, false
+	}
+	label := s[i+1 : labelEnd]
+	safe := st.r.safeURL(dest)
+	if safe {
+		st.addLink(dest)
+	}
+	titleAttr := ""
+	if title != "" {
+		titleAttr = ` title="` + html.EscapeString(title) + `"`
+	}
+	if image {
+		alt := unescape(label)
+		if safe && st.r.allowed("img") {
+			st.html.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(alt) + `"` + titleAttr + `>`)
+			st.text.WriteString(alt)
+		} else {
+			st.literal(alt)
+		}
+		return end, true
+	}
+	if safe && st.r.allowed("a") {
+		st.html.WriteString(`<a href="` + html.EscapeString(dest) + `"` + titleAttr + linkRel + `>`)
+		st.inline(label, true)
+		st.html.WriteString("</a>")
+	} else {
+		st.inline(label, true)
+	}
+	return end, true
+}
+
+// matchBracket returns the index of the ] matching the [ at s[i], or -1.
+func matchBracket(s string, i int) int {
+	depth := 0
+	for k := i; k < len(s) && k-i <= maxLinkLabel; k++ {
+		switch s[k] {
+		case '\\':
+			k++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return k
+			}
+		}
+	}
+	return -1
+}
+
+// parseDestination parses `url "title")` or `<url> "title")` starting at s[i] (after the opening parenthesis).
+func parseDestination(s string, i int) (dest, title string, end int, ok bool) {
+	i = skipSpaces(s, i)
+	if i < len(s) && s[i] == '<' {
+		j := strings.IndexAny(s[i+1:], "<>\n")
+		if j < 0 || s[i+1+j] != '>' {
+			return "", "", 0, false
+		}
+		dest = s[i+1 : i+1+j]
+		i += j + 2
+	} else {
+		depth, j := 0, i
+		for ; j < len(s) && j-i <= maxLinkLabel; j++ {
+			c := s[j]
+			if c == '\\' && j+1 < len(s) && isASCIIPunct(s[j+1]) {
+				j++
+				continue
+			}
+			if c <= ' ' {
+				break
+			}
+			if c == '(' {
+				depth++
+			}
+			if c == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		dest = s[i:j]
+		i = j
+	}
+	i = skipSpaces(s, i)
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closer := s[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		j := i + 1
+		for ; j < len(s) && s[j] != closer; j++ {
+			if s[j] == '\\' {
+				j++
+			}
+		}
+		if j >= len(s) {
+			return "", "", 0, false
+		}
+		title = unescape(s[i+1 : j])
+		i = skipSpaces(s, j+1)
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", "", 0, false
+	}
+	return unescape(dest), title, i + 1, true
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// unescape removes backslashes before ASCII punctuation.
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+var autolinkRegex = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`)
+var emailAutolinkRegex = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*)>`)
+
+// autolink renders <scheme:...> or <address@example.com> at s[i].
+func (st *state) autolink(s string, i int) (int, bool) {
+	if m := autolinkRegex.FindStringSubmatch(s[i:]); m != nil {
+		st.linkTo(m[1], m[1])
+		return i + len(m[0]), true
+	}
+	if m := emailAutolinkRegex.FindStringSubmatch(s[i:]); m != nil {
+		st.linkTo("mailto:"+m[1], m[1])
+		return i + len(m[0]), true
+	}
+	return 0, false
+}
+
+var bareURLRegex = regexp.MustCompile(`^(?i:https?://)[^\s<]+`)
+
+// bareURL renders an http(s) URL in text at s[i] as a link. Trailing punctuation and unbalanced closing parentheses
+// stay text.
+func (st *state) bareURL(s string, i int) (int, bool) {
+	u := bareURLRegex.FindString(s[i:])
+	if u == "" {
+		return 0, false
+	}
+	for len(u) > 0 {
+		last := u[len(u)-1]
+		if strings.IndexByte(".,:;!?'\"*_~", last) >= 0 || (last == ')' && strings.Count(u, ")") > strings.Count(u, "(")) {
+			u = u[:len(u)-1]
+			continue
+		}
+		break
+	}
+	if !strings.Contains(u[strings.Index(u, "://")+3:], ".") && !strings.Contains(u, "localhost") {
+		return 0, false
+	}
+	st.linkTo(u, u)
+	return i + len(u), true
+}
+
+// linkTo renders a link to u with text, or the text alone if u is not allowed.
+func (st *state) linkTo(u, text string) {
+	if !st.r.safeURL(u) {
+		st.literal(text)
+		return
+	}
+	st.addLink(u)
+	if st.r.allowed("a") {
+		st.html.WriteString(`<a href="` + html.EscapeString(u) + `"` + linkRel + `>`)
+		st.literal(text)
+		st.html.WriteString("</a>")
+		return
+	}
+	st.literal(text)
+}
+
+var mentionRegex = regexp.MustCompile(`^@([A-Za-z0-9]{4,64})`)
+
+// mentionStart reports whether an @ at s[i] can start a mention: not inside a word or an email address.
+func mentionStart(s string, i int) bool {
+	r := prevRune(s, i)
+	return !isAlnum(r) && strings.IndexRune("@._-/+", r) < 0
+}
+
+// mention renders @handle at s[i] as a link to the artist page.
+func (st *state) mention(s string, i int) (int, bool) {
+	m := mentionRegex.FindStringSubmatch(s[i:])
+	if m == nil {
+		return 0, false
+	}
+	end := i + len(m[0])
+	if r := nextRune(s, end); isAlnum(r) || r == '_' {
+		return 0, false
+	}
+	handle := strings.ToLower(m[1])
+	st.addMention(handle)
+	if st.r.allowed("a") {
+		st.html.WriteString(`<a href="` + html.EscapeString(strings.ReplaceAll(st.r.mentionURL, "{handle}", handle)) + `" class="mention">`)
+		st.literal(m[0])
+		st.html.WriteString("</a>")
+		return end, true
+	}
+	st.literal(m[0])
+	return end, true
+}
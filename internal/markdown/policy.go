@@ -0,0 +1,115 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// knownElements are the elements the renderer can produce.
+var knownElements = map[string]bool{
+	"p": true, "br": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"strong": true, "em": true, "del": true, "code": true, "pre": true, "blockquote": true,
+	"ul": true, "ol": true, "li": true,
+	"a": true, "img": true,
+}
+
+// DefaultElements are allowed unless configured otherwise: everything but img (post images are hosted uploads).
+var DefaultElements = []string{
+	"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+	"strong", "em", "del", "code", "pre", "blockquote", "ul", "ol", "li", "a",
+}
+
+// DefaultSchemes are the URL schemes allowed in links unless configured otherwise.
+var DefaultSchemes = []string{"http", "https", "mailto"}
+
+// DefaultMentionURL is where @handle mentions link to: the artist page.
+const DefaultMentionURL = "https://{handle}.afterwave.fm"
+
+var schemeRegex = regexp.MustCompile(`^[a-z][a-z0-9+.-]{0,31}$`)
+
+// Policy is what the renderer may output. Markdown that maps to an element not in Elements is rendered as its
+// content only; links and images whose URL has a scheme not in Schemes are rendered as their text. Relative URLs are
+// always allowed.
+type Policy struct {
+	Elements   []string
+	Schemes    []string
+	MentionURL string // {handle} is replaced by the mentioned handle
+}
+
+// DefaultPolicy returns the policy used when none is configured.
+func DefaultPolicy() Policy {
+	return Policy{Elements: DefaultElements, Schemes: DefaultSchemes, MentionURL: DefaultMentionURL}
+}
+
+// Renderer renders Markdown to sanitized HTML under a policy. Safe for concurrent use. Create via New.
+type Renderer struct {
+	elements   map[string]bool
+	schemes    map[string]bool
+	mentionURL string
+}
+
+// Default renders with DefaultPolicy.
+var Default = mustNew(DefaultPolicy())
+
+// New returns a renderer for p. Unknown elements and invalid schemes are an error, so configuration typos fail at
+// startup instead of silently dropping markup.
+func New(p Policy) (*Renderer, error) {
+	r := &Renderer{elements: make(map[string]bool), schemes: make(map[string]bool), mentionURL: p.MentionURL}
+	for _, e := range p.Elements {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if !knownElements[e] {
+			return nil, fmt.Errorf("markdown: unsupported element %q", e)
+		}
+		r.elements[e] = true
+	}
+	for _, s := range p.Schemes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if !schemeRegex.MatchString(s) || s == "javascript" || s == "vbscript" || s == "data" {
+			return nil, fmt.Errorf("markdown: invalid or unsafe scheme %q", s)
+		}
+		r.schemes[s] = true
+	}
+	if r.mentionURL == "" {
+		r.mentionURL = DefaultMentionURL
+	}
+	return r, nil
+}
+
+func mustNew(p Policy) *Renderer {
+	r, err := New(p)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *Renderer) allowed(element string) bool {
+	return r.elements[element]
+}
+
+// safeURL reports whether u may be used as a link or image URL: a relative URL, or one with an allowed scheme.
+// URLs with whitespace, control characters or backslashes are rejected, since browsers strip or rewrite them
+// before resolving the scheme.
+func (r *Renderer) safeURL(u string) bool {
+	if u == "" {
+		return false
+	}
+	for _, c := range u {
+		if c <= ' ' || c == 0x7f || c == '\\' || (c >= 0x80 && c <= 0x9f) {
+			return false
+		}
+	}
+	// Any colon before the path counts as a scheme separator, even after ? or #, so text that only looks relative
+	// (like an entity reference) is not allowed through.
+	end := strings.IndexByte(u, '/')
+	if end < 0 {
+		end = len(u)
+	}
+	colon := strings.IndexByte(u[:end], ':')
+	if colon < 0 {
+		return true // relative
+	}
+	return r.schemes[strings.ToLower(u[:colon])]
+}
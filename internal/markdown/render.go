@@ -0,0 +1,370 @@
+// Package markdown renders post bodies to sanitized HTML, plain text, and the links and mentions they contain.
+//
+// It supports the Markdown most posts use: paragraphs, ATX headings, emphasis, strong, ~~strikethrough~~, code
+// spans, fenced code blocks, block quotes, lists, thematic breaks, links, images, <autolinks>, bare http(s) URLs and
+// @handle mentions. The output is built from the parsed document, never copied from the input: raw HTML and entity
+// references in the source are escaped and shown as text, attributes are limited to href, src, alt, title, start,
+// rel and a language class on code blocks, and URLs must be relative or use an allowed scheme.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDepth limits nested block quotes and lists; deeper markers are rendered as text.
+const maxDepth = 16
+
+// Result is a rendered body.
+type Result struct {
+	HTML     string
+	Text     string   // plain text, one line per block
+	Links    []string // link and image URLs, in order, without duplicates (mentions are not included)
+	Mentions []string // mentioned handles, lowercased, in order, without duplicates
+}
+
+type state struct {
+	r        *Renderer
+	html     strings.Builder
+	text     strings.Builder
+	links    []string
+	mentions []string
+	seen     map[string]bool
+	depth    int
+}
+
+// Render renders src.
+func (r *Renderer) Render(src string) Result {
+	st := &state{r: r, seen: make(map[string]bool)}
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\x00", "�")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	st.blocks(strings.Split(src, "\n"), false)
+	return Result{
+		HTML:     strings.TrimRight(st.html.String(), "\n"),
+		Text:     strings.TrimSpace(st.text.String()),
+		Links:    st.links,
+		Mentions: st.mentions,
+	}
+}
+
+func (st *state) open(element, attrs string) {
+	if st.r.allowed(element) {
+		st.html.WriteString("<" + element + attrs + ">")
+	}
+}
+
+func (st *state) close(element string) {
+	if st.r.allowed(element) {
+		st.html.WriteString("</" + element + ">")
+	}
+}
+
+// endBlock ends a leaf block in both outputs.
+func (st *state) endBlock() {
+	st.html.WriteByte('\n')
+	st.text.WriteByte('\n')
+}
+
+func (st *state) addLink(u string) {
+	if !st.seen["link:"+u] {
+		st.seen["link:"+u] = true
+		st.links = append(st.links, u)
+	}
+}
+
+func (st *state) addMention(handle string) {
+	if !st.seen["mention:"+handle] {
+		st.seen["mention:"+handle] = true
+		st.mentions = append(st.mentions, handle)
+	}
+}
+
+// blocks renders lines as a sequence of blocks. In tight list items, paragraphs are rendered without <p>.
+func (st *state) blocks(lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case isFence(line):
+			i = st.codeBlock(lines, i)
+		case isHeading(line):
+			st.heading(line)
+			i++
+		case isRule(line):
+			st.open("hr", "")
+			st.endBlock()
+			i++
+		case isQuote(line) && st.depth < maxDepth:
+			j := i
+			var inner []string
+			for j < len(lines) && isQuote(lines[j]) {
+				inner = append(inner, stripQuote(lines[j]))
+				j++
+			}
+			st.depth++
+			st.open("blockquote", "")
+			st.html.WriteByte('\n')
+			st.blocks(inner, false)
+			st.close("blockquote")
+			st.html.WriteByte('\n')
+			st.depth--
+			i = j
+		case isListItem(line) && st.depth < maxDepth:
+			i = st.list(lines, i)
+		default:
+			j := i + 1
+			for j < len(lines) && !isBlank(lines[j]) && !interruptsParagraph(lines[j]) {
+				j++
+			}
+			st.paragraph(lines[i:j], tight)
+			i = j
+		}
+	}
+}
+
+func (st *state) paragraph(lines []string, tight bool) {
+	for i := range lines {
+		lines[i] = strings.TrimLeft(lines[i], " ")
+	}
+	if !tight {
+		st.open("p", "")
+	}
+	st.inline(strings.TrimRight(strings.Join(lines, "\n"), " "), false)
+	if !tight {
+		st.close("p")
+	}
+	st.endBlock()
+}
+
+var headingRegex = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*))?$`)
+var closingHashesRegex = regexp.MustCompile(`(^|[ ]+)#+[ ]*$`)
+
+func isHeading(line string) bool {
+	return headingRegex.MatchString(line)
+}
+
+func (st *state) heading(line string) {
+	m := headingRegex.FindStringSubmatch(line)
+	element := "h" + strconv.Itoa(len(m[1]))
+	content := closingHashesRegex.ReplaceAllString(strings.TrimRight(m[2], " "), "")
+	st.open(element, "")
+	st.inline(strings.TrimSpace(content), false)
+	st.close(element)
+	st.endBlock()
+}
+
+var ruleRegex = regexp.MustCompile(`^ {0,3}(?:(?:\*[ ]*){3,}|(?:-[ ]*){3,}|(?:_[ ]*){3,})$`)
+
+func isRule(line string) bool {
+	return ruleRegex.MatchString(line)
+}
+
+var quoteRegex = regexp.MustCompile(`^ {0,3}>`)
+
+func isQuote(line string) bool {
+	return quoteRegex.MatchString(line)
+}
+
+func stripQuote(line string) string {
+	line = strings.TrimLeft(line, " ")[1:]
+	return strings.TrimPrefix(line, " ")
+}
+
+var fenceRegex = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+
+func isFence(line string) bool {
+	m := fenceRegex.FindStringSubmatch(line)
+	return m != nil && !(m[2][0] == '`' && strings.Contains(m[3], "`"))
+}
+
+var languageRegex = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,32}$`)
+
+// codeBlock renders the fenced code block starting at lines[i] and returns the index after it. An unclosed fence
+// runs to the end of the body.
+func (st *state) codeBlock(lines []string, i int) int {
+	m := fenceRegex.FindStringSubmatch(lines[i])
+	indent, fence := len(m[1]), m[2]
+	attrs := ""
+	if info := strings.Fields(m[3]); len(info) > 0 && languageRegex.MatchString(info[0]) {
+		attrs = ` class="language-` + html.EscapeString(info[0]) + `"`
+	}
+	var code []string
+	j := i + 1
+	for ; j < len(lines); j++ {
+		trimmed := strings.TrimLeft(lines[j], " ")
+		if len(lines[j])-len(trimmed) <= 3 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" ") == "" {
+			j++
+			break
+		}
+		code = append(code, dedent(lines[j], indent))
+	}
+	body := strings.Join(code, "\n")
+	if len(code) > 0 {
+		body += "\n"
+	}
+	st.open("pre", "")
+	st.open("code", attrs)
+	st.html.WriteString(html.EscapeString(body))
+	st.close("code")
+	st.close("pre")
+	st.text.WriteString(strings.TrimSuffix(body, "\n"))
+	st.endBlock()
+	return j
+}
+
+// listMarker is a list item's marker: its type and the column its content starts at.
+type listMarker struct {
+	ordered bool
+	char    byte // bullet (-, *, +) or delimiter (., ))
+	start   int
+	width   int
+}
+
+var bulletRegex = regexp.MustCompile(`^( {0,3})([-*+])( +|$)(.*)$`)
+var orderedRegex = regexp.MustCompile(`^( {0,3})([0-9]{1,9})([.)])( +|$)(.*)$`)
+
+// parseListItem returns the marker and content of a list item line.
+func parseListItem(line string) (listMarker, string, bool) {
+	if isRule(line) {
+		return listMarker{}, "", false
+	}
+	if m := bulletRegex.FindStringSubmatch(line); m != nil {
+		return listMarker{char: m[2][0], width: itemWidth(len(m[1])+1, m[3], m[4])}, m[4], true
+	}
+	if m := orderedRegex.FindStringSubmatch(line); m != nil {
+		start, _ := strconv.Atoi(m[2])
+		return listMarker{ordered: true, char: m[3][0], start: start, width: itemWidth(len(m[1])+len(m[2])+1, m[4], m[5])}, m[5], true
+	}
+	return listMarker{}, "", false
+}
+
+// itemWidth is the content column of an item: after the marker and 1-4 spaces (1 if more, or if the item is empty).
+func itemWidth(marker int, spaces, content string) int {
+	if n := len(spaces); n >= 1 && n <= 4 && content != "" {
+		return marker + n
+	}
+	return marker + 1
+}
+
+func isListItem(line string) bool {
+	_, _, ok := parseListItem(line)
+	return ok
+}
+
+// interruptsParagraph reports whether line starts a block that ends a paragraph. Like CommonMark, only an ordered
+// list starting at 1 interrupts a paragraph, so a line like "2024. What a year" stays text.
+func interruptsParagraph(line string) bool {
+	if isFence(line) || isHeading(line) || isRule(line) || isQuote(line) {
+		return true
+	}
+	m, content, ok := parseListItem(line)
+	return ok && content != "" && (!m.ordered || m.start == 1)
+}
+
+func sameList(a, b listMarker) bool {
+	return a.ordered == b.ordered && a.char == b.char
+}
+
+// list renders the list starting at lines[i] and returns the index after it. A list is loose (items wrapped in
+// <p>) if its items are separated by blank lines.
+func (st *state) list(lines []string, i int) int {
+	first, content, _ := parseListItem(lines[i])
+	m := first
+	var items [][]string
+	cur := []string{content}
+	loose := false
+	j := i + 1
+	for j < len(lines) {
+		line := lines[j]
+		if isBlank(line) {
+			k := j
+			for k < len(lines) && isBlank(lines[k]) {
+				k++
+			}
+			if k == len(lines) {
+				break
+			}
+			if indentOf(lines[k]) >= m.width {
+				cur = append(cur, "")
+				loose = true
+				j++
+				continue
+			}
+			if next, _, ok := parseListItem(lines[k]); ok && sameList(first, next) {
+				loose = true
+				j = k
+				continue
+			}
+			break
+		}
+		if indentOf(line) >= m.width {
+			cur = append(cur, dedent(line, m.width))
+			j++
+			continue
+		}
+		if next, c, ok := parseListItem(line); ok {
+			if !sameList(first, next) {
+				break
+			}
+			items = append(items, cur)
+			cur, m = []string{c}, next
+			j++
+			continue
+		}
+		if isRule(line) || interruptsParagraph(line) || (len(cur) > 0 && isBlank(cur[len(cur)-1])) {
+			break
+		}
+		cur = append(cur, strings.TrimLeft(line, " ")) // lazy paragraph continuation
+		j++
+	}
+	items = append(items, cur)
+
+	element, attrs := "ul", ""
+	if first.ordered {
+		element = "ol"
+		if first.start != 1 {
+			attrs = ` start="` + strconv.Itoa(first.start) + `"`
+		}
+	}
+	st.depth++
+	st.open(element, attrs)
+	st.html.WriteByte('\n')
+	for _, item := range items {
+		st.open("li", "")
+		// Render the item on its own so its last block's newline can be dropped before </li>.
+		outer := st.html
+		st.html = strings.Builder{}
+		st.blocks(item, !loose)
+		inner := st.html.String()
+		st.html = outer
+		st.html.WriteString(strings.TrimSuffix(inner, "\n"))
+		st.close("li")
+		st.html.WriteByte('\n')
+	}
+	st.close(element)
+	st.html.WriteByte('\n')
+	st.depth--
+	return j
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// dedent removes up to n leading spaces.
+func dedent(line string, n int) string {
+	i := 0
+	for i < n && i < len(line) && line[i] == ' ' {
+		i++
+	}
+	return line[i:]
+}
@@ -0,0 +1,176 @@
+package media
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sopatech/afterwave.fm/internal/infra"
+)
+
+// FileStore is an ObjectStore on the local filesystem, for development and tests when no S3-compatible store is
+// configured. It is also the http.Handler that serves the files and accepts the presigned uploads: PUT and GET
+// <baseURL>/<key>. Upload URLs carry an HMAC of the key, content type, size and expiry, so like S3 presigned URLs
+// they only accept the declared file.
+type FileStore struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+var keyRegex = regexp.MustCompile(`^[a-z0-9]+(/[a-z0-9-]+)*$`)
+
+// NewFileStore stores files under dir, served at baseURL (where Handler is mounted). secret signs upload URLs.
+func NewFileStore(dir, baseURL string, secret []byte) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), secret: secret}, nil
+}
+
+func (f *FileStore) path(key string) (string, error) {
+	if !keyRegex.MatchString(key) {
+		return "", errors.New("invalid object key")
+	}
+	return filepath.Join(f.dir, filepath.FromSlash(key)), nil
+}
+
+func (f *FileStore) signature(key, contentType string, size, expires int64) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write([]byte(key + "\n" + contentType + "\n" + strconv.FormatInt(size, 10) + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL is where the object is served from.
+func (f *FileStore) URL(key string) string {
+	return f.baseURL + "/" + key
+}
+
+// PresignPut returns a signed upload URL for the key, valid until expires passes.
+func (f *FileStore) PresignPut(_ context.Context, key, contentType string, size int64, expires time.Duration) (string, map[string]string, error) {
+	if _, err := f.path(key); err != nil {
+		return "", nil, err
+	}
+	exp := time.Now().Add(expires).Unix()
+	q := url.Values{}
+	q.Set("size", strconv.FormatInt(size, 10))
+	q.Set("expires", strconv.FormatInt(exp, 10))
+	q.Set("signature", f.signature(key, contentType, size, exp))
+	return f.URL(key) + "?" + q.Encode(), map[string]string{"Content-Type": contentType}, nil
+}
+
+// Stat returns the file's size and content type, or nil if it does not exist.
+func (f *FileStore) Stat(_ context.Context, key string) (*infra.ObjectInfo, error) {
+	p, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := os.ReadFile(p + ".type")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return &infra.ObjectInfo{Size: fi.Size(), ContentType: string(contentType)}, nil
+}
+
+// Delete removes the file. Deleting a missing file is not an error.
+func (f *FileStore) Delete(_ context.Context, key string) error {
+	p, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{p, p + ".type"} {
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP accepts presigned uploads (PUT) and serves files (GET, HEAD).
+func (f *FileStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	p, err := f.path(key)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		contentType, err := os.ReadFile(p + ".type")
+		if err != nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", string(contentType))
+		http.ServeFile(w, r, p)
+	case http.MethodPut:
+		f.put(w, r, key, p)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *FileStore) put(w http.ResponseWriter, r *http.Request, key, p string) {
+	q := r.URL.Query()
+	size, err1 := strconv.ParseInt(q.Get("size"), 10, 64)
+	exp, err2 := strconv.ParseInt(q.Get("expires"), 10, 64)
+	contentType := r.Header.Get("Content-Type")
+	if err1 != nil || err2 != nil || !hmac.Equal([]byte(q.Get("signature")), []byte(f.signature(key, contentType, size, exp))) {
+		http.Error(w, "signature does not match", http.StatusForbidden)
+		return
+	}
+	if time.Now().Unix() > exp {
+		http.Error(w, "upload URL expired", http.StatusForbidden)
+		return
+	}
+	if r.ContentLength != size {
+		http.Error(w, "content length does not match", http.StatusBadRequest)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(r.Body, size+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || n != size {
+		http.Error(w, "content length does not match", http.StatusBadRequest)
+		return
+	}
+	if err := os.WriteFile(p+".type", []byte(contentType), 0o644); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
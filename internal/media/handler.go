@@ -0,0 +1,89 @@
+package media
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sopatech/afterwave.fm/internal/auth"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// CreateUpload creates a pending image upload and returns where to PUT the file. Body: {"content_type": "...",
+// "size": <bytes>}.
+func (h *Handler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		ContentType string `json:"content_type"`
+		Size        int64  `json:"size"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	u, err := h.svc.CreateUpload(r.Context(), r.PathValue("handle"), body.ContentType, body.Size, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(u)
+}
+
+// Finalize checks the uploaded file and marks the media ready to attach to a post.
+func (h *Handler) Finalize(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	m, err := h.svc.Finalize(r.Context(), r.PathValue("handle"), r.PathValue("mediaId"), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// Get returns the media and its status.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	m, err := h.svc.Get(r.Context(), r.PathValue("handle"), r.PathValue("mediaId"), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case err == ErrArtistNotFound, err == ErrMediaNotFound:
+		http.Error(w, "not found", http.StatusNotFound)
+	case err == ErrForbidden:
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case err == ErrInvalidContentType, err == ErrInvalidSize:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == ErrUploadIncomplete, err == ErrUploadMismatch, err == ErrUploadExpired:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
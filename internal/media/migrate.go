@@ -0,0 +1,47 @@
+package media
+
+import "context"
+
+// HandleData moves an artist's media to a new handle after a rename and deletes it when the artist is purged.
+// Implements artists.HandleData. Object keys do not include the handle, so files stay where they are.
+type HandleData struct {
+	store   *Store
+	objects ObjectStore
+}
+
+// NewHandleData returns the handle data for media.
+func NewHandleData(store *Store, objects ObjectStore) *HandleData {
+	return &HandleData{store: store, objects: objects}
+}
+
+// MigrateHandle moves every media row (and the orphan queue entry of unattached media) to newHandle. Idempotent.
+func (m *HandleData) MigrateHandle(ctx context.Context, oldHandle, newHandle string) error {
+	rows, err := m.store.ListByArtist(ctx, oldHandle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := m.store.move(ctx, row, newHandle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeHandle deletes every file and media row of the artist. The file goes first so an interrupted run never
+// leaves a file without a record. Idempotent.
+func (m *HandleData) PurgeHandle(ctx context.Context, handle string) error {
+	rows, err := m.store.ListByArtist(ctx, handle)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := m.objects.Delete(ctx, row.Key); err != nil {
+			return err
+		}
+		if err := m.store.delete(ctx, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
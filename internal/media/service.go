@@ -0,0 +1,379 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/infra"
+)
+
+// Hosted images for posts. An upload is created for a content type and size, which returns a presigned PUT URL on
+// the object store; the client uploads the file there and then finalizes the upload, which checks the stored object
+// against what was declared. Ready media is attached to a post by ID. Uploads that are never finalized or never
+// attached (or are detached from their post) are deleted, object and record, once OrphanTTL has passed.
+
+var (
+	ErrArtistNotFound     = errors.New("artist not found")
+	ErrForbidden          = errors.New("forbidden")
+	ErrMediaNotFound      = errors.New("media not found")
+	ErrInvalidContentType = errors.New("content_type must be one of image/jpeg, image/png, image/gif, image/webp")
+	ErrInvalidSize        = errors.New("size must be between 1 byte and 10 MiB")
+	ErrUploadIncomplete   = errors.New("the file has not been uploaded yet")
+	ErrUploadMismatch     = errors.New("the uploaded file does not match the declared content type and size; create a new upload")
+	ErrNotFinalized       = errors.New("media must be finalized before it is attached to a post")
+	ErrMediaAttached      = errors.New("media is attached to another post")
+	ErrUploadExpired      = errors.New("upload expired; create a new upload")
+)
+
+// Media statuses.
+const (
+	StatusPending  = "pending"  // created; waiting for the file and finalize
+	StatusReady    = "ready"    // finalized; can be attached to a post
+	StatusAttached = "attached" // used by a post
+)
+
+// MaxImageSize is the largest image that can be uploaded.
+const MaxImageSize = 10 << 20
+
+// UploadURLTTL is how long a presigned upload URL is valid.
+const UploadURLTTL = 15 * time.Minute
+
+// DefaultOrphanTTL is how long media may go unattached before the cleaner deletes it.
+const DefaultOrphanTTL = 24 * time.Hour
+
+const cleanupBatchSize = 100
+
+var allowedContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ObjectStore holds the uploaded files. *infra.S3 implements it; FileStore is a filesystem stand-in.
+type ObjectStore interface {
+	PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (url string, headers map[string]string, err error)
+	Stat(ctx context.Context, key string) (*infra.ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// ArtistResolver resolves artist pages and checks member permissions. Implemented by artists.Service.
+type ArtistResolver interface {
+	GetByHandle(ctx context.Context, handle string) (*artists.Artist, error)
+	HasPermission(ctx context.Context, handle, userID, permission string) (bool, error)
+}
+
+type Service interface {
+	CreateUpload(ctx context.Context, handle, contentType string, size int64, actorUserID string) (*Upload, error)
+	Finalize(ctx context.Context, handle, mediaID, actorUserID string) (*Media, error)
+	Get(ctx context.Context, handle, mediaID, actorUserID string) (*Media, error)
+	Attach(ctx context.Context, handle, postID string, mediaIDs []string) ([]Media, error)
+	Detach(ctx context.Context, handle, postID string, mediaIDs []string) error
+	Reattach(ctx context.Context, handle, postID, newPostID string, mediaIDs []string) error
+	CleanupOrphans(ctx context.Context, now time.Time) (int, error)
+}
+
+// Media is an uploaded image. ExpiresAt is set while it is not attached to a post.
+type Media struct {
+	MediaID     string `json:"media_id"`
+	Status      string `json:"status"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+	PostID      string `json:"post_id,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+}
+
+// Upload is a new pending upload: PUT the file to UploadURL with UploadHeaders before UploadExpiresAt, then finalize.
+type Upload struct {
+	Media           *Media            `json:"media"`
+	UploadURL       string            `json:"upload_url"`
+	UploadMethod    string            `json:"upload_method"`
+	UploadHeaders   map[string]string `json:"upload_headers"`
+	UploadExpiresAt string            `json:"upload_expires_at"`
+}
+
+type service struct {
+	store     *Store
+	objects   ObjectStore
+	artist    ArtistResolver
+	orphanTTL time.Duration
+}
+
+// NewService returns the media service. orphanTTL <= 0 means DefaultOrphanTTL.
+func NewService(store *Store, objects ObjectStore, artist ArtistResolver, orphanTTL time.Duration) Service {
+	if orphanTTL <= 0 {
+		orphanTTL = DefaultOrphanTTL
+	}
+	return &service{store: store, objects: objects, artist: artist, orphanTTL: orphanTTL}
+}
+
+func (s *service) rowToMedia(r *mediaRow) *Media {
+	return &Media{
+		MediaID:     r.MediaID,
+		Status:      r.Status,
+		ContentType: r.ContentType,
+		Size:        r.Size,
+		URL:         s.objects.URL(r.Key),
+		PostID:      r.PostID,
+		CreatedAt:   r.CreatedAt,
+		ExpiresAt:   r.ExpiresAt,
+	}
+}
+
+// CreateUpload creates a pending upload (members who can write posts) and presigns its PUT URL.
+func (s *service) CreateUpload(ctx context.Context, handle, contentType string, size int64, actorUserID string) (*Upload, error) {
+	handle, err := s.requirePostWriter(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if !allowedContentTypes[contentType] {
+		return nil, ErrInvalidContentType
+	}
+	if size <= 0 || size > MaxImageSize {
+		return nil, ErrInvalidSize
+	}
+	now := time.Now().UTC()
+	id := uuid.New().String()
+	row := mediaRow{
+		MediaID:     id,
+		Handle:      handle,
+		Key:         "media/" + id,
+		ContentType: contentType,
+		Size:        size,
+		Status:      StatusPending,
+		CreatedBy:   actorUserID,
+		CreatedAt:   now.Format(time.RFC3339),
+		ExpiresAt:   now.Add(s.orphanTTL).Format(time.RFC3339),
+	}
+	uploadURL, headers, err := s.objects.PresignPut(ctx, row.Key, contentType, size, UploadURLTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Create(ctx, row); err != nil {
+		return nil, err
+	}
+	return &Upload{
+		Media:           s.rowToMedia(&row),
+		UploadURL:       uploadURL,
+		UploadMethod:    "PUT",
+		UploadHeaders:   headers,
+		UploadExpiresAt: now.Add(UploadURLTTL).Format(time.RFC3339),
+	}, nil
+}
+
+// Finalize checks the uploaded object against the declared content type and size and marks the upload ready. An
+// object that does not match is deleted; the upload stays pending until the cleaner removes it.
+func (s *service) Finalize(ctx context.Context, handle, mediaID, actorUserID string) (*Media, error) {
+	handle, err := s.requirePostWriter(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.store.Get(ctx, handle, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrMediaNotFound
+	}
+	if row.Status != StatusPending {
+		return s.rowToMedia(row), nil
+	}
+	now := time.Now().UTC()
+	if row.ExpiresAt <= now.Format(time.RFC3339) {
+		return nil, ErrUploadExpired
+	}
+	info, err := s.objects.Stat(ctx, row.Key)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ErrUploadIncomplete
+	}
+	if info.Size != row.Size || !strings.EqualFold(strings.TrimSpace(info.ContentType), row.ContentType) {
+		if err := s.objects.Delete(ctx, row.Key); err != nil {
+			return nil, err
+		}
+		return nil, ErrUploadMismatch
+	}
+	row.FinalizedAt = now.Format(time.RFC3339)
+	if err := s.store.Finalize(ctx, row, row.FinalizedAt); err != nil {
+		if !dynamo.IsCondCheckFailed(err) {
+			return nil, err
+		}
+		// Finalized by a concurrent request.
+		if row, err = s.store.Get(ctx, handle, mediaID); err != nil || row == nil {
+			return nil, ErrMediaNotFound
+		}
+		return s.rowToMedia(row), nil
+	}
+	row.Status = StatusReady
+	return s.rowToMedia(row), nil
+}
+
+// Get returns the artist's media (members who can write posts).
+func (s *service) Get(ctx context.Context, handle, mediaID, actorUserID string) (*Media, error) {
+	handle, err := s.requirePostWriter(ctx, handle, actorUserID)
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.store.Get(ctx, handle, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrMediaNotFound
+	}
+	return s.rowToMedia(row), nil
+}
+
+// Attach attaches the artist's ready media to the post, in order, and returns it. Media already attached to the post
+// is left as is. The caller checks the actor may edit the post. On error, media attached by this call stays
+// attached; the caller detaches it if the post is not saved.
+func (s *service) Attach(ctx context.Context, handle, postID string, mediaIDs []string) ([]Media, error) {
+	handle = normalizeHandle(handle)
+	now := time.Now().UTC().Format(time.RFC3339)
+	out := make([]Media, 0, len(mediaIDs))
+	for _, id := range mediaIDs {
+		row, err := s.store.Get(ctx, handle, id)
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			return nil, ErrMediaNotFound
+		}
+		switch {
+		case row.Status == StatusPending:
+			return nil, ErrNotFinalized
+		case row.Status == StatusAttached && row.PostID != postID:
+			return nil, ErrMediaAttached
+		case row.Status == StatusReady && row.ExpiresAt <= now:
+			return nil, ErrUploadExpired // the cleaner may be deleting it
+		case row.Status == StatusReady:
+			if err := s.store.Attach(ctx, row, postID, now); err != nil {
+				if dynamo.IsCondCheckFailed(err) {
+					return nil, ErrMediaAttached
+				}
+				return nil, err
+			}
+			row.Status, row.PostID, row.ExpiresAt = StatusAttached, postID, ""
+		}
+		out = append(out, *s.rowToMedia(row))
+	}
+	return out, nil
+}
+
+// Detach releases media attached to the post; it is deleted once OrphanTTL passes unless attached again. Media
+// that is missing or attached elsewhere is skipped.
+func (s *service) Detach(ctx context.Context, handle, postID string, mediaIDs []string) error {
+	handle = normalizeHandle(handle)
+	expiresAt := time.Now().UTC().Add(s.orphanTTL).Format(time.RFC3339)
+	var errs []error
+	for _, id := range mediaIDs {
+		row, err := s.store.Get(ctx, handle, id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if row == nil || row.Status != StatusAttached || row.PostID != postID {
+			continue
+		}
+		if err := s.store.Detach(ctx, row, postID, expiresAt); err != nil && !dynamo.IsCondCheckFailed(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reattach moves media attached to the post over to newPostID when the post gets a new slug. Media that is missing,
+// attached elsewhere or already moved is skipped, so an interrupted move can be repeated.
+func (s *service) Reattach(ctx context.Context, handle, postID, newPostID string, mediaIDs []string) error {
+	handle = normalizeHandle(handle)
+	var errs []error
+	for _, id := range mediaIDs {
+		row, err := s.store.Get(ctx, handle, id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if row == nil || row.Status != StatusAttached || row.PostID != postID {
+			continue
+		}
+		if err := s.store.Reattach(ctx, row, postID, newPostID); err != nil && !dynamo.IsCondCheckFailed(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CleanupOrphans deletes up to cleanupBatchSize uploads whose orphan deadline has passed: the object first, then
+// the record. Returns how many were deleted. Safe to run on several tasks at once.
+func (s *service) CleanupOrphans(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.store.ListOrphansDue(ctx, now.UTC().Format(time.RFC3339), cleanupBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	var errs []error
+	for _, e := range due {
+		row, err := s.store.Get(ctx, e.Handle, e.MediaID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if row == nil || row.Status == StatusAttached || row.ExpiresAt != e.ExpiresAt {
+			// Attached, detached again with a new deadline, or moved to a new handle meanwhile.
+			if err := s.store.DeleteQueueEntry(ctx, e); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := s.objects.Delete(ctx, row.Key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.store.DeleteOrphan(ctx, row); err != nil {
+			if !dynamo.IsCondCheckFailed(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// requirePostWriter normalizes the handle and returns ErrArtistNotFound or ErrForbidden unless the user can create
+// or edit posts on the page.
+func (s *service) requirePostWriter(ctx context.Context, handle, userID string) (string, error) {
+	handle = normalizeHandle(handle)
+	if handle == "" {
+		return "", ErrArtistNotFound
+	}
+	if a, err := s.artist.GetByHandle(ctx, handle); err != nil || a == nil {
+		return "", ErrArtistNotFound
+	}
+	for _, perm := range []string{artists.PermFeedCreate, artists.PermFeedUpdate, artists.PermFeedUpdateOwn} {
+		ok, err := s.artist.HasPermission(ctx, handle, userID, perm)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return handle, nil
+		}
+	}
+	return "", ErrForbidden
+}
+
+func normalizeHandle(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
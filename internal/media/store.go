@@ -0,0 +1,196 @@
+package media
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guregu/dynamo/v2"
+
+	"github.com/sopatech/afterwave.fm/internal/infra"
+)
+
+// Media uploads live in the artist partition; orphans are queued in one partition for the cleaner.
+// - Media row: PK = ARTISTS#<handle>, SK = MEDIA#<media_id> — object key, content type, size, status, the post it
+//   is attached to, and expires_at while it is not attached.
+// - Orphan queue row: PK = MEDIA#ORPHANS, SK = <expires_at>#<media_id> — every upload that is not attached to a post
+//   (pending, or ready but unused), for the cleaner. Attaching removes it; detaching adds it back with a new deadline.
+//   (Handles are lowercase, so MEDIA#ORPHANS cannot collide with an artist partition.)
+
+const (
+	artistPKPrefix = "ARTISTS#"
+	mediaSKPrefix  = "MEDIA#"
+	orphansPK      = "MEDIA#ORPHANS"
+)
+
+type mediaRow struct {
+	PK          string `dynamo:"pk"`
+	SK          string `dynamo:"sk"`
+	MediaID     string `dynamo:"media_id"`
+	Handle      string `dynamo:"handle"`
+	Key         string `dynamo:"object_key"`
+	ContentType string `dynamo:"content_type"`
+	Size        int64  `dynamo:"size"`
+	Status      string `dynamo:"status"`
+	PostID      string `dynamo:"post_id,omitempty"`
+	CreatedBy   string `dynamo:"created_by"`
+	CreatedAt   string `dynamo:"created_at"`
+	FinalizedAt string `dynamo:"finalized_at,omitempty"`
+	ExpiresAt   string `dynamo:"expires_at,omitempty"` // while not attached: when the cleaner deletes it
+}
+
+type orphanRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	MediaID   string `dynamo:"media_id"`
+	Handle    string `dynamo:"handle"`
+	ExpiresAt string `dynamo:"expires_at"`
+}
+
+type Store struct {
+	db        *infra.Dynamo
+	tableName string
+}
+
+func NewStore(db *infra.Dynamo, tableName string) *Store {
+	return &Store{db: db, tableName: tableName}
+}
+
+func (s *Store) tbl() dynamo.Table {
+	return s.db.Table(s.tableName)
+}
+
+func artistPK(handle string) string {
+	return artistPKPrefix + handle
+}
+
+func mediaSK(mediaID string) string {
+	return mediaSKPrefix + mediaID
+}
+
+func orphanSK(expiresAt, mediaID string) string {
+	return expiresAt + "#" + mediaID
+}
+
+func orphanFor(row *mediaRow) orphanRow {
+	return orphanRow{PK: orphansPK, SK: orphanSK(row.ExpiresAt, row.MediaID), MediaID: row.MediaID, Handle: row.Handle, ExpiresAt: row.ExpiresAt}
+}
+
+// Create writes a new pending upload and its orphan queue entry in one transaction.
+func (s *Store) Create(ctx context.Context, row mediaRow) error {
+	row.PK, row.SK = artistPK(row.Handle), mediaSK(row.MediaID)
+	return s.db.WriteTx().
+		Put(s.tbl().Put(row).If("attribute_not_exists(pk)")).
+		Put(s.tbl().Put(orphanFor(&row))).
+		Run(ctx)
+}
+
+// Get returns the artist's media row, or nil if not found.
+func (s *Store) Get(ctx context.Context, handle, mediaID string) (*mediaRow, error) {
+	var row mediaRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.Equal, mediaSK(mediaID)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// Finalize marks a pending upload ready. Fails with a condition check if it is no longer pending.
+func (s *Store) Finalize(ctx context.Context, row *mediaRow, finalizedAt string) error {
+	return s.tbl().Update("pk", artistPK(row.Handle)).Range("sk", mediaSK(row.MediaID)).
+		Set("status", StatusReady).
+		Set("finalized_at", finalizedAt).
+		If("$ = ?", "status", StatusPending).
+		Run(ctx)
+}
+
+// Attach attaches ready media to the post and drops its orphan queue entry, in one transaction. Fails with a
+// condition check if the media is no longer ready (attached elsewhere, or deleted by the cleaner) or its deadline is
+// not after now, so the cleaner never deletes media that is being attached.
+func (s *Store) Attach(ctx context.Context, row *mediaRow, postID, now string) error {
+	return s.db.WriteTx().
+		Update(s.tbl().Update("pk", artistPK(row.Handle)).Range("sk", mediaSK(row.MediaID)).
+			Set("status", StatusAttached).
+			Set("post_id", postID).
+			Remove("expires_at").
+			If("$ = ? AND expires_at = ? AND expires_at > ?", "status", StatusReady, row.ExpiresAt, now)).
+		Delete(s.tbl().Delete("pk", orphansPK).Range("sk", orphanSK(row.ExpiresAt, row.MediaID))).
+		Run(ctx)
+}
+
+// Reattach points media attached to postID at newPostID (the post's new slug). Fails with a condition check if it is
+// not attached to postID.
+func (s *Store) Reattach(ctx context.Context, row *mediaRow, postID, newPostID string) error {
+	return s.tbl().Update("pk", artistPK(row.Handle)).Range("sk", mediaSK(row.MediaID)).
+		Set("post_id", newPostID).
+		If("$ = ? AND post_id = ?", "status", StatusAttached, postID).
+		Run(ctx)
+}
+
+// Detach makes media attached to postID ready again with a new orphan deadline, and queues it, in one transaction.
+// Fails with a condition check if it is not attached to postID.
+func (s *Store) Detach(ctx context.Context, row *mediaRow, postID, expiresAt string) error {
+	detached := *row
+	detached.ExpiresAt = expiresAt
+	return s.db.WriteTx().
+		Update(s.tbl().Update("pk", artistPK(row.Handle)).Range("sk", mediaSK(row.MediaID)).
+			Set("status", StatusReady).
+			Remove("post_id").
+			Set("expires_at", expiresAt).
+			If("$ = ? AND post_id = ?", "status", StatusAttached, postID)).
+		Put(s.tbl().Put(orphanFor(&detached))).
+		Run(ctx)
+}
+
+// ListOrphansDue returns up to limit orphan queue entries whose deadline is before now (RFC 3339), oldest first.
+func (s *Store) ListOrphansDue(ctx context.Context, now string, limit int) ([]orphanRow, error) {
+	var out []orphanRow
+	err := s.tbl().Get("pk", orphansPK).Range("sk", dynamo.Less, now).Limit(limit).All(ctx, &out)
+	return out, err
+}
+
+// DeleteOrphan removes the media row and its orphan queue entry in one transaction. Fails with a condition check if
+// the media was attached (or detached again with a new deadline) meanwhile.
+func (s *Store) DeleteOrphan(ctx context.Context, row *mediaRow) error {
+	return s.db.WriteTx().
+		Delete(s.tbl().Delete("pk", artistPK(row.Handle)).Range("sk", mediaSK(row.MediaID)).
+			If("$ <> ? AND expires_at = ?", "status", StatusAttached, row.ExpiresAt)).
+		Delete(s.tbl().Delete("pk", orphansPK).Range("sk", orphanSK(row.ExpiresAt, row.MediaID))).
+		Run(ctx)
+}
+
+// DeleteQueueEntry removes a stale orphan queue entry (the media was attached, moved or deleted).
+func (s *Store) DeleteQueueEntry(ctx context.Context, e orphanRow) error {
+	return s.tbl().Delete("pk", e.PK).Range("sk", e.SK).Run(ctx)
+}
+
+// ListByArtist returns every media row of the artist.
+func (s *Store) ListByArtist(ctx context.Context, handle string) ([]mediaRow, error) {
+	var out []mediaRow
+	err := s.tbl().Get("pk", artistPK(handle)).Range("sk", dynamo.BeginsWith, mediaSKPrefix).All(ctx, &out)
+	return out, err
+}
+
+// move moves the media row to newHandle and points its orphan queue entry (if unattached) at it, in one transaction.
+func (s *Store) move(ctx context.Context, row mediaRow, newHandle string) error {
+	moved := row
+	moved.PK, moved.Handle = artistPK(newHandle), newHandle
+	tx := s.db.WriteTx().
+		Put(s.tbl().Put(moved)).
+		Delete(s.tbl().Delete("pk", row.PK).Range("sk", row.SK))
+	if row.ExpiresAt != "" {
+		tx = tx.Put(s.tbl().Put(orphanFor(&moved)))
+	}
+	return tx.Run(ctx)
+}
+
+// delete removes the media row and its orphan queue entry, if any, in one transaction.
+func (s *Store) delete(ctx context.Context, row mediaRow) error {
+	tx := s.db.WriteTx().Delete(s.tbl().Delete("pk", row.PK).Range("sk", row.SK))
+	if row.ExpiresAt != "" {
+		tx = tx.Delete(s.tbl().Delete("pk", orphansPK).Range("sk", orphanSK(row.ExpiresAt, row.MediaID)))
+	}
+	return tx.Run(ctx)
+}
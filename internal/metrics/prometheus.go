@@ -49,3 +49,27 @@ func Handler() http.Handler {
 func HandlerForRegistry(reg *prometheus.Registry) http.Handler {
 	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
 }
+
+// DynamoReadRecorder records how many DynamoDB read requests each HTTP request made, by route pattern
+// (e.g. "GET /artists/{handle}"). The histogram's _sum/_count per route is the average reads per request.
+type DynamoReadRecorder struct {
+	reads *prometheus.HistogramVec
+}
+
+// NewDynamoReadRecorder creates a recorder and registers its histogram on reg.
+func NewDynamoReadRecorder(reg prometheus.Registerer) (*DynamoReadRecorder, error) {
+	reads := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_dynamodb_reads",
+		Help:    "DynamoDB read requests (GetItem, BatchGetItem, TransactGetItems, Query, Scan) per HTTP request, by route.",
+		Buckets: []float64{0, 1, 2, 3, 4, 6, 8, 12, 16, 24, 32, 64},
+	}, []string{"route"})
+	if err := reg.Register(reads); err != nil {
+		return nil, err
+	}
+	return &DynamoReadRecorder{reads: reads}, nil
+}
+
+// ObserveReads records the reads made by one request to route.
+func (r *DynamoReadRecorder) ObserveReads(route string, reads int64) {
+	r.reads.WithLabelValues(route).Observe(float64(reads))
+}
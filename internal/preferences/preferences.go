@@ -0,0 +1,224 @@
+package preferences
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // embed the zone database so timezone validation works in minimal containers
+)
+
+// CurrentVersion is the schema version of the Preferences document. Bump it when fields change
+// meaning; Normalize upgrades older stored documents on read.
+const CurrentVersion = 1
+
+// Email notification categories a user can opt out of individually.
+const (
+	EmailNewPosts       = "new_posts"
+	EmailNewMusic       = "new_music"
+	EmailNewGigs        = "new_gigs"
+	EmailProductUpdates = "product_updates"
+)
+
+// maxMutedArtists caps the muted-artist list so the feed query stays small.
+const maxMutedArtists = 500
+
+// maxBlockedUsers caps the blocked-user list, which is read on every comment listing.
+const maxBlockedUsers = 1000
+
+var ErrInvalid = errors.New("invalid preferences")
+
+var (
+	localeRegex = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+	handleRegex = regexp.MustCompile(`^[a-z0-9]{4,64}$`)
+	userIDRegex = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
+)
+
+// Reader returns a user's preferences with defaults applied. Implemented by users.Service;
+// feed and other subsystems depend on this interface instead of importing users.
+type Reader interface {
+	GetPreferences(ctx context.Context, userID string) (*Preferences, error)
+}
+
+// Preferences is the per-user settings document (GET/PATCH /users/me/settings).
+type Preferences struct {
+	Version      int              `json:"version"`
+	ShowExplicit bool             `json:"show_explicit"`
+	Timezone     string           `json:"timezone"` // IANA zone, e.g. Europe/London; used for the collated gig calendar
+	Locale       string           `json:"locale"`   // BCP 47 tag, e.g. en or en-GB
+	Email        EmailPreferences `json:"email"`
+	FeedFilters  FeedFilters      `json:"feed_filters"`
+	BlockedUsers []string         `json:"blocked_users"` // user IDs whose comments are hidden from the user and who can't reply to them
+	UpdatedAt    string           `json:"updated_at,omitempty"`
+}
+
+// EmailPreferences controls notification emails. Enabled=false is the global "email off" switch;
+// OptOuts lists categories the user does not want even when email is enabled.
+type EmailPreferences struct {
+	Enabled bool     `json:"enabled"`
+	OptOuts []string `json:"opt_outs"`
+}
+
+// FeedFilters are the defaults applied to the collated feed (GET /feed).
+type FeedFilters struct {
+	MutedArtists []string `json:"muted_artists"` // followed artists hidden from the collated feed
+}
+
+// Patch is a partial update; nil fields are left unchanged.
+type Patch struct {
+	ShowExplicit *bool             `json:"show_explicit"`
+	Timezone     *string           `json:"timezone"`
+	Locale       *string           `json:"locale"`
+	Email        *EmailPatch       `json:"email"`
+	FeedFilters  *FeedFiltersPatch `json:"feed_filters"`
+	BlockedUsers *[]string         `json:"blocked_users"`
+}
+
+type EmailPatch struct {
+	Enabled *bool     `json:"enabled"`
+	OptOuts *[]string `json:"opt_outs"`
+}
+
+type FeedFiltersPatch struct {
+	MutedArtists *[]string `json:"muted_artists"`
+}
+
+// Default returns the preferences for a user who has never saved any.
+func Default() Preferences {
+	return Preferences{
+		Version:      CurrentVersion,
+		ShowExplicit: true,
+		Timezone:     "UTC",
+		Locale:       "en",
+		Email:        EmailPreferences{Enabled: true, OptOuts: []string{}},
+		FeedFilters:  FeedFilters{MutedArtists: []string{}},
+		BlockedUsers: []string{},
+	}
+}
+
+// EmailCategories returns the categories accepted in Email.OptOuts.
+func EmailCategories() []string {
+	return []string{EmailNewPosts, EmailNewMusic, EmailNewGigs, EmailProductUpdates}
+}
+
+// Normalize fills empty fields with defaults and upgrades the document to CurrentVersion.
+func (p Preferences) Normalize() Preferences {
+	def := Default()
+	if p.Timezone == "" {
+		p.Timezone = def.Timezone
+	}
+	if p.Locale == "" {
+		p.Locale = def.Locale
+	}
+	if p.Email.OptOuts == nil {
+		p.Email.OptOuts = []string{}
+	}
+	if p.FeedFilters.MutedArtists == nil {
+		p.FeedFilters.MutedArtists = []string{}
+	}
+	if p.BlockedUsers == nil {
+		p.BlockedUsers = []string{}
+	}
+	p.Version = CurrentVersion
+	return p
+}
+
+// Apply returns p with the non-nil fields of patch applied. Values are trimmed and deduplicated; call Validate on the result.
+func (p Preferences) Apply(patch Patch) Preferences {
+	if patch.ShowExplicit != nil {
+		p.ShowExplicit = *patch.ShowExplicit
+	}
+	if patch.Timezone != nil {
+		p.Timezone = strings.TrimSpace(*patch.Timezone)
+	}
+	if patch.Locale != nil {
+		p.Locale = strings.TrimSpace(*patch.Locale)
+	}
+	if patch.Email != nil {
+		if patch.Email.Enabled != nil {
+			p.Email.Enabled = *patch.Email.Enabled
+		}
+		if patch.Email.OptOuts != nil {
+			p.Email.OptOuts = dedupe(*patch.Email.OptOuts, strings.TrimSpace)
+		}
+	}
+	if patch.FeedFilters != nil && patch.FeedFilters.MutedArtists != nil {
+		p.FeedFilters.MutedArtists = dedupe(*patch.FeedFilters.MutedArtists, func(s string) string {
+			return strings.ToLower(strings.TrimSpace(s))
+		})
+	}
+	if patch.BlockedUsers != nil {
+		p.BlockedUsers = dedupe(*patch.BlockedUsers, strings.TrimSpace)
+	}
+	return p
+}
+
+// Validate returns an error wrapping ErrInvalid if any field is out of range.
+func (p Preferences) Validate() error {
+	if p.Timezone == "" || p.Timezone == "Local" {
+		return fmt.Errorf("%w: timezone must be an IANA zone name", ErrInvalid)
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalid, p.Timezone)
+	}
+	if !localeRegex.MatchString(p.Locale) {
+		return fmt.Errorf("%w: locale must be a language tag such as en or en-GB", ErrInvalid)
+	}
+	for _, c := range p.Email.OptOuts {
+		if !validEmailCategory(c) {
+			return fmt.Errorf("%w: unknown email category %q", ErrInvalid, c)
+		}
+	}
+	if len(p.FeedFilters.MutedArtists) > maxMutedArtists {
+		return fmt.Errorf("%w: at most %d muted artists", ErrInvalid, maxMutedArtists)
+	}
+	for _, h := range p.FeedFilters.MutedArtists {
+		if !handleRegex.MatchString(h) {
+			return fmt.Errorf("%w: invalid artist handle %q", ErrInvalid, h)
+		}
+	}
+	if len(p.BlockedUsers) > maxBlockedUsers {
+		return fmt.Errorf("%w: at most %d blocked users", ErrInvalid, maxBlockedUsers)
+	}
+	for _, id := range p.BlockedUsers {
+		if !userIDRegex.MatchString(id) {
+			return fmt.Errorf("%w: invalid user ID %q", ErrInvalid, id)
+		}
+	}
+	return nil
+}
+
+// Blocks reports whether the user has blocked userID.
+func (p Preferences) Blocks(userID string) bool {
+	for _, id := range p.BlockedUsers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func validEmailCategory(c string) bool {
+	for _, known := range EmailCategories() {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupe(in []string, clean func(string) string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		s = clean(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
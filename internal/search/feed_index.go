@@ -71,7 +71,8 @@ func (f *FeedIndex) DeletePost(ctx context.Context, artistHandle, postID string)
 		return fmt.Errorf("artist_handle and post_id required")
 	}
 	id := artistHandle + "#" + postID
-	url := f.os.BaseURL + "/" + f.index + "/_doc/" + id
+	// refresh=wait_for so the post is gone from search when the call returns (e.g. a page that just went private)
+	url := f.os.BaseURL + "/" + f.index + "/_doc/" + id + "?refresh=wait_for"
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return err
@@ -99,7 +100,8 @@ type SearchFeedResult struct {
 
 // SearchFeed returns post refs from the feed index: filter by artist_handle in handles, sort by created_at desc.
 // cursor is optional (opaque, from previous response next_cursor). nextCursor is non-empty when more results exist.
-func (f *FeedIndex) SearchFeed(ctx context.Context, artistHandles []string, size int, cursor string) ([]SearchFeedResult, string, error) {
+// When excludeExplicit is true, posts marked explicit are filtered out.
+func (f *FeedIndex) SearchFeed(ctx context.Context, artistHandles []string, size int, cursor string, excludeExplicit bool) ([]SearchFeedResult, string, error) {
 	if size <= 0 {
 		size = 20
 	}
@@ -110,6 +112,14 @@ func (f *FeedIndex) SearchFeed(ctx context.Context, artistHandles []string, size
 		query = map[string]any{
 			"terms": map[string]any{"artist_handle": artistHandles},
 		}
+		if excludeExplicit {
+			query = map[string]any{
+				"bool": map[string]any{
+					"filter":   []any{query},
+					"must_not": []any{map[string]any{"term": map[string]any{"explicit": true}}},
+				},
+			}
+		}
 	}
 	// sort by created_at desc then _id asc for deterministic search_after cursor
 	sortSpec := []map[string]any{
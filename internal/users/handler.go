@@ -15,6 +15,7 @@ import (
 
 	"github.com/sopatech/afterwave.fm/internal/auth"
 	"github.com/sopatech/afterwave.fm/internal/cognito"
+	"github.com/sopatech/afterwave.fm/internal/preferences"
 )
 
 const oauthStateCookieName = "oauth_state"
@@ -86,6 +87,10 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 	}
 	code, expiresIn, err := h.authSvc.CreateAuthCode(r.Context(), userID, body.ClientID, body.CodeChallenge, codeChallengeMethod)
 	if err != nil {
+		if err == auth.ErrUserSuspended {
+			http.Error(w, "account suspended", http.StatusForbidden)
+			return
+		}
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -131,6 +136,10 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 	code, expiresIn, err := h.authSvc.CreateAuthCode(r.Context(), userID, body.ClientID, body.CodeChallenge, codeChallengeMethod)
 	if err != nil {
+		if err == auth.ErrUserSuspended {
+			http.Error(w, "account suspended", http.StatusForbidden)
+			return
+		}
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -163,6 +172,131 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
+// GetSettings returns the current user's preferences (defaults when none saved).
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := h.svc.GetPreferences(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// UpdateSettings applies a partial update to the current user's preferences. Omitted fields are unchanged.
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var patch preferences.Patch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := h.svc.UpdatePreferences(r.Context(), userID, patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, preferences.ErrInvalid):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err == ErrUserNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// RequestEmailChange sends a verification token to the new address. The email changes only after ConfirmEmailChange.
+func (h *Handler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.RequestEmailChange(r.Context(), userID, body.Email); err != nil {
+		switch {
+		case err == ErrInvalidEmail, err == ErrSameEmail:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err == ErrEmailManagedByProvider:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case err == ErrEmailTaken:
+			http.Error(w, "email already in use", http.StatusConflict)
+		case err == ErrUserNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{
+		"expires_in": int(EmailChangeTTL.Seconds()),
+	})
+}
+
+// ConfirmEmailChange applies the pending email change if the token matches. Returns the updated user.
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.svc.ConfirmEmailChange(r.Context(), userID, body.Token)
+	if err != nil {
+		switch {
+		case err == ErrEmailChangeInvalid:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err == ErrEmailManagedByProvider:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case err == ErrEmailTaken:
+			http.Error(w, "email already in use", http.StatusConflict)
+		case err == ErrUserNotFound:
+			http.Error(w, "not found", http.StatusNotFound)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
 func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	userID := auth.UserIDFromContext(r.Context())
 	if userID == "" {
@@ -540,6 +674,10 @@ func (h *Handler) FederatedCallback(w http.ResponseWriter, r *http.Request) {
 
 	authCode, _, err := h.authSvc.CreateAuthCode(r.Context(), userID, clientID, codeChallenge, codeMethod)
 	if err != nil {
+		if err == auth.ErrUserSuspended {
+			http.Error(w, "account suspended", http.StatusForbidden)
+			return
+		}
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
@@ -2,14 +2,20 @@ package users
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/guregu/dynamo/v2"
 
 	"github.com/sopatech/afterwave.fm/internal/cognito"
+	"github.com/sopatech/afterwave.fm/internal/mail"
+	"github.com/sopatech/afterwave.fm/internal/preferences"
 )
 
 var (
@@ -17,16 +23,30 @@ var (
 	ErrInvalidCreds           = errors.New("invalid email or password")
 	ErrUserNotFound           = errors.New("user not found")
 	ErrAccountExistsWithPassword = errors.New("account already exists with email and password; use password login")
+	ErrInvalidEmail           = errors.New("invalid email address")
+	ErrSameEmail              = errors.New("new email is the same as the current email")
+	ErrEmailManagedByProvider = errors.New("email is managed by your sign-in provider")
+	ErrEmailChangeInvalid     = errors.New("invalid or expired email verification token")
 )
 
+// EmailChangeTTL is how long an email change verification token is valid.
+const EmailChangeTTL = 24 * time.Hour
+
 
 type Service interface {
 	Signup(ctx context.Context, email, password string) (userID string, err error)
 	Login(ctx context.Context, email, password string) (userID string, err error)
 	DeleteAccount(ctx context.Context, userID string) error
 	GetByID(ctx context.Context, userID string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByCognitoSub(ctx context.Context, cognitoSub string) (*User, error)
+	ListIdentities(ctx context.Context, userID string) ([]Identity, error)
 	EnsureUserForCognito(ctx context.Context, email, cognitoSub string) (userID string, err error)
 	LinkCognitoSub(ctx context.Context, userID, cognitoSub string) error
+	GetPreferences(ctx context.Context, userID string) (*preferences.Preferences, error)
+	UpdatePreferences(ctx context.Context, userID string, patch preferences.Patch) (*preferences.Preferences, error)
+	RequestEmailChange(ctx context.Context, userID, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, userID, token string) (*User, error)
 }
 
 type User struct {
@@ -35,20 +55,30 @@ type User struct {
 	CreatedAt string `json:"created_at"`
 }
 
+// Identity is a Cognito identity that signs in as the user: the sub the account was created with (primary)
+// and any Google/Apple subs linked afterwards.
+type Identity struct {
+	CognitoSub string `json:"cognito_sub"`
+	Primary    bool   `json:"primary"`
+}
+
 type service struct {
 	store   *Store
 	cognito cognito.Client
+	mailer  mail.Sender
 }
 
-func NewService(store *Store, cognitoClient cognito.Client) Service {
+// NewService returns the users service. mailer delivers email change verification and notices; when nil, email change is unavailable.
+func NewService(store *Store, cognitoClient cognito.Client, mailer mail.Sender) Service {
 	return &service{
 		store:   store,
 		cognito: cognitoClient,
+		mailer:  mailer,
 	}
 }
 
 func (s *service) Signup(ctx context.Context, email, password string) (string, error) {
-	email = normalizeEmail(email)
+	email = NormalizeEmail(email)
 	if email == "" || len(password) < 8 {
 		return "", fmt.Errorf("email and password (min 8 chars) required")
 	}
@@ -72,7 +102,7 @@ func (s *service) Signup(ctx context.Context, email, password string) (string, e
 
 	userID := uuid.New().String()
 	now := time.Now().UTC().Format(time.RFC3339)
-	if err := s.store.PutUser(ctx, userID, email, cognitoSub, now); err != nil {
+	if err := s.store.PutUser(ctx, userID, email, cognitoSub, now, false); err != nil {
 		if dynamo.IsCondCheckFailed(err) {
 			return "", ErrEmailTaken
 		}
@@ -82,7 +112,7 @@ func (s *service) Signup(ctx context.Context, email, password string) (string, e
 }
 
 func (s *service) Login(ctx context.Context, email, password string) (string, error) {
-	email = normalizeEmail(email)
+	email = NormalizeEmail(email)
 	if email == "" {
 		return "", ErrInvalidCreds
 	}
@@ -134,16 +164,86 @@ func (s *service) GetByID(ctx context.Context, userID string) (*User, error) {
 	}, nil
 }
 
+// GetByEmail returns the user registered with the email (case-insensitive), or ErrUserNotFound.
+func (s *service) GetByEmail(ctx context.Context, email string) (*User, error) {
+	email = NormalizeEmail(email)
+	if email == "" {
+		return nil, ErrUserNotFound
+	}
+	row, err := s.store.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrUserNotFound
+	}
+	return rowToUser(row), nil
+}
+
+// GetByCognitoSub returns the user for a primary or linked Cognito sub, or ErrUserNotFound.
+func (s *service) GetByCognitoSub(ctx context.Context, cognitoSub string) (*User, error) {
+	row, err := s.store.GetByCognitoSub(ctx, cognitoSub)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrUserNotFound
+	}
+	return rowToUser(row), nil
+}
+
+// ListIdentities returns the Cognito identities that sign in as the user, primary first.
+func (s *service) ListIdentities(ctx context.Context, userID string) ([]Identity, error) {
+	row, err := s.store.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrUserNotFound
+	}
+	out := []Identity{}
+	if row.CognitoSub != "" {
+		out = append(out, Identity{CognitoSub: row.CognitoSub, Primary: true})
+	}
+	linked, err := s.store.listLinkedCognitoSubs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range linked {
+		out = append(out, Identity{CognitoSub: sub})
+	}
+	return out, nil
+}
+
+func rowToUser(r *userRow) *User {
+	return &User{
+		ID:        r.ID,
+		Email:     r.Email,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
 // EnsureUserForCognito finds or creates a user for the given Cognito identity (email + sub).
 // Used by federated login flows where Cognito has already authenticated the user.
 // Returns ErrAccountExistsWithPassword if a user with this email already exists with a different
 // Cognito identity (e.g. native signup), to prevent federated account takeover.
 func (s *service) EnsureUserForCognito(ctx context.Context, email, cognitoSub string) (string, error) {
-	email = normalizeEmail(email)
+	email = NormalizeEmail(email)
 	if email == "" {
 		return "", fmt.Errorf("email required")
 	}
 
+	// A known sub wins over the email: the user may have changed their email since linking this IdP.
+	if cognitoSub != "" {
+		bySub, err := s.store.GetByCognitoSub(ctx, cognitoSub)
+		if err != nil {
+			return "", err
+		}
+		if bySub != nil {
+			return bySub.ID, nil
+		}
+	}
+
 	row, err := s.store.GetByEmail(ctx, email)
 	if err != nil {
 		return "", err
@@ -165,7 +265,7 @@ func (s *service) EnsureUserForCognito(ctx context.Context, email, cognitoSub st
 
 	userID := uuid.New().String()
 	now := time.Now().UTC().Format(time.RFC3339)
-	if err := s.store.PutUser(ctx, userID, email, cognitoSub, now); err != nil {
+	if err := s.store.PutUser(ctx, userID, email, cognitoSub, now, true); err != nil {
 		if dynamo.IsCondCheckFailed(err) {
 			row, err := s.store.GetByEmail(ctx, email)
 			if err != nil {
@@ -185,7 +285,203 @@ func (s *service) LinkCognitoSub(ctx context.Context, userID, cognitoSub string)
 	return s.store.AddLinkedCognitoSub(ctx, userID, cognitoSub)
 }
 
-func normalizeEmail(s string) string {
+// GetPreferences returns the user's settings document, or defaults if none has been saved.
+// Also satisfies preferences.Reader so other subsystems can consult settings without importing users.
+func (s *service) GetPreferences(ctx context.Context, userID string) (*preferences.Preferences, error) {
+	if userID == "" {
+		return nil, ErrUserNotFound
+	}
+	row, err := s.store.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		p := preferences.Default()
+		return &p, nil
+	}
+	p := rowToPreferences(row).Normalize()
+	return &p, nil
+}
+
+// UpdatePreferences applies a partial update, validates the result, and saves it.
+// Returns an error wrapping preferences.ErrInvalid on validation failure.
+func (s *service) UpdatePreferences(ctx context.Context, userID string, patch preferences.Patch) (*preferences.Preferences, error) {
+	user, err := s.store.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	current, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	updated := current.Apply(patch)
+	if err := updated.Validate(); err != nil {
+		return nil, err
+	}
+	updated.Version = preferences.CurrentVersion
+	updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := s.store.PutPreferences(ctx, userID, preferencesToRow(updated)); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// RequestEmailChange records a pending change to newEmail and sends a verification token to that address.
+// The email is not changed until ConfirmEmailChange. Accounts created through Google/Apple cannot change email
+// here (ErrEmailManagedByProvider); password accounts with a linked IdP can.
+func (s *service) RequestEmailChange(ctx context.Context, userID, newEmail string) error {
+	if s.mailer == nil {
+		return fmt.Errorf("mail sender not configured")
+	}
+	newEmail = NormalizeEmail(newEmail)
+	if !ValidEmail(newEmail) {
+		return ErrInvalidEmail
+	}
+	row, err := s.store.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		return ErrUserNotFound
+	}
+	if federated, err := s.federated(ctx, row); err != nil {
+		return err
+	} else if federated {
+		return ErrEmailManagedByProvider
+	}
+	if newEmail == row.Email {
+		return ErrSameEmail
+	}
+	existing, err := s.store.GetByEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrEmailTaken
+	}
+
+	token := uuid.New().String()
+	if err := s.store.PutEmailChange(ctx, userID, newEmail, hashToken(token), time.Now().Add(EmailChangeTTL)); err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body:    "Use this code to confirm your new Afterwave email address: " + token + "\n\nIt expires in 24 hours. If you did not request this, ignore this email.",
+	})
+}
+
+// ConfirmEmailChange verifies the token and moves the account to the pending address: swaps the email lookup rows
+// (conditional on the new address being free), updates Cognito, and notifies the old address.
+func (s *service) ConfirmEmailChange(ctx context.Context, userID, token string) (*User, error) {
+	row, err := s.store.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, ErrUserNotFound
+	}
+	if federated, err := s.federated(ctx, row); err != nil {
+		return nil, err
+	} else if federated {
+		return nil, ErrEmailManagedByProvider
+	}
+	pending, err := s.store.GetEmailChange(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil || token == "" || subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(pending.TokenHash)) != 1 {
+		return nil, ErrEmailChangeInvalid
+	}
+	expiresAt, err := time.Parse(time.RFC3339, pending.ExpiresAt)
+	if err != nil || time.Now().After(expiresAt) {
+		_ = s.store.DeleteEmailChange(ctx, userID)
+		return nil, ErrEmailChangeInvalid
+	}
+
+	oldEmail, newEmail := row.Email, pending.NewEmail
+	if err := s.store.SwapEmail(ctx, userID, oldEmail, newEmail); err != nil {
+		if errors.Is(err, ErrEmailChangeConflict) {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+	if s.cognito != nil && row.CognitoSub != "" {
+		if err := s.cognito.AdminUpdateEmail(ctx, row.CognitoSub, newEmail); err != nil {
+			// Keep DynamoDB and Cognito in agreement: put the old address back.
+			if rbErr := s.store.SwapEmail(ctx, userID, newEmail, oldEmail); rbErr != nil {
+				return nil, errors.Join(err, rbErr)
+			}
+			return nil, err
+		}
+	}
+	if s.mailer != nil {
+		// Best effort: the change has already happened.
+		_ = s.mailer.Send(ctx, mail.Message{
+			To:      oldEmail,
+			Subject: "Your email address was changed",
+			Body:    "The email address on your Afterwave account was changed to " + newEmail + ". If you did not make this change, contact support.",
+		})
+	}
+	return &User{
+		ID:        row.ID,
+		Email:     newEmail,
+		CreatedAt: row.CreatedAt,
+	}, nil
+}
+
+// federated reports whether the user signed up through Google/Apple. Users created before the flag was stored are
+// looked up in Cognito by their primary sub, and the flag is backfilled when they turn out to be federated.
+func (s *service) federated(ctx context.Context, row *userRow) (bool, error) {
+	if row.Federated || s.cognito == nil || row.CognitoSub == "" {
+		return row.Federated, nil
+	}
+	federated, err := s.cognito.IsFederated(ctx, row.CognitoSub)
+	if err != nil || !federated {
+		return false, err
+	}
+	// Best effort: the next request asks Cognito again.
+	_ = s.store.SetFederated(ctx, row.ID)
+	return true, nil
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+func rowToPreferences(r *preferencesRow) preferences.Preferences {
+	return preferences.Preferences{
+		Version:      r.Version,
+		ShowExplicit: r.ShowExplicit,
+		Timezone:     r.Timezone,
+		Locale:       r.Locale,
+		Email:        preferences.EmailPreferences{Enabled: r.EmailEnabled, OptOuts: r.EmailOptOuts},
+		FeedFilters:  preferences.FeedFilters{MutedArtists: r.MutedArtists},
+		BlockedUsers: r.BlockedUsers,
+		UpdatedAt:    r.UpdatedAt,
+	}
+}
+
+func preferencesToRow(p preferences.Preferences) preferencesRow {
+	return preferencesRow{
+		Version:      p.Version,
+		ShowExplicit: p.ShowExplicit,
+		Timezone:     p.Timezone,
+		Locale:       p.Locale,
+		EmailEnabled: p.Email.Enabled,
+		EmailOptOuts: p.Email.OptOuts,
+		MutedArtists: p.FeedFilters.MutedArtists,
+		BlockedUsers: p.BlockedUsers,
+		UpdatedAt:    p.UpdatedAt,
+	}
+}
+
+// NormalizeEmail trims surrounding whitespace and lowercases the address; lookups and uniqueness use this form.
+func NormalizeEmail(s string) string {
 	b := []byte(s)
 	start := 0
 	for start < len(b) && (b[start] == ' ' || b[start] == '\t') {
@@ -206,3 +502,14 @@ func normalizeEmail(s string) string {
 	}
 	return string(out)
 }
+
+// ValidEmail is a minimal shape check (local@domain.tld); deliverability is proven by the verification email.
+func ValidEmail(s string) bool {
+	at := strings.LastIndexByte(s, '@')
+	if at < 1 || at == len(s)-1 || len(s) > 254 || strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+	domain := s[at+1:]
+	dot := strings.LastIndexByte(domain, '.')
+	return dot > 0 && dot < len(domain)-1
+}
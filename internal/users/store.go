@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/guregu/dynamo/v2"
 
@@ -18,26 +19,34 @@ import (
 // Email lookup row: PK = USERS#email#<shard>, SK = <email> — sharded by hash of email to avoid hot partition.
 // Cognito sub lookup row: PK = USERS#cognito_sub#<first2>, SK = <sub> — for login-by-sub (sharded by first 2 chars of sub).
 // Linked sub row (for cleanup on delete): PK = userPK(userID), SK = LINKED_SUB#<sub> — one per linked IdP.
+// Preferences row: PK = userPK(userID), SK = PREFERENCES#<id> — settings document (absent until first PATCH; defaults apply).
+// Pending email change: PK = userPK(userID), SK = EMAIL_CHANGE#<id> — new address + token hash; one per user, replaced on each request.
 
 const (
-	usersPrefix        = "USERS#user,"
-	userSKPrefix       = "USER#"
-	emailPKPrefix      = "USERS#email#"
-	emailShardLen      = 2 // first N hex chars of sha256; 2 => 256 partitions
-	cognitoSubPKPrefix = "USERS#cognito_sub#"
-	cognitoSubShardLen = 2   // first 2 chars of sub (UUID) for partition spread
-	linkedSubSKPrefix  = "LINKED_SUB#"
+	usersPrefix         = "USERS#user,"
+	userSKPrefix        = "USER#"
+	emailPKPrefix       = "USERS#email#"
+	emailShardLen       = 2 // first N hex chars of sha256; 2 => 256 partitions
+	cognitoSubPKPrefix  = "USERS#cognito_sub#"
+	cognitoSubShardLen  = 2   // first 2 chars of sub (UUID) for partition spread
+	linkedSubSKPrefix   = "LINKED_SUB#"
+	preferencesSKPrefix = "PREFERENCES#"
+	emailChangeSKPrefix = "EMAIL_CHANGE#"
 )
 
 // ErrSubLinkedToOtherAccount is returned by AddLinkedCognitoSub when the Cognito sub is already linked to a different user.
 var ErrSubLinkedToOtherAccount = errors.New("this identity is already linked to another account")
 
+// ErrEmailChangeConflict is returned by SwapEmail when the new address is taken or the user's email changed concurrently.
+var ErrEmailChangeConflict = errors.New("email change conflict")
+
 type userRow struct {
 	PK         string `dynamo:"pk"`
 	SK         string `dynamo:"sk"`
 	ID         string `dynamo:"id"`
 	Email      string `dynamo:"email"`
 	CognitoSub string `dynamo:"cognito_sub,omitempty"`
+	Federated  bool   `dynamo:"federated,omitempty"` // created via Google/Apple; no password, email owned by the IdP
 	CreatedAt  string `dynamo:"created_at"`
 }
 
@@ -55,6 +64,30 @@ type cognitoSubLookupRow struct {
 	UserID string `dynamo:"user_id"`
 }
 
+// preferencesRow is the stored settings document (see preferences.Preferences).
+type preferencesRow struct {
+	PK           string   `dynamo:"pk"`
+	SK           string   `dynamo:"sk"`
+	Version      int      `dynamo:"version"`
+	ShowExplicit bool     `dynamo:"show_explicit"`
+	Timezone     string   `dynamo:"timezone"`
+	Locale       string   `dynamo:"locale"`
+	EmailEnabled bool     `dynamo:"email_enabled"`
+	EmailOptOuts []string `dynamo:"email_opt_outs,omitempty"`
+	MutedArtists []string `dynamo:"muted_artists,omitempty"`
+	BlockedUsers []string `dynamo:"blocked_users,omitempty"`
+	UpdatedAt    string   `dynamo:"updated_at"`
+}
+
+// emailChangeRow is a pending, unverified email change. Only the token hash is stored.
+type emailChangeRow struct {
+	PK        string `dynamo:"pk"`
+	SK        string `dynamo:"sk"`
+	NewEmail  string `dynamo:"new_email"`
+	TokenHash string `dynamo:"token_hash"`
+	ExpiresAt string `dynamo:"expires_at"`
+}
+
 // linkedSubRow is stored under the user so we can delete all linked subs when the user is deleted.
 type linkedSubRow struct {
 	PK string `dynamo:"pk"`
@@ -85,6 +118,14 @@ func userSK(userID string) string {
 	return userSKPrefix + userID
 }
 
+func preferencesSK(userID string) string {
+	return preferencesSKPrefix + userID
+}
+
+func emailChangeSK(userID string) string {
+	return emailChangeSKPrefix + userID
+}
+
 // emailShard returns the first emailShardLen hex chars of sha256(email) to partition email lookups.
 func emailShard(email string) string {
 	h := sha256.Sum256([]byte(email))
@@ -206,13 +247,15 @@ func (s *Store) GetByID(ctx context.Context, userID string) (*userRow, error) {
 
 // PutUser creates a user (main row + email lookup row + optional cognito_sub lookup) in one transaction.
 // Email must be normalized (lowercase). Fails if a user with the same ID already exists.
-func (s *Store) PutUser(ctx context.Context, userID, email, cognitoSub, createdAt string) error {
+// federated marks users created through an IdP (no password); their email cannot be changed here.
+func (s *Store) PutUser(ctx context.Context, userID, email, cognitoSub, createdAt string, federated bool) error {
 	mainRow := userRow{
 		PK:         userPK(userID),
 		SK:         userSK(userID),
 		ID:         userID,
 		Email:      email,
 		CognitoSub: cognitoSub,
+		Federated:  federated,
 		CreatedAt:  createdAt,
 	}
 	emailRow := emailLookupRow{
@@ -234,7 +277,16 @@ func (s *Store) PutUser(ctx context.Context, userID, email, cognitoSub, createdA
 	return tx.Run(ctx)
 }
 
-// DeleteUser deletes the user by ID (main row + email lookup row + primary cognito_sub + all linked cognito_sub rows).
+// SetFederated marks an existing user as created through an IdP, for users stored before the flag was.
+func (s *Store) SetFederated(ctx context.Context, userID string) error {
+	return s.tbl().Update("pk", userPK(userID)).Range("sk", userSK(userID)).
+		Set("federated", true).
+		If("attribute_exists(pk)").
+		Run(ctx)
+}
+
+// DeleteUser deletes the user by ID (main row + email lookup row + primary cognito_sub + all linked cognito_sub rows,
+// plus preferences and any pending email change).
 func (s *Store) DeleteUser(ctx context.Context, userID string) error {
 	if userID == "" {
 		return fmt.Errorf("user id required")
@@ -249,7 +301,9 @@ func (s *Store) DeleteUser(ctx context.Context, userID string) error {
 	}
 	tx := s.db.WriteTx().
 		Delete(s.tbl().Delete("pk", userPK(userID)).Range("sk", userSK(userID))).
-		Delete(s.tbl().Delete("pk", emailPK(row.Email)).Range("sk", row.Email))
+		Delete(s.tbl().Delete("pk", emailPK(row.Email)).Range("sk", row.Email)).
+		Delete(s.tbl().Delete("pk", userPK(userID)).Range("sk", preferencesSK(userID))).
+		Delete(s.tbl().Delete("pk", userPK(userID)).Range("sk", emailChangeSK(userID)))
 	if row.CognitoSub != "" {
 		tx = tx.Delete(s.tbl().Delete("pk", cognitoSubPK(row.CognitoSub)).Range("sk", row.CognitoSub))
 	}
@@ -260,3 +314,82 @@ func (s *Store) DeleteUser(ctx context.Context, userID string) error {
 	}
 	return tx.Run(ctx)
 }
+
+// GetPreferences returns the stored preferences row for the user, or nil if the user has never saved any.
+func (s *Store) GetPreferences(ctx context.Context, userID string) (*preferencesRow, error) {
+	var row preferencesRow
+	err := s.tbl().Get("pk", userPK(userID)).Range("sk", dynamo.Equal, preferencesSK(userID)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// PutPreferences overwrites the user's preferences row.
+func (s *Store) PutPreferences(ctx context.Context, userID string, row preferencesRow) error {
+	if userID == "" {
+		return fmt.Errorf("user id required")
+	}
+	row.PK = userPK(userID)
+	row.SK = preferencesSK(userID)
+	return s.tbl().Put(row).Run(ctx)
+}
+
+// PutEmailChange stores (or replaces) the user's pending email change.
+func (s *Store) PutEmailChange(ctx context.Context, userID, newEmail, tokenHash string, expiresAt time.Time) error {
+	if userID == "" {
+		return fmt.Errorf("user id required")
+	}
+	row := emailChangeRow{
+		PK:        userPK(userID),
+		SK:        emailChangeSK(userID),
+		NewEmail:  newEmail,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	}
+	return s.tbl().Put(row).Run(ctx)
+}
+
+// GetEmailChange returns the user's pending email change, or nil if there is none.
+func (s *Store) GetEmailChange(ctx context.Context, userID string) (*emailChangeRow, error) {
+	var row emailChangeRow
+	err := s.tbl().Get("pk", userPK(userID)).Range("sk", dynamo.Equal, emailChangeSK(userID)).One(ctx, &row)
+	if err != nil {
+		if errors.Is(err, dynamo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// DeleteEmailChange removes the user's pending email change (no-op if absent).
+func (s *Store) DeleteEmailChange(ctx context.Context, userID string) error {
+	return s.tbl().Delete("pk", userPK(userID)).Range("sk", emailChangeSK(userID)).Run(ctx)
+}
+
+// SwapEmail moves the user from oldEmail to newEmail in one transaction: conditional put of the new lookup row,
+// delete of the old one, update of the main row, and removal of the pending change.
+// Returns ErrEmailChangeConflict if newEmail is already registered or the user's email is no longer oldEmail.
+func (s *Store) SwapEmail(ctx context.Context, userID, oldEmail, newEmail string) error {
+	newRow := emailLookupRow{
+		PK:     emailPK(newEmail),
+		SK:     newEmail,
+		UserID: userID,
+	}
+	err := s.db.WriteTx().
+		Put(s.tbl().Put(newRow).If("attribute_not_exists(pk)")).
+		Delete(s.tbl().Delete("pk", emailPK(oldEmail)).Range("sk", oldEmail).If("user_id = ?", userID)).
+		Update(s.tbl().Update("pk", userPK(userID)).Range("sk", userSK(userID)).
+			Set("email", newEmail).
+			If("email = ?", oldEmail)).
+		Delete(s.tbl().Delete("pk", userPK(userID)).Range("sk", emailChangeSK(userID))).
+		Run(ctx)
+	if dynamo.IsCondCheckFailed(err) {
+		return ErrEmailChangeConflict
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/admin"
+)
+
+// signupAdmin signs up a user and grants them platform admin directly in the store.
+func signupAdmin(t *testing.T, client *http.Client, base string) (session, userID string) {
+	t.Helper()
+	session, userID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	require.NoError(t, admin.NewStore(testDB, testTable).PutAdmin(context.Background(), userID))
+	return session, userID
+}
+
+func TestAdmin_NonAdminForbidden(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, userID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	resp, err := get(client, base, "/admin/users/"+userID, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp2, err := get(client, base, "/admin/users/"+userID, "")
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp2.StatusCode)
+}
+
+func TestAdmin_LookupUser(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	adminSession, _ := signupAdmin(t, client, base)
+	email := strings.ToLower(uniqueEmail(t))
+	session, userID, err := signupWithPKCEAndMe(client, base, email, "password123", "web")
+	require.NoError(t, err)
+	handle := uniqueHandle(t, "adminlookup")
+	resp, err := postJSON(client, base, "/artists", `{"handle":"`+handle+`","display_name":"Band","bio":""}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	for _, path := range []string{
+		"/admin/users/" + userID,
+		"/admin/users?email=" + url.QueryEscape(strings.ToUpper(email)),
+		"/admin/users?cognito_sub=" + url.QueryEscape(email+"-sub"), // fake Cognito sub
+	} {
+		resp, err := get(client, base, path, adminSession)
+		require.NoError(t, err)
+		body, _ := readBody(resp)
+		require.Equalf(t, http.StatusOK, resp.StatusCode, "%s: %s", path, body)
+		var detail map[string]any
+		require.NoError(t, json.Unmarshal(body, &detail))
+		user, _ := detail["user"].(map[string]any)
+		require.Equal(t, userID, user["id"])
+		require.Equal(t, false, detail["suspended"])
+		require.Equal(t, float64(1), detail["session_count"])
+		owned, _ := detail["owned_artists"].([]any)
+		require.Len(t, owned, 1)
+		require.Equal(t, handle, owned[0].(map[string]any)["handle"])
+		identities, _ := detail["linked_identities"].([]any)
+		require.Len(t, identities, 1)
+		require.Equal(t, true, identities[0].(map[string]any)["primary"])
+	}
+
+	resp2, err := get(client, base, "/admin/users?email=nobody@test.example", adminSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp2.StatusCode)
+
+	resp3, err := get(client, base, "/admin/users", adminSession)
+	require.NoError(t, err)
+	resp3.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp3.StatusCode)
+}
+
+func TestAdmin_SuspendAndUnsuspend(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	adminSession, adminID := signupAdmin(t, client, base)
+	email := uniqueEmail(t)
+	session, userID, err := signupWithPKCEAndMe(client, base, email, "password123", "web")
+	require.NoError(t, err)
+
+	resp, err := postJSON(client, base, "/admin/users/"+userID+"/suspend", `{"reason":"spam"}`, adminSession)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var detail map[string]any
+	require.NoError(t, json.Unmarshal(body, &detail))
+	require.Equal(t, true, detail["suspended"])
+	require.Equal(t, float64(0), detail["session_count"])
+
+	// Existing, unexpired session token is rejected
+	meResp, err := get(client, base, "/users/me", session)
+	require.NoError(t, err)
+	meResp.Body.Close()
+	require.Equal(t, http.StatusForbidden, meResp.StatusCode)
+
+	// New logins are rejected
+	_, _, err = loginWithPKCE(client, base, email, "password123", "web")
+	require.ErrorContains(t, err, "403")
+
+	resp2, err := postJSON(client, base, "/admin/users/"+userID+"/unsuspend", `{}`, adminSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusOK, resp2.StatusCode)
+	_, _, err = loginWithPKCE(client, base, email, "password123", "web")
+	require.NoError(t, err)
+
+	// Audit trail, newest first
+	auditResp, err := get(client, base, "/admin/users/"+userID+"/audit", adminSession)
+	require.NoError(t, err)
+	defer auditResp.Body.Close()
+	require.Equal(t, http.StatusOK, auditResp.StatusCode)
+	var audit struct {
+		Entries []map[string]any `json:"entries"`
+	}
+	require.NoError(t, json.NewDecoder(auditResp.Body).Decode(&audit))
+	require.Len(t, audit.Entries, 2)
+	require.Equal(t, "user.unsuspend", audit.Entries[0]["action"])
+	require.Equal(t, "user.suspend", audit.Entries[1]["action"])
+	require.Equal(t, "spam", audit.Entries[1]["detail"])
+	require.Equal(t, adminID, audit.Entries[1]["actor_user_id"])
+}
+
+func TestAdmin_SuspendValidation(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	adminSession, adminID := signupAdmin(t, client, base)
+	_, userID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	resp, err := postJSON(client, base, "/admin/users/"+adminID+"/suspend", `{"reason":"oops"}`, adminSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp2, err := postJSON(client, base, "/admin/users/"+userID+"/suspend", `{"reason":""}`, adminSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp2.StatusCode)
+
+	resp3, err := postJSON(client, base, "/admin/users/nonexistent/suspend", `{"reason":"spam"}`, adminSession)
+	require.NoError(t, err)
+	resp3.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp3.StatusCode)
+}
+
+func TestAdmin_ForceSignOut(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	adminSession, _ := signupAdmin(t, client, base)
+	email := uniqueEmail(t)
+	_, userID, err := signupWithPKCEAndMe(client, base, email, "password123", "web")
+	require.NoError(t, err)
+	// Second device
+	_, refresh, err := loginWithPKCE(client, base, email, "password123", "web")
+	require.NoError(t, err)
+
+	resp, err := postJSON(client, base, "/admin/users/"+userID+"/sign-out", `{}`, adminSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	refreshResp, err := postRefreshWithClientID(client, base, `{"refresh_token":"`+refresh+`"}`, "web")
+	require.NoError(t, err)
+	refreshResp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, refreshResp.StatusCode)
+
+	detailResp, err := get(client, base, "/admin/users/"+userID, adminSession)
+	require.NoError(t, err)
+	defer detailResp.Body.Close()
+	var detail map[string]any
+	require.NoError(t, json.NewDecoder(detailResp.Body).Decode(&detail))
+	require.Equal(t, float64(0), detail["session_count"])
+}
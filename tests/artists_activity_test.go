@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type activityEntry struct {
+	ID          string `json:"id"`
+	Action      string `json:"action"`
+	ActorUserID string `json:"actor_user_id"`
+	Target      string `json:"target"`
+	Changes     []struct {
+		Field  string `json:"field"`
+		Before string `json:"before"`
+		After  string `json:"after"`
+	} `json:"changes"`
+	CreatedAt string `json:"created_at"`
+}
+
+type activityPage struct {
+	Activity   []activityEntry `json:"activity"`
+	HasMore    bool            `json:"has_more"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+func listActivity(t *testing.T, client *http.Client, base, handle, query, session string) activityPage {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle+"/activity"+query, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var page activityPage
+	require.NoError(t, json.Unmarshal(b, &page))
+	return page
+}
+
+func TestArtists_Activity_RecordsChanges(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, ownerID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	strangerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "activity", ownerSession)
+
+	resp, err := patchJSON(client, base, "/artists/"+handle, `{"display_name":"New name"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/members/"+memberID, `{"roles":["feed","site"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Hello","body":"first"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/posts/hello", `{"body":"second"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/posts/hello", ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Any member can read the log, newest first
+	page := listActivity(t, client, base, handle, "", memberSession)
+	require.False(t, page.HasMore)
+	actions := make([]string, len(page.Activity))
+	for i, e := range page.Activity {
+		actions[i] = e.Action
+		require.NotEmpty(t, e.ID)
+		require.NotEmpty(t, e.CreatedAt)
+	}
+	require.Equal(t, []string{"post.delete", "post.update", "post.create", "member.update_roles", "member.add", "artist.update"}, actions)
+
+	update := page.Activity[5]
+	require.Equal(t, ownerID, update.ActorUserID)
+	require.Len(t, update.Changes, 1)
+	require.Equal(t, "display_name", update.Changes[0].Field)
+	require.Equal(t, "Band", update.Changes[0].Before)
+	require.Equal(t, "New name", update.Changes[0].After)
+
+	roles := page.Activity[3]
+	require.Equal(t, memberID, roles.Target)
+	require.Equal(t, "feed", roles.Changes[0].Before)
+	require.Equal(t, "feed,site", roles.Changes[0].After)
+
+	created, edited := page.Activity[2], page.Activity[1]
+	require.Equal(t, memberID, created.ActorUserID)
+	require.Equal(t, "hello", created.Target)
+	require.Equal(t, ownerID, edited.ActorUserID)
+	require.Len(t, edited.Changes, 1)
+	require.Equal(t, "body", edited.Changes[0].Field)
+	require.Equal(t, "first", edited.Changes[0].Before)
+	require.Equal(t, "second", edited.Changes[0].After)
+
+	// Removing the member is logged with the roles they had
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/members/"+memberID, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	page = listActivity(t, client, base, handle, "?limit=1", ownerSession)
+	require.Equal(t, "member.remove", page.Activity[0].Action)
+	require.Equal(t, "feed,site", page.Activity[0].Changes[0].Before)
+	require.Empty(t, page.Activity[0].Changes[0].After)
+
+	// Non-members can't read it
+	for _, session := range []string{strangerSession, memberSession} {
+		resp, err = get(client, base, "/artists/"+handle+"/activity", session)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func TestArtists_Activity_PaginationAndRename(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "activitypages", ownerSession)
+	for _, color := range []string{"#111111", "#222222", "#333333", "#444444", "#555555"} {
+		resp, err := patchJSON(client, base, "/artists/"+handle, `{"accent_color":"`+color+`"}`, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	newHandle := uniqueHandle(t, "activitymoved")
+	resp, err := renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Walk the log two at a time under the new handle
+	var all []activityEntry
+	cursor := ""
+	for i := 0; i < 10; i++ {
+		query := "?limit=2"
+		if cursor != "" {
+			query += "&cursor=" + cursor
+		}
+		page := listActivity(t, client, base, newHandle, query, ownerSession)
+		require.LessOrEqual(t, len(page.Activity), 2)
+		all = append(all, page.Activity...)
+		if !page.HasMore {
+			break
+		}
+		require.NotEmpty(t, page.NextCursor)
+		cursor = page.NextCursor
+	}
+	require.Len(t, all, 6)
+	require.Equal(t, "artist.rename", all[0].Action)
+	require.Equal(t, handle, all[0].Changes[0].Before)
+	require.Equal(t, newHandle, all[0].Changes[0].After)
+	require.Equal(t, "#555555", all[1].Changes[0].After)
+	require.Equal(t, "#111111", all[5].Changes[0].After)
+	seen := map[string]bool{}
+	for _, e := range all {
+		require.False(t, seen[e.ID])
+		seen[e.ID] = true
+	}
+
+	resp, err = get(client, base, "/artists/"+newHandle+"/activity?cursor=bogus", ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,230 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/feed"
+	"github.com/sopatech/afterwave.fm/internal/follows"
+	"github.com/sopatech/afterwave.fm/internal/infra"
+	"github.com/sopatech/afterwave.fm/internal/invitations"
+	"github.com/sopatech/afterwave.fm/internal/search"
+)
+
+// myArtistStatus returns status and purge_after of the handle in the user's GET /artists/me, and whether it is listed.
+func myArtistStatus(t *testing.T, client *http.Client, base, session, handle string) (status, purgeAfter string, listed bool) {
+	t.Helper()
+	resp, err := get(client, base, "/artists/me", session)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var out struct {
+		Artists []struct {
+			Handle     string `json:"handle"`
+			Status     string `json:"status"`
+			PurgeAfter string `json:"purge_after"`
+		} `json:"artists"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	for _, a := range out.Artists {
+		if a.Handle == handle {
+			return a.Status, a.PurgeAfter, true
+		}
+	}
+	return "", "", false
+}
+
+// newPurgeService returns an artists service on the test table with the same handle data as newTestServer,
+// so tests can run the purge job directly.
+func newPurgeService() artists.Service {
+	var feedIndexer feed.FeedIndexer
+	if testOpenSearchEndpoint != "" {
+		feedIndexer = search.NewFeedIndex(infra.NewOpenSearch(testOpenSearchEndpoint, nil), testFeedIndexName)
+	}
+	return artists.NewService(artists.NewStore(testDB, testTable), artists.NewMemberStore(testDB, testTable),
+		artists.DefaultHandlePolicy(nil, nil),
+		follows.NewStore(testDB, testTable), invitations.NewStore(testDB, testTable),
+		feed.NewHandleData(feed.NewStore(testDB, testTable), feedIndexer))
+}
+
+// purgeAllDue runs the purge job as if the restore period of every deleted page had ended.
+func purgeAllDue(t *testing.T) {
+	t.Helper()
+	svc := newPurgeService()
+	for {
+		n, err := svc.PurgeDue(context.Background(), time.Now().Add(artists.DeletionGracePeriod+time.Hour))
+		require.NoError(t, err)
+		if n == 0 {
+			return
+		}
+	}
+}
+
+func TestArtists_Delete_HidesAndRestore(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "softdelete", ownerSession)
+	resp, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Still here","body":"hello"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// Restore of an active page
+	resp, err = postJSON(client, base, "/artists/"+handle+"/restore", `{}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = deleteReq(client, base, "/artists/"+handle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Hidden from the public, from fans and from members
+	for _, path := range []string{"/artists/" + handle, "/artists/" + handle + "/posts", "/artists/" + handle + "/links"} {
+		resp, err = get(client, base, path, "")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+	resp, err = postJSON(client, base, "/users/me/following/"+handle, `{}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	_, _, listed := myArtistStatus(t, client, base, memberSession, handle)
+	require.False(t, listed)
+	resp, err = postJSON(client, base, "/artists/"+handle+"/restore", `{}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The handle stays taken until the purge
+	available, reason := handleAvailability(t, client, base, handle, fanSession)
+	require.False(t, available)
+	require.Equal(t, "taken", reason)
+
+	// The owner sees it pending deletion
+	status, purgeAfter, listed := myArtistStatus(t, client, base, ownerSession, handle)
+	require.True(t, listed)
+	require.Equal(t, artists.StatusPendingDeletion, status)
+	at, err := time.Parse(time.RFC3339, purgeAfter)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(artists.DeletionGracePeriod), at, time.Minute)
+
+	// Deleting again is a 404
+	resp, err = deleteReq(client, base, "/artists/"+handle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Restore brings everything back
+	resp, err = postJSON(client, base, "/artists/"+handle+"/restore", `{}`, ownerSession)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var restored struct {
+		Handle string `json:"handle"`
+		Status string `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal(body, &restored))
+	require.Equal(t, handle, restored.Handle)
+	require.Empty(t, restored.Status)
+
+	getSiteArtist(t, client, base, handle)
+	resp, err = get(client, base, "/artists/"+handle+"/posts", "")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	role, roles := artistRoles(t, client, base, memberSession, handle)
+	require.Equal(t, "member", role)
+	require.Equal(t, []string{"feed"}, roles)
+	status, _, listed = myArtistStatus(t, client, base, ownerSession, handle)
+	require.True(t, listed)
+	require.Empty(t, status)
+
+	// A restored page is not purged
+	purgeAllDue(t)
+	getSiteArtist(t, client, base, handle)
+}
+
+func TestArtists_Delete_PurgeCascade(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "purgeband", ownerSession)
+
+	resp, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Gone soon","body":"bye"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp, err = postJSON(client, base, "/users/me/following/"+handle, `{}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	createLink(t, client, base, handle, "Site", "https://example.com", ownerSession)
+	resp, err = postJSON(client, base, "/artists/"+handle+"/invitations", `{"email":"`+uniqueEmail(t)+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = deleteReq(client, base, "/artists/"+handle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Not due yet: nothing happens
+	n, err := newPurgeService().PurgeDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Zero(t, n)
+	_, _, listed := myArtistStatus(t, client, base, ownerSession, handle)
+	require.True(t, listed)
+
+	purgeAllDue(t)
+
+	// Every row keyed by the handle is gone, in the artist partition and elsewhere
+	var rows []map[string]any
+	require.NoError(t, testDB.Table(testTable).Get("pk", "ARTISTS#"+handle).All(context.Background(), &rows))
+	require.Empty(t, rows)
+	_, _, listed = myArtistStatus(t, client, base, ownerSession, handle)
+	require.False(t, listed)
+	_, _, listed = myArtistStatus(t, client, base, memberSession, handle)
+	require.False(t, listed)
+	resp, err = get(client, base, "/users/me/following", fanSession)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.NotContains(t, string(body), handle)
+
+	// Restore is no longer possible and the handle is free again
+	resp, err = postJSON(client, base, "/artists/"+handle+"/restore", `{}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	available, _ := handleAvailability(t, client, base, handle, fanSession)
+	require.True(t, available)
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type artistLink struct {
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	URL      string `json:"url"`
+	Platform string `json:"platform"`
+}
+
+func createLink(t *testing.T, client *http.Client, base, handle, label, url, session string) artistLink {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/links", `{"label":"`+label+`","url":"`+url+`"}`, session)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(body))
+	var link artistLink
+	require.NoError(t, json.Unmarshal(body, &link))
+	return link
+}
+
+func listLinks(t *testing.T, client *http.Client, base, handle string) []artistLink {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle+"/links", "")
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var out struct {
+		Links []artistLink `json:"links"`
+	}
+	require.NoError(t, json.Unmarshal(body, &out))
+	return out.Links
+}
+
+func TestArtists_Links_CRUDAndReorder(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "linksband", session)
+
+	bandcamp := createLink(t, client, base, handle, "Bandcamp", "https://linksband.bandcamp.com/album/first", session)
+	require.Equal(t, "bandcamp", bandcamp.Platform)
+	insta := createLink(t, client, base, handle, "Instagram", "https://www.instagram.com/linksband", session)
+	require.Equal(t, "instagram", insta.Platform)
+	shop := createLink(t, client, base, handle, "Merch", "https://shop.example.com", session)
+	require.Equal(t, "website", shop.Platform)
+
+	links := listLinks(t, client, base, handle)
+	require.Len(t, links, 3)
+	require.Equal(t, []string{bandcamp.ID, insta.ID, shop.ID}, []string{links[0].ID, links[1].ID, links[2].ID})
+
+	// Reorder
+	resp, err := putJSON(client, base, "/artists/"+handle+"/links/order", `{"ids":["`+shop.ID+`","`+bandcamp.ID+`","`+insta.ID+`"]}`, session)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	links = listLinks(t, client, base, handle)
+	require.Equal(t, []string{shop.ID, bandcamp.ID, insta.ID}, []string{links[0].ID, links[1].ID, links[2].ID})
+
+	// Order must list every link exactly once
+	resp, err = putJSON(client, base, "/artists/"+handle+"/links/order", `{"ids":["`+shop.ID+`","`+shop.ID+`","`+insta.ID+`"]}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Update re-detects the platform
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/links/"+shop.ID, `{"url":"https://linksband.bigcartel.com"}`, session)
+	require.NoError(t, err)
+	body, _ = readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var updated artistLink
+	require.NoError(t, json.Unmarshal(body, &updated))
+	require.Equal(t, "bigcartel", updated.Platform)
+	require.Equal(t, "Merch", updated.Label)
+
+	// Links are part of the public artist response
+	resp, err = get(client, base, "/artists/"+handle, "")
+	require.NoError(t, err)
+	body, _ = readBody(resp)
+	var artist struct {
+		Links []artistLink `json:"links"`
+	}
+	require.NoError(t, json.Unmarshal(body, &artist))
+	require.Len(t, artist.Links, 3)
+	require.Equal(t, shop.ID, artist.Links[0].ID)
+
+	// Delete
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/links/"+insta.ID, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/links/"+insta.ID, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Len(t, listLinks(t, client, base, handle), 2)
+}
+
+func TestArtists_Links_ValidationAndPermission(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	feedSession, feedID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "linksvalid", ownerSession)
+	resp, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+feedID+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	for _, body := range []string{
+		`{"label":"Site","url":"http://example.com"}`,
+		`{"label":"Site","url":"javascript:alert(1)"}`,
+		`{"label":"Site","url":"https://"}`,
+		`{"label":"","url":"https://example.com"}`,
+	} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/links", body, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	resp, err = postJSON(client, base, "/artists/"+handle+"/links", `{"label":"Site","url":"https://example.com"}`, feedSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = postJSON(client, base, "/artists/"+handle+"/links", `{"label":"Site","url":"https://example.com"}`, "")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestArtists_Links_MoveOnRename(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "linksrename", session)
+	link := createLink(t, client, base, handle, "Spotify", "https://open.spotify.com/artist/abc", session)
+	require.Equal(t, "spotify", link.Platform)
+
+	newHandle := uniqueHandle(t, "linksrenamed")
+	resp, err := renameArtist(client, base, handle, newHandle, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	links := listLinks(t, client, base, newHandle)
+	require.Len(t, links, 1)
+	require.Equal(t, link.ID, links[0].ID)
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func renameArtist(client *http.Client, base, handle, newHandle, session string) (*http.Response, error) {
+	return postJSON(client, base, "/artists/"+handle+"/rename", `{"handle":"`+newHandle+`"}`, session)
+}
+
+func TestArtists_Rename_MigratesDataAndRedirects(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	oldHandle := createArtist(t, client, base, "renameold", ownerSession)
+	resp, err := postJSON(client, base, "/artists/"+oldHandle+"/members", `{"user_id":"`+memberID+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = postJSON(client, base, "/artists/"+oldHandle+"/posts", `{"title":"Before rename","body":"Before rename"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp, err = postJSON(client, base, "/users/me/following/"+oldHandle, `{}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	newHandle := uniqueHandle(t, "renamenew")
+	resp, err = renameArtist(client, base, oldHandle, newHandle, ownerSession)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var artist map[string]any
+	require.NoError(t, json.Unmarshal(body, &artist))
+	require.Equal(t, newHandle, artist["handle"])
+
+	// Old handle redirects permanently
+	oldResp, err := get(&noRedirect, base, "/artists/"+oldHandle, "")
+	require.NoError(t, err)
+	oldBody, _ := readBody(oldResp)
+	require.Equal(t, http.StatusMovedPermanently, oldResp.StatusCode)
+	require.True(t, strings.HasSuffix(oldResp.Header.Get("Location"), "/v1/artists/"+newHandle), oldResp.Header.Get("Location"))
+	require.Contains(t, string(oldBody), newHandle)
+
+	newResp, err := get(client, base, "/artists/"+newHandle, "")
+	require.NoError(t, err)
+	newBody, _ := readBody(newResp)
+	require.Equal(t, http.StatusOK, newResp.StatusCode)
+	require.NoError(t, json.Unmarshal(newBody, &artist))
+	require.Equal(t, float64(1), artist["follower_count"])
+
+	// Posts, members, owner's list and follows moved
+	postsResp, err := get(client, base, "/artists/"+newHandle+"/posts", "")
+	require.NoError(t, err)
+	postsBody, _ := readBody(postsResp)
+	var posts struct {
+		Posts []map[string]any `json:"posts"`
+	}
+	require.NoError(t, json.Unmarshal(postsBody, &posts))
+	require.Len(t, posts.Posts, 1)
+	require.Equal(t, newHandle, posts.Posts[0]["artist_handle"])
+
+	role, roles := artistRoles(t, client, base, memberSession, newHandle)
+	require.Equal(t, "member", role)
+	require.Equal(t, []string{"feed"}, roles)
+	role, _ = artistRoles(t, client, base, memberSession, oldHandle)
+	require.Equal(t, "", role)
+	role, _ = artistRoles(t, client, base, ownerSession, newHandle)
+	require.Equal(t, "owner", role)
+	resp, err = postJSON(client, base, "/artists/"+newHandle+"/posts", `{"title":"After rename","body":"After rename"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	followResp, err := get(client, base, "/users/me/following", fanSession)
+	require.NoError(t, err)
+	followBody, _ := readBody(followResp)
+	var following struct {
+		Handles []string `json:"handles"`
+	}
+	require.NoError(t, json.Unmarshal(followBody, &following))
+	require.Equal(t, []string{newHandle}, following.Handles)
+}
+
+func TestArtists_Rename_ReservesOldHandle(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	oldHandle := createArtist(t, client, base, "reserveold", ownerSession)
+	newHandle := uniqueHandle(t, "reservenew")
+	resp, err := renameArtist(client, base, oldHandle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Someone else cannot take the old handle during the cool-off
+	resp2, err := postJSON(client, base, "/artists", `{"handle":"`+oldHandle+`","display_name":"Squatter","bio":""}`, otherSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusConflict, resp2.StatusCode)
+	otherHandle := createArtist(t, client, base, "reserveother", otherSession)
+	resp3, err := renameArtist(client, base, otherHandle, oldHandle, otherSession)
+	require.NoError(t, err)
+	resp3.Body.Close()
+	require.Equal(t, http.StatusConflict, resp3.StatusCode)
+
+	// The renamed artist can take it back
+	resp4, err := renameArtist(client, base, newHandle, oldHandle, ownerSession)
+	require.NoError(t, err)
+	resp4.Body.Close()
+	require.Equal(t, http.StatusOK, resp4.StatusCode)
+	getResp, err := get(client, base, "/artists/"+oldHandle, "")
+	require.NoError(t, err)
+	getResp.Body.Close()
+	require.Equal(t, http.StatusOK, getResp.StatusCode)
+}
+
+func TestArtists_Rename_Validation(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "renameval", ownerSession)
+	taken := createArtist(t, client, base, "renametaken", otherSession)
+
+	cases := []struct {
+		newHandle, session string
+		status             int
+	}{
+		{"Bad Handle!", ownerSession, http.StatusBadRequest},
+		{"abc", ownerSession, http.StatusBadRequest},
+		{handle, ownerSession, http.StatusBadRequest},
+		{taken, ownerSession, http.StatusConflict},
+		{uniqueHandle(t, "renamefree"), otherSession, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		resp, err := renameArtist(client, base, handle, c.newHandle, c.session)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equalf(t, c.status, resp.StatusCode, "rename to %q", c.newHandle)
+	}
+	resp, err := renameArtist(client, base, "nosuchartist", uniqueHandle(t, "renamefree"), ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
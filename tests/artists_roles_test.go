@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type artistRole struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	Custom      bool     `json:"custom"`
+}
+
+func createRole(t *testing.T, client *http.Client, base, handle, body, session string) artistRole {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/roles", body, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(b))
+	var role artistRole
+	require.NoError(t, json.Unmarshal(b, &role))
+	return role
+}
+
+func TestPermissions_Catalog(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	resp, err := get(client, base, "/permissions", "")
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var out struct {
+		Permissions []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"permissions"`
+		Roles []artistRole `json:"roles"`
+	}
+	require.NoError(t, json.Unmarshal(body, &out))
+	names := make([]string, len(out.Permissions))
+	for i, p := range out.Permissions {
+		names[i] = p.Name
+		require.NotEmpty(t, p.Description)
+	}
+	require.Contains(t, names, "gigs:manage")
+	require.Contains(t, names, "feed:update_own")
+	require.NotContains(t, names, "artist:delete")
+	require.NotEmpty(t, out.Roles)
+	for _, r := range out.Roles {
+		require.False(t, r.Custom)
+		require.NotEqual(t, "owner", r.ID)
+	}
+}
+
+func TestArtists_CustomRoles_CRUDAndPermissions(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "customroles", ownerSession)
+
+	// Validation
+	for body, status := range map[string]int{
+		`{"name":"Helper","permissions":["artist:delete"]}`: http.StatusBadRequest,
+		`{"name":"Helper","permissions":["nope"]}`:          http.StatusBadRequest,
+		`{"name":"Helper","permissions":[]}`:                http.StatusBadRequest,
+		`{"name":"Admin","permissions":["gigs:manage"]}`:    http.StatusBadRequest,
+		`{"name":"","permissions":["gigs:manage"]}`:         http.StatusBadRequest,
+	} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/roles", body, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode, body)
+	}
+
+	role := createRole(t, client, base, handle, `{"name":"Site helper","permissions":["site:edit","artist:list_members"]}`, ownerSession)
+	require.True(t, role.Custom)
+	resp, err := postJSON(client, base, "/artists/"+handle+"/roles", `{"name":"site HELPER","permissions":["gigs:manage"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Unknown role ids can't be assigned; custom role ids can
+	resp, err = postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["not-a-role"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, err = postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["`+role.ID+`"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// The custom role grants site:edit but not artist:update
+	resp, err = patchJSON(client, base, "/artists/"+handle, `{"accent_color":"#123456"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = patchJSON(client, base, "/artists/"+handle, `{"display_name":"Nope"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Members can list roles; only managers can change them
+	resp, err = get(client, base, "/artists/"+handle+"/roles", memberSession)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var list struct {
+		Roles []artistRole `json:"roles"`
+	}
+	require.NoError(t, json.Unmarshal(body, &list))
+	last := list.Roles[len(list.Roles)-1]
+	require.Equal(t, role.ID, last.ID)
+	require.Equal(t, "Site helper", last.Name)
+	resp, err = postJSON(client, base, "/artists/"+handle+"/roles", `{"name":"Sneaky","permissions":["artist:update"]}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Editing the role changes the member's permissions at once
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/roles/"+role.ID, `{"name":"Tour manager","permissions":["gigs:manage","photos:manage"]}`, ownerSession)
+	require.NoError(t, err)
+	body, _ = readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var updated artistRole
+	require.NoError(t, json.Unmarshal(body, &updated))
+	require.Equal(t, "Tour manager", updated.Name)
+	require.ElementsMatch(t, []string{"gigs:manage", "photos:manage"}, updated.Permissions)
+	resp, err = patchJSON(client, base, "/artists/"+handle, `{"accent_color":"#654321"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// A role in use can't be deleted
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/roles/"+role.ID, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/members/"+memberID, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/roles/"+role.ID, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/roles/"+role.ID, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestArtists_CustomRoles_OwnPostsOnly(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "ownposts", ownerSession)
+	role := createRole(t, client, base, handle, `{"name":"Contributor","permissions":["feed:create","feed:update_own","feed:delete_own"]}`, ownerSession)
+	resp, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["`+role.ID+`"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	createPost := func(title, session string) string {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"`+title+`","body":"hi"}`, session)
+		require.NoError(t, err)
+		body, _ := readBody(resp)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, string(body))
+		var p struct {
+			PostID string `json:"post_id"`
+		}
+		require.NoError(t, json.Unmarshal(body, &p))
+		return p.PostID
+	}
+	ownPost := createPost("Member post", memberSession)
+	ownerPost := createPost("Owner post", ownerSession)
+
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/posts/"+ownPost, `{"body":"edited"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/posts/"+ownerPost, `{"body":"edited"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/posts/"+ownerPost, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/posts/"+ownPost, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestArtists_CustomRoles_InviteAndRename(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	email := uniqueEmail(t)
+	memberSession, _, err := signupWithPKCEAndMe(client, base, email, "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "rolerename", ownerSession)
+	role := createRole(t, client, base, handle, `{"name":"Branding","permissions":["site:edit"]}`, ownerSession)
+
+	resp, err := postJSON(client, base, "/artists/"+handle+"/invitations", `{"email":"`+uniqueEmail(t)+`","roles":["bogus"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	invitationID := createInvitation(t, client, base, handle, email, `["`+role.ID+`"]`, ownerSession)
+	resp, err = postJSON(client, base, "/users/me/invitations/"+invitationID+"/accept", `{}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	newHandle := uniqueHandle(t, "rolerenamed")
+	resp, err = renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The role moved with the page and still grants its permissions
+	resp, err = patchJSON(client, base, "/artists/"+newHandle, `{"accent_color":"#abcdef"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
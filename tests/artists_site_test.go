@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type siteArtist struct {
+	LogoURL       string `json:"logo_url"`
+	CoverImageURL string `json:"cover_image_url"`
+	AccentColor   string `json:"accent_color"`
+	Sections      []struct {
+		Name    string `json:"name"`
+		Visible bool   `json:"visible"`
+	} `json:"sections"`
+}
+
+func getSiteArtist(t *testing.T, client *http.Client, base, handle string) siteArtist {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle, "")
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var a siteArtist
+	require.NoError(t, json.Unmarshal(body, &a))
+	return a
+}
+
+func TestArtists_Site_DefaultsAndUpdate(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "siteband", session)
+
+	a := getSiteArtist(t, client, base, handle)
+	require.Empty(t, a.AccentColor)
+	var names []string
+	for _, s := range a.Sections {
+		names = append(names, s.Name)
+		require.True(t, s.Visible)
+	}
+	// Exact default order, as listed in ErrInvalidSections
+	require.Equal(t, []string{"bio", "feed", "music", "photos", "gigs", "support", "links"}, names)
+
+	resp, err := patchJSON(client, base, "/artists/"+handle, `{
+		"logo_url": "https://cdn.example.com/logo.png",
+		"cover_image_url": "https://cdn.example.com/cover.jpg",
+		"accent_color": "#FF8800",
+		"sections": [
+			{"name":"music","visible":true},{"name":"feed","visible":true},{"name":"bio","visible":true},
+			{"name":"gigs","visible":false},{"name":"photos","visible":true},{"name":"support","visible":true},
+			{"name":"links","visible":true}
+		]}`, session)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+	a = getSiteArtist(t, client, base, handle)
+	require.Equal(t, "https://cdn.example.com/logo.png", a.LogoURL)
+	require.Equal(t, "https://cdn.example.com/cover.jpg", a.CoverImageURL)
+	require.Equal(t, "#ff8800", a.AccentColor)
+	require.Len(t, a.Sections, 7)
+	require.Equal(t, "music", a.Sections[0].Name)
+	require.Equal(t, "gigs", a.Sections[3].Name)
+	require.False(t, a.Sections[3].Visible)
+
+	// Empty string clears; other fields are unchanged
+	resp, err = patchJSON(client, base, "/artists/"+handle, `{"logo_url":""}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	a = getSiteArtist(t, client, base, handle)
+	require.Empty(t, a.LogoURL)
+	require.Equal(t, "#ff8800", a.AccentColor)
+	require.Equal(t, "music", a.Sections[0].Name)
+}
+
+func TestArtists_Site_Validation(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "sitevalid", session)
+
+	for _, body := range []string{
+		`{"accent_color":"red"}`,
+		`{"accent_color":"#12345"}`,
+		`{"logo_url":"javascript:alert(1)"}`,
+		`{"cover_image_url":"/relative.png"}`,
+		`{"sections":[{"name":"bio","visible":true}]}`,
+		`{"sections":[{"name":"bio","visible":true},{"name":"bio","visible":true},{"name":"music","visible":true},{"name":"gigs","visible":true},{"name":"photos","visible":true},{"name":"support","visible":true},{"name":"links","visible":true}]}`,
+		`{"sections":[{"name":"shop","visible":true},{"name":"feed","visible":true},{"name":"music","visible":true},{"name":"gigs","visible":true},{"name":"photos","visible":true},{"name":"support","visible":true},{"name":"links","visible":true}]}`,
+	} {
+		resp, err := patchJSON(client, base, "/artists/"+handle, body, session)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+}
+
+func TestArtists_Site_Permission(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	siteSession, siteID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	feedSession, feedID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "siteperm", ownerSession)
+	for _, m := range []struct{ id, roles string }{{siteID, `["site"]`}, {feedID, `["feed"]`}} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+m.id+`","roles":`+m.roles+`}`, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+
+	// site role edits branding but not display name or bio
+	resp, err := patchJSON(client, base, "/artists/"+handle, `{"accent_color":"#123"}`, siteSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = patchJSON(client, base, "/artists/"+handle, `{"display_name":"Hijacked"}`, siteSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// feed role cannot edit branding
+	resp, err = patchJSON(client, base, "/artists/"+handle, `{"accent_color":"#456"}`, feedSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	require.Equal(t, "#123", getSiteArtist(t, client, base, handle).AccentColor)
+}
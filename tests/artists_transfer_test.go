@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupTransfer creates an artist owned by one user with a second user as a feed member.
+func setupTransfer(t *testing.T, client *http.Client, base, prefix string) (handle, ownerSession, ownerID, memberSession, memberID string) {
+	t.Helper()
+	var err error
+	ownerSession, ownerID, err = signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err = signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle = uniqueHandle(t, prefix)
+	resp, err := postJSON(client, base, "/artists", `{"handle":"`+handle+`","display_name":"Band","bio":""}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp2, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp2.StatusCode)
+	return handle, ownerSession, ownerID, memberSession, memberID
+}
+
+// artistRoles returns the user's role and roles for the handle from GET /artists/me ("" if not listed).
+func artistRoles(t *testing.T, client *http.Client, base, session, handle string) (role string, roles []string) {
+	t.Helper()
+	resp, err := get(client, base, "/artists/me", session)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var out struct {
+		Artists []struct {
+			Handle string   `json:"handle"`
+			Role   string   `json:"role"`
+			Roles  []string `json:"roles"`
+		} `json:"artists"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	for _, a := range out.Artists {
+		if a.Handle == handle {
+			return a.Role, a.Roles
+		}
+	}
+	return "", nil
+}
+
+func TestArtists_Transfer_KeepAsAdmin(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	handle, ownerSession, ownerID, memberSession, memberID := setupTransfer(t, client, base, "transferkeep")
+
+	resp, err := postJSON(client, base, "/artists/"+handle+"/transfer", `{"user_id":"`+memberID+`","keep_as_admin":true}`, ownerSession)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, string(body))
+
+	// Nominee can see the pending transfer; nothing changes until accepted
+	getResp, err := get(client, base, "/artists/"+handle+"/transfer", memberSession)
+	require.NoError(t, err)
+	getBody, _ := readBody(getResp)
+	require.Equal(t, http.StatusOK, getResp.StatusCode)
+	var pending map[string]any
+	require.NoError(t, json.Unmarshal(getBody, &pending))
+	require.Equal(t, memberID, pending["nominee_user_id"])
+	require.Equal(t, true, pending["keep_as_admin"])
+	role, _ := artistRoles(t, client, base, ownerSession, handle)
+	require.Equal(t, "owner", role)
+
+	// Only the nominee can accept
+	respOwnerAccept, err := postJSON(client, base, "/artists/"+handle+"/transfer/accept", `{}`, ownerSession)
+	require.NoError(t, err)
+	respOwnerAccept.Body.Close()
+	require.Equal(t, http.StatusForbidden, respOwnerAccept.StatusCode)
+
+	resp2, err := postJSON(client, base, "/artists/"+handle+"/transfer/accept", `{}`, memberSession)
+	require.NoError(t, err)
+	body2, _ := readBody(resp2)
+	require.Equal(t, http.StatusOK, resp2.StatusCode, string(body2))
+	var artist map[string]any
+	require.NoError(t, json.Unmarshal(body2, &artist))
+	require.Equal(t, memberID, artist["owner_user_id"])
+
+	artistResp, err := get(client, base, "/artists/"+handle, "")
+	require.NoError(t, err)
+	artistBody, _ := readBody(artistResp)
+	require.NoError(t, json.Unmarshal(artistBody, &artist))
+	require.Equal(t, memberID, artist["owner_user_id"])
+
+	role, _ = artistRoles(t, client, base, memberSession, handle)
+	require.Equal(t, "owner", role)
+	role, roles := artistRoles(t, client, base, ownerSession, handle)
+	require.Equal(t, "member", role)
+	require.Equal(t, []string{"admin"}, roles)
+
+	// Old owner is now an admin: can update, but cannot delete the page
+	respPatch, err := patchJSON(client, base, "/artists/"+handle, `{"bio":"still here"}`, ownerSession)
+	require.NoError(t, err)
+	respPatch.Body.Close()
+	require.Equal(t, http.StatusOK, respPatch.StatusCode)
+	respDel, err := deleteReq(client, base, "/artists/"+handle, ownerSession)
+	require.NoError(t, err)
+	respDel.Body.Close()
+	require.Equal(t, http.StatusForbidden, respDel.StatusCode)
+
+	// Members list reflects the swap
+	listResp, err := get(client, base, "/artists/"+handle+"/members", memberSession)
+	require.NoError(t, err)
+	listBody, _ := readBody(listResp)
+	var list struct {
+		Members []struct {
+			UserID string   `json:"user_id"`
+			Roles  []string `json:"roles"`
+		} `json:"members"`
+	}
+	require.NoError(t, json.Unmarshal(listBody, &list))
+	require.Len(t, list.Members, 2)
+	for _, m := range list.Members {
+		switch m.UserID {
+		case memberID:
+			require.Equal(t, []string{"owner"}, m.Roles)
+		case ownerID:
+			require.Equal(t, []string{"admin"}, m.Roles)
+		default:
+			t.Fatalf("unexpected member %s", m.UserID)
+		}
+	}
+
+	// Transfer is consumed
+	respAgain, err := postJSON(client, base, "/artists/"+handle+"/transfer/accept", `{}`, memberSession)
+	require.NoError(t, err)
+	respAgain.Body.Close()
+	require.Equal(t, http.StatusNotFound, respAgain.StatusCode)
+}
+
+func TestArtists_Transfer_RemoveOldOwner(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	handle, ownerSession, _, memberSession, memberID := setupTransfer(t, client, base, "transferdrop")
+
+	resp, err := postJSON(client, base, "/artists/"+handle+"/transfer", `{"user_id":"`+memberID+`"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp2, err := postJSON(client, base, "/artists/"+handle+"/transfer/accept", `{}`, memberSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusOK, resp2.StatusCode)
+
+	role, _ := artistRoles(t, client, base, ownerSession, handle)
+	require.Equal(t, "", role)
+	respList, err := get(client, base, "/artists/"+handle+"/members", ownerSession)
+	require.NoError(t, err)
+	respList.Body.Close()
+	require.Equal(t, http.StatusForbidden, respList.StatusCode)
+}
+
+func TestArtists_Transfer_Validation(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	handle, ownerSession, ownerID, memberSession, memberID := setupTransfer(t, client, base, "transferval")
+	_, outsiderID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	// Nominee must be an existing member other than the owner
+	for _, id := range []string{outsiderID, ownerID} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/transfer", `{"user_id":"`+id+`"}`, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	// Only the owner can nominate
+	resp, err := postJSON(client, base, "/artists/"+handle+"/transfer", `{"user_id":"`+memberID+`"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Nominee declines
+	resp2, err := postJSON(client, base, "/artists/"+handle+"/transfer", `{"user_id":"`+memberID+`"}`, ownerSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp2.StatusCode)
+	resp3, err := deleteReq(client, base, "/artists/"+handle+"/transfer", memberSession)
+	require.NoError(t, err)
+	resp3.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp3.StatusCode)
+	resp4, err := postJSON(client, base, "/artists/"+handle+"/transfer/accept", `{}`, memberSession)
+	require.NoError(t, err)
+	resp4.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp4.StatusCode)
+
+	// Nominee removed from the page before accepting
+	resp5, err := postJSON(client, base, "/artists/"+handle+"/transfer", `{"user_id":"`+memberID+`"}`, ownerSession)
+	require.NoError(t, err)
+	resp5.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp5.StatusCode)
+	resp6, err := deleteReq(client, base, "/artists/"+handle+"/members/"+memberID, ownerSession)
+	require.NoError(t, err)
+	resp6.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp6.StatusCode)
+	resp7, err := postJSON(client, base, "/artists/"+handle+"/transfer/accept", `{}`, memberSession)
+	require.NoError(t, err)
+	resp7.Body.Close()
+	require.Equal(t, http.StatusConflict, resp7.StatusCode)
+	role, _ := artistRoles(t, client, base, ownerSession, handle)
+	require.Equal(t, "owner", role)
+}
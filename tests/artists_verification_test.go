@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type verification struct {
+	Handle         string   `json:"handle"`
+	Status         string   `json:"status"`
+	EvidenceLinks  []string `json:"evidence_links"`
+	RequestedBy    string   `json:"requested_by"`
+	ReopenedReason string   `json:"reopened_reason"`
+	ReviewedBy     string   `json:"reviewed_by"`
+	ReviewNote     string   `json:"review_note"`
+}
+
+func requestVerification(t *testing.T, client *http.Client, base, handle, session string) verification {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/verification", `{"evidence_links":["https://band.example.com"],"note":"Official site"}`, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(b))
+	var out verification
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out
+}
+
+// queuedVerification returns the handle's entry in the admin queue, or nil.
+func queuedVerification(t *testing.T, client *http.Client, base, handle, adminSession string) *verification {
+	t.Helper()
+	resp, err := get(client, base, "/admin/verifications?limit=100", adminSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out struct {
+		Verifications []verification `json:"verifications"`
+	}
+	require.NoError(t, json.Unmarshal(b, &out))
+	for i := range out.Verifications {
+		if out.Verifications[i].Handle == handle {
+			return &out.Verifications[i]
+		}
+	}
+	return nil
+}
+
+func approveVerification(t *testing.T, client *http.Client, base, handle, adminSession string) {
+	t.Helper()
+	resp, err := postJSON(client, base, "/admin/verifications/"+handle+"/approve", `{}`, adminSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+}
+
+func artistVerified(t *testing.T, client *http.Client, base, handle string) bool {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle, "")
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out struct {
+		Verified   bool   `json:"verified"`
+		VerifiedAt string `json:"verified_at"`
+	}
+	require.NoError(t, json.Unmarshal(b, &out))
+	require.Equal(t, out.Verified, out.VerifiedAt != "")
+	return out.Verified
+}
+
+func TestArtists_Verification_RequestAndApprove(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	adminSession, adminID := signupAdmin(t, client, base)
+
+	ownerSession, ownerID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "verify", ownerSession)
+	require.False(t, artistVerified(t, client, base, handle))
+
+	for _, body := range []string{
+		`{"evidence_links":[]}`,
+		`{"evidence_links":["http://band.example.com"]}`,
+		`{"evidence_links":["a","b","c","d","e","f"]}`,
+	} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/verification", body, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	v := requestVerification(t, client, base, handle, ownerSession)
+	require.Equal(t, "pending", v.Status)
+	require.Equal(t, ownerID, v.RequestedBy)
+	resp, err := postJSON(client, base, "/artists/"+handle+"/verification", `{"evidence_links":["https://band.example.com"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Only platform admins see the queue and review
+	resp, err = get(client, base, "/admin/verifications", ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	queued := queuedVerification(t, client, base, handle, adminSession)
+	require.NotNil(t, queued)
+	require.Equal(t, []string{"https://band.example.com"}, queued.EvidenceLinks)
+
+	approveVerification(t, client, base, handle, adminSession)
+	require.True(t, artistVerified(t, client, base, handle))
+	require.Nil(t, queuedVerification(t, client, base, handle, adminSession))
+	resp, err = get(client, base, "/artists/"+handle+"/verification", ownerSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.Unmarshal(b, &v))
+	require.Equal(t, "approved", v.Status)
+	require.Equal(t, adminID, v.ReviewedBy)
+
+	// Reviewing again conflicts; asking again while verified conflicts
+	resp, err = postJSON(client, base, "/admin/verifications/"+handle+"/approve", `{}`, adminSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, err = postJSON(client, base, "/artists/"+handle+"/verification", `{"evidence_links":["https://band.example.com"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// The approval is audited on the requester
+	resp, err = get(client, base, "/admin/users/"+ownerID+"/audit", adminSession)
+	require.NoError(t, err)
+	b, _ = readBody(resp)
+	require.Contains(t, string(b), `"artist.verify"`)
+}
+
+func TestArtists_Verification_Reject(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	adminSession, _ := signupAdmin(t, client, base)
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "verifyno", ownerSession)
+	requestVerification(t, client, base, handle, ownerSession)
+
+	resp, err := postJSON(client, base, "/admin/verifications/"+handle+"/reject", `{"reason":""}`, adminSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, err = postJSON(client, base, "/admin/verifications/"+handle+"/reject", `{"reason":"Links don't mention this page"}`, adminSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.False(t, artistVerified(t, client, base, handle))
+
+	resp, err = get(client, base, "/artists/"+handle+"/verification", ownerSession)
+	require.NoError(t, err)
+	b, _ = readBody(resp)
+	var v verification
+	require.NoError(t, json.Unmarshal(b, &v))
+	require.Equal(t, "rejected", v.Status)
+	require.Equal(t, "Links don't mention this page", v.ReviewNote)
+
+	// A rejected page can ask again
+	v = requestVerification(t, client, base, handle, ownerSession)
+	require.Equal(t, "pending", v.Status)
+	require.NotNil(t, queuedVerification(t, client, base, handle, adminSession))
+}
+
+func TestArtists_Verification_RevokedOnRenameAndTransfer(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	adminSession, _ := signupAdmin(t, client, base)
+
+	handle, ownerSession, _, memberSession, memberID := setupTransfer(t, client, base, "verifymove")
+	requestVerification(t, client, base, handle, ownerSession)
+	approveVerification(t, client, base, handle, adminSession)
+	require.True(t, artistVerified(t, client, base, handle))
+
+	// Rename: the badge goes and the request is back in the queue under the new handle
+	newHandle := uniqueHandle(t, "verifyrenamed")
+	resp, err := renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.False(t, artistVerified(t, client, base, newHandle))
+	require.Nil(t, queuedVerification(t, client, base, handle, adminSession))
+	queued := queuedVerification(t, client, base, newHandle, adminSession)
+	require.NotNil(t, queued)
+	require.Equal(t, "handle_renamed", queued.ReopenedReason)
+
+	approveVerification(t, client, base, newHandle, adminSession)
+	require.True(t, artistVerified(t, client, base, newHandle))
+
+	// Ownership transfer: same again
+	resp, err = postJSON(client, base, "/artists/"+newHandle+"/transfer", `{"user_id":"`+memberID+`"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp, err = postJSON(client, base, "/artists/"+newHandle+"/transfer/accept", `{}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.False(t, artistVerified(t, client, base, newHandle))
+	queued = queuedVerification(t, client, base, newHandle, adminSession)
+	require.NotNil(t, queued)
+	require.Equal(t, "ownership_transferred", queued.ReopenedReason)
+}
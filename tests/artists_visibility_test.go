@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/infra"
+	"github.com/sopatech/afterwave.fm/internal/search"
+)
+
+func setVisibility(t *testing.T, client *http.Client, base, handle, visibility, session string) {
+	t.Helper()
+	resp, err := patchJSON(client, base, "/artists/"+handle, `{"visibility":"`+visibility+`"}`, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.Contains(t, string(b), `"visibility":"`+visibility+`"`)
+}
+
+// statusOf returns the status code of GET path as session ("" for anonymous).
+func statusOf(t *testing.T, client *http.Client, base, path, session string) int {
+	t.Helper()
+	resp, err := get(client, base, path, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// indexedPostIDs returns the post IDs in the feed index for the handle.
+func indexedPostIDs(t *testing.T, handle string) []string {
+	t.Helper()
+	idx := search.NewFeedIndex(infra.NewOpenSearch(testOpenSearchEndpoint, nil), testFeedIndexName)
+	refs, _, err := idx.SearchFeed(context.Background(), []string{handle}, 100, "", false)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(refs))
+	for _, r := range refs {
+		ids = append(ids, r.PostID)
+	}
+	return ids
+}
+
+func TestArtists_Visibility_PrivateHiddenFromNonMembers(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	handle, ownerSession, _, memberSession, _ := setupTransfer(t, client, base, "private")
+	resp, err := postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Coming soon","body":"Soon"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	resp, err = patchJSON(client, base, "/artists/"+handle, `{"visibility":"secret"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	setVisibility(t, client, base, handle, "private", ownerSession)
+
+	for _, path := range []string{"/artists/" + handle, "/artists/" + handle + "/posts", "/artists/" + handle + "/posts/coming-soon", "/artists/" + handle + "/links"} {
+		require.Equal(t, http.StatusNotFound, statusOf(t, client, base, path, ""), path)
+		require.Equal(t, http.StatusNotFound, statusOf(t, client, base, path, otherSession), path)
+		require.Equal(t, http.StatusOK, statusOf(t, client, base, path, memberSession), path)
+		require.Equal(t, http.StatusOK, statusOf(t, client, base, path, ownerSession), path)
+	}
+	resp, err = postJSON(client, base, "/users/me/following/"+handle, `{}`, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// A rename does not reveal the new handle to non-members
+	newHandle := uniqueHandle(t, "privaterenamed")
+	resp, err = renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, http.StatusNotFound, statusOf(t, client, base, "/artists/"+handle, otherSession))
+
+	// Unlisted pages are reachable by URL
+	setVisibility(t, client, base, newHandle, "unlisted", ownerSession)
+	require.Equal(t, http.StatusOK, statusOf(t, client, base, "/artists/"+newHandle, ""))
+	require.Equal(t, http.StatusOK, statusOf(t, client, base, "/artists/"+newHandle+"/posts/coming-soon", ""))
+
+	resp, err = get(client, base, "/artists/"+newHandle+"/activity", ownerSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Contains(t, string(b), `"visibility"`)
+}
+
+func TestArtists_Visibility_FeedIndexFollowsPrivacy(t *testing.T) {
+	if testOpenSearchEndpoint == "" {
+		t.Skip("requires OpenSearch")
+	}
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "privatefeed", ownerSession)
+	resp, err := postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Before launch","body":"One"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, []string{"before-launch"}, indexedPostIDs(t, handle))
+
+	// Going private removes the documents; new posts are not indexed
+	setVisibility(t, client, base, handle, "private", ownerSession)
+	require.Empty(t, indexedPostIDs(t, handle))
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Launch day","body":"Two"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Empty(t, indexedPostIDs(t, handle))
+
+	// Asking for private again removes documents a failed removal left behind
+	idx := search.NewFeedIndex(infra.NewOpenSearch(testOpenSearchEndpoint, nil), testFeedIndexName)
+	require.NoError(t, idx.IndexPost(context.Background(), search.FeedDoc{PostID: "launch-day", ArtistHandle: handle, CreatedAt: "2026-01-01T00:00:00Z"}))
+	require.Equal(t, []string{"launch-day"}, indexedPostIDs(t, handle))
+	setVisibility(t, client, base, handle, "private", ownerSession)
+	require.Empty(t, indexedPostIDs(t, handle))
+
+	// Going public backfills every post
+	setVisibility(t, client, base, handle, "public", ownerSession)
+	require.ElementsMatch(t, []string{"before-launch", "launch-day"}, indexedPostIDs(t, handle))
+}
+
+func TestArtists_Visibility_UnlistedNotDiscoverable(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "unlisted", ownerSession)
+	svc := newPurgeService()
+	a, err := svc.GetByHandle(context.Background(), handle)
+	require.NoError(t, err)
+	require.True(t, a.Discoverable())
+
+	// Reachable and followable by handle, but not discoverable
+	setVisibility(t, client, base, handle, "unlisted", ownerSession)
+	require.Equal(t, http.StatusOK, statusOf(t, client, base, "/artists/"+handle, ""))
+	resp, err := postJSON(client, base, "/users/me/following/"+handle, `{}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	a, err = svc.GetByHandle(context.Background(), handle)
+	require.NoError(t, err)
+	require.False(t, a.Discoverable())
+
+	setVisibility(t, client, base, handle, "private", ownerSession)
+	a, err = svc.GetByHandle(context.Background(), handle)
+	require.NoError(t, err)
+	require.False(t, a.Discoverable())
+}
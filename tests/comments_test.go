@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/feed"
+)
+
+type postComment struct {
+	CommentID    string `json:"comment_id"`
+	ParentID     string `json:"parent_id"`
+	Depth        int    `json:"depth"`
+	AuthorUserID string `json:"author_user_id"`
+	Body         string `json:"body"`
+	Edited       bool   `json:"edited"`
+	Hidden       bool   `json:"hidden"`
+	Deleted      bool   `json:"deleted"`
+	ReplyCount   int    `json:"reply_count"`
+}
+
+func createComment(t *testing.T, client *http.Client, base, handle, postID, body, session string) postComment {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/posts/"+postID+"/comments", body, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(b))
+	var c postComment
+	require.NoError(t, json.Unmarshal(b, &c))
+	return c
+}
+
+// listComments returns one page of comments (query is e.g. "?parent_id=..." or "") and the next cursor.
+func listComments(t *testing.T, client *http.Client, base, handle, postID, query, session string) ([]postComment, string) {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle+"/posts/"+postID+"/comments"+query, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out struct {
+		Comments   []postComment `json:"comments"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out.Comments, out.NextCursor
+}
+
+func commentCount(t *testing.T, client *http.Client, base, handle, postID string) int {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle+"/posts/"+postID, "")
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var p feedPost
+	require.NoError(t, json.Unmarshal(b, &p))
+	return p.CommentCount
+}
+
+func TestComments_ThreadsAndPagination(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, fanID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "comments", ownerSession)
+	createPost(t, client, base, handle, `{"title":"Tour dates"}`, ownerSession)
+	createPost(t, client, base, handle, `{"title":"Secret","status":"draft"}`, ownerSession)
+	path := "/artists/" + handle + "/posts/tour-dates/comments"
+
+	// Signed-in users only, on published posts, with a non-empty body of limited length
+	resp, err := postJSON(client, base, path, `{"body":"hi"}`, "")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts/secret/comments", `{"body":"hi"}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	for _, body := range []string{`{"body":"  "}`, `{"body":"` + strings.Repeat("a", feed.MaxCommentLength+1) + `"}`, `{"body":"hi","parent_id":"nope"}`} {
+		resp, err = postJSON(client, base, path, body, fanSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Contains(t, []int{http.StatusBadRequest, http.StatusNotFound}, resp.StatusCode, body)
+	}
+
+	first := createComment(t, client, base, handle, "tour-dates", `{"body":"See you in Berlin!"}`, fanSession)
+	require.Equal(t, fanID, first.AuthorUserID)
+	require.Equal(t, 0, first.Depth)
+	second := createComment(t, client, base, handle, "tour-dates", `{"body":"Come to Lisbon"}`, fanSession)
+	reply := createComment(t, client, base, handle, "tour-dates", `{"body":"Thanks!","parent_id":"`+first.CommentID+`"}`, ownerSession)
+	require.Equal(t, first.CommentID, reply.ParentID)
+	require.Equal(t, 1, reply.Depth)
+	createComment(t, client, base, handle, "tour-dates", `{"body":"Me too","parent_id":"`+reply.CommentID+`"}`, fanSession)
+	require.Equal(t, 4, commentCount(t, client, base, handle, "tour-dates"))
+
+	// Top-level comments newest first, a page at a time; readable without signing in
+	page, cursor := listComments(t, client, base, handle, "tour-dates", "?limit=1", "")
+	require.Len(t, page, 1)
+	require.Equal(t, second.CommentID, page[0].CommentID)
+	require.NotEmpty(t, cursor)
+	page, cursor = listComments(t, client, base, handle, "tour-dates", "?limit=1&cursor="+cursor, "")
+	require.Len(t, page, 1)
+	require.Equal(t, first.CommentID, page[0].CommentID)
+	require.Equal(t, 1, page[0].ReplyCount)
+	require.Empty(t, cursor)
+
+	// Replies oldest first
+	replies, _ := listComments(t, client, base, handle, "tour-dates", "?parent_id="+first.CommentID, "")
+	require.Len(t, replies, 1)
+	require.Equal(t, reply.CommentID, replies[0].CommentID)
+	require.Equal(t, 1, replies[0].ReplyCount)
+
+	require.Equal(t, http.StatusBadRequest, statusOf(t, client, base, path+"?cursor=bogus", ""))
+}
+
+func TestComments_EditDeleteAndModerate(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "moderate", ownerSession)
+	createPost(t, client, base, handle, `{"title":"New single"}`, ownerSession)
+	path := "/artists/" + handle + "/posts/new-single/comments/"
+
+	c := createComment(t, client, base, handle, "new-single", `{"body":"Great tune"}`, fanSession)
+
+	// Only the author edits
+	resp, err := patchJSON(client, base, path+c.CommentID, `{"body":"Hijacked"}`, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, err = patchJSON(client, base, path+c.CommentID, `{"body":"Great tune!"}`, fanSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var edited postComment
+	require.NoError(t, json.Unmarshal(b, &edited))
+	require.Equal(t, "Great tune!", edited.Body)
+	require.True(t, edited.Edited)
+
+	// Members with feed:update hide comments; others can't
+	resp, err = postJSON(client, base, path+c.CommentID+"/hide", `{}`, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, err = postJSON(client, base, path+c.CommentID+"/hide", `{}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 0, commentCount(t, client, base, handle, "new-single"))
+	list, _ := listComments(t, client, base, handle, "new-single", "", otherSession)
+	require.Empty(t, list)
+	list, _ = listComments(t, client, base, handle, "new-single", "", fanSession)
+	require.Len(t, list, 1)
+	require.True(t, list[0].Hidden)
+	list, _ = listComments(t, client, base, handle, "new-single", "", ownerSession)
+	require.Len(t, list, 1)
+	resp, err = postJSON(client, base, path+c.CommentID+"/unhide", `{}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, commentCount(t, client, base, handle, "new-single"))
+
+	// A deleted comment with replies stays as a tombstone; one without is gone
+	reply := createComment(t, client, base, handle, "new-single", `{"body":"Agreed","parent_id":"`+c.CommentID+`"}`, otherSession)
+	resp, err = deleteReq(client, base, path+c.CommentID, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, err = deleteReq(client, base, path+c.CommentID, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	list, _ = listComments(t, client, base, handle, "new-single", "", "")
+	require.Len(t, list, 1)
+	require.True(t, list[0].Deleted)
+	require.Empty(t, list[0].Body)
+	require.Empty(t, list[0].AuthorUserID)
+	require.Equal(t, 1, commentCount(t, client, base, handle, "new-single"))
+
+	// The artist can delete anyone's comment
+	resp, err = deleteReq(client, base, path+reply.CommentID, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	replies, _ := listComments(t, client, base, handle, "new-single", "?parent_id="+c.CommentID, "")
+	require.Empty(t, replies)
+	require.Equal(t, 0, commentCount(t, client, base, handle, "new-single"))
+
+	// Deleting the post removes its comments
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/posts/new-single", ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	createPost(t, client, base, handle, `{"title":"New single"}`, ownerSession)
+	list, _ = listComments(t, client, base, handle, "new-single", "", "")
+	require.Empty(t, list)
+}
+
+func TestComments_BlockListsAndRateLimit(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	trollSession, trollID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "blocks", ownerSession)
+	createPost(t, client, base, handle, `{"title":"Hello"}`, ownerSession)
+
+	fanComment := createComment(t, client, base, handle, "hello", `{"body":"Hi all"}`, fanSession)
+	createComment(t, client, base, handle, "hello", `{"body":"Boo"}`, trollSession)
+
+	resp, err := patchJSON(client, base, "/users/me/settings", `{"blocked_users":["`+trollID+`"]}`, fanSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.Contains(t, string(b), trollID)
+
+	// The fan no longer sees the troll's comments; everyone else still does
+	list, _ := listComments(t, client, base, handle, "hello", "", fanSession)
+	require.Len(t, list, 1)
+	require.Equal(t, fanComment.CommentID, list[0].CommentID)
+	list, _ = listComments(t, client, base, handle, "hello", "", ownerSession)
+	require.Len(t, list, 2)
+
+	// and the troll can't reply to the fan
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts/hello/comments", `{"body":"Boo","parent_id":"`+fanComment.CommentID+`"}`, trollSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Each user can post DefaultCommentRateLimit.Max comments per window
+	for i := 1; i < feed.DefaultCommentRateLimit.Max; i++ {
+		createComment(t, client, base, handle, "hello", `{"body":"Again"}`, fanSession)
+	}
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts/hello/comments", `{"body":"Again"}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	createComment(t, client, base, handle, "hello", `{"body":"Still here"}`, ownerSession)
+}
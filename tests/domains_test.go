@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type customDomain struct {
+	Host     string `json:"host"`
+	Handle   string `json:"handle"`
+	Status   string `json:"status"`
+	TXTName  string `json:"txt_name"`
+	TXTValue string `json:"txt_value"`
+}
+
+func registerDomain(t *testing.T, client *http.Client, base, handle, host, session string) customDomain {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/domains", `{"host":"`+host+`"}`, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(b))
+	var out customDomain
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out
+}
+
+func verifyDomain(t *testing.T, client *http.Client, base, handle, host, session string) customDomain {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/domains/"+host+"/verify", `{}`, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out customDomain
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out
+}
+
+// lookupDomain returns the handle GET /domains/{host} maps the host to, or "" if it is not found.
+func lookupDomain(t *testing.T, client *http.Client, base, host string) string {
+	t.Helper()
+	resp, err := get(client, base, "/domains/"+host, "")
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	if resp.StatusCode == http.StatusNotFound {
+		return ""
+	}
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out struct {
+		Handle string `json:"handle"`
+	}
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out.Handle
+}
+
+func TestDomains_RegisterVerifyLookupRemove(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "domains", ownerSession)
+	host := uniqueHandle(t, "band") + ".example.com"
+
+	for _, bad := range []string{"localhost", "192.168.0.1", "band.afterwave.fm", "afterwave.fm", "bad_host.example.com"} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/domains", `{"host":"`+bad+`"}`, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, bad)
+	}
+
+	d := registerDomain(t, client, base, handle, "WWW."+host+".", ownerSession)
+	host = "www." + host
+	require.Equal(t, host, d.Host)
+	require.Equal(t, "pending", d.Status)
+	require.Equal(t, "_afterwave-verify."+host, d.TXTName)
+	require.Equal(t, "", lookupDomain(t, client, base, host))
+
+	// No TXT record yet: still pending
+	require.Equal(t, "pending", verifyDomain(t, client, base, handle, host, ownerSession).Status)
+	testResolver.setTXT(d.TXTName, "v=spf1 -all", d.TXTValue)
+	require.Equal(t, "verified", verifyDomain(t, client, base, handle, host, ownerSession).Status)
+	require.Equal(t, handle, lookupDomain(t, client, base, host))
+
+	resp, err := get(client, base, "/artists/"+handle+"/domains", ownerSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Domains []customDomain `json:"domains"`
+	}
+	require.NoError(t, json.Unmarshal(b, &list))
+	require.Len(t, list.Domains, 1)
+	require.Equal(t, "verified", list.Domains[0].Status)
+
+	// A domain belongs to one artist
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	other := createArtist(t, client, base, "domainsother", otherSession)
+	resp, err = postJSON(client, base, "/artists/"+other+"/domains", `{"host":"`+host+`"}`, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Only members with site:edit manage domains
+	resp, err = get(client, base, "/artists/"+handle+"/domains", otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Removed: no longer served, and free for anyone
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/domains/"+host, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "", lookupDomain(t, client, base, host))
+	d = registerDomain(t, client, base, other, host, otherSession)
+	require.Equal(t, "pending", d.Status)
+	require.Equal(t, "", lookupDomain(t, client, base, host), "the new owner must verify with its own token")
+}
+
+func TestDomains_FailedAfterWindow(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "domainfail", ownerSession)
+	host := uniqueHandle(t, "fail") + ".example.org"
+	registerDomain(t, client, base, handle, host, ownerSession)
+
+	// Move the verification deadline into the past
+	past := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	require.NoError(t, testDB.Table(testTable).Update("pk", "DOMAINS#"+host).Range("sk", "DOMAIN").Set("verify_by", past).Run(context.Background()))
+	require.Equal(t, "failed", verifyDomain(t, client, base, handle, host, ownerSession).Status)
+	require.Equal(t, "", lookupDomain(t, client, base, host))
+
+	resp, err := postJSON(client, base, "/artists/"+handle+"/domains/"+host+"/verify", `{}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// A failed domain can be registered again, with a new token
+	d := registerDomain(t, client, base, handle, host, ownerSession)
+	require.Equal(t, "pending", d.Status)
+}
+
+func TestDomains_FollowRename(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "domainmove", ownerSession)
+	host := uniqueHandle(t, "move") + ".example.net"
+	d := registerDomain(t, client, base, handle, host, ownerSession)
+	testResolver.setTXT(d.TXTName, d.TXTValue)
+	verifyDomain(t, client, base, handle, host, ownerSession)
+
+	newHandle := uniqueHandle(t, "domainmoved")
+	resp, err := renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, newHandle, lookupDomain(t, client, base, host))
+}
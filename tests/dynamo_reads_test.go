@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+)
+
+// routeReads returns the total DynamoDB reads recorded for the route (http_request_dynamodb_reads_sum).
+func routeReads(t *testing.T, client *http.Client, base, route string) float64 {
+	t.Helper()
+	resp, err := client.Get(strings.TrimSuffix(base, "/v1") + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	prefix := `http_request_dynamodb_reads_sum{route="` + route + `"} `
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), prefix); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			require.NoError(t, err)
+			return f
+		}
+	}
+	require.NoError(t, sc.Err())
+	return 0
+}
+
+// readsFor makes the request and returns the DynamoDB reads it took.
+func readsFor(t *testing.T, client *http.Client, base, route string, do func() (*http.Response, error)) float64 {
+	t.Helper()
+	before := routeReads(t, client, base, route)
+	resp, err := do()
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Less(t, resp.StatusCode, 300, string(b))
+	return routeReads(t, client, base, route) - before
+}
+
+func TestDynamoReads_MyArtistsBatched(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	addTo := func(handle string) {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["feed"]}`, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	listMine := func() (*http.Response, error) { return get(client, base, "/artists/me", memberSession) }
+
+	addTo(createArtist(t, client, base, "batchone", ownerSession))
+	one := readsFor(t, client, base, "GET /artists/me", listMine)
+	require.Greater(t, one, 0.0)
+
+	for i := 0; i < 4; i++ {
+		addTo(createArtist(t, client, base, "batchmore", ownerSession))
+	}
+	five := readsFor(t, client, base, "GET /artists/me", listMine)
+	require.Equal(t, one, five, "member pages are loaded in one batch, not one read each")
+}
+
+func TestDynamoReads_FeedReusesArtistAndMember(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "cachedfeed", ownerSession)
+	resp, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Cached","body":"hi"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// The owner and a member do the same edit: the member's extra reads are only their membership row, since
+	// the artist row is read once per request however many checks use it.
+	route := "PATCH /artists/{handle}/posts/{postId}"
+	byOwner := readsFor(t, client, base, route, func() (*http.Response, error) {
+		return patchJSON(client, base, "/artists/"+handle+"/posts/cached", `{"body":"owner edit"}`, ownerSession)
+	})
+	byMember := readsFor(t, client, base, route, func() (*http.Response, error) {
+		return patchJSON(client, base, "/artists/"+handle+"/posts/cached", `{"body":"member edit"}`, memberSession)
+	})
+	require.Equal(t, byOwner+1, byMember)
+
+	// Each request starts with an empty cache: removing the member takes effect on the next request
+	resp, err = deleteReq(client, base, "/artists/"+handle+"/members/"+memberID, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/posts/cached", `{"body":"nope"}`, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestDynamoReads_WritesDropCachedRowsWithinRequest(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, ownerID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	_, memberID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "cachedwrite", ownerSession)
+
+	// One request context: reads fill the cache, then writes through the same services must not leave stale rows
+	ctx := artists.WithRequestCache(context.Background())
+	svc := artists.NewService(artists.NewStore(testDB, testTable), artists.NewMemberStore(testDB, testTable), nil)
+
+	ok, err := svc.HasPermission(ctx, handle, memberID, artists.PermFeedUpdate)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, svc.AddMember(ctx, handle, memberID, []string{"feed"}, ownerID))
+	ok, err = svc.HasPermission(ctx, handle, memberID, artists.PermFeedUpdate)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, svc.RemoveMember(ctx, handle, memberID, ownerID))
+	ok, err = svc.HasPermission(ctx, handle, memberID, artists.PermFeedUpdate)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	before, err := svc.GetByHandle(ctx, handle)
+	require.NoError(t, err)
+	name := before.DisplayName + " (live)"
+	_, err = svc.Update(ctx, handle, artists.ArtistUpdate{DisplayName: &name}, ownerID)
+	require.NoError(t, err)
+	after, err := svc.GetByHandle(ctx, handle)
+	require.NoError(t, err)
+	require.Equal(t, name, after.DisplayName)
+}
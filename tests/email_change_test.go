@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/users"
+)
+
+var uuidRegex = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// lastTokenSentTo returns the verification token from the most recent email to the address.
+func lastTokenSentTo(t *testing.T, to string) string {
+	t.Helper()
+	msgs := testMailer.messagesTo(to)
+	require.NotEmpty(t, msgs, "expected an email to %s", to)
+	token := uuidRegex.FindString(msgs[len(msgs)-1].Body)
+	require.NotEmpty(t, token, "expected a token in the email body")
+	return token
+}
+
+func TestEmailChange_RequestAndConfirm(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	oldEmail := strings.ToLower(uniqueEmail(t))
+	newEmail := strings.ToLower("new-" + uniqueEmail(t))
+	session, userID, err := signupWithPKCEAndMe(client, base, oldEmail, "password123", "web")
+	require.NoError(t, err)
+
+	resp, err := postJSON(client, base, "/users/me/email", `{"email":"`+newEmail+`"}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	// Not changed until confirmed
+	meResp, err := get(client, base, "/users/me", session)
+	require.NoError(t, err)
+	meBody, _ := readBody(meResp)
+	_, email := parseUser(meBody)
+	require.Equal(t, oldEmail, email)
+
+	token := lastTokenSentTo(t, newEmail)
+	resp2, err := postJSON(client, base, "/users/me/email/confirm", `{"token":"`+token+`"}`, session)
+	require.NoError(t, err)
+	defer resp2.Body.Close()
+	require.Equal(t, http.StatusOK, resp2.StatusCode)
+	var user map[string]any
+	require.NoError(t, json.NewDecoder(resp2.Body).Decode(&user))
+	require.Equal(t, userID, user["id"])
+	require.Equal(t, newEmail, user["email"])
+
+	// Old address is notified
+	notices := testMailer.messagesTo(oldEmail)
+	require.Len(t, notices, 1)
+	require.Contains(t, notices[0].Body, newEmail)
+
+	// Login works with the new address only, and resolves to the same user
+	_, _, err = loginWithPKCE(client, base, oldEmail, "password123", "web")
+	require.Error(t, err)
+	newSession, _, err := loginWithPKCE(client, base, newEmail, "password123", "web")
+	require.NoError(t, err)
+	meResp2, err := get(client, base, "/users/me", newSession)
+	require.NoError(t, err)
+	meBody2, _ := readBody(meResp2)
+	id, email2 := parseUser(meBody2)
+	require.Equal(t, userID, id)
+	require.Equal(t, newEmail, email2)
+
+	// Token is single-use
+	resp3, err := postJSON(client, base, "/users/me/email/confirm", `{"token":"`+token+`"}`, session)
+	require.NoError(t, err)
+	resp3.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp3.StatusCode)
+
+	// Old address is free for a new signup
+	_, _, err = signupWithPKCE(client, base, oldEmail, "password123", "web")
+	require.NoError(t, err)
+}
+
+func TestEmailChange_EmailTaken(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCE(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	takenEmail := strings.ToLower("taken-" + uniqueEmail(t))
+	_, _, err = signupWithPKCE(client, base, takenEmail, "password123", "web")
+	require.NoError(t, err)
+
+	resp, err := postJSON(client, base, "/users/me/email", `{"email":"`+takenEmail+`"}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestEmailChange_TakenBeforeConfirm(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCE(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	newEmail := strings.ToLower("race-" + uniqueEmail(t))
+	resp, err := postJSON(client, base, "/users/me/email", `{"email":"`+newEmail+`"}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	token := lastTokenSentTo(t, newEmail)
+
+	// Someone else registers the address before the confirm
+	_, _, err = signupWithPKCE(client, base, newEmail, "password123", "web")
+	require.NoError(t, err)
+
+	resp2, err := postJSON(client, base, "/users/me/email/confirm", `{"token":"`+token+`"}`, session)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusConflict, resp2.StatusCode)
+}
+
+func TestEmailChange_InvalidRequests(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	email := strings.ToLower(uniqueEmail(t))
+	session, _, err := signupWithPKCE(client, base, email, "password123", "web")
+	require.NoError(t, err)
+
+	for _, body := range []string{`{"email":"not-an-email"}`, `{"email":"` + email + `"}`} {
+		resp, err := postJSON(client, base, "/users/me/email", body, session)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equalf(t, http.StatusBadRequest, resp.StatusCode, "body %s", body)
+	}
+
+	resp, err := postJSON(client, base, "/users/me/email/confirm", `{"token":"00000000-0000-0000-0000-000000000000"}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp2, err := postJSON(client, base, "/users/me/email", `{"email":"x@example.com"}`, "")
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp2.StatusCode)
+}
+
+func TestEmailChange_FederatedUserWithoutFlag(t *testing.T) {
+	ctx := context.Background()
+	store := users.NewStore(testDB, testTable)
+
+	// A Google/Apple user stored before the federated flag existed: Cognito knows the sub as an external user
+	userID := uuid.New().String()
+	email := strings.ToLower(uniqueEmail(t))
+	sub := "google_" + userID
+	require.NoError(t, store.PutUser(ctx, userID, email, sub, time.Now().UTC().Format(time.RFC3339), false))
+	cognitoClient := newFakeCognitoClient()
+	cognitoClient.addFederated(sub)
+	svc := users.NewService(store, cognitoClient, testMailer)
+	newEmail := strings.ToLower("new-" + uniqueEmail(t))
+	require.ErrorIs(t, svc.RequestEmailChange(ctx, userID, newEmail), users.ErrEmailManagedByProvider)
+	require.Empty(t, testMailer.messagesTo(newEmail))
+
+	// The flag was backfilled, so the answer no longer depends on Cognito
+	require.ErrorIs(t, users.NewService(store, nil, testMailer).RequestEmailChange(ctx, userID, newEmail), users.ErrEmailManagedByProvider)
+}
+
+func TestEmailChange_SubUnknownToCognito(t *testing.T) {
+	ctx := context.Background()
+	store := users.NewStore(testDB, testTable)
+
+	// A user without the flag whose sub the pool does not know is not treated as federated
+	userID := uuid.New().String()
+	email := strings.ToLower(uniqueEmail(t))
+	require.NoError(t, store.PutUser(ctx, userID, email, "missing_"+userID, time.Now().UTC().Format(time.RFC3339), false))
+	svc := users.NewService(store, newFakeCognitoClient(), testMailer)
+	newEmail := strings.ToLower("new-" + uniqueEmail(t))
+	require.NoError(t, svc.RequestEmailChange(ctx, userID, newEmail))
+	require.Len(t, testMailer.messagesTo(newEmail), 1)
+}
@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/feed"
+	"github.com/sopatech/afterwave.fm/internal/infra"
+	"github.com/sopatech/afterwave.fm/internal/search"
+)
+
+type feedPost struct {
+	PostID          string         `json:"post_id"`
+	Title           string         `json:"title"`
+	Status          string         `json:"status"`
+	PublishAt       string         `json:"publish_at"`
+	PublishedAt     string         `json:"published_at"`
+	BodyHTML        string         `json:"body_html"`
+	Excerpt         string         `json:"excerpt"`
+	Links           []string       `json:"links"`
+	Mentions        []string       `json:"mentions"`
+	CommentCount    int            `json:"comment_count"`
+	ReactionCounts  map[string]int `json:"reaction_counts"`
+	ViewerReactions []string       `json:"viewer_reactions"`
+	Pinned          bool           `json:"pinned"`
+	Media           []struct {
+		MediaID     string `json:"media_id"`
+		URL         string `json:"url"`
+		ContentType string `json:"content_type"`
+		AltText     string `json:"alt_text"`
+	} `json:"media"`
+}
+
+func createPost(t *testing.T, client *http.Client, base, handle, body, session string) feedPost {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/posts", body, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(b))
+	var out feedPost
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out
+}
+
+// listedPostIDs returns the post IDs GET /artists/{handle}/posts lists, newest first.
+func listedPostIDs(t *testing.T, client *http.Client, base, handle string) []string {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle+"/posts", "")
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out struct {
+		Posts []feedPost `json:"posts"`
+	}
+	require.NoError(t, json.Unmarshal(b, &out))
+	ids := make([]string, 0, len(out.Posts))
+	for _, p := range out.Posts {
+		ids = append(ids, p.PostID)
+	}
+	return ids
+}
+
+// publishDue runs the scheduled post publisher as of now.
+func publishDue(t *testing.T, now time.Time) {
+	t.Helper()
+	artistSvc := artists.NewService(artists.NewStore(testDB, testTable), artists.NewMemberStore(testDB, testTable), nil)
+	svc := feed.NewService(feed.NewStore(testDB, testTable), artistSvc)
+	if testOpenSearchEndpoint != "" {
+		// Same wiring as the server, so published posts are indexed and logged
+		feedIndex := search.NewFeedIndex(infra.NewOpenSearch(testOpenSearchEndpoint, nil), testFeedIndexName)
+		svc = feed.NewServiceWithSearch(feed.NewStore(testDB, testTable), artistSvc, artistSvc, artistSvc, feedIndex, nil, feedIndex, nil, nil, nil, nil, feed.CommentRateLimit{})
+	}
+	_, err := svc.PublishDue(context.Background(), now)
+	require.NoError(t, err)
+}
+
+func TestFeed_Drafts_VisibleOnlyToFeedMembers(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	handle, ownerSession, _, memberSession, _ := setupTransfer(t, client, base, "drafts")
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	for _, body := range []string{
+		`{"title":"Bad status","status":"hidden"}`,
+		`{"title":"No time","status":"scheduled"}`,
+		`{"title":"Past","status":"scheduled","publish_at":"2020-01-01T00:00:00Z"}`,
+	} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/posts", body, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	p := createPost(t, client, base, handle, `{"title":"Liner notes","body":"WIP","status":"draft"}`, ownerSession)
+	require.Equal(t, "draft", p.Status)
+	require.Empty(t, listedPostIDs(t, client, base, handle))
+	require.Equal(t, http.StatusNotFound, statusOf(t, client, base, "/artists/"+handle+"/posts/liner-notes", ""))
+	require.Equal(t, http.StatusNotFound, statusOf(t, client, base, "/artists/"+handle+"/posts/liner-notes", otherSession))
+	require.Equal(t, http.StatusOK, statusOf(t, client, base, "/artists/"+handle+"/posts/liner-notes", memberSession))
+	require.Equal(t, http.StatusForbidden, statusOf(t, client, base, "/artists/"+handle+"/drafts", otherSession))
+
+	resp, err := get(client, base, "/artists/"+handle+"/drafts", memberSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var drafts struct {
+		Posts []feedPost `json:"posts"`
+	}
+	require.NoError(t, json.Unmarshal(b, &drafts))
+	require.Len(t, drafts.Posts, 1)
+	require.Equal(t, "liner-notes", drafts.Posts[0].PostID)
+
+	// Publishing the draft lists it; it can't go back to draft
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/posts/liner-notes", `{"status":"published"}`, ownerSession)
+	require.NoError(t, err)
+	b, _ = readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.NoError(t, json.Unmarshal(b, &p))
+	require.Equal(t, "published", p.Status)
+	require.NotEmpty(t, p.PublishedAt)
+	require.Equal(t, []string{"liner-notes"}, listedPostIDs(t, client, base, handle))
+	require.Equal(t, http.StatusOK, statusOf(t, client, base, "/artists/"+handle+"/posts/liner-notes", ""))
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/posts/liner-notes", `{"status":"draft"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestFeed_Scheduled_PublishedWhenDue(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "scheduled", ownerSession)
+	publishAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	p := createPost(t, client, base, handle, `{"title":"Tour dates","body":"Soon","status":"scheduled","publish_at":"`+publishAt.Format(time.RFC3339)+`"}`, ownerSession)
+	require.Equal(t, "scheduled", p.Status)
+	require.Equal(t, publishAt.Format(time.RFC3339), p.PublishAt)
+
+	// Not due yet
+	publishDue(t, publishAt.Add(-time.Minute))
+	require.Empty(t, listedPostIDs(t, client, base, handle))
+
+	// Due: published once, even when the publisher runs again
+	publishDue(t, publishAt)
+	publishDue(t, publishAt.Add(time.Minute))
+	require.Equal(t, []string{"tour-dates"}, listedPostIDs(t, client, base, handle))
+	resp, err := get(client, base, "/artists/"+handle+"/posts/tour-dates", "")
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.NoError(t, json.Unmarshal(b, &p))
+	require.Equal(t, "published", p.Status)
+	require.Empty(t, p.PublishAt)
+
+	resp, err = get(client, base, "/artists/"+handle+"/activity", ownerSession)
+	require.NoError(t, err)
+	b, _ = readBody(resp)
+	require.Contains(t, string(b), `"post.publish"`)
+}
+
+func TestFeed_Scheduled_RescheduleAndRename(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "resched", ownerSession)
+	first := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	later := first.Add(3 * time.Hour)
+	createPost(t, client, base, handle, `{"title":"Album out","status":"scheduled","publish_at":"`+first.Format(time.RFC3339)+`"}`, ownerSession)
+
+	resp, err := patchJSON(client, base, "/artists/"+handle+"/posts/album-out", `{"publish_at":"`+later.Format(time.RFC3339)+`"}`, ownerSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+
+	newHandle := uniqueHandle(t, "rescheduled")
+	resp, err = renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The old time no longer publishes it; the new one does, under the new handle
+	publishDue(t, first)
+	require.Empty(t, listedPostIDs(t, client, base, newHandle))
+	publishDue(t, later)
+	require.Equal(t, []string{"album-out"}, listedPostIDs(t, client, base, newHandle))
+}
+
+func TestFeed_Scheduled_NotIndexedForPrivateOrDeletedArtist(t *testing.T) {
+	if testOpenSearchEndpoint == "" {
+		t.Skip("requires OpenSearch")
+	}
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	publishAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	scheduled := `{"title":"Tour dates","status":"scheduled","publish_at":"` + publishAt.Format(time.RFC3339) + `"}`
+	public := createArtist(t, client, base, "schedpublic", ownerSession)
+	createPost(t, client, base, public, scheduled, ownerSession)
+	private := createArtist(t, client, base, "schedprivate", ownerSession)
+	createPost(t, client, base, private, scheduled, ownerSession)
+	setVisibility(t, client, base, private, "private", ownerSession)
+	deleted := createArtist(t, client, base, "scheddeleted", ownerSession)
+	createPost(t, client, base, deleted, scheduled, ownerSession)
+	resp, err := deleteReq(client, base, "/artists/"+deleted, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// All three are published; only the public artist's post is indexed
+	publishDue(t, publishAt)
+	require.Equal(t, []string{"tour-dates"}, indexedPostIDs(t, public))
+	require.Equal(t, "published", getPost(t, client, base, private, "tour-dates", ownerSession).Status)
+	require.Empty(t, indexedPostIDs(t, private))
+	require.Empty(t, indexedPostIDs(t, deleted))
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type postRevision struct {
+	Revision       int    `json:"revision"`
+	Body           string `json:"body"`
+	Explicit       bool   `json:"explicit"`
+	EditedByUserID string `json:"edited_by_user_id"`
+	Changes        []struct {
+		Field  string `json:"field"`
+		Before string `json:"before"`
+		After  string `json:"after"`
+	} `json:"changes"`
+	RevertedTo *int `json:"reverted_to"`
+}
+
+func listRevisions(t *testing.T, client *http.Client, base, handle, postID, session string) []postRevision {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle+"/posts/"+postID+"/revisions", session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out struct {
+		Revisions []postRevision `json:"revisions"`
+	}
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out.Revisions
+}
+
+func TestFeed_Revisions_RecordedPerEdit(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	handle, ownerSession, ownerID, memberSession, memberID := setupTransfer(t, client, base, "revisions")
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	adminSession, _ := signupAdmin(t, client, base)
+	createPost(t, client, base, handle, `{"title":"Setlist","body":"v1"}`, ownerSession)
+	require.Empty(t, listRevisions(t, client, base, handle, "setlist", ownerSession))
+
+	for _, edit := range []struct{ body, session string }{
+		{`{"body":"v2"}`, ownerSession},
+		{`{"body":"v3","explicit":true}`, memberSession},
+		{`{"body":"v3","explicit":true}`, ownerSession}, // no content change: no revision
+	} {
+		resp, err := patchJSON(client, base, "/artists/"+handle+"/posts/setlist", edit.body, edit.session)
+		require.NoError(t, err)
+		b, _ := readBody(resp)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	}
+
+	resp, err := get(client, base, "/artists/"+handle+"/posts/setlist", "")
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Contains(t, string(b), `"edited":true`)
+	require.Contains(t, string(b), `"revision_count":2`)
+
+	// Newest first, ending with the original content
+	revs := listRevisions(t, client, base, handle, "setlist", memberSession)
+	require.Len(t, revs, 3)
+	require.Equal(t, []int{2, 1, 0}, []int{revs[0].Revision, revs[1].Revision, revs[2].Revision})
+	require.Equal(t, memberID, revs[0].EditedByUserID)
+	require.True(t, revs[0].Explicit)
+	require.Len(t, revs[0].Changes, 2)
+	require.Equal(t, "v2", revs[1].Body)
+	require.Equal(t, ownerID, revs[1].EditedByUserID)
+	require.Equal(t, "v1", revs[2].Body)
+	require.False(t, revs[2].Explicit)
+
+	// Platform admins (moderators) can read them; other users can't
+	require.Len(t, listRevisions(t, client, base, handle, "setlist", adminSession), 3)
+	require.Equal(t, http.StatusForbidden, statusOf(t, client, base, "/artists/"+handle+"/posts/setlist/revisions", otherSession))
+	require.Equal(t, http.StatusUnauthorized, statusOf(t, client, base, "/artists/"+handle+"/posts/setlist/revisions", ""))
+}
+
+func TestFeed_Revisions_Revert(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "revert", ownerSession)
+	createPost(t, client, base, handle, `{"title":"Bio","body":"original"}`, ownerSession)
+	resp, err := patchJSON(client, base, "/artists/"+handle+"/posts/bio", `{"body":"bad edit"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts/bio/revisions/5/revert", `{}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Reverting to the original is itself a new revision
+	resp, err = postJSON(client, base, "/artists/"+handle+"/posts/bio/revisions/0/revert", `{}`, ownerSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.Contains(t, string(b), `"body":"original"`)
+	require.Contains(t, string(b), `"revision_count":2`)
+
+	revs := listRevisions(t, client, base, handle, "bio", ownerSession)
+	require.Len(t, revs, 3)
+	require.Equal(t, 2, revs[0].Revision)
+	require.NotNil(t, revs[0].RevertedTo)
+	require.Equal(t, 0, *revs[0].RevertedTo)
+	require.Equal(t, "bad edit", revs[1].Body)
+
+	// Revisions follow the post to a new handle
+	newHandle := uniqueHandle(t, "reverted")
+	resp, err = renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, listRevisions(t, client, base, newHandle, "bio", ownerSession), 3)
+}
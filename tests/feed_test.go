@@ -49,17 +49,21 @@ func TestFeed_CreatePost_WithImageAndYouTube(t *testing.T) {
 	require.NoError(t, err)
 	_, err = postJSON(client, base, "/artists", `{"handle":"band2","display_name":"Band Two","bio":""}`, session)
 	require.NoError(t, err)
+	mediaID := uploadImage(t, client, base, "band2", session)
 
-	resp, err := postJSON(client, base, "/artists/band2/posts", `{"title":"Check this out","body":"Check this out","image_url":"https://example.com/img.png","youtube_url":"https://www.youtube.com/watch?v=dQw4w9WgXcQ","explicit":false}`, session)
+	resp, err := postJSON(client, base, "/artists/band2/posts", `{"title":"Check this out","body":"Check this out","media":[{"media_id":"`+mediaID+`","alt_text":"Album cover"}],"youtube_url":"https://www.youtube.com/watch?v=dQw4w9WgXcQ","explicit":false}`, session)
 	require.NoError(t, err)
 	defer resp.Body.Close()
 	body, _ := readBody(resp)
 	require.Equal(t, http.StatusCreated, resp.StatusCode, "body: %s", string(body))
 
-	var post map[string]any
+	var post feedPost
 	require.NoError(t, json.Unmarshal(body, &post))
-	require.Equal(t, "https://example.com/img.png", post["image_url"])
-	require.Equal(t, "https://www.youtube.com/watch?v=dQw4w9WgXcQ", post["youtube_url"])
+	require.Len(t, post.Media, 1)
+	require.Equal(t, mediaID, post.Media[0].MediaID)
+	require.Equal(t, "Album cover", post.Media[0].AltText)
+	require.NotEmpty(t, post.Media[0].URL)
+	require.Contains(t, string(body), `"youtube_url":"https://www.youtube.com/watch?v=dQw4w9WgXcQ"`)
 }
 
 func TestFeed_CreatePost_Unauthorized(t *testing.T) {
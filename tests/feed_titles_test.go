@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// editTitle sets the post's title and returns the status and, on success, the post.
+func editTitle(t *testing.T, client *http.Client, base, handle, postID, title, session string) (int, feedPost) {
+	t.Helper()
+	b, _ := json.Marshal(map[string]string{"title": title})
+	resp, err := patchJSON(client, base, "/artists/"+handle+"/posts/"+postID, string(b), session)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	var p feedPost
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.Unmarshal(body, &p))
+	}
+	return resp.StatusCode, p
+}
+
+func TestFeed_TitleEdit_MovesPostAndRedirects(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "titles", ownerSession)
+	image := uploadImage(t, client, base, handle, ownerSession)
+	createPost(t, client, base, handle, `{"title":"Teh tour","body":"Dates soon","media":[{"media_id":"`+image+`","alt_text":"Poster"}]}`, ownerSession)
+	createPost(t, client, base, handle, `{"title":"Setlist"}`, ownerSession)
+
+	// Revisions, comments, reactions and the pin are kept under the slug
+	resp, err := patchJSON(client, base, "/artists/"+handle+"/posts/teh-tour", `{"body":"Dates below"}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	createComment(t, client, base, handle, "teh-tour", `{"body":"Come to Lisbon"}`, fanSession)
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "teh-tour", "love", fanSession, true))
+	status, _ := pinPost(t, client, base, handle, "teh-tour", ownerSession, true)
+	require.Equal(t, http.StatusOK, status)
+
+	status, _ = editTitle(t, client, base, handle, "teh-tour", "The tour", fanSession)
+	require.Equal(t, http.StatusForbidden, status)
+	status, _ = editTitle(t, client, base, handle, "teh-tour", "Setlist", ownerSession)
+	require.Equal(t, http.StatusConflict, status)
+	status, _ = editTitle(t, client, base, handle, "teh-tour", "  ", ownerSession)
+	require.Equal(t, http.StatusBadRequest, status)
+	status, _ = editTitle(t, client, base, handle, "teh-tour", "!!!", ownerSession)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status, p := editTitle(t, client, base, handle, "teh-tour", "The tour", ownerSession)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "the-tour", p.PostID)
+	require.Equal(t, "The tour", p.Title)
+
+	// The old slug redirects permanently
+	oldResp, err := get(&noRedirect, base, "/artists/"+handle+"/posts/teh-tour", "")
+	require.NoError(t, err)
+	oldBody, _ := readBody(oldResp)
+	require.Equal(t, http.StatusMovedPermanently, oldResp.StatusCode)
+	require.True(t, strings.HasSuffix(oldResp.Header.Get("Location"), "/v1/artists/"+handle+"/posts/the-tour"), oldResp.Header.Get("Location"))
+	require.Contains(t, string(oldBody), `"previous_post_id":"teh-tour"`)
+	require.Equal(t, "the-tour", getPost(t, client, base, handle, "teh-tour", "").PostID)
+
+	p = getPost(t, client, base, handle, "the-tour", fanSession)
+	require.Equal(t, 1, p.CommentCount)
+	require.Equal(t, 1, p.ReactionCounts["love"])
+	require.Equal(t, []string{"love"}, p.ViewerReactions)
+	require.True(t, p.Pinned)
+	require.Len(t, p.Media, 1)
+	require.Equal(t, []string{"the-tour", "setlist"}, listedPostIDs(t, client, base, handle))
+	comments, _ := listComments(t, client, base, handle, "the-tour", "", "")
+	require.Len(t, comments, 1)
+	revs := listRevisions(t, client, base, handle, "the-tour", ownerSession)
+	require.Len(t, revs, 2)
+	require.Equal(t, "Dates below", revs[0].Body)
+	require.Equal(t, "Dates soon", revs[1].Body)
+
+	// Reactions and images follow the new slug
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "the-tour", "love", fanSession, false))
+	require.Equal(t, 0, getPost(t, client, base, handle, "the-tour", "").ReactionCounts["love"])
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/posts/the-tour", `{"media":[]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, createPost(t, client, base, handle, `{"title":"Poster","media":[{"media_id":"`+image+`"}]}`, ownerSession).Media, 1)
+
+	// A title with the same slug keeps the post ID
+	status, p = editTitle(t, client, base, handle, "the-tour", "The Tour!", ownerSession)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "the-tour", p.PostID)
+	require.Equal(t, "The Tour!", p.Title)
+
+	// A new post can take the old slug
+	require.Equal(t, "teh-tour", createPost(t, client, base, handle, `{"title":"Teh tour"}`, ownerSession).PostID)
+	require.False(t, getPost(t, client, base, handle, "teh-tour", "").Pinned)
+}
+
+func TestFeed_TitleEdit_DraftsAndFeed(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "retitle", ownerSession)
+	createPost(t, client, base, handle, `{"title":"Draft idea","status":"draft"}`, ownerSession)
+	createPost(t, client, base, handle, `{"title":"New singel"}`, ownerSession)
+
+	// A draft's old slug only redirects for those who can see the draft
+	status, _ := editTitle(t, client, base, handle, "draft-idea", "Final idea", ownerSession)
+	require.Equal(t, http.StatusOK, status)
+	resp, err := get(&noRedirect, base, "/artists/"+handle+"/posts/draft-idea", fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, err = get(&noRedirect, base, "/artists/"+handle+"/posts/draft-idea", ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+
+	// The feed index document moves with the post
+	resp, err = postJSON(client, base, "/users/me/following/"+handle, `{}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	status, _ = editTitle(t, client, base, handle, "new-singel", "New single", ownerSession)
+	require.Equal(t, http.StatusOK, status)
+	time.Sleep(2 * time.Second)
+	posts := listPosts(t, client, base, "/feed", fanSession)
+	require.Len(t, posts, 1)
+	require.Equal(t, "new-single", posts[0].PostID)
+
+	// Chains of edits still lead to the current slug
+	status, _ = editTitle(t, client, base, handle, "new-single", "New single (video)", ownerSession)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "new-single-video", getPost(t, client, base, handle, "new-singel", "").PostID)
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/guregu/dynamo/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type followerInsights struct {
+	TotalFollowers int `json:"total_followers"`
+	Daily          []struct {
+		Date      string `json:"date"`
+		Follows   int    `json:"follows"`
+		Unfollows int    `json:"unfollows"`
+		Net       int    `json:"net"`
+	} `json:"daily"`
+	Windows []struct {
+		Days      int `json:"days"`
+		Follows   int `json:"follows"`
+		Unfollows int `json:"unfollows"`
+		Net       int `json:"net"`
+	} `json:"windows"`
+}
+
+func getInsights(t *testing.T, client *http.Client, base, handle, query, session string) followerInsights {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle+"/followers/insights"+query, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out followerInsights
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out
+}
+
+func TestFollows_Insights_TodayAndAccess(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "insights", ownerSession)
+	var fans []string
+	for i := 0; i < 3; i++ {
+		session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+		require.NoError(t, err)
+		resp, err := postJSON(client, base, "/users/me/following/"+handle, `{}`, session)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		fans = append(fans, session)
+	}
+	resp, err := deleteReq(client, base, "/users/me/following/"+handle, fans[0])
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	out := getInsights(t, client, base, handle, "", ownerSession)
+	require.Equal(t, 2, out.TotalFollowers)
+	require.Len(t, out.Daily, 30)
+	today := out.Daily[len(out.Daily)-1]
+	require.Equal(t, time.Now().UTC().Format("2006-01-02"), today.Date)
+	require.Equal(t, 3, today.Follows)
+	require.Equal(t, 1, today.Unfollows)
+	require.Equal(t, 2, today.Net)
+	require.Len(t, out.Windows, 3)
+	for i, days := range []int{7, 30, 90} {
+		require.Equal(t, days, out.Windows[i].Days)
+		require.Equal(t, 2, out.Windows[i].Net)
+	}
+
+	out = getInsights(t, client, base, handle, "?days=7&windows=1,14", ownerSession)
+	require.Len(t, out.Daily, 7)
+	require.Len(t, out.Windows, 2)
+	require.Equal(t, 3, out.Windows[0].Follows)
+
+	// Followers are not members; only members see insights
+	resp, err = get(client, base, "/artists/"+handle+"/followers/insights", fans[1])
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	for _, query := range []string{"?days=0", "?days=366", "?windows=7,abc", "?windows=400"} {
+		resp, err = get(client, base, "/artists/"+handle+"/followers/insights"+query, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func TestFollows_Insights_RollsUpPastDays(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "rollup", ownerSession)
+
+	// History from before today: a follow 3 days ago that stands, and one 5 days ago undone 2 days ago
+	day := func(n int) time.Time { return time.Now().UTC().AddDate(0, 0, -n) }
+	tbl := testDB.Table(testTable)
+	follow := struct {
+		PK         string `dynamo:"pk"`
+		SK         string `dynamo:"sk"`
+		UserID     string `dynamo:"user_id"`
+		FollowedAt string `dynamo:"followed_at"`
+	}{"ARTISTS#" + handle, "FOLLOWED#" + day(3).Format(time.RFC3339) + "#old-fan", "old-fan", day(3).Format(time.RFC3339)}
+	require.NoError(t, tbl.Put(follow).Run(ctx))
+	unfollow := struct {
+		PK           string `dynamo:"pk"`
+		SK           string `dynamo:"sk"`
+		FollowedAt   string `dynamo:"followed_at"`
+		UnfollowedAt string `dynamo:"unfollowed_at"`
+	}{"ARTISTS#" + handle, "UNFOLLOWED#" + day(2).Format(time.RFC3339) + "#e1", day(5).Format(time.RFC3339), day(2).Format(time.RFC3339)}
+	require.NoError(t, tbl.Put(unfollow).Run(ctx))
+
+	check := func(h string) {
+		out := getInsights(t, client, base, h, "?days=7&windows=7", ownerSession)
+		byDate := map[string][2]int{}
+		for _, d := range out.Daily {
+			byDate[d.Date] = [2]int{d.Follows, d.Unfollows}
+		}
+		require.Equal(t, [2]int{1, 0}, byDate[day(5).Format("2006-01-02")])
+		require.Equal(t, [2]int{1, 0}, byDate[day(3).Format("2006-01-02")])
+		require.Equal(t, [2]int{0, 1}, byDate[day(2).Format("2006-01-02")])
+		require.Equal(t, 2, out.Windows[0].Follows)
+		require.Equal(t, 1, out.Windows[0].Unfollows)
+	}
+	check(handle)
+
+	// Past days are now stored as rollup rows and read from there
+	var rollups []struct {
+		SK string `dynamo:"sk"`
+	}
+	require.NoError(t, tbl.Get("pk", "ARTISTS#"+handle).Range("sk", dynamo.BeginsWith, "FOLLOWSTATS#").All(ctx, &rollups))
+	require.Len(t, rollups, 3)
+	check(handle)
+
+	// Insights move with the page on rename
+	newHandle := uniqueHandle(t, "rolledup")
+	resp, err := renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	check(newHandle)
+}
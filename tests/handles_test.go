@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func handleAvailability(t *testing.T, client *http.Client, base, handle, session string) (available bool, reason string) {
+	t.Helper()
+	resp, err := get(client, base, "/artists/handles/"+handle+"/availability", session)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var out struct {
+		Handle    string `json:"handle"`
+		Available bool   `json:"available"`
+		Reason    string `json:"reason"`
+	}
+	require.NoError(t, json.Unmarshal(body, &out))
+	return out.Available, out.Reason
+}
+
+func TestHandles_ReservedAndDisallowed(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		handle string
+		status int
+	}{
+		{"support", http.StatusConflict},
+		{"adm1n", http.StatusConflict}, // confusable of "admin"
+		{uniqueHandle(t, "aftervvavefans"), http.StatusBadRequest},
+		{uniqueHandle(t, "realafterwave"), http.StatusBadRequest},
+		{"aftervvave", http.StatusBadRequest}, // confusable of "afterwave"
+		{"fuck2026", http.StatusBadRequest},
+	} {
+		resp, err := postJSON(client, base, "/artists", `{"handle":"`+tc.handle+`","display_name":"Band"}`, session)
+		require.NoError(t, err)
+		body, _ := readBody(resp)
+		require.Equalf(t, tc.status, resp.StatusCode, "%s: %s", tc.handle, body)
+	}
+
+	// Renames are held to the same policy
+	handle := createArtist(t, client, base, "policyrename", session)
+	resp, err := renameArtist(client, base, handle, "support", session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestHandles_Availability(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	taken := createArtist(t, client, base, "availtaken", session)
+
+	for _, tc := range []struct {
+		handle string
+		reason string
+	}{
+		{uniqueHandle(t, "availfree"), "available"},
+		{taken, "taken"},
+		{"abc", "invalid"},
+		{"help", "reserved"},
+		{"4fterwave", "disallowed"},
+		{"1999shit", "disallowed"},
+		{uniqueHandle(t, "afterwavefan"), "disallowed"},
+		{uniqueHandle(t, "fuckband"), "disallowed"},
+		// Allowed words containing a denied term can be claimed, but not with a denied term beside them
+		{uniqueHandle(t, "scunthorpe"), "available"},
+		{uniqueHandle(t, "shitake"), "available"},
+		{uniqueHandle(t, "shitakeshit"), "disallowed"},
+	} {
+		available, reason := handleAvailability(t, client, base, tc.handle, session)
+		require.Equal(t, tc.reason, reason, tc.handle)
+		require.Equal(t, tc.reason == "available", available, tc.handle)
+	}
+
+	resp, err := get(client, base, "/artists/handles/help/availability", "")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandles_AdminGrant(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	adminSession, _ := signupAdmin(t, client, base)
+	session, userID, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := uniqueHandle(t, "afterwaveofficial")
+
+	// Non-admins cannot grant
+	resp, err := postJSON(client, base, "/admin/handles/"+handle+"/grant", `{"user_id":"`+userID+`"}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = postJSON(client, base, "/admin/handles/"+handle+"/grant", `{"user_id":"`+userID+`"}`, adminSession)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode, string(body))
+
+	// Only the grantee sees it as available
+	available, _ := handleAvailability(t, client, base, handle, session)
+	require.True(t, available)
+	available, reason := handleAvailability(t, client, base, handle, otherSession)
+	require.False(t, available)
+	require.Equal(t, "disallowed", reason)
+	resp, err = postJSON(client, base, "/artists", `{"handle":"`+handle+`","display_name":"Band"}`, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = postJSON(client, base, "/artists", `{"handle":"`+handle+`","display_name":"Band"}`, session)
+	require.NoError(t, err)
+	body, _ = readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(body))
+
+	// The grant is consumed by the create; granting a taken handle conflicts
+	resp, err = deleteReq(client, base, "/admin/handles/"+handle+"/grant", adminSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, err = postJSON(client, base, "/admin/handles/"+handle+"/grant", `{"user_id":"`+userID+`"}`, adminSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Revoke removes an unclaimed grant; both actions are audited on the user
+	revoked := uniqueHandle(t, "afterwaverevoke")
+	resp, err = postJSON(client, base, "/admin/handles/"+revoked+"/grant", `{"user_id":"`+userID+`"}`, adminSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, err = deleteReq(client, base, "/admin/handles/"+revoked+"/grant", adminSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	available, _ = handleAvailability(t, client, base, revoked, session)
+	require.False(t, available)
+
+	resp, err = get(client, base, "/admin/users/"+userID+"/audit", adminSession)
+	require.NoError(t, err)
+	body, _ = readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var audit struct {
+		Entries []struct {
+			Action string `json:"action"`
+			Detail string `json:"detail"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(body, &audit))
+	actions := map[string]bool{}
+	for _, e := range audit.Entries {
+		actions[e.Action+" "+e.Detail] = true
+	}
+	require.True(t, actions["handle.grant "+handle])
+	require.True(t, actions["handle.grant "+revoked])
+	require.True(t, actions["handle.revoke_grant "+revoked])
+}
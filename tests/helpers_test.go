@@ -309,6 +309,18 @@ func patchJSON(client *http.Client, baseURL, path, body string, authToken string
 	return client.Do(req)
 }
 
+func putJSON(client *http.Client, baseURL, path, body string, authToken string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPut, baseURL+path, bytes.NewBufferString(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
+	return client.Do(req)
+}
+
 func deleteReq(client *http.Client, baseURL, path, authToken string) (*http.Response, error) {
 	req, err := http.NewRequest(http.MethodDelete, baseURL+path, nil)
 	if err != nil {
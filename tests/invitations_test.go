@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var inviteCodeRegex = regexp.MustCompile(`Code: (\S+)`)
+
+// createInvitation invites email to handle and returns the invitation ID.
+func createInvitation(t *testing.T, client *http.Client, base, handle, email, roles, session string) string {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/invitations", `{"email":"`+email+`","roles":`+roles+`}`, session)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(body))
+	var inv map[string]any
+	require.NoError(t, json.Unmarshal(body, &inv))
+	id, _ := inv["id"].(string)
+	require.NotEmpty(t, id)
+	return id
+}
+
+// myInvitationIDs returns the IDs from GET /users/me/invitations.
+func myInvitationIDs(t *testing.T, client *http.Client, base, session string) []string {
+	t.Helper()
+	resp, err := get(client, base, "/users/me/invitations", session)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var out struct {
+		Invitations []struct {
+			ID string `json:"id"`
+		} `json:"invitations"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	ids := make([]string, 0, len(out.Invitations))
+	for _, inv := range out.Invitations {
+		ids = append(ids, inv.ID)
+	}
+	return ids
+}
+
+func createArtist(t *testing.T, client *http.Client, base, prefix, session string) string {
+	t.Helper()
+	handle := uniqueHandle(t, prefix)
+	resp, err := postJSON(client, base, "/artists", `{"handle":"`+handle+`","display_name":"Band","bio":""}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return handle
+}
+
+func TestInvitations_InviteRegisteredUserAndAccept(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "inviteaccept", ownerSession)
+	email := strings.ToLower(uniqueEmail(t))
+	inviteeSession, _, err := signupWithPKCEAndMe(client, base, email, "password123", "web")
+	require.NoError(t, err)
+
+	id := createInvitation(t, client, base, handle, strings.ToUpper(email), `["feed","music"]`, ownerSession)
+	require.Len(t, testMailer.messagesTo(email), 1)
+
+	// Pending on both sides
+	require.Equal(t, []string{id}, myInvitationIDs(t, client, base, inviteeSession))
+	listResp, err := get(client, base, "/artists/"+handle+"/invitations", ownerSession)
+	require.NoError(t, err)
+	listBody, _ := readBody(listResp)
+	require.Equal(t, http.StatusOK, listResp.StatusCode)
+	require.Contains(t, string(listBody), id)
+	require.NotContains(t, string(listBody), "token")
+
+	// Not a member until accepted
+	respPost, err := postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Too early","body":"x"}`, inviteeSession)
+	require.NoError(t, err)
+	respPost.Body.Close()
+	require.Equal(t, http.StatusForbidden, respPost.StatusCode)
+
+	resp, err := postJSON(client, base, "/users/me/invitations/"+id+"/accept", ``, inviteeSession)
+	require.NoError(t, err)
+	body, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+	role, roles := artistRoles(t, client, base, inviteeSession, handle)
+	require.Equal(t, "member", role)
+	require.ElementsMatch(t, []string{"feed", "music"}, roles)
+	require.Empty(t, myInvitationIDs(t, client, base, inviteeSession))
+
+	// Single use
+	resp2, err := postJSON(client, base, "/users/me/invitations/"+id+"/accept", ``, inviteeSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp2.StatusCode)
+}
+
+func TestInvitations_AcceptByMemberKeepsRoles(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "invitemember", ownerSession)
+	email := strings.ToLower(uniqueEmail(t))
+	memberSession, memberID, err := signupWithPKCEAndMe(client, base, email, "password123", "web")
+	require.NoError(t, err)
+	resp, err := postJSON(client, base, "/artists/"+handle+"/members", `{"user_id":"`+memberID+`","roles":["admin"]}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Accepting a narrower invitation does not demote an existing member
+	id := createInvitation(t, client, base, handle, email, `["feed"]`, ownerSession)
+	resp, err = postJSON(client, base, "/users/me/invitations/"+id+"/accept", ``, memberSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	_, roles := artistRoles(t, client, base, memberSession, handle)
+	require.Equal(t, []string{"admin"}, roles)
+	require.Equal(t, []string{id}, myInvitationIDs(t, client, base, memberSession))
+}
+
+func TestInvitations_UnregisteredEmailResolvesAfterSignup(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "invitelater", ownerSession)
+	email := strings.ToLower(uniqueEmail(t))
+	id := createInvitation(t, client, base, handle, email, `["photos"]`, ownerSession)
+
+	inviteeSession, _, err := signupWithPKCEAndMe(client, base, email, "password123", "web")
+	require.NoError(t, err)
+	require.Equal(t, []string{id}, myInvitationIDs(t, client, base, inviteeSession))
+
+	resp, err := postJSON(client, base, "/users/me/invitations/"+id+"/decline", `{}`, inviteeSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Empty(t, myInvitationIDs(t, client, base, inviteeSession))
+	role, _ := artistRoles(t, client, base, inviteeSession, handle)
+	require.Equal(t, "", role)
+}
+
+func TestInvitations_AcceptWithTokenFromOtherAccount(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "invitetoken", ownerSession)
+	email := strings.ToLower("work-" + uniqueEmail(t))
+	id := createInvitation(t, client, base, handle, email, `["gigs"]`, ownerSession)
+	msgs := testMailer.messagesTo(email)
+	require.Len(t, msgs, 1)
+	m := inviteCodeRegex.FindStringSubmatch(msgs[0].Body)
+	require.Len(t, m, 2)
+	token := m[1]
+
+	// Signed in with a different address: ID alone is not enough, the emailed code is
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	require.Empty(t, myInvitationIDs(t, client, base, otherSession))
+	resp, err := postJSON(client, base, "/users/me/invitations/"+id+"/accept", `{}`, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp2, err := postJSON(client, base, "/users/me/invitations/"+id+"/accept", `{"token":"wrong"}`, otherSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp2.StatusCode)
+	resp3, err := postJSON(client, base, "/users/me/invitations/"+id+"/accept", `{"token":"`+token+`"}`, otherSession)
+	require.NoError(t, err)
+	resp3.Body.Close()
+	require.Equal(t, http.StatusOK, resp3.StatusCode)
+	role, roles := artistRoles(t, client, base, otherSession, handle)
+	require.Equal(t, "member", role)
+	require.Equal(t, []string{"gigs"}, roles)
+}
+
+func TestInvitations_RevokeAndValidation(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerEmail := strings.ToLower(uniqueEmail(t))
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, ownerEmail, "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "inviterevoke", ownerSession)
+	email := strings.ToLower(uniqueEmail(t))
+	inviteeSession, _, err := signupWithPKCEAndMe(client, base, email, "password123", "web")
+	require.NoError(t, err)
+
+	for _, body := range []string{
+		`{"email":"not-an-email","roles":["feed"]}`,
+		`{"email":"` + email + `","roles":[]}`,
+		`{"email":"` + email + `","roles":["owner"]}`,
+	} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/invitations", body, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equalf(t, http.StatusBadRequest, resp.StatusCode, "body %s", body)
+	}
+	respOwner, err := postJSON(client, base, "/artists/"+handle+"/invitations", `{"email":"`+ownerEmail+`","roles":["feed"]}`, ownerSession)
+	require.NoError(t, err)
+	respOwner.Body.Close()
+	require.Equal(t, http.StatusConflict, respOwner.StatusCode)
+
+	// Only owner/admin can invite
+	respForbidden, err := postJSON(client, base, "/artists/"+handle+"/invitations", `{"email":"`+email+`","roles":["feed"]}`, inviteeSession)
+	require.NoError(t, err)
+	respForbidden.Body.Close()
+	require.Equal(t, http.StatusForbidden, respForbidden.StatusCode)
+
+	id := createInvitation(t, client, base, handle, email, `["feed"]`, ownerSession)
+	respDup, err := postJSON(client, base, "/artists/"+handle+"/invitations", `{"email":"`+email+`","roles":["music"]}`, ownerSession)
+	require.NoError(t, err)
+	respDup.Body.Close()
+	require.Equal(t, http.StatusConflict, respDup.StatusCode)
+
+	resp, err := deleteReq(client, base, "/artists/"+handle+"/invitations/"+id, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Empty(t, myInvitationIDs(t, client, base, inviteeSession))
+	resp2, err := postJSON(client, base, "/users/me/invitations/"+id+"/accept", `{}`, inviteeSession)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp2.StatusCode)
+
+	// Can invite again after revoking
+	createInvitation(t, client, base, handle, email, `["music"]`, ownerSession)
+}
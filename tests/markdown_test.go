@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/feed"
+	"github.com/sopatech/afterwave.fm/internal/markdown"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestMarkdown_Golden renders each testdata/markdown/*.md with the default policy and compares it with the .html file
+// next to it. Run with -update to rewrite the .html files after checking the new output by hand.
+func TestMarkdown_Golden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/markdown/*.md")
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs)
+	for _, in := range inputs {
+		t.Run(filepath.Base(in), func(t *testing.T) {
+			src, err := os.ReadFile(in)
+			require.NoError(t, err)
+			got := markdown.Default.Render(string(src)).HTML + "\n"
+			golden := strings.TrimSuffix(in, ".md") + ".html"
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(golden, []byte(got), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestMarkdown_NoActiveContent(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/markdown/xss_*.md")
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs)
+	for _, in := range inputs {
+		src, err := os.ReadFile(in)
+		require.NoError(t, err)
+		out := strings.ToLower(markdown.Default.Render(string(src)).HTML)
+		for _, tag := range strings.Split(out, "<")[1:] {
+			tag = "<" + tag[:strings.IndexByte(tag+">", '>')]
+			// Quoted attribute values are escaped text; only the markup around them could be active.
+			markup := stripQuoted(tag)
+			for _, bad := range []string{"<script", "<img", "<svg", "<!--", " on"} {
+				require.NotContains(t, markup, bad, "%s: %s", in, tag)
+			}
+			if i := strings.Index(tag, `href="`); i >= 0 {
+				href := tag[i+len(`href="`):]
+				for _, scheme := range []string{"javascript:", "vbscript:", "data:"} {
+					require.False(t, strings.HasPrefix(href, scheme), "%s: %s", in, tag)
+				}
+			}
+		}
+	}
+}
+
+// stripQuoted removes "..." attribute values from a tag.
+func stripQuoted(tag string) string {
+	var b strings.Builder
+	quoted := false
+	for _, c := range tag {
+		if c == '"' {
+			quoted = !quoted
+			continue
+		}
+		if !quoted {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func TestMarkdown_Policy(t *testing.T) {
+	src := "# Title\n\nA [link](https://example.com) and ![cover](https://cdn.example.com/c.png) and [mail](mailto:a@example.com) and [ftp](ftp://example.com/f)."
+
+	// Elements left out render as their content only
+	r, err := markdown.New(markdown.Policy{Elements: []string{"p", "img"}, Schemes: []string{"https"}})
+	require.NoError(t, err)
+	res := r.Render(src)
+	require.Equal(t, "Title\n<p>A link and <img src=\"https://cdn.example.com/c.png\" alt=\"cover\"> and mail and ftp.</p>", res.HTML)
+	require.Equal(t, []string{"https://example.com", "https://cdn.example.com/c.png"}, res.Links)
+
+	res = markdown.Default.Render(src)
+	require.Contains(t, res.HTML, `<h1>Title</h1>`)
+	require.Contains(t, res.HTML, `<a href="mailto:a@example.com" rel="nofollow ugc noopener">mail</a>`)
+	require.Contains(t, res.HTML, `</a> and cover and `) // images are off by default
+	require.Contains(t, res.HTML, ` and ftp.</p>`)
+
+	for _, p := range []markdown.Policy{
+		{Elements: []string{"p", "script"}},
+		{Elements: []string{"p"}, Schemes: []string{"javascript"}},
+		{Elements: []string{"p"}, Schemes: []string{"DATA"}},
+		{Elements: []string{"p"}, Schemes: []string{"not a scheme"}},
+	} {
+		_, err := markdown.New(p)
+		require.Error(t, err, "%+v", p)
+	}
+}
+
+func TestMarkdown_Excerpt(t *testing.T) {
+	require.Equal(t, "short text", markdown.Excerpt("short\n\n  text ", 200))
+	require.Equal(t, "The quick brown…", markdown.Excerpt("The quick brown fox jumps", 19))
+	require.Equal(t, "The quick brown…", markdown.Excerpt("The quick brown, fox jumps", 19))
+	// Cut on runes, not bytes
+	require.Equal(t, "Ünïcödé…", markdown.Excerpt("Ünïcödé ünïcödé", 12))
+	require.Equal(t, "Ünïcö…", markdown.Excerpt("Ünïcödéünïcödé", 6))
+}
+
+func TestFeed_PostBody_RenderedOnWrite(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "markdown", session)
+
+	body := "# New *single*\n\nOut now with @GuestSinger, listen at [our site](https://example.com/listen) or https://example.org.\n\n<script>alert(1)</script> " +
+		strings.Repeat("Ünïcödé ", 40)
+	req, err := json.Marshal(map[string]string{"title": "Release", "body": body})
+	require.NoError(t, err)
+	post := createPost(t, client, base, handle, string(req), session)
+	require.True(t, strings.HasPrefix(post.BodyHTML, "<h1>New <em>single</em></h1>\n<p>Out now with <a href=\"https://guestsinger.afterwave.fm\" class=\"mention\">@GuestSinger</a>"), post.BodyHTML)
+	require.Contains(t, post.BodyHTML, "<p>&lt;script&gt;alert(1)&lt;/script&gt; Ünïcödé")
+	require.Equal(t, []string{"https://example.com/listen", "https://example.org"}, post.Links)
+	require.Equal(t, []string{"guestsinger"}, post.Mentions)
+	require.True(t, strings.HasPrefix(post.Excerpt, "New single Out now with @GuestSinger, listen at our site or https://example.org. <script>"), post.Excerpt)
+	require.True(t, strings.HasSuffix(post.Excerpt, "Ünïcödé…"), post.Excerpt)
+	require.LessOrEqual(t, len([]rune(post.Excerpt)), feed.ExcerptLength)
+
+	// Edits are rendered again
+	resp, err := patchJSON(client, base, "/artists/"+handle+"/posts/release", `{"body":"Plain **update**"}`, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.Contains(t, string(b), `"links":[]`)
+	require.Contains(t, string(b), `"mentions":[]`)
+	var updated feedPost
+	require.NoError(t, json.Unmarshal(b, &updated))
+	require.Equal(t, "<p>Plain <strong>update</strong></p>", updated.BodyHTML)
+	require.Equal(t, "Plain update", updated.Excerpt)
+}
@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/media"
+)
+
+type mediaUpload struct {
+	Media struct {
+		MediaID string `json:"media_id"`
+		Status  string `json:"status"`
+		URL     string `json:"url"`
+	} `json:"media"`
+	UploadURL     string            `json:"upload_url"`
+	UploadMethod  string            `json:"upload_method"`
+	UploadHeaders map[string]string `json:"upload_headers"`
+}
+
+func createUpload(t *testing.T, client *http.Client, base, handle, contentType string, size int, session string) mediaUpload {
+	t.Helper()
+	resp, err := postJSON(client, base, "/artists/"+handle+"/media", `{"content_type":"`+contentType+`","size":`+strconv.Itoa(size)+`}`, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, string(b))
+	var u mediaUpload
+	require.NoError(t, json.Unmarshal(b, &u))
+	require.Equal(t, "pending", u.Media.Status)
+	require.Equal(t, "PUT", u.UploadMethod)
+	return u
+}
+
+// putUpload sends data to the presigned upload URL with the returned headers and returns the status code.
+func putUpload(t *testing.T, client *http.Client, u mediaUpload, data []byte) int {
+	t.Helper()
+	req, err := http.NewRequest(u.UploadMethod, u.UploadURL, bytes.NewReader(data))
+	require.NoError(t, err)
+	for k, v := range u.UploadHeaders {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// uploadImage creates, uploads and finalizes an image and returns its media ID.
+func uploadImage(t *testing.T, client *http.Client, base, handle, session string) string {
+	t.Helper()
+	data := []byte("\x89PNG fake image " + uniqueHandle(t, "img"))
+	u := createUpload(t, client, base, handle, "image/png", len(data), session)
+	require.Equal(t, http.StatusOK, putUpload(t, client, u, data))
+	resp, err := postJSON(client, base, "/artists/"+handle+"/media/"+u.Media.MediaID+"/finalize", `{}`, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.Contains(t, string(b), `"status":"ready"`)
+	return u.Media.MediaID
+}
+
+// cleanupMedia runs the orphaned upload cleaner as of now.
+func cleanupMedia(t *testing.T, now time.Time) {
+	t.Helper()
+	files, err := media.NewFileStore(testMediaDir, "http://unused/files", testMediaSecret)
+	require.NoError(t, err)
+	artistSvc := artists.NewService(artists.NewStore(testDB, testTable), artists.NewMemberStore(testDB, testTable), nil)
+	svc := media.NewService(media.NewStore(testDB, testTable), files, artistSvc, 0)
+	for {
+		n, err := svc.CleanupOrphans(context.Background(), now)
+		require.NoError(t, err)
+		if n == 0 {
+			return
+		}
+	}
+}
+
+func TestMedia_Upload_ValidateAndFinalize(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "uploads", ownerSession)
+
+	for _, body := range []string{
+		`{"content_type":"image/svg+xml","size":100}`,
+		`{"content_type":"application/pdf","size":100}`,
+		`{"content_type":"image/png","size":0}`,
+		`{"content_type":"image/png","size":20971520}`,
+	} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/media", body, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+	resp, err := postJSON(client, base, "/artists/"+handle+"/media", `{"content_type":"image/png","size":10}`, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	data := []byte("GIF89a fake gif")
+	u := createUpload(t, client, base, handle, "image/gif", len(data), ownerSession)
+	finalize := "/artists/" + handle + "/media/" + u.Media.MediaID + "/finalize"
+
+	// Nothing uploaded yet
+	resp, err = postJSON(client, base, finalize, `{}`, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// The presigned URL only accepts the declared content type and size
+	wrongType := u
+	wrongType.UploadHeaders = map[string]string{"Content-Type": "image/png"}
+	require.Equal(t, http.StatusForbidden, putUpload(t, client, wrongType, data))
+	require.Equal(t, http.StatusBadRequest, putUpload(t, client, u, append(data, '!')))
+	require.Equal(t, http.StatusOK, putUpload(t, client, u, data))
+
+	resp, err = postJSON(client, base, finalize, `{}`, otherSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, err = postJSON(client, base, finalize, `{}`, ownerSession)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.Contains(t, string(b), `"status":"ready"`)
+
+	resp, err = get(client, base, "/artists/"+handle+"/media/"+u.Media.MediaID, ownerSession)
+	require.NoError(t, err)
+	b, _ = readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(b), `"content_type":"image/gif"`)
+	require.Equal(t, http.StatusNotFound, statusOf(t, client, base, "/artists/"+handle+"/media/nope", ownerSession))
+
+	// The file is served from the media URL
+	resp, err = client.Get(u.Media.URL)
+	require.NoError(t, err)
+	served, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "image/gif", resp.Header.Get("Content-Type"))
+	require.Equal(t, data, served)
+}
+
+func TestFeed_PostMedia_OrderedWithAltText(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "gallery", session)
+	first := uploadImage(t, client, base, handle, session)
+	second := uploadImage(t, client, base, handle, session)
+	pending := createUpload(t, client, base, handle, "image/jpeg", 10, session).Media.MediaID
+
+	for _, body := range []string{
+		`{"title":"Bad","image_url":"https://example.com/img.png"}`,
+		`{"title":"Bad","media":[{"media_id":"` + pending + `"}]}`,
+		`{"title":"Bad","media":[{"media_id":"unknown"}]}`,
+		`{"title":"Bad","media":[{"media_id":"` + first + `"},{"media_id":"` + first + `"}]}`,
+		`{"title":"Bad","media":[{"media_id":"` + first + `","alt_text":"` + strings.Repeat("a", 1001) + `"}]}`,
+	} {
+		resp, err := postJSON(client, base, "/artists/"+handle+"/posts", body, session)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	post := createPost(t, client, base, handle, `{"title":"Tour photos","media":[{"media_id":"`+second+`","alt_text":"The crowd"},{"media_id":"`+first+`","alt_text":"The stage"}]}`, session)
+	require.Len(t, post.Media, 2)
+	require.Equal(t, second, post.Media[0].MediaID)
+	require.Equal(t, "The crowd", post.Media[0].AltText)
+	require.Equal(t, first, post.Media[1].MediaID)
+	require.NotEmpty(t, post.Media[1].URL)
+	require.Equal(t, "image/png", post.Media[1].ContentType)
+
+	// An image belongs to one post
+	resp, err := postJSON(client, base, "/artists/"+handle+"/posts", `{"title":"Copy","media":[{"media_id":"`+first+`"}]}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Dropping an image releases it for other posts
+	resp, err = patchJSON(client, base, "/artists/"+handle+"/posts/tour-photos", `{"media":[{"media_id":"`+second+`","alt_text":"The crowd"}]}`, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	require.NotContains(t, string(b), first)
+	copied := createPost(t, client, base, handle, `{"title":"Copy","media":[{"media_id":"`+first+`"}]}`, session)
+	require.Len(t, copied.Media, 1)
+
+	// The image list is part of the revision history
+	revs := listRevisions(t, client, base, handle, "tour-photos", session)
+	require.Len(t, revs, 2)
+	require.Equal(t, "media", revs[0].Changes[0].Field)
+}
+
+func TestMedia_OrphansCleanedUp(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "orphans", session)
+	used := uploadImage(t, client, base, handle, session)
+	unused := uploadImage(t, client, base, handle, session)
+	createPost(t, client, base, handle, `{"title":"Cover","media":[{"media_id":"`+used+`"}]}`, session)
+
+	resp, err := get(client, base, "/artists/"+handle+"/media/"+unused, session)
+	require.NoError(t, err)
+	var orphan struct {
+		URL       string `json:"url"`
+		ExpiresAt string `json:"expires_at"`
+	}
+	b, _ := readBody(resp)
+	require.NoError(t, json.Unmarshal(b, &orphan))
+	require.NotEmpty(t, orphan.ExpiresAt)
+
+	// Nothing is due yet
+	cleanupMedia(t, time.Now())
+	require.Equal(t, http.StatusOK, statusOf(t, client, base, "/artists/"+handle+"/media/"+unused, session))
+
+	cleanupMedia(t, time.Now().Add(media.DefaultOrphanTTL+time.Hour))
+	require.Equal(t, http.StatusNotFound, statusOf(t, client, base, "/artists/"+handle+"/media/"+unused, session))
+	resp, err = client.Get(orphan.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Equal(t, http.StatusOK, statusOf(t, client, base, "/artists/"+handle+"/media/"+used, session))
+}
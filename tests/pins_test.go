@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/feed"
+)
+
+func pinPost(t *testing.T, client *http.Client, base, handle, postID, session string, pin bool) (int, feedPost) {
+	t.Helper()
+	path := "/artists/" + handle + "/posts/" + postID + "/pin"
+	var resp *http.Response
+	var err error
+	if pin {
+		resp, err = postJSON(client, base, path, `{}`, session)
+	} else {
+		resp, err = deleteReq(client, base, path, session)
+	}
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	var p feedPost
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.Unmarshal(b, &p))
+	}
+	return resp.StatusCode, p
+}
+
+// allListedPostIDs follows next_cursor through every page of GET /artists/{handle}/posts.
+func allListedPostIDs(t *testing.T, client *http.Client, base, handle string, limit string) []string {
+	t.Helper()
+	var ids []string
+	cursor := ""
+	for {
+		path := "/artists/" + handle + "/posts?limit=" + limit
+		if cursor != "" {
+			path += "&cursor=" + cursor
+		}
+		resp, err := get(client, base, path, "")
+		require.NoError(t, err)
+		b, _ := readBody(resp)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+		var out struct {
+			Posts      []feedPost `json:"posts"`
+			NextCursor string     `json:"next_cursor"`
+		}
+		require.NoError(t, json.Unmarshal(b, &out))
+		for _, p := range out.Posts {
+			ids = append(ids, p.PostID)
+		}
+		if out.NextCursor == "" {
+			return ids
+		}
+		cursor = out.NextCursor
+	}
+}
+
+func TestPins_PinnedPostsListedFirst(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "pins", ownerSession)
+	for _, title := range []string{"First", "Second", "Third", "Fourth", "Fifth"} {
+		createPost(t, client, base, handle, `{"title":"`+title+`"}`, ownerSession)
+	}
+	createPost(t, client, base, handle, `{"title":"Draft","status":"draft"}`, ownerSession)
+	byTime := listedPostIDs(t, client, base, handle)
+	require.Len(t, byTime, 5)
+	oldest := byTime[4]
+
+	status, _ := pinPost(t, client, base, handle, oldest, "", true)
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = pinPost(t, client, base, handle, oldest, fanSession, true)
+	require.Equal(t, http.StatusForbidden, status)
+	status, _ = pinPost(t, client, base, handle, "draft", ownerSession, true)
+	require.Equal(t, http.StatusBadRequest, status)
+	status, _ = pinPost(t, client, base, handle, "nope", ownerSession, true)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, p := pinPost(t, client, base, handle, oldest, ownerSession, true)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, p.Pinned)
+	status, _ = pinPost(t, client, base, handle, oldest, ownerSession, true)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, getPost(t, client, base, handle, oldest, "").Pinned)
+
+	// First page starts with the pinned post; no page repeats it
+	want := append([]string{oldest}, byTime[:4]...)
+	require.Equal(t, want, listedPostIDs(t, client, base, handle))
+	require.Equal(t, want, allListedPostIDs(t, client, base, handle, "2"))
+
+	// Most recently pinned first, up to the limit
+	for _, id := range byTime[1:feed.MaxPinnedPosts] {
+		status, _ = pinPost(t, client, base, handle, id, ownerSession, true)
+		require.Equal(t, http.StatusOK, status)
+	}
+	status, _ = pinPost(t, client, base, handle, byTime[0], ownerSession, true)
+	require.Equal(t, http.StatusConflict, status)
+	pinned := []string{byTime[2], byTime[1], oldest}
+	require.Equal(t, append(pinned, byTime[0], byTime[3]), allListedPostIDs(t, client, base, handle, "1"))
+
+	// Unpinning or deleting a pinned post frees a slot
+	status, p = pinPost(t, client, base, handle, byTime[1], ownerSession, false)
+	require.Equal(t, http.StatusOK, status)
+	require.False(t, p.Pinned)
+	status, _ = pinPost(t, client, base, handle, byTime[1], ownerSession, false)
+	require.Equal(t, http.StatusOK, status)
+	resp, err := deleteReq(client, base, "/artists/"+handle+"/posts/"+byTime[2], ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	status, _ = pinPost(t, client, base, handle, byTime[0], ownerSession, true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{byTime[0], oldest, byTime[1], byTime[3]}, listedPostIDs(t, client, base, handle))
+
+	// Pins move with the artist
+	newHandle := uniqueHandle(t, "pinsmoved")
+	resp, err = renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{byTime[0], oldest, byTime[1], byTime[3]}, listedPostIDs(t, client, base, newHandle))
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sopatech/afterwave.fm/internal/artists"
+	"github.com/sopatech/afterwave.fm/internal/feed"
+)
+
+func react(t *testing.T, client *http.Client, base, handle, postID, reactionType, session string, on bool) int {
+	t.Helper()
+	path := "/artists/" + handle + "/posts/" + postID + "/reactions/" + reactionType
+	var resp *http.Response
+	var err error
+	if on {
+		resp, err = putJSON(client, base, path, ``, session)
+	} else {
+		resp, err = deleteReq(client, base, path, session)
+	}
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func getPost(t *testing.T, client *http.Client, base, handle, postID, session string) feedPost {
+	t.Helper()
+	resp, err := get(client, base, "/artists/"+handle+"/posts/"+postID, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var p feedPost
+	require.NoError(t, json.Unmarshal(b, &p))
+	return p
+}
+
+func listPosts(t *testing.T, client *http.Client, base, path, session string) []feedPost {
+	t.Helper()
+	resp, err := get(client, base, path, session)
+	require.NoError(t, err)
+	b, _ := readBody(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(b))
+	var out struct {
+		Posts []feedPost `json:"posts"`
+	}
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out.Posts
+}
+
+func TestReactions_CountsAndViewerReactions(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	otherSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "reactions", ownerSession)
+	post := createPost(t, client, base, handle, `{"title":"New video"}`, ownerSession)
+	require.Equal(t, map[string]int{"like": 0, "love": 0, "fire": 0, "laugh": 0, "sad": 0}, post.ReactionCounts)
+	createPost(t, client, base, handle, `{"title":"Unreleased","status":"draft"}`, ownerSession)
+
+	require.Equal(t, http.StatusUnauthorized, react(t, client, base, handle, "new-video", "like", "", true))
+	require.Equal(t, http.StatusBadRequest, react(t, client, base, handle, "new-video", "angry", fanSession, true))
+	require.Equal(t, http.StatusNotFound, react(t, client, base, handle, "unreleased", "like", fanSession, true))
+	require.Equal(t, http.StatusNotFound, react(t, client, base, handle, "nope", "like", fanSession, true))
+
+	// At most one reaction per user and type
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "new-video", "like", fanSession, true))
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "new-video", "like", fanSession, true))
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "new-video", "fire", fanSession, true))
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "new-video", "like", otherSession, true))
+
+	p := getPost(t, client, base, handle, "new-video", "")
+	require.Equal(t, 2, p.ReactionCounts["like"])
+	require.Equal(t, 1, p.ReactionCounts["fire"])
+	require.Equal(t, 0, p.ReactionCounts["sad"])
+	require.Empty(t, p.ViewerReactions)
+	require.Equal(t, []string{"like", "fire"}, getPost(t, client, base, handle, "new-video", fanSession).ViewerReactions)
+
+	// The signed-in viewer's reactions on the artist's post list
+	posts := listPosts(t, client, base, "/artists/"+handle+"/posts", fanSession)
+	require.Len(t, posts, 1)
+	require.Equal(t, []string{"like", "fire"}, posts[0].ViewerReactions)
+	posts = listPosts(t, client, base, "/artists/"+handle+"/posts", otherSession)
+	require.Equal(t, []string{"like"}, posts[0].ViewerReactions)
+	posts = listPosts(t, client, base, "/artists/"+handle+"/posts", ownerSession)
+	require.Empty(t, posts[0].ViewerReactions)
+	require.Equal(t, 2, posts[0].ReactionCounts["like"])
+
+	// Removing is idempotent too
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "new-video", "fire", fanSession, false))
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "new-video", "fire", fanSession, false))
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "new-video", "love", fanSession, false))
+	p = getPost(t, client, base, handle, "new-video", fanSession)
+	require.Equal(t, 0, p.ReactionCounts["fire"])
+	require.Equal(t, 2, p.ReactionCounts["like"])
+	require.Equal(t, []string{"like"}, p.ViewerReactions)
+
+	// and on my feed
+	resp, err := postJSON(client, base, "/users/me/following/"+handle, `{}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	time.Sleep(2 * time.Second)
+	posts = listPosts(t, client, base, "/feed", fanSession)
+	require.Len(t, posts, 1)
+	require.Equal(t, []string{"like"}, posts[0].ViewerReactions)
+	require.Equal(t, 2, posts[0].ReactionCounts["like"])
+}
+
+func TestReactions_FollowPostOnRenameAndDelete(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "reactmove", ownerSession)
+	createPost(t, client, base, handle, `{"title":"Tour"}`, ownerSession)
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "tour", "love", fanSession, true))
+
+	newHandle := uniqueHandle(t, "reactmoved")
+	resp, err := renameArtist(client, base, handle, newHandle, ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	p := getPost(t, client, base, newHandle, "tour", fanSession)
+	require.Equal(t, 1, p.ReactionCounts["love"])
+	require.Equal(t, []string{"love"}, p.ViewerReactions)
+	require.Equal(t, http.StatusNoContent, react(t, client, base, newHandle, "tour", "love", fanSession, false))
+	require.Equal(t, 0, getPost(t, client, base, newHandle, "tour", fanSession).ReactionCounts["love"])
+
+	// Reactions go with a deleted post
+	require.Equal(t, http.StatusNoContent, react(t, client, base, newHandle, "tour", "fire", fanSession, true))
+	resp, err = deleteReq(client, base, "/artists/"+newHandle+"/posts/tour", ownerSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	createPost(t, client, base, newHandle, `{"title":"Tour"}`, ownerSession)
+	p = getPost(t, client, base, newHandle, "tour", fanSession)
+	require.Equal(t, 0, p.ReactionCounts["fire"])
+	require.Empty(t, p.ViewerReactions)
+}
+
+func TestReactions_ReconcileFixesDriftedCounts(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	fanSession, _, err := signupWithPKCEAndMe(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	handle := createArtist(t, client, base, "reconcile", ownerSession)
+	createPost(t, client, base, handle, `{"title":"Drift"}`, ownerSession)
+	require.Equal(t, http.StatusNoContent, react(t, client, base, handle, "drift", "like", fanSession, true))
+
+	// Counts drift (e.g. a write outside a transaction); the reconciler recounts recently reacted posts
+	tbl := testDB.Table(testTable)
+	require.NoError(t, tbl.Update("pk", "ARTISTS#"+handle).Range("sk", "POST#drift").
+		Set("reactions_like", 7).Set("reactions_sad", -2).Run(context.Background()))
+	p := getPost(t, client, base, handle, "drift", "")
+	require.Equal(t, 7, p.ReactionCounts["like"])
+	require.Equal(t, 0, p.ReactionCounts["sad"])
+
+	artistSvc := artists.NewService(artists.NewStore(testDB, testTable), artists.NewMemberStore(testDB, testTable), nil)
+	svc := feed.NewService(feed.NewStore(testDB, testTable), artistSvc)
+	n, err := svc.ReconcileReactions(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, 1)
+	p = getPost(t, client, base, handle, "drift", "")
+	require.Equal(t, 1, p.ReactionCounts["like"])
+
+	// The post was recounted and unmarked; an unchanged post isn't corrected again
+	n, err = svc.ReconcileReactions(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
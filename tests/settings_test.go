@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettings_Get_Defaults(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCE(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	resp, err := get(client, base, "/users/me/settings", session)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var prefs map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&prefs))
+	require.Equal(t, float64(1), prefs["version"])
+	require.Equal(t, true, prefs["show_explicit"])
+	require.Equal(t, "UTC", prefs["timezone"])
+	require.Equal(t, "en", prefs["locale"])
+	email, _ := prefs["email"].(map[string]any)
+	require.Equal(t, true, email["enabled"])
+	require.Empty(t, email["opt_outs"])
+}
+
+func TestSettings_Patch_Success(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCE(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	resp, err := patchJSON(client, base, "/users/me/settings", `{"timezone":"Europe/London","locale":"en-GB","email":{"opt_outs":["new_gigs","new_gigs"]},"feed_filters":{"muted_artists":["SomeBand"]}}`, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Second PATCH only touches show_explicit; earlier fields are kept
+	resp2, err := patchJSON(client, base, "/users/me/settings", `{"show_explicit":false}`, session)
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusOK, resp2.StatusCode)
+
+	resp3, err := get(client, base, "/users/me/settings", session)
+	require.NoError(t, err)
+	defer resp3.Body.Close()
+	require.Equal(t, http.StatusOK, resp3.StatusCode)
+	var prefs map[string]any
+	require.NoError(t, json.NewDecoder(resp3.Body).Decode(&prefs))
+	require.Equal(t, false, prefs["show_explicit"])
+	require.Equal(t, "Europe/London", prefs["timezone"])
+	require.Equal(t, "en-GB", prefs["locale"])
+	require.NotEmpty(t, prefs["updated_at"])
+	email, _ := prefs["email"].(map[string]any)
+	require.Equal(t, []any{"new_gigs"}, email["opt_outs"])
+	filters, _ := prefs["feed_filters"].(map[string]any)
+	require.Equal(t, []any{"someband"}, filters["muted_artists"])
+}
+
+func TestSettings_Patch_Invalid(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	session, _, err := signupWithPKCE(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+
+	for _, body := range []string{
+		`{"timezone":"Mars/Olympus_Mons"}`,
+		`{"locale":"english"}`,
+		`{"email":{"opt_outs":["spam"]}}`,
+		`{"feed_filters":{"muted_artists":["no"]}}`,
+	} {
+		resp, err := patchJSON(client, base, "/users/me/settings", body, session)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equalf(t, http.StatusBadRequest, resp.StatusCode, "body %s", body)
+	}
+}
+
+func TestSettings_Unauthorized(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	resp, err := get(client, base, "/users/me/settings", "")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp2, err := patchJSON(client, base, "/users/me/settings", `{"show_explicit":false}`, "")
+	require.NoError(t, err)
+	resp2.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp2.StatusCode)
+}
+
+// TestSettings_FeedFilters requires DynamoDB and OpenSearch. Muted artists and explicit posts are hidden from GET /feed.
+func TestSettings_FeedFilters(t *testing.T) {
+	server, base := newTestServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	ownerSession, _, err := signupWithPKCE(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	kept := uniqueHandle(t, "kept")
+	muted := uniqueHandle(t, "muted")
+	for _, h := range []string{kept, muted} {
+		resp, err := postJSON(client, base, "/artists", `{"handle":"`+h+`","display_name":"Band","bio":""}`, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	for _, p := range []struct{ handle, body string }{
+		{kept, `{"title":"Clean post","body":"clean"}`},
+		{kept, `{"title":"Explicit post","body":"explicit","explicit":true}`},
+		{muted, `{"title":"Muted post","body":"muted"}`},
+	} {
+		resp, err := postJSON(client, base, "/artists/"+p.handle+"/posts", p.body, ownerSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	fanSession, _, err := signupWithPKCE(client, base, uniqueEmail(t), "password123", "web")
+	require.NoError(t, err)
+	for _, h := range []string{kept, muted} {
+		resp, err := postJSON(client, base, "/users/me/following/"+h, `{}`, fanSession)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	resp, err := patchJSON(client, base, "/users/me/settings", `{"show_explicit":false,"feed_filters":{"muted_artists":["`+muted+`"]}}`, fanSession)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	time.Sleep(2 * time.Second)
+
+	feedResp, err := get(client, base, "/feed", fanSession)
+	require.NoError(t, err)
+	defer feedResp.Body.Close()
+	require.Equal(t, http.StatusOK, feedResp.StatusCode)
+	var feedBody map[string]any
+	require.NoError(t, json.NewDecoder(feedResp.Body).Decode(&feedBody))
+	posts, _ := feedBody["posts"].([]any)
+	require.Len(t, posts, 1)
+	require.Equal(t, "clean", posts[0].(map[string]any)["body"])
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/rsa"
 	"errors"
 	"log/slog"
+	"net"
 	"net/http/httptest"
 	"os"
 	"sync"
@@ -15,13 +16,18 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
+	"github.com/sopatech/afterwave.fm/internal/admin"
 	"github.com/sopatech/afterwave.fm/internal/artists"
 	"github.com/sopatech/afterwave.fm/internal/auth"
 	"github.com/sopatech/afterwave.fm/internal/cognito"
+	"github.com/sopatech/afterwave.fm/internal/domains"
 	"github.com/sopatech/afterwave.fm/internal/feed"
 	"github.com/sopatech/afterwave.fm/internal/follows"
 	apphttp "github.com/sopatech/afterwave.fm/internal/http"
 	"github.com/sopatech/afterwave.fm/internal/infra"
+	"github.com/sopatech/afterwave.fm/internal/invitations"
+	"github.com/sopatech/afterwave.fm/internal/mail"
+	"github.com/sopatech/afterwave.fm/internal/media"
 	"github.com/sopatech/afterwave.fm/internal/metrics"
 	"github.com/sopatech/afterwave.fm/internal/search"
 	"github.com/sopatech/afterwave.fm/internal/users"
@@ -36,8 +42,11 @@ var (
 	testJWTPubKey         *rsa.PublicKey
 	testOpenSearchEndpoint string
 	testFeedIndexName     string
+	testMediaDir          string // uploaded files (media.FileStore) for every test server
 )
 
+var testMediaSecret = []byte("test-media-upload-secret")
+
 // fakeCognitoClient is an in-memory implementation of cognito.Client for tests.
 type fakeCognitoClient struct {
 	mu     sync.Mutex
@@ -45,6 +54,7 @@ type fakeCognitoClient struct {
 		password string
 		sub      string
 	}
+	federated map[string]bool // subs of users that signed up through an external IdP
 }
 
 var _ cognito.Client = (*fakeCognitoClient)(nil)
@@ -55,6 +65,7 @@ func newFakeCognitoClient() *fakeCognitoClient {
 			password string
 			sub      string
 		}),
+		federated: make(map[string]bool),
 	}
 }
 
@@ -89,6 +100,89 @@ func (f *fakeCognitoClient) AdminDeleteUser(ctx context.Context, email string) e
 	return nil
 }
 
+func (f *fakeCognitoClient) AdminUpdateEmail(ctx context.Context, sub, newEmail string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.users[newEmail]; exists {
+		return errors.New("alias already exists")
+	}
+	for email, u := range f.users {
+		if u.sub == sub {
+			delete(f.users, email)
+			f.users[newEmail] = u
+			return nil
+		}
+	}
+	return errors.New("user not found")
+}
+
+// IsFederated is true only for subs added with addFederated; like the pool, an unknown sub is not federated.
+func (f *fakeCognitoClient) IsFederated(ctx context.Context, sub string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.federated[sub], nil
+}
+
+// addFederated records a user that signed up through an external IdP.
+func (f *fakeCognitoClient) addFederated(sub string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.federated[sub] = true
+}
+
+// fakeMailer records sent messages by recipient so tests can read verification tokens.
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent map[string][]mail.Message
+}
+
+var _ mail.Sender = (*fakeMailer)(nil)
+
+// testMailer is shared by all test servers; recipients are unique per test (uniqueEmail).
+var testMailer = &fakeMailer{sent: make(map[string][]mail.Message)}
+
+func (f *fakeMailer) Send(ctx context.Context, msg mail.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent[msg.To] = append(f.sent[msg.To], msg)
+	return nil
+}
+
+// messagesTo returns the messages sent to the given address, oldest first.
+func (f *fakeMailer) messagesTo(to string) []mail.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]mail.Message(nil), f.sent[to]...)
+}
+
+// fakeResolver serves TXT records set by tests instead of querying DNS.
+type fakeResolver struct {
+	mu  sync.Mutex
+	txt map[string][]string
+}
+
+var _ domains.Resolver = (*fakeResolver)(nil)
+
+// testResolver is shared by all test servers; hosts are unique per test.
+var testResolver = &fakeResolver{txt: make(map[string][]string)}
+
+func (f *fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records, ok := f.txt[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return append([]string(nil), records...), nil
+}
+
+// setTXT replaces the TXT records for name.
+func (f *fakeResolver) setTXT(name string, values ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.txt[name] = values
+}
+
 func TestMain(m *testing.M) {
 	testTable = getEnv("DYNAMO_TABLE", "afterwave-test")
 	region := getEnv("AWS_REGION", "us-east-1")
@@ -128,7 +222,14 @@ func TestMain(m *testing.M) {
 		}
 	}
 
+	testMediaDir, err = os.MkdirTemp("", "afterwave-media-")
+	if err != nil {
+		slog.Default().Error("create media dir", "err", err)
+		os.Exit(1)
+	}
+
 	code := m.Run()
+	os.RemoveAll(testMediaDir)
 	os.Exit(code)
 }
 
@@ -261,40 +362,76 @@ func newTestServer(t *testing.T) (*httptest.Server, string) {
 	if err != nil {
 		t.Fatalf("new MAU recorder: %v", err)
 	}
+	dynamoReads, err := metrics.NewDynamoReadRecorder(metricsReg)
+	if err != nil {
+		t.Fatalf("new DynamoDB read recorder: %v", err)
+	}
 	authSvc := auth.NewService(authStore, testJWTPrivKey, mauRecorder)
 	cookieCfg := auth.CookieConfig{Secure: false} // HTTP in tests
 	authH := auth.NewHandler(authSvc, cookieCfg)
 
 	userStore := users.NewStore(testDB, testTable)
-	userSvc := users.NewService(userStore, newFakeCognitoClient())
+	userSvc := users.NewService(userStore, newFakeCognitoClient(), testMailer)
 	// For tests we don't exercise federated endpoints; pass empty Cognito Hosted UI config.
 	userH := users.NewHandler(userSvc, authSvc, cookieCfg, "", "", "", "", "", "", "", "")
 
+	followsStore := follows.NewStore(testDB, testTable)
+	inviteStore := invitations.NewStore(testDB, testTable)
+	feedStore := feed.NewStore(testDB, testTable)
+	domainStore := domains.NewStore(testDB, testTable)
+	mediaStore := media.NewStore(testDB, testTable)
+	// The server serves its own uploads (/files), so its URL is needed before the handler is built.
+	server := httptest.NewUnstartedServer(nil)
+	mediaFiles, err := media.NewFileStore(testMediaDir, "http://"+server.Listener.Addr().String()+"/files", testMediaSecret)
+	if err != nil {
+		t.Fatalf("new media file store: %v", err)
+	}
+	var feedIndex *search.FeedIndex
+	if testOpenSearchEndpoint != "" {
+		osClient := infra.NewOpenSearch(testOpenSearchEndpoint, nil)
+		feedIndex = search.NewFeedIndex(osClient, testFeedIndexName)
+		if err := feedIndex.EnsureIndex(ctx); err != nil {
+			t.Logf("opensearch ensure index (my feed tests may be skipped): %v", err)
+		}
+	}
+	var feedIndexer feed.FeedIndexer
+	if feedIndex != nil {
+		feedIndexer = feedIndex
+	}
+
 	artistStore := artists.NewStore(testDB, testTable)
 	artistMemberStore := artists.NewMemberStore(testDB, testTable)
-	artistSvc := artists.NewService(artistStore, artistMemberStore)
+	artistSvc := artists.NewService(artistStore, artistMemberStore, artists.DefaultHandlePolicy(nil, nil),
+		followsStore, inviteStore, feed.NewHandleData(feedStore, feedIndexer), domainStore, media.NewHandleData(mediaStore, mediaFiles))
 	artistH := artists.NewHandler(artistSvc)
 
-	followsStore := follows.NewStore(testDB, testTable)
+	inviteSvc := invitations.NewService(inviteStore, artistSvc, artistMemberStore, userSvc, testMailer)
+	inviteH := invitations.NewHandler(inviteSvc)
+
+	domainSvc := domains.NewService(domainStore, artistSvc, testResolver)
+	domainH := domains.NewHandler(domainSvc)
+
+	mediaSvc := media.NewService(mediaStore, mediaFiles, artistSvc, 0)
+	mediaH := media.NewHandler(mediaSvc)
+
 	followsSvc := follows.NewService(followsStore, artistSvc)
 	followsH := follows.NewHandler(followsSvc)
 
-	feedStore := feed.NewStore(testDB, testTable)
+	adminStore := admin.NewStore(testDB, testTable)
 	var feedSvc feed.Service
-	if testOpenSearchEndpoint != "" {
-		osClient := infra.NewOpenSearch(testOpenSearchEndpoint, nil)
-		feedIndex := search.NewFeedIndex(osClient, testFeedIndexName)
-		if err := feedIndex.EnsureIndex(ctx); err != nil {
-			t.Logf("opensearch ensure index (my feed tests may be skipped): %v", err)
-		}
-		feedSvc = feed.NewServiceWithSearch(feedStore, artistSvc, artistSvc, feedIndex, followsSvc, feedIndex)
+	if feedIndex != nil {
+		feedSvc = feed.NewServiceWithSearch(feedStore, artistSvc, artistSvc, artistSvc, feedIndex, followsSvc, feedIndex, userSvc, adminStore, mediaSvc, nil, feed.CommentRateLimit{})
 	} else {
 		feedSvc = feed.NewService(feedStore, artistSvc)
 	}
 	feedH := feed.NewHandler(feedSvc)
 
-	handler := apphttp.NewRouter(logger, userH, authH, artistH, followsH, feedH, metrics.HandlerForRegistry(metricsReg), testJWTPubKey)
-	server := httptest.NewServer(handler)
+	adminSvc := admin.NewService(adminStore, userSvc, artistSvc, authSvc, artistSvc, artistSvc)
+	adminH := admin.NewHandler(adminSvc)
+
+	handler := apphttp.NewRouter(logger, userH, authH, artistH, followsH, feedH, inviteH, domainH, mediaH, mediaFiles, adminH, metrics.HandlerForRegistry(metricsReg), dynamoReads, testJWTPubKey, authSvc)
+	server.Config.Handler = handler
+	server.Start()
 	base := server.URL + "/v1"
 	return server, base
 }